@@ -3,17 +3,24 @@ package main
 import (
 	"context"
 	_ "marketplace/docs"
+	"marketplace/internal/blob"
 	"marketplace/internal/configs"
 	"marketplace/internal/controller"
 	"marketplace/internal/db"
+	"marketplace/internal/events"
+	"marketplace/internal/models/domain"
+	"marketplace/internal/notify"
+	"marketplace/internal/rates"
 	"marketplace/internal/repository"
 	"marketplace/internal/service"
+	"marketplace/internal/worker"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
 
@@ -47,21 +54,78 @@ func main() {
 		log.Error().Err(err).Msg("Error during database connection initialization: " + err.Error())
 		return
 	}
+	rateSource, err := rates.NewSource(configs.AppSettings.CurrencyParams)
+	if err != nil {
+		log.Error().Err(err).Msg("Error during exchange rate source initialization: " + err.Error())
+		return
+	}
+	notificationParams := configs.AppSettings.NotificationParams
+	emailSender, err := notify.NewSender(notificationParams.EmailSender, domain.NotificationChannelEmail, notificationParams.OutboxDir)
+	if err != nil {
+		log.Error().Err(err).Msg("Error during notification sender initialization: " + err.Error())
+		return
+	}
+	smsSender, err := notify.NewSender(notificationParams.SmsSender, domain.NotificationChannelSMS, notificationParams.OutboxDir)
+	if err != nil {
+		log.Error().Err(err).Msg("Error during notification sender initialization: " + err.Error())
+		return
+	}
+	blobStore, err := blob.NewStore(configs.AppSettings.BlobParams)
+	if err != nil {
+		log.Error().Err(err).Msg("Error during blob store initialization: " + err.Error())
+		return
+	}
 	repo := repository.NewRepository(dbConn)
-	svc := service.NewService(repo)
+	svc := service.NewService(repo,
+		service.WithRateSource(rateSource),
+		service.WithBlobStore(blobStore),
+		service.WithNotificationSender(domain.NotificationChannelEmail, emailSender),
+		service.WithNotificationSender(domain.NotificationChannelSMS, smsSender))
 	ctrl := controller.NewController(svc)
+	expiryWorker := worker.NewOrderExpiryWorker(svc, time.Duration(configs.AppSettings.OrderExpiryParams.CheckIntervalSeconds)*time.Second)
+	jobParams := configs.AppSettings.JobParams
+	jobRunner := worker.NewJobRunner(svc, worker.JobRunnerConfig{
+		Concurrency:      jobParams.Concurrency,
+		PollInterval:     time.Duration(jobParams.PollIntervalMs) * time.Millisecond,
+		ScheduleInterval: time.Duration(jobParams.ScheduleIntervalSeconds) * time.Second,
+	})
+
+	dispatcher := events.NewDispatcher(svc)
+	dispatcher.Subscribe("event-log", events.LogHandler(log.Logger))
+	dispatcher.Subscribe("webhooks", events.WebhookHandler(svc), domain.WebhookEventTypes...)
+	dispatcher.Subscribe("stream", events.StreamHandler(svc),
+		domain.EventOrderCreated, domain.EventOrderStatusChanged, domain.EventStockChanged, domain.EventStockLow)
+	dispatcher.Subscribe("notifications", events.NotificationHandler(svc), domain.NotificationEventTypes...)
+	streamListener := worker.NewStreamListener(svc, db.DSN())
+	eventParams := configs.AppSettings.EventParams
+	outboxRelay := worker.NewOutboxRelay(svc, dispatcher, time.Duration(eventParams.RelayIntervalMs)*time.Millisecond, eventParams.RelayBatchSize)
 
 	router := ctrl.InitRoutes()
+	if configs.AppSettings.AppParams.GinMode != gin.ReleaseMode {
+		// Локальная заглушка HTTP-источника курсов: отдает курсы из файла
+		router.GET("/stub/exchange-rates", gin.WrapH(rates.StubHandler(rates.NewFileSource(configs.AppSettings.CurrencyParams.RatesFile))))
+		// Локальная заглушка S3-совместимого хранилища: объекты лежат в tmp/s3-stub
+		router.Any("/stub/s3/*path", gin.WrapH(http.StripPrefix("/stub/s3", blob.StubHandler(blob.NewLocalStore("tmp/s3-stub", "")))))
+	}
 	srv := &http.Server{
 		Addr:    ":" + configs.AppSettings.AppParams.PortRun,
 		Handler: router,
 	}
+	// SSE и WebSocket соединения сами не завершаются: Shutdown закрывает их подписки,
+	// иначе он ждал бы их до таймаута
+	srv.RegisterOnShutdown(svc.CloseStreams)
 	go func() {
 		log.Info().Msg("Starting server on port " + configs.AppSettings.AppParams.PortRun)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatal().Err(err).Msg("Failed to start server")
 		}
 	}()
+	expiryWorker.Start()
+	jobRunner.Start()
+	outboxRelay.Start()
+	if err := streamListener.Start(); err != nil {
+		log.Error().Err(err).Msg("Error starting stream listener: " + err.Error())
+	}
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -69,8 +133,19 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal().Err(err).Msg("Server forced to shutdown")
+		// Остановка воркеров и закрытие БД ниже должны выполниться и в этом случае
+		log.Error().Err(err).Msg("Server forced to shutdown")
+		if err := srv.Close(); err != nil {
+			log.Error().Err(err).Msg("Error closing server connections")
+		}
 	}
+	expiryWorker.Stop()
+	// Выполняющимся задачам дается отдельное время на завершение
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), time.Duration(jobParams.DrainTimeoutSeconds)*time.Second)
+	defer drainCancel()
+	jobRunner.Stop(drainCtx)
+	outboxRelay.Stop()
+	streamListener.Stop()
 	if err = db.CloseConnection(dbConn); err != nil {
 		log.Error().Err(err).Msg("Error during database connection close")
 	} else {
@@ -0,0 +1,54 @@
+package blob
+
+import (
+	"fmt"
+	"marketplace/internal/configs"
+	"marketplace/internal/contracts"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	StoreLocal = "local"
+	StoreS3    = "s3"
+)
+
+// keyPattern - допустимые ключи: сегменты из латиницы, цифр, "-", "_" и "." через "/".
+// Ключ приходит и из URL (/media/*key), поэтому ".." и абсолютные пути отклоняются.
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+(/[A-Za-z0-9_.-]+)*$`)
+
+// NewStore создает хранилище файлов по настройкам.
+func NewStore(params configs.BlobParams) (contracts.BlobStore, error) {
+	switch params.Store {
+	case "", StoreLocal:
+		return NewLocalStore(params.LocalDir, params.PublicURL), nil
+	case StoreS3:
+		store, err := NewS3Store(S3Config{
+			Endpoint:  params.S3Endpoint,
+			Region:    params.S3Region,
+			Bucket:    params.S3Bucket,
+			AccessKey: os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PathStyle: params.S3PathStyle,
+			PublicURL: params.PublicURL,
+		}, &http.Client{Timeout: 30 * time.Second})
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	}
+	return nil, fmt.Errorf("unknown blob store %q", params.Store)
+}
+
+// ValidKey проверяет ключ файла.
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key) && !strings.Contains(key, "..")
+}
+
+// publicURL склеивает базовый адрес и ключ.
+func publicURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + key
+}
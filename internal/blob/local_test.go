@@ -0,0 +1,88 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"marketplace/internal/contracts"
+	"marketplace/internal/errs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testStoreRoundTrip - общий сценарий для всех хранилищ: запись, чтение, перезапись и удаление.
+func testStoreRoundTrip(t *testing.T, store contracts.BlobStore) {
+	t.Helper()
+	ctx := context.Background()
+	const key = "products/1/a1b2c3.png"
+	if err := store.Put(ctx, key, []byte("first"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, key, []byte("second"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	blob, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(blob.Body)
+	blob.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "second" || blob.ContentType != "image/png" {
+		t.Errorf("get = %q (%s), want second (image/png)", data, blob.ContentType)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, errs.ErrBlobNotFound) {
+		t.Errorf("get after delete error = %v, want ErrBlobNotFound", err)
+	}
+	// Удаление отсутствующего файла не ошибка
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("second delete: %v", err)
+	}
+	for _, bad := range []string{"", "../secret", "products/../../etc/passwd", "/abs/path", "a//b", "products/1/a b.png"} {
+		if err := store.Put(ctx, bad, []byte("x"), "text/plain"); !errors.Is(err, errs.ErrBlobNotFound) {
+			t.Errorf("put %q error = %v, want ErrBlobNotFound", bad, err)
+		}
+		if _, err := store.Get(ctx, bad); !errors.Is(err, errs.ErrBlobNotFound) {
+			t.Errorf("get %q error = %v, want ErrBlobNotFound", bad, err)
+		}
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocalStore(dir, "")
+	testStoreRoundTrip(t, store)
+
+	if err := store.Put(context.Background(), "products/2/b.jpg", []byte("jpeg"), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	// Временные файлы загрузки не остаются в каталоге
+	entries, err := os.ReadDir(filepath.Join(dir, "products", "2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "b.jpg" {
+		t.Errorf("dir entries %v, want only b.jpg", entries)
+	}
+	if url := store.URL("products/2/b.jpg"); url != "/media/products/2/b.jpg" {
+		t.Errorf("URL = %s", url)
+	}
+	if url := NewLocalStore(dir, "https://cdn.example.com/").URL("a.png"); url != "https://cdn.example.com/a.png" {
+		t.Errorf("URL with public base = %s", url)
+	}
+}
+
+func TestLocalStoreCancelledPut(t *testing.T) {
+	store := NewLocalStore(t.TempDir(), "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := store.Put(ctx, "a.png", []byte("x"), "image/png"); !errors.Is(err, context.Canceled) {
+		t.Errorf("put error = %v, want context.Canceled", err)
+	}
+}
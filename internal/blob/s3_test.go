@@ -0,0 +1,80 @@
+package blob
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// authorizationPattern - формат заголовка AWS Signature V4
+var authorizationPattern = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=AKIDTEST/\d{8}/eu-central-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=[0-9a-f]{64}$`)
+
+// testS3Stub поднимает заглушку S3 поверх локального хранилища и запоминает пути и подписи запросов.
+func testS3Stub(t *testing.T) (*S3Store, *[]string) {
+	t.Helper()
+	stub := StubHandler(NewLocalStore(t.TempDir(), ""))
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorizationPattern.MatchString(r.Header.Get("Authorization")) {
+			t.Errorf("%s %s: authorization %q", r.Method, r.URL.Path, r.Header.Get("Authorization"))
+		}
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		stub.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	store, err := NewS3Store(S3Config{
+		Endpoint:  server.URL,
+		Region:    "eu-central-1",
+		Bucket:    "media",
+		AccessKey: "AKIDTEST",
+		SecretKey: "secret",
+		PathStyle: true,
+	}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, &requests
+}
+
+func TestS3StoreWithStub(t *testing.T) {
+	store, requests := testS3Stub(t)
+	testStoreRoundTrip(t, store)
+
+	// Невалидные ключи отклоняются до запроса в хранилище
+	for _, request := range *requests {
+		if !strings.HasPrefix(strings.SplitN(request, " ", 2)[1], "/media/products/1/") {
+			t.Errorf("unexpected request %s", request)
+		}
+	}
+	if !strings.HasPrefix(store.URL("a.png"), store.endpoint.String()+"/media/a.png") {
+		t.Errorf("URL = %s", store.URL("a.png"))
+	}
+}
+
+func TestS3StoreURL(t *testing.T) {
+	virtualHost, err := NewS3Store(S3Config{Endpoint: "https://s3.example.com", Bucket: "media"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url := virtualHost.URL("products/1/a.png"); url != "https://media.s3.example.com/products/1/a.png" {
+		t.Errorf("virtual-host URL = %s", url)
+	}
+	public, err := NewS3Store(S3Config{Endpoint: "https://s3.example.com", Bucket: "media", PublicURL: "https://cdn.example.com"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url := public.URL("products/1/a.png"); url != "https://cdn.example.com/products/1/a.png" {
+		t.Errorf("public URL = %s", url)
+	}
+	for _, config := range []S3Config{{Endpoint: "s3.example.com", Bucket: "media"}, {Endpoint: "https://s3.example.com"}} {
+		if _, err := NewS3Store(config, nil); err == nil {
+			t.Errorf("NewS3Store(%+v) accepted invalid config", config)
+		}
+	}
+}
@@ -1,9 +1,21 @@
 package configs
 
 type Configs struct {
-	AppParams      AppParams      `json:"app_params"`
-	PostgresParams PostgresParams `json:"postgres_params"`
-	AuthParams     AuthParams     `json:"auth_params"`
+	AppParams          AppParams          `json:"app_params"`
+	PostgresParams     PostgresParams     `json:"postgres_params"`
+	AuthParams         AuthParams         `json:"auth_params"`
+	CurrencyParams     CurrencyParams     `json:"currency_params"`
+	IdempotencyParams  IdempotencyParams  `json:"idempotency_params"`
+	FlashSaleParams    FlashSaleParams    `json:"flash_sale_params"`
+	OrderExpiryParams  OrderExpiryParams  `json:"order_expiry_params"`
+	JobParams          JobParams          `json:"job_params"`
+	EventParams        EventParams        `json:"event_params"`
+	WebhookParams      WebhookParams      `json:"webhook_params"`
+	NotificationParams NotificationParams `json:"notification_params"`
+	PaginationParams   PaginationParams   `json:"pagination_params"`
+	BlobParams         BlobParams         `json:"blob_params"`
+	ImageParams        ImageParams        `json:"image_params"`
+	ImportParams       ImportParams       `json:"import_params"`
 }
 type AppParams struct {
 	ServerURL  string `json:"server_url"`
@@ -21,3 +33,81 @@ type AuthParams struct {
 	AccessTokenTtlMinutes int `json:"access_token_ttl_minutes"`
 	RefreshTokenTtlDays   int `json:"refresh_token_ttl_days"`
 }
+type CurrencyParams struct {
+	BaseCurrency          string `json:"base_currency"`
+	RateSource            string `json:"rate_source"`
+	RatesFile             string `json:"rates_file"`
+	RatesURL              string `json:"rates_url"`
+	RequestTimeoutSeconds int    `json:"request_timeout_seconds"`
+}
+type IdempotencyParams struct {
+	KeyTtlHours int `json:"key_ttl_hours"`
+}
+type FlashSaleParams struct {
+	DefaultSlotCount int `json:"default_slot_count"`
+	QueueCapacity    int `json:"queue_capacity"`
+	QueueWaitMs      int `json:"queue_wait_ms"`
+}
+type OrderExpiryParams struct {
+	PendingTtlMinutes    int `json:"pending_ttl_minutes"`
+	CheckIntervalSeconds int `json:"check_interval_seconds"`
+	BatchSize            int `json:"batch_size"`
+}
+type JobParams struct {
+	Concurrency             int `json:"concurrency"`
+	PollIntervalMs          int `json:"poll_interval_ms"`
+	MaxAttempts             int `json:"max_attempts"`
+	BackoffBaseSeconds      int `json:"backoff_base_seconds"`
+	BackoffMaxSeconds       int `json:"backoff_max_seconds"`
+	LockTimeoutSeconds      int `json:"lock_timeout_seconds"`
+	ScheduleIntervalSeconds int `json:"schedule_interval_seconds"`
+	DrainTimeoutSeconds     int `json:"drain_timeout_seconds"`
+}
+type EventParams struct {
+	StockLowThreshold int `json:"stock_low_threshold"`
+	RelayIntervalMs   int `json:"relay_interval_ms"`
+	RelayBatchSize    int `json:"relay_batch_size"`
+	RetentionHours    int `json:"retention_hours"`
+}
+type WebhookParams struct {
+	TimeoutSeconds       int `json:"timeout_seconds"`
+	MaxAttempts          int `json:"max_attempts"`
+	DisableAfterFailures int `json:"disable_after_failures"`
+	// AllowPrivateTargets разрешает доставку на loopback и внутренние адреса (локальная разработка, тесты)
+	AllowPrivateTargets bool `json:"allow_private_targets"`
+}
+type NotificationParams struct {
+	DefaultLocale       string `json:"default_locale"`
+	EmailSender         string `json:"email_sender"`
+	SmsSender           string `json:"sms_sender"`
+	OutboxDir           string `json:"outbox_dir"`
+	DigestThreshold     int    `json:"digest_threshold"`
+	DigestWindowMinutes int    `json:"digest_window_minutes"`
+}
+type PaginationParams struct {
+	DefaultPageSize int `json:"default_page_size"`
+	MaxPageSize     int `json:"max_page_size"`
+}
+
+// BlobParams - хранилище файлов. Ключи доступа S3 берутся из переменных окружения
+// S3_ACCESS_KEY_ID и S3_SECRET_ACCESS_KEY.
+type BlobParams struct {
+	Store       string `json:"store"`
+	LocalDir    string `json:"local_dir"`
+	PublicURL   string `json:"public_url"`
+	S3Endpoint  string `json:"s3_endpoint"`
+	S3Region    string `json:"s3_region"`
+	S3Bucket    string `json:"s3_bucket"`
+	S3PathStyle bool   `json:"s3_path_style"`
+}
+type ImageParams struct {
+	MaxUploadMB         int   `json:"max_upload_mb"`
+	MaxImagesPerProduct int   `json:"max_images_per_product"`
+	MaxDimension        int   `json:"max_dimension"`
+	ThumbnailSizes      []int `json:"thumbnail_sizes"`
+}
+type ImportParams struct {
+	MaxFileMB     int `json:"max_file_mb"`
+	MaxRows       int `json:"max_rows"`
+	RetentionDays int `json:"retention_days"`
+}
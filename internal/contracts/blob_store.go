@@ -0,0 +1,24 @@
+package contracts
+
+import (
+	"context"
+	"io"
+)
+
+// Blob - содержимое файла из хранилища; Body закрывает вызывающий
+type Blob struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+}
+
+// BlobStore - хранилище файлов (локальный диск, S3-совместимое хранилище)
+type BlobStore interface {
+	Name() string
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (*Blob, error)
+	// Delete удаляет файл; отсутствие файла ошибкой не считается
+	Delete(ctx context.Context, key string) error
+	// URL возвращает публичный адрес файла
+	URL(key string) string
+}
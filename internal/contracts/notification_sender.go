@@ -0,0 +1,12 @@
+package contracts
+
+import (
+	"context"
+	"marketplace/internal/models/domain"
+)
+
+// NotificationSender - отправитель уведомлений одного канала (email, SMS)
+type NotificationSender interface {
+	Name() string
+	Send(ctx context.Context, message domain.NotificationMessage) error
+}
@@ -0,0 +1,12 @@
+package contracts
+
+import (
+	"context"
+	"marketplace/internal/models/domain"
+)
+
+// RateSource - внешний источник курсов валют (файл, HTTP API и т.п.)
+type RateSource interface {
+	Name() string
+	FetchRates(ctx context.Context) ([]domain.ExchangeRate, error)
+}
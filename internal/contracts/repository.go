@@ -2,12 +2,13 @@ package contracts
 
 import (
 	"marketplace/internal/models/domain"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
 type RepositoryI interface {
-	CreateUser(user domain.User) (err error)
+	CreateUserWithTx(tx *sqlx.Tx, user domain.User) (id int, err error)
 	GetUserByID(id int) (domain.User, error)
 	GetUserByUsername(username string) (domain.User, error)
 	GetUserByEmail(email string) (domain.User, error)
@@ -16,18 +17,191 @@ type RepositoryI interface {
 	UpdateUserRole(userID int, role string) error
 	CreateProduct(product *domain.Product) error
 	GetProductByID(id int64) (*domain.Product, error)
-	UpdateProduct(product *domain.Product) error
-	DeleteProduct(id int64) error
-	ListProducts(shopID int64, limit, offset int) ([]*domain.Product, error)
+	UpdateProductWithTx(tx *sqlx.Tx, product *domain.Product) error
+	DeleteProductWithTx(tx *sqlx.Tx, id, expectedVersion int64) error
+	TouchProductWithTx(tx *sqlx.Tx, productID int64) error
+	CreateProductWithTx(tx *sqlx.Tx, product *domain.Product) error
+	FindShopProductBySKU(shopID int64, sku string) (*domain.Product, error)
+	LockShopProductBySKUWithTx(tx *sqlx.Tx, shopID int64, sku string) (*domain.Product, error)
+	ListShopProductsAfter(shopID, afterID int64, limit int) ([]*domain.Product, error)
+	CreateProductImportWithTx(tx *sqlx.Tx, productImport *domain.ProductImport) error
+	GetProductImportByID(id int64) (*domain.ProductImport, error)
+	StartProductImport(id int64) (bool, error)
+	UpdateProductImportProgress(productImport *domain.ProductImport) error
+	FinishProductImport(productImport *domain.ProductImport) error
+	PurgeProductImports(before time.Time) (int64, error)
+	ListProducts(filter domain.ProductFilter) (*domain.ProductListResult, error)
+	CreateCategoryWithTx(tx *sqlx.Tx, category *domain.Category) error
+	GetCategoryByID(id int64) (*domain.Category, error)
+	GetCategoryByIDWithTx(tx *sqlx.Tx, id int64) (*domain.Category, error)
+	ListCategories() ([]*domain.Category, error)
+	UpdateCategory(category *domain.Category) error
+	LockCategoryTreeWithTx(tx *sqlx.Tx) error
+	IsCategoryInSubtreeWithTx(tx *sqlx.Tx, rootID, id int64) (bool, error)
+	ListCategoryChildIDsWithTx(tx *sqlx.Tx, parentID *int64) ([]int64, error)
+	MoveCategoryWithTx(tx *sqlx.Tx, id int64, parentID *int64) error
+	SetCategoryPositionsWithTx(tx *sqlx.Tx, ids []int64) error
+	DeleteCategory(id int64) error
+	SetProductCategoriesWithTx(tx *sqlx.Tx, productID int64, categoryIDs []int64) error
+	ListProductCategories(productIDs []int64) (map[int64][]domain.ProductCategory, error)
+	ListProductOptions(productIDs []int64) (map[int64][]domain.ProductOption, error)
+	SetProductOptionsWithTx(tx *sqlx.Tx, productID int64, options []domain.ProductOption) error
+	ListProductVariants(productIDs []int64) (map[int64][]domain.ProductVariant, error)
+	ListProductVariantsWithTx(tx *sqlx.Tx, productID int64) ([]*domain.ProductVariant, error)
+	GetProductVariantByID(id int64) (*domain.ProductVariant, error)
+	LockProductVariantsWithTx(tx *sqlx.Tx, ids []int64) ([]*domain.ProductVariant, error)
+	ListProductIDsWithVariantsWithTx(tx *sqlx.Tx, productIDs []int64) ([]int64, error)
+	CreateProductVariantWithTx(tx *sqlx.Tx, variant *domain.ProductVariant) error
+	UpdateProductVariantWithTx(tx *sqlx.Tx, variant *domain.ProductVariant) error
+	DeleteProductVariantWithTx(tx *sqlx.Tx, id int64) error
+	IncreaseVariantQuantitiesWithTx(tx *sqlx.Tx, quantities map[int64]int) error
+	DecreaseVariantQuantitiesWithTx(tx *sqlx.Tx, quantities map[int64]int) error
+	RecomputeProductQuantityWithTx(tx *sqlx.Tx, productID int64) (int, error)
+	ListCategoryAttributes(categoryIDs []int64) ([]domain.CategoryAttribute, error)
+	SetCategoryAttributesWithTx(tx *sqlx.Tx, categoryID int64, attributes []domain.CategoryAttribute) error
+	CreateProductImageWithTx(tx *sqlx.Tx, image *domain.ProductImage) error
+	ListProductImages(productIDs []int64) (map[int64][]domain.ProductImage, error)
+	LockProductImagesWithTx(tx *sqlx.Tx, productID int64) ([]*domain.ProductImage, error)
+	GetProductImageByID(id int64) (*domain.ProductImage, error)
+	UpdateProductImageWithTx(tx *sqlx.Tx, image *domain.ProductImage) error
+	SetPrimaryProductImageWithTx(tx *sqlx.Tx, productID, imageID int64) error
+	SetProductImagePositionsWithTx(tx *sqlx.Tx, ids []int64) error
+	SetProductImageThumbnails(id int64, thumbnails []domain.ImageThumbnail, status string) error
+	DeleteProductImageWithTx(tx *sqlx.Tx, id int64) error
+	DeleteProductImagesWithTx(tx *sqlx.Tx, productID int64) (int64, error)
+	QueueBlobDeletion(keys []string) error
+	ListBlobDeletions(limit, maxAttempts int) ([]domain.BlobDeletion, error)
+	DeleteBlobDeletions(ids []int64) error
+	IncrementBlobDeletionAttempts(ids []int64) error
+	SearchProducts(filter domain.ProductSearchFilter) (*domain.ProductSearchResult, error)
 	DecreaseProductQuantity(productID int64, quantity int) error
-	CreateShop(shop *domain.Shop) error
+	CreateShopWithTx(tx *sqlx.Tx, shop *domain.Shop) error
 	GetShopByID(id int64) (*domain.Shop, error)
 	UpdateShop(shop *domain.Shop) error
-	DeleteShop(id int64) error
-	ListShops(ownerID int64, limit, offset int) ([]*domain.Shop, error)
+	DeleteShop(id, expectedVersion int64) error
+	ListShops(ownerID int64, page domain.PageRequest) (*domain.Page[*domain.Shop], error)
 	GetOrderByID(orderID int64) (*domain.Order, []domain.OrderItem, error)
 	BeginTx() (*sqlx.Tx, error)
 	CreateOrderWithTx(tx *sqlx.Tx, order *domain.Order, items []domain.OrderItem) (int64, error)
 	GetProductByIDWithTx(tx *sqlx.Tx, id int64) (*domain.Product, error)
-	DecreaseProductQuantityWithTx(tx *sqlx.Tx, productID int64, quantity int) error
+	LockProductsWithTx(tx *sqlx.Tx, ids []int64) ([]*domain.Product, error)
+	DecreaseProductQuantitiesWithTx(tx *sqlx.Tx, quantities map[int64]int) error
+	GetProductsByIDsWithTx(tx *sqlx.Tx, ids []int64) ([]*domain.Product, error)
+	GetOrderByIDWithTx(tx *sqlx.Tx, orderID int64) (*domain.Order, []domain.OrderItem, error)
+	GetOrderItemShopIDsWithTx(tx *sqlx.Tx, orderID int64) (map[int64]int64, error)
+	UpdateOrderStatusWithTx(tx *sqlx.Tx, orderID int64, status string) error
+	TouchOrderWithTx(tx *sqlx.Tx, orderID int64) error
+	ListExpiredPendingOrderIDsWithTx(tx *sqlx.Tx, before time.Time, limit int) ([]int64, error)
+	CancelOrderWithTx(tx *sqlx.Tx, orderID int64, reason string) error
+	AddOrderStatusChangeWithTx(tx *sqlx.Tx, change *domain.OrderStatusChange) error
+	IncreaseProductQuantitiesWithTx(tx *sqlx.Tx, quantities map[int64]int) error
+	ReturnFlashSaleStockWithTx(tx *sqlx.Tx, saleID, userID int64, quantity int) error
+	CreateShipmentWithTx(tx *sqlx.Tx, shipment *domain.Shipment) error
+	GetShipmentByIDWithTx(tx *sqlx.Tx, id int64) (*domain.Shipment, error)
+	UpdateShipmentStatusWithTx(tx *sqlx.Tx, shipment *domain.Shipment) error
+	CreateShipmentEventWithTx(tx *sqlx.Tx, event *domain.ShipmentEvent) error
+	ListShipmentsByOrderIDWithTx(tx *sqlx.Tx, orderID int64) ([]domain.Shipment, error)
+	ListShipmentsByOrderID(orderID int64) ([]domain.Shipment, error)
+	CreateAddress(address *domain.Address) error
+	GetAddressByID(id int64) (*domain.Address, error)
+	GetDefaultAddress(userID int64) (*domain.Address, error)
+	ListAddresses(userID int64) ([]*domain.Address, error)
+	UpdateAddress(address *domain.Address) error
+	SetDefaultAddress(userID, addressID int64) error
+	DeleteAddress(userID, addressID int64) error
+	CreateShippingZone(zone *domain.ShippingZone) error
+	GetShippingZoneByID(id int64) (*domain.ShippingZone, error)
+	DeleteShippingZone(id int64) error
+	ListShippingZones(shopIDs []int64, activeOnly bool) ([]domain.ShippingZone, error)
+	ListShippingZonesPage(shopID int64, page domain.PageRequest) (*domain.Page[domain.ShippingZone], error)
+	CreateShippingMethod(method *domain.ShippingMethod) error
+	GetShippingMethodByID(id int64) (*domain.ShippingMethod, error)
+	UpdateShippingMethod(method *domain.ShippingMethod) error
+	DeleteShippingMethod(id int64) error
+	CreateCoupon(coupon *domain.Coupon) error
+	GetCouponByID(id int64) (*domain.Coupon, error)
+	ListCoupons(shopID *int64, page domain.PageRequest) (*domain.Page[*domain.Coupon], error)
+	DeactivateCoupon(id int64) error
+	GetCouponsByCodesWithTx(tx *sqlx.Tx, codes []string) ([]*domain.Coupon, error)
+	CountCouponRedemptionsWithTx(tx *sqlx.Tx, couponID, userID int64) (int, error)
+	RedeemCouponWithTx(tx *sqlx.Tx, couponID, userID, orderID int64, amount domain.Money) error
+	ReleaseCouponRedemptionsWithTx(tx *sqlx.Tx, orderIDs []int64) (int, error)
+	UpsertExchangeRate(rate *domain.ExchangeRate) error
+	ListExchangeRates() ([]domain.ExchangeRate, error)
+	ListExchangeRatesPage(page domain.PageRequest) (*domain.Page[domain.ExchangeRate], error)
+	DeleteExchangeRate(id int64) error
+	GetUserDisplayCurrency(userID int) (string, error)
+	SetUserDisplayCurrency(userID int, currency string) error
+	CreateTaxRate(rate *domain.TaxRate) error
+	GetTaxRateByID(id int64) (*domain.TaxRate, error)
+	UpdateTaxRate(rate *domain.TaxRate) error
+	DeleteTaxRate(id int64) error
+	ListTaxRates(country string, activeOnly bool) ([]domain.TaxRate, error)
+	ListTaxRatesPage(country string, page domain.PageRequest) (*domain.Page[domain.TaxRate], error)
+	CreateIdempotencyKey(record *domain.IdempotencyRecord) (bool, error)
+	GetIdempotencyKey(userID int64, key string) (*domain.IdempotencyRecord, error)
+	CompleteIdempotencyKey(id int64, status int, body []byte, contentType string) error
+	DeleteIdempotencyKey(id int64) error
+	PurgeExpiredIdempotencyKeys() (int64, error)
+	CreateFlashSaleWithTx(tx *sqlx.Tx, sale *domain.FlashSale) error
+	GetFlashSaleByID(id int64) (*domain.FlashSale, error)
+	GetFlashSaleByIDForUpdateWithTx(tx *sqlx.Tx, id int64) (*domain.FlashSale, error)
+	ListActiveFlashSales(productIDs []int64) ([]*domain.FlashSale, error)
+	ReserveFlashSaleStockWithTx(tx *sqlx.Tx, saleID int64, quantity int) (bool, error)
+	AddFlashSaleUserQuantityWithTx(tx *sqlx.Tx, saleID, userID int64, quantity, limit int) (bool, error)
+	FinishFlashSaleWithTx(tx *sqlx.Tx, sale *domain.FlashSale) error
+	GetFlashSaleReport(id int64) (*domain.FlashSaleReport, error)
+	ListEndedFlashSaleIDs() ([]int64, error)
+	CreateJob(job *domain.Job) error
+	CreateJobWithTx(tx *sqlx.Tx, job *domain.Job) error
+	ClaimJobs(workerID string, limit int, lockTimeout time.Duration) ([]domain.Job, error)
+	CompleteJob(id int64, workerID string) error
+	FailJob(id int64, workerID, lastError string, retryAt *time.Time) error
+	GetJobByID(id int64) (*domain.Job, error)
+	ListJobs(filter domain.JobFilter) (*domain.Page[domain.Job], error)
+	RetryJob(id int64) (*domain.Job, error)
+	CancelJob(id int64) (*domain.Job, error)
+	ListJobSchedules() ([]domain.JobSchedule, error)
+	ListDueJobSchedulesWithTx(tx *sqlx.Tx) ([]domain.JobSchedule, error)
+	UpdateJobScheduleRunWithTx(tx *sqlx.Tx, id int64, lastRunAt time.Time, nextRunAt *time.Time) error
+	AddOutboxEventWithTx(tx *sqlx.Tx, event *domain.Event) error
+	LockPendingOutboxEventsWithTx(tx *sqlx.Tx, limit int) ([]domain.Event, error)
+	MarkOutboxEventPublishedWithTx(tx *sqlx.Tx, id int64) error
+	MarkOutboxEventFailedWithTx(tx *sqlx.Tx, id int64, lastError string, nextAttemptAt time.Time) error
+	IsEventProcessed(eventID, subscriber string) (bool, error)
+	MarkEventProcessed(eventID, subscriber string) error
+	PurgeOutboxEvents(before time.Time) (int64, error)
+	CreateWebhookEndpoint(endpoint *domain.WebhookEndpoint) error
+	GetWebhookEndpointByID(id int64) (*domain.WebhookEndpoint, error)
+	ListWebhookEndpoints(shopID int64) ([]domain.WebhookEndpoint, error)
+	ListActiveWebhookEndpointsForEvent(shopIDs []int64, eventType string) ([]domain.WebhookEndpoint, error)
+	UpdateWebhookEndpoint(endpoint *domain.WebhookEndpoint) error
+	DeleteWebhookEndpoint(id int64) error
+	IncrementWebhookFailuresWithTx(tx *sqlx.Tx, id int64) (int, error)
+	ResetWebhookFailuresWithTx(tx *sqlx.Tx, id int64) error
+	DisableWebhookEndpointWithTx(tx *sqlx.Tx, id int64, reason string) error
+	CreateWebhookDeliveryWithTx(tx *sqlx.Tx, delivery *domain.WebhookDelivery) (bool, error)
+	GetWebhookDeliveryByID(id int64) (*domain.WebhookDelivery, error)
+	ListWebhookDeliveries(filter domain.WebhookDeliveryFilter) (*domain.Page[domain.WebhookDelivery], error)
+	ListWebhookDeliveryAttempts(deliveryID int64) ([]domain.WebhookDeliveryAttempt, error)
+	RecordWebhookAttemptWithTx(tx *sqlx.Tx, attempt *domain.WebhookDeliveryAttempt, status string) error
+	ResetWebhookDeliveryWithTx(tx *sqlx.Tx, id int64) (*domain.WebhookDelivery, error)
+	NotifyStreamEvent(payload string) error
+	ListShopIDsByOwner(ownerID int64) ([]int64, error)
+	ListShopOwnerIDs(shopIDs []int64) ([]int64, error)
+	CreateNotificationWithTx(tx *sqlx.Tx, notification *domain.Notification) (bool, error)
+	GetNotificationByID(id int64) (*domain.Notification, error)
+	ListUserNotifications(filter domain.NotificationFilter) (*domain.Page[domain.Notification], error)
+	CountUnreadNotifications(userID int64) (int, error)
+	MarkNotificationRead(id, userID int64) (*domain.Notification, error)
+	MarkAllNotificationsRead(userID int64) (int64, error)
+	UpdateNotificationStatus(id int64, status, lastError string) error
+	CountRecentNotificationsWithTx(tx *sqlx.Tx, userID int64, channel string, since time.Time) (int, error)
+	ListNotificationDigestGroups() ([]domain.NotificationDigestGroup, error)
+	LockDigestNotificationsWithTx(tx *sqlx.Tx, userID int64, channel string) ([]domain.Notification, error)
+	MarkNotificationsDigestedWithTx(tx *sqlx.Tx, ids []int64, digestID int64) error
+	ListNotificationTemplates(eventType string) ([]domain.NotificationTemplate, error)
+	UpsertNotificationTemplate(template *domain.NotificationTemplate) error
+	GetNotificationPreferences(userID int64) (*domain.NotificationPreferences, error)
+	SaveNotificationPreferences(userID int64, prefs *domain.NotificationPreferences) error
 }
@@ -1,6 +1,12 @@
 package contracts
 
-import "marketplace/internal/models/domain"
+import (
+	"context"
+	"io"
+	"marketplace/internal/models/domain"
+	"marketplace/internal/realtime"
+	"time"
+)
 
 type ServiceI interface {
 	CreateUser(user domain.User) error
@@ -8,14 +14,124 @@ type ServiceI interface {
 	CreateProduct(product *domain.Product) error
 	SetUserRole(actorUserID int, actorRole string, targetUserID int, newRole string) error
 	GetProductByID(id int64) (*domain.Product, error)
-	UpdateProduct(product *domain.Product, userID int, userRole string) error
-	DeleteProduct(id int64, userID int, userRole string) error
-	ListProducts(shopID int64, limit, offset int) ([]*domain.Product, error)
+	UpdateProduct(product *domain.Product, expectedVersion int64, userID int, userRole string) error
+	PatchProduct(id int64, patch []byte, expectedVersion int64, userID int, userRole string) (*domain.Product, error)
+	DeleteProduct(id, expectedVersion int64, userID int, userRole string) error
+	ListProducts(filter domain.ProductFilter) (*domain.ProductListResult, error)
+	ListCategoryTree() ([]*domain.Category, error)
+	GetCategory(id int64) (*domain.Category, error)
+	CreateCategory(input domain.CreateCategoryInput) (*domain.Category, error)
+	UpdateCategory(id int64, input domain.UpdateCategoryInput) (*domain.Category, error)
+	MoveCategory(id int64, input domain.MoveCategoryInput) (*domain.Category, error)
+	DeleteCategory(id int64) error
+	SetProductCategories(productID int64, categoryIDs []int64, userID int, userRole string) ([]domain.ProductCategory, error)
+	AttachProductCategories(products ...*domain.Product) error
+	GetProductVariants(productID int64) (*domain.Product, error)
+	AttachProductVariants(products ...*domain.Product) error
+	SetProductOptions(productID int64, input domain.SetProductOptionsInput, userID int, userRole string) ([]domain.ProductOption, error)
+	CreateProductVariant(productID int64, input domain.ProductVariantInput, userID int, userRole string) (*domain.ProductVariant, error)
+	UpdateProductVariant(variantID int64, input domain.ProductVariantInput, userID int, userRole string) (*domain.ProductVariant, error)
+	DeleteProductVariant(variantID int64, userID int, userRole string) error
+	GetCategoryAttributes(categoryID int64) ([]domain.CategoryAttribute, error)
+	SetCategoryAttributes(categoryID int64, input domain.SetCategoryAttributesInput) ([]domain.CategoryAttribute, error)
+	UploadProductImage(productID int64, input domain.UploadImageInput, userID int, userRole string) (*domain.ProductImage, error)
+	ListProductImages(productID int64) ([]domain.ProductImage, error)
+	AttachProductImages(products ...*domain.Product) error
+	UpdateProductImage(imageID int64, input domain.UpdateProductImageInput, userID int, userRole string) (*domain.ProductImage, error)
+	ReorderProductImages(productID int64, input domain.ReorderProductImagesInput, userID int, userRole string) ([]domain.ProductImage, error)
+	DeleteProductImage(imageID int64, userID int, userRole string) error
+	OpenBlob(ctx context.Context, key string) (*Blob, error)
+	CreateProductImport(shopID int64, input domain.CreateProductImportInput, userID int, userRole string) (*domain.ProductImport, error)
+	GetProductImport(id int64, userID int, userRole string) (*domain.ProductImport, error)
+	OpenProductImportErrors(id int64, userID int, userRole string) (*Blob, error)
+	ExportShopProducts(shopID int64, format string, userID int, userRole string, w io.Writer) error
+	SearchProducts(filter domain.ProductSearchFilter) (*domain.ProductSearchResult, error)
 	CreateShop(shop *domain.Shop) error
 	GetShopByID(id int64) (*domain.Shop, error)
-	UpdateShop(shop *domain.Shop, userID int, userRole string) error
-	DeleteShop(id int64, userID int, userRole string) error
-	ListShops(ownerID int64, limit, offset int) ([]*domain.Shop, error)
+	UpdateShop(shop *domain.Shop, expectedVersion int64, userID int, userRole string) error
+	PatchShop(id int64, patch []byte, expectedVersion int64, userID int, userRole string) (*domain.Shop, error)
+	DeleteShop(id, expectedVersion int64, userID int, userRole string) error
+	ListShops(ownerID int64, page domain.PageRequest) (*domain.Page[*domain.Shop], error)
 	CreateOrder(userID int, input domain.CreateOrderInput) (int64, error)
 	GetOrderByID(orderID int64) (*domain.Order, []domain.OrderItem, error)
+	ConfirmOrder(orderID int64, userID int, input domain.ConfirmOrderInput) (*domain.Order, error)
+	CreateShipment(orderID int64, userID int, userRole string, input domain.CreateShipmentInput) (*domain.Shipment, error)
+	AddShipmentEvent(shipmentID int64, userID int, userRole string, input domain.CreateShipmentEventInput) (*domain.ShipmentEvent, error)
+	GetOrderShipments(orderID int64) ([]domain.Shipment, error)
+	CreateAddress(userID int, address *domain.Address) error
+	GetAddress(userID int, addressID int64) (*domain.Address, error)
+	ListAddresses(userID int) ([]*domain.Address, error)
+	UpdateAddress(userID int, address *domain.Address) error
+	SetDefaultAddress(userID int, addressID int64) error
+	DeleteAddress(userID int, addressID int64) error
+	CreateShippingZone(zone *domain.ShippingZone, userID int, userRole string) error
+	ListShippingZones(shopID int64, page domain.PageRequest) (*domain.Page[domain.ShippingZone], error)
+	DeleteShippingZone(zoneID int64, userID int, userRole string) error
+	CreateShippingMethod(method *domain.ShippingMethod, userID int, userRole string) error
+	UpdateShippingMethod(method *domain.ShippingMethod, userID int, userRole string) error
+	DeleteShippingMethod(methodID int64, userID int, userRole string) error
+	QuoteShipping(userID int, input domain.ShippingQuoteInput) ([]domain.ShippingQuote, error)
+	ListExchangeRates(page domain.PageRequest) (*domain.Page[domain.ExchangeRate], error)
+	SetExchangeRate(rate *domain.ExchangeRate) error
+	DeleteExchangeRate(id int64) error
+	RefreshExchangeRates(ctx context.Context) ([]domain.ExchangeRate, error)
+	GetUserPreferences(userID int) (*domain.UserPreferences, error)
+	UpdateUserPreferences(userID int, prefs *domain.UserPreferences) error
+	ApplyDisplayCurrency(userID int, products ...*domain.Product)
+	CreateFlashSale(productID int64, input domain.CreateFlashSaleInput, userID int, userRole string) (*domain.FlashSale, error)
+	GetFlashSaleReport(saleID int64, userID int, userRole string) (*domain.FlashSaleReport, error)
+	FinishFlashSale(saleID int64, userID int, userRole string) (*domain.FlashSaleReport, error)
+	ExpireAbandonedOrders() (int, error)
+	FinishEndedFlashSales() (int, error)
+	PurgeExpiredIdempotencyKeys() (int64, error)
+	EnqueueJob(jobType string, payload interface{}, runAt time.Time) (*domain.Job, error)
+	ClaimJobs(workerID string, limit int) ([]domain.Job, error)
+	CompleteJob(job *domain.Job, workerID string) error
+	FailJob(job *domain.Job, workerID string, jobErr error) error
+	GetJob(jobID int64) (*domain.Job, error)
+	ListJobs(filter domain.JobFilter) (*domain.Page[domain.Job], error)
+	RetryJob(jobID int64) (*domain.Job, error)
+	CancelJob(jobID int64) (*domain.Job, error)
+	ListJobSchedules() ([]domain.JobSchedule, error)
+	EnqueueDueSchedules() (int, error)
+	RelayOutboxEvents(ctx context.Context, limit int, publish func(ctx context.Context, event domain.Event) error) (int, error)
+	IsEventProcessed(eventID, subscriber string) (bool, error)
+	MarkEventProcessed(eventID, subscriber string) error
+	PurgeOutboxEvents() (int64, error)
+	CreateWebhook(shopID int64, input domain.WebhookEndpointInput, userID int, userRole string) (*domain.WebhookEndpoint, error)
+	ListWebhooks(shopID int64, userID int, userRole string) ([]domain.WebhookEndpoint, error)
+	UpdateWebhook(webhookID int64, input domain.WebhookEndpointInput, userID int, userRole string) (*domain.WebhookEndpoint, error)
+	DeleteWebhook(webhookID int64, userID int, userRole string) error
+	ListWebhookDeliveries(filter domain.WebhookDeliveryFilter, userID int, userRole string) (*domain.Page[domain.WebhookDelivery], error)
+	GetWebhookDelivery(deliveryID int64, userID int, userRole string) (*domain.WebhookDelivery, error)
+	RedeliverWebhook(deliveryID int64, userID int, userRole string) (*domain.WebhookDelivery, error)
+	EnqueueWebhookDeliveries(event domain.Event) (int, error)
+	DeliverWebhook(ctx context.Context, deliveryID int64) error
+	OpenStream(userID int, productIDs []int64) (*realtime.Subscription, error)
+	PublishStreamEvent(event domain.Event) error
+	BroadcastStreamEvent(payload string) error
+	CreateNotifications(event domain.Event) (int, error)
+	SendNotification(ctx context.Context, notificationID int64) error
+	SendNotificationDigests(ctx context.Context) (int, error)
+	GenerateImageThumbnails(ctx context.Context, imageID int64) error
+	PurgeOrphanBlobs(ctx context.Context) (int, error)
+	RunProductImport(ctx context.Context, importID int64) error
+	PurgeProductImports() (int64, error)
+	ListNotifications(userID int, unreadOnly bool, page domain.PageRequest) (*domain.NotificationInbox, error)
+	MarkNotificationRead(notificationID int64, userID int) (*domain.Notification, error)
+	MarkAllNotificationsRead(userID int) (int64, error)
+	GetNotificationPreferences(userID int) (*domain.NotificationPreferences, error)
+	UpdateNotificationPreferences(userID int, prefs *domain.NotificationPreferences) error
+	ListNotificationTemplates(eventType string) ([]domain.NotificationTemplate, error)
+	SaveNotificationTemplate(tmpl *domain.NotificationTemplate) error
+	BeginIdempotentRequest(userID int, key, method, path, fingerprint string) (*domain.IdempotencyRecord, bool, error)
+	CompleteIdempotentRequest(record *domain.IdempotencyRecord, status int, body []byte, contentType string) error
+	AbortIdempotentRequest(record *domain.IdempotencyRecord) error
+	CreateTaxRate(rate *domain.TaxRate) error
+	ListTaxRates(country string, page domain.PageRequest) (*domain.Page[domain.TaxRate], error)
+	UpdateTaxRate(rate *domain.TaxRate) error
+	DeleteTaxRate(id int64) error
+	CreateCoupon(coupon *domain.Coupon, userID int, userRole string) error
+	ListCoupons(shopID *int64, page domain.PageRequest, userID int, userRole string) (*domain.Page[*domain.Coupon], error)
+	DeactivateCoupon(couponID int64, userID int, userRole string) error
 }
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListAddressesHandler godoc
+// @Summary Список адресов
+// @Description Возвращает адресную книгу текущего пользователя
+// @Tags addresses
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.Address
+// @Failure 401 {object} CommonError
+// @Failure 500 {object} CommonError
+// @Router /api/v1/me/addresses [get]
+func (ctrl *Controller) ListAddressesHandler(c *gin.Context) {
+	userIDUntyped, _ := c.Get(userIDCtx)
+	addresses, err := ctrl.service.ListAddresses(userIDUntyped.(int))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, addresses)
+}
+
+// CreateAddressHandler godoc
+// @Summary Добавить адрес
+// @Description Добавляет адрес в адресную книгу; первый адрес становится адресом по умолчанию
+// @Tags addresses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body domain.Address true "Адрес"
+// @Success 201 {object} domain.Address
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/me/addresses [post]
+func (ctrl *Controller) CreateAddressHandler(c *gin.Context) {
+	var address domain.Address
+	if err := c.ShouldBindJSON(&address); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	if err := ctrl.service.CreateAddress(userIDUntyped.(int), &address); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, address)
+}
+
+// GetAddressHandler godoc
+// @Summary Получить адрес
+// @Description Возвращает адрес из адресной книги текущего пользователя
+// @Tags addresses
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Address ID"
+// @Success 200 {object} domain.Address
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/me/addresses/{id} [get]
+func (ctrl *Controller) GetAddressHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	address, err := ctrl.service.GetAddress(userIDUntyped.(int), id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, address)
+}
+
+// UpdateAddressHandler godoc
+// @Summary Обновить адрес
+// @Description Обновляет адрес; уже оформленные заказы хранят собственный снимок адреса и не меняются
+// @Tags addresses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Address ID"
+// @Param input body domain.Address true "Адрес"
+// @Success 200 {object} domain.Address
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/me/addresses/{id} [put]
+func (ctrl *Controller) UpdateAddressHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	var address domain.Address
+	if err := c.ShouldBindJSON(&address); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	address.ID = id
+	userIDUntyped, _ := c.Get(userIDCtx)
+	if err := ctrl.service.UpdateAddress(userIDUntyped.(int), &address); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, address)
+}
+
+// SetDefaultAddressHandler godoc
+// @Summary Сделать адрес основным
+// @Description Назначает адрес адресом доставки по умолчанию
+// @Tags addresses
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Address ID"
+// @Success 200 {object} CommonResponse
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/me/addresses/{id}/default [put]
+func (ctrl *Controller) SetDefaultAddressHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	if err := ctrl.service.SetDefaultAddress(userIDUntyped.(int), id); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, CommonResponse{Message: "default address updated successfully"})
+}
+
+// DeleteAddressHandler godoc
+// @Summary Удалить адрес
+// @Description Удаляет адрес из адресной книги (soft delete)
+// @Tags addresses
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Address ID"
+// @Success 200 {object} CommonResponse
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/me/addresses/{id} [delete]
+func (ctrl *Controller) DeleteAddressHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	if err := ctrl.service.DeleteAddress(userIDUntyped.(int), id); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, CommonResponse{Message: "address deleted successfully"})
+}
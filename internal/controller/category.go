@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListCategoriesHandler godoc
+// @Summary Дерево категорий
+// @Description Возвращает корневые категории с вложенными подкатегориями, упорядоченными по position
+// @Tags categories
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.Category
+// @Failure 401 {object} CommonError
+// @Router /api/v1/categories [get]
+func (ctrl *Controller) ListCategoriesHandler(c *gin.Context) {
+	categories, err := ctrl.service.ListCategoryTree()
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, categories)
+}
+
+// GetCategoryHandler godoc
+// @Summary Категория
+// @Description Возвращает категорию с поддеревом
+// @Tags categories
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Success 200 {object} domain.Category
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/categories/{id} [get]
+func (ctrl *Controller) GetCategoryHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctrl.handleError(c, errs.ErrCategoryNotFound)
+		return
+	}
+	category, err := ctrl.service.GetCategory(id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, category)
+}
+
+// CreateCategoryHandler godoc
+// @Summary Создать категорию
+// @Description Создает категорию; без parent_id - корневую, без position - в конец списка соседей (только админ)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body domain.CreateCategoryInput true "Категория"
+// @Success 201 {object} domain.Category
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 409 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/admin/categories [post]
+func (ctrl *Controller) CreateCategoryHandler(c *gin.Context) {
+	var input domain.CreateCategoryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	category, err := ctrl.service.CreateCategory(input)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategoryHandler godoc
+// @Summary Переименовать категорию
+// @Description Меняет название и/или slug категории (только админ)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Param input body domain.UpdateCategoryInput true "Название и slug"
+// @Success 200 {object} domain.Category
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 409 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/admin/categories/{id} [put]
+func (ctrl *Controller) UpdateCategoryHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctrl.handleError(c, errs.ErrCategoryNotFound)
+		return
+	}
+	var input domain.UpdateCategoryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	category, err := ctrl.service.UpdateCategory(id, input)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, category)
+}
+
+// MoveCategoryHandler godoc
+// @Summary Перенести категорию
+// @Description Переносит категорию к другому родителю (null - в корень) и/или меняет ее позицию среди соседей. Перенос в собственное поддерево запрещен (только админ)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Param input body domain.MoveCategoryInput true "Новый родитель и позиция"
+// @Success 200 {object} domain.Category
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/admin/categories/{id}/move [post]
+func (ctrl *Controller) MoveCategoryHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctrl.handleError(c, errs.ErrCategoryNotFound)
+		return
+	}
+	var input domain.MoveCategoryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	category, err := ctrl.service.MoveCategory(id, input)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategoryHandler godoc
+// @Summary Удалить категорию
+// @Description Удаляет категорию без подкатегорий; товары отвязываются от нее (только админ)
+// @Tags categories
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Success 200 {object} CommonResponse
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 409 {object} CommonError
+// @Router /api/v1/admin/categories/{id} [delete]
+func (ctrl *Controller) DeleteCategoryHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctrl.handleError(c, errs.ErrCategoryNotFound)
+		return
+	}
+	if err := ctrl.service.DeleteCategory(id); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, CommonResponse{Message: "category deleted successfully"})
+}
+
+// SetProductCategoriesHandler godoc
+// @Summary Категории товара
+// @Description Заменяет набор категорий товара (владелец магазина или админ)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param input body domain.SetProductCategoriesInput true "Категории"
+// @Success 200 {array} domain.ProductCategory
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/products/{id}/categories [put]
+func (ctrl *Controller) SetProductCategoriesHandler(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || productID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidProductID)
+		return
+	}
+	var input domain.SetProductCategoriesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	categories, err := ctrl.service.SetProductCategories(productID, input.CategoryIDs, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	if categories == nil {
+		categories = []domain.ProductCategory{}
+	}
+	c.JSON(http.StatusOK, categories)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"marketplace/internal/contracts"
 	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
 	"net/http"
 	"time"
 
@@ -26,16 +27,89 @@ func (ctrl *Controller) handleError(c *gin.Context, err error) {
 	switch {
 	case errors.Is(err, errs.ErrProductNotfound) ||
 		errors.Is(err, errs.ErrUserNotFound) ||
+		errors.Is(err, errs.ErrOrderNotFound) ||
+		errors.Is(err, errs.ErrShipmentNotFound) ||
+		errors.Is(err, errs.ErrAddressNotFound) ||
+		errors.Is(err, errs.ErrShippingZoneNotFound) ||
+		errors.Is(err, errs.ErrShippingMethodNotFound) ||
+		errors.Is(err, errs.ErrCouponNotFound) ||
+		errors.Is(err, errs.ErrExchangeRateNotFound) ||
+		errors.Is(err, errs.ErrTaxRateNotFound) ||
+		errors.Is(err, errs.ErrFlashSaleNotFound) ||
+		errors.Is(err, errs.ErrJobNotFound) ||
+		errors.Is(err, errs.ErrWebhookNotFound) ||
+		errors.Is(err, errs.ErrWebhookDeliveryNotFound) ||
+		errors.Is(err, errs.ErrNotificationNotFound) ||
+		errors.Is(err, errs.ErrCategoryNotFound) ||
+		errors.Is(err, errs.ErrVariantNotFound) ||
+		errors.Is(err, errs.ErrImageNotFound) ||
+		errors.Is(err, errs.ErrBlobNotFound) ||
+		errors.Is(err, errs.ErrImportNotFound) ||
 		errors.Is(err, errs.ErrNotfound):
 		c.JSON(http.StatusNotFound, CommonError{Error: err.Error()})
-	case errors.Is(err, errs.ErrInvalidProductID) || errors.Is(err, errs.ErrInvalidRequestBody):
+	case errors.Is(err, errs.ErrInvalidProductID) || errors.Is(err, errs.ErrInvalidRequestBody) || errors.Is(err, errs.ErrInvalidIdempotencyKey) ||
+		errors.Is(err, errs.ErrInvalidJobStatus) || errors.Is(err, errs.ErrInvalidSearchQuery) || errors.Is(err, errs.ErrInvalidCursor):
 		c.JSON(http.StatusBadRequest, CommonError{Error: err.Error()})
+	case errors.Is(err, errs.ErrCouponAlreadyExists) ||
+		errors.Is(err, errs.ErrTaxRateAlreadyExists) ||
+		errors.Is(err, errs.ErrIdempotencyKeyReused) ||
+		errors.Is(err, errs.ErrIdempotencyKeyInProgress) ||
+		errors.Is(err, errs.ErrTxConflict) ||
+		errors.Is(err, errs.ErrFlashSaleAlreadyExists) ||
+		errors.Is(err, errs.ErrJobStateConflict) ||
+		errors.Is(err, errs.ErrOrderNotPending) ||
+		errors.Is(err, errs.ErrWebhookDisabled) ||
+		errors.Is(err, errs.ErrWebhookDeliveryPending) ||
+		errors.Is(err, errs.ErrCategoryAlreadyExists) ||
+		errors.Is(err, errs.ErrCategoryNotEmpty) ||
+		errors.Is(err, errs.ErrVariantAlreadyExists) ||
+		errors.Is(err, errs.ErrProductAlreadyExists) ||
+		errors.Is(err, errs.ErrShopSlugAlreadyExists):
+		c.JSON(http.StatusConflict, CommonError{Error: err.Error()})
 	case errors.Is(err, errs.ErrIncorrectUsernameOrPassword) || errors.Is(err, errs.ErrInvalidToken):
 		c.JSON(http.StatusUnauthorized, CommonError{Error: err.Error()})
+	case errors.Is(err, errs.ErrForbidden):
+		c.JSON(http.StatusForbidden, CommonError{Error: err.Error()})
 	case errors.Is(err, errs.ErrInvalidFieldValue) ||
 		errors.Is(err, errs.ErrInvalidProductName) ||
+		errors.Is(err, errs.ErrInvalidShopName) ||
+		errors.Is(err, errs.ErrImmutableField) ||
+		errors.Is(err, errs.ErrInvalidShipmentStatus) ||
+		errors.Is(err, errs.ErrOrderNotShippable) ||
+		errors.Is(err, errs.ErrShippingAddressRequired) ||
+		errors.Is(err, errs.ErrShippingMethodUnavailable) ||
+		errors.Is(err, errs.ErrShippingMethodRequired) ||
+		errors.Is(err, errs.ErrCouponNotApplicable) ||
+		errors.Is(err, errs.ErrCouponUsageLimitReached) ||
+		errors.Is(err, errs.ErrCouponsNotStackable) ||
+		errors.Is(err, errs.ErrInvalidCurrency) ||
+		errors.Is(err, domain.ErrInvalidMoney) ||
+		errors.Is(err, errs.ErrExchangeRateUnavailable) ||
+		errors.Is(err, errs.ErrRateSourceNotConfigured) ||
+		errors.Is(err, errs.ErrInsufficientStock) ||
+		errors.Is(err, errs.ErrFlashSaleSoldOut) ||
+		errors.Is(err, errs.ErrFlashSaleLimitExceeded) ||
+		errors.Is(err, errs.ErrInvalidWebhookURL) ||
+		errors.Is(err, errs.ErrWebhookTargetForbidden) ||
+		errors.Is(err, errs.ErrInvalidWebhookEvent) ||
+		errors.Is(err, errs.ErrInvalidNotificationTemplate) ||
+		errors.Is(err, errs.ErrInvalidLocale) ||
+		errors.Is(err, errs.ErrCategoryCycle) ||
+		errors.Is(err, errs.ErrVariantRequired) ||
+		errors.Is(err, errs.ErrInvalidVariantOptions) ||
+		errors.Is(err, errs.ErrInvalidAttribute) ||
+		errors.Is(err, errs.ErrInvalidImage) ||
+		errors.Is(err, errs.ErrImageLimitReached) ||
+		errors.Is(err, errs.ErrBlobStoreNotConfigured) ||
+		errors.Is(err, errs.ErrInvalidImportFile) ||
 		errors.Is(err, errs.ErrUsernameAlreadyExists):
 		c.JSON(http.StatusUnprocessableEntity, CommonError{Error: err.Error()})
+	case errors.Is(err, errs.ErrVersionMismatch):
+		c.JSON(http.StatusPreconditionFailed, CommonError{Error: err.Error()})
+	case errors.Is(err, errs.ErrImageTooLarge) || errors.Is(err, errs.ErrImportFileTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, CommonError{Error: err.Error()})
+	case errors.Is(err, errs.ErrFlashSaleBusy):
+		c.JSON(http.StatusTooManyRequests, CommonError{Error: err.Error()})
 	default:
 		c.JSON(http.StatusInternalServerError, CommonError{Error: err.Error()})
 	}
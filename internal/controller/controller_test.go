@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"marketplace/internal/errs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleErrorStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: fmt.Errorf("%w: you are not the owner of this shop", errs.ErrForbidden), want: http.StatusForbidden},
+		{err: errs.ErrForbidden, want: http.StatusForbidden},
+		{err: errs.ErrOrderNotFound, want: http.StatusNotFound},
+		{err: fmt.Errorf("%w for product: Phone", errs.ErrInsufficientStock), want: http.StatusUnprocessableEntity},
+		{err: errs.ErrVersionMismatch, want: http.StatusPreconditionFailed},
+		{err: errors.New("boom"), want: http.StatusInternalServerError},
+	}
+	ctrl := NewController(nil)
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		ctrl.handleError(c, tt.err)
+		if w.Code != tt.want {
+			t.Errorf("handleError(%v) = %d, want %d", tt.err, w.Code, tt.want)
+		}
+	}
+}
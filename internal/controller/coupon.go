@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateCouponHandler godoc
+// @Summary Создать купон
+// @Description Создает купон (percentage, fixed_amount, free_shipping) для магазина, набора товаров или всего маркетплейса (только админ)
+// @Tags coupons
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body domain.Coupon true "Купон"
+// @Success 201 {object} domain.Coupon
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 409 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/coupons [post]
+func (ctrl *Controller) CreateCouponHandler(c *gin.Context) {
+	var coupon domain.Coupon
+	if err := c.ShouldBindJSON(&coupon); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	if err := ctrl.service.CreateCoupon(&coupon, userIDUntyped.(int), c.GetString(userRoleCtx)); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, coupon)
+}
+
+// ListCouponsHandler godoc
+// @Summary Список купонов
+// @Description Возвращает купоны магазина (shop_id) или купоны маркетплейса (без shop_id, только админ)
+// @Tags coupons
+// @Produce json
+// @Security BearerAuth
+// @Param shop_id query int false "Shop ID"
+// @Param cursor query string false "Курсор следующей страницы (next_cursor)"
+// @Param limit query int false "Размер страницы (не больше max_page_size)" default(20)
+// @Param with_total query bool false "Посчитать total"
+// @Success 200 {object} domain.Page[domain.Coupon]
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Router /api/v1/coupons [get]
+func (ctrl *Controller) ListCouponsHandler(c *gin.Context) {
+	var shopID *int64
+	if param := c.Query("shop_id"); param != "" {
+		id, err := strconv.ParseInt(param, 10, 64)
+		if err != nil || id <= 0 {
+			ctrl.handleError(c, errs.ErrInvalidShopID)
+			return
+		}
+		shopID = &id
+	}
+	page, err := parsePageRequest(c)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	coupons, err := ctrl.service.ListCoupons(shopID, page, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, coupons)
+}
+
+// DeactivateCouponHandler godoc
+// @Summary Деактивировать купон
+// @Description Отключает купон; история погашений сохраняется
+// @Tags coupons
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Coupon ID"
+// @Success 200 {object} CommonResponse
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/coupons/{id} [delete]
+func (ctrl *Controller) DeactivateCouponHandler(c *gin.Context) {
+	couponID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || couponID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	if err := ctrl.service.DeactivateCoupon(couponID, userIDUntyped.(int), c.GetString(userRoleCtx)); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, CommonResponse{Message: "coupon deactivated successfully"})
+}
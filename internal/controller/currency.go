@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListExchangeRatesHandler godoc
+// @Summary Курсы валют
+// @Description Возвращает текущие курсы валют (1 единица base_currency = rate единиц quote_currency)
+// @Tags currencies
+// @Produce json
+// @Security BearerAuth
+// @Param cursor query string false "Курсор следующей страницы (next_cursor)"
+// @Param limit query int false "Размер страницы (не больше max_page_size)" default(20)
+// @Param with_total query bool false "Посчитать total"
+// @Success 200 {object} domain.Page[domain.ExchangeRate]
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Router /api/v1/exchange-rates [get]
+func (ctrl *Controller) ListExchangeRatesHandler(c *gin.Context) {
+	page, err := parsePageRequest(c)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	rates, err := ctrl.service.ListExchangeRates(page)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rates)
+}
+
+// SetExchangeRateHandler godoc
+// @Summary Задать курс валюты
+// @Description Создает или обновляет курс вручную (только для админов)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body domain.ExchangeRate true "Курс"
+// @Success 200 {object} domain.ExchangeRate
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/admin/exchange-rates [post]
+func (ctrl *Controller) SetExchangeRateHandler(c *gin.Context) {
+	var rate domain.ExchangeRate
+	if err := c.ShouldBindJSON(&rate); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	if err := ctrl.service.SetExchangeRate(&rate); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rate)
+}
+
+// DeleteExchangeRateHandler godoc
+// @Summary Удалить курс валюты
+// @Description Удаляет курс (только для админов)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Exchange rate ID"
+// @Success 200 {object} CommonResponse
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/admin/exchange-rates/{id} [delete]
+func (ctrl *Controller) DeleteExchangeRateHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	if err := ctrl.service.DeleteExchangeRate(id); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, CommonResponse{Message: "exchange rate deleted successfully"})
+}
+
+// RefreshExchangeRatesHandler godoc
+// @Summary Обновить курсы из источника
+// @Description Загружает курсы из настроенного источника (файл или HTTP) и сохраняет их (только для админов)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.ExchangeRate
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Failure 500 {object} CommonError
+// @Router /api/v1/admin/exchange-rates/refresh [post]
+func (ctrl *Controller) RefreshExchangeRatesHandler(c *gin.Context) {
+	rates, err := ctrl.service.RefreshExchangeRates(c.Request.Context())
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rates)
+}
+
+// GetPreferencesHandler godoc
+// @Summary Настройки покупателя
+// @Description Возвращает валюту отображения цен
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.UserPreferences
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/me/preferences [get]
+func (ctrl *Controller) GetPreferencesHandler(c *gin.Context) {
+	userIDUntyped, _ := c.Get(userIDCtx)
+	prefs, err := ctrl.service.GetUserPreferences(userIDUntyped.(int))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferencesHandler godoc
+// @Summary Изменить настройки покупателя
+// @Description Задает валюту отображения цен (ISO 4217); пустое значение сбрасывает выбор
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body domain.UserPreferences true "Настройки"
+// @Success 200 {object} domain.UserPreferences
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/me/preferences [put]
+func (ctrl *Controller) UpdatePreferencesHandler(c *gin.Context) {
+	var prefs domain.UserPreferences
+	if err := c.ShouldBindJSON(&prefs); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	if err := ctrl.service.UpdateUserPreferences(userIDUntyped.(int), &prefs); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
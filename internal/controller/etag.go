@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"marketplace/internal/errs"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag отдает версию ресурса в заголовке ETag.
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", formatETag(version))
+}
+
+func formatETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatchVersion возвращает версию из If-Match для PUT, PATCH и DELETE: 0 - заголовка нет
+// или "*". Сравнение строгое, поэтому слабый ETag или список ETag не совпадает ни с одной
+// версией (412).
+func ifMatchVersion(c *gin.Context) (int64, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	unquoted, ok := strings.CutPrefix(value, `"`)
+	if ok {
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if !ok || err != nil || version <= 0 {
+		return 0, errs.ErrVersionMismatch
+	}
+	return version, nil
+}
+
+// notModified отвечает 304, если If-None-Match содержит текущий ETag или "*". Сравнение
+// слабое: W/"3" совпадает с "3".
+func notModified(c *gin.Context, version int64) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	current := formatETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			setETag(c, version)
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateFlashSaleHandler godoc
+// @Summary Запустить флеш-распродажу
+// @Description Выделяет quantity единиц из остатка товара под распродажу и делит их на слоты. Покупки идут через очередь с лимитом на покупателя
+// @Tags flash-sales
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param input body domain.CreateFlashSaleInput true "Параметры распродажи"
+// @Success 201 {object} domain.FlashSale
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 409 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/products/{id}/flash-sales [post]
+func (ctrl *Controller) CreateFlashSaleHandler(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || productID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidProductID)
+		return
+	}
+	var input domain.CreateFlashSaleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	sale, err := ctrl.service.CreateFlashSale(productID, input, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, sale)
+}
+
+// GetFlashSaleHandler godoc
+// @Summary Сверка флеш-распродажи
+// @Description Возвращает распродажу с проданным количеством, остатком в слотах и проверкой allocated = sold + remaining + returned
+// @Tags flash-sales
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Flash sale ID"
+// @Success 200 {object} domain.FlashSaleReport
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/flash-sales/{id} [get]
+func (ctrl *Controller) GetFlashSaleHandler(c *gin.Context) {
+	saleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || saleID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	report, err := ctrl.service.GetFlashSaleReport(saleID, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// FinishFlashSaleHandler godoc
+// @Summary Завершить флеш-распродажу
+// @Description Закрывает распродажу и возвращает нераспроданный остаток в products.quantity
+// @Tags flash-sales
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Flash sale ID"
+// @Success 200 {object} domain.FlashSaleReport
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/flash-sales/{id}/finish [post]
+func (ctrl *Controller) FinishFlashSaleHandler(c *gin.Context) {
+	saleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || saleID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	report, err := ctrl.service.FinishFlashSale(saleID, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// responseRecorder дублирует тело ответа, чтобы сохранить его под ключом идемпотентности.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotency обрабатывает заголовок Idempotency-Key: повтор запроса с тем же ключом и телом
+// получает сохраненный ответ, а не выполняется заново. Без заголовка запрос проходит как обычно.
+func (ctrl *Controller) idempotency(c *gin.Context) {
+	key := c.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		c.Next()
+		return
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, CommonError{Error: "failed to read request body"})
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	hash.Write(body)
+	fingerprint := hex.EncodeToString(hash.Sum(nil))
+
+	userIDUntyped, _ := c.Get(userIDCtx)
+	record, started, err := ctrl.service.BeginIdempotentRequest(userIDUntyped.(int), key, c.Request.Method, c.Request.URL.Path, fingerprint)
+	if err != nil {
+		ctrl.handleError(c, err)
+		c.Abort()
+		return
+	}
+	if !started {
+		c.Header(idempotentReplayedHeader, "true")
+		c.Data(record.ResponseStatus, record.ContentType, record.ResponseBody)
+		c.Abort()
+		return
+	}
+
+	// Паника обработчика не должна оставлять ключ в processing до истечения его срока: ключ
+	// освобождается, а паника передается дальше, в gin.Recovery.
+	defer func() {
+		if p := recover(); p != nil {
+			_ = ctrl.service.AbortIdempotentRequest(record)
+			panic(p)
+		}
+	}()
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+	c.Next()
+
+	status := recorder.Status()
+	if status >= http.StatusInternalServerError {
+		_ = ctrl.service.AbortIdempotentRequest(record)
+		return
+	}
+	// Ответ уже отправлен, поэтому ошибка сохранения попадает только в лог (сервис и c.Errors в
+	// логе gin). Сохранение повторяется один раз, а если и оно не удалось, ключ освобождается:
+	// иначе повтор запроса до истечения срока ключа получал бы 409, как будто первый еще выполняется.
+	contentType := recorder.Header().Get("Content-Type")
+	err = ctrl.service.CompleteIdempotentRequest(record, status, recorder.body.Bytes(), contentType)
+	if err == nil {
+		return
+	}
+	_ = c.Error(err)
+	if err = ctrl.service.CompleteIdempotentRequest(record, status, recorder.body.Bytes(), contentType); err == nil {
+		return
+	}
+	_ = c.Error(err)
+	if err = ctrl.service.AbortIdempotentRequest(record); err != nil {
+		_ = c.Error(err)
+	}
+}
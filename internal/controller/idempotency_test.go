@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"marketplace/internal/contracts"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotencyService запоминает, чем закончился запрос с ключом идемпотентности.
+type idempotencyService struct {
+	contracts.ServiceI
+	completed, aborted int
+	completeFailures   int // сколько первых попыток сохранить ответ завершатся ошибкой
+}
+
+func (s *idempotencyService) BeginIdempotentRequest(userID int, key, method, path, fingerprint string) (*domain.IdempotencyRecord, bool, error) {
+	return &domain.IdempotencyRecord{ID: 1, UserID: int64(userID), Key: key}, true, nil
+}
+
+func (s *idempotencyService) CompleteIdempotentRequest(*domain.IdempotencyRecord, int, []byte, string) error {
+	if s.completeFailures > 0 {
+		s.completeFailures--
+		return errors.New("connection reset")
+	}
+	s.completed++
+	return nil
+}
+
+func (s *idempotencyService) AbortIdempotentRequest(*domain.IdempotencyRecord) error {
+	s.aborted++
+	return nil
+}
+
+func TestIdempotencyReleasesKeyOnPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name          string
+		handler       gin.HandlerFunc
+		failures      int
+		wantStatus    int
+		wantCompleted int
+		wantAborted   int
+	}{
+		{name: "success", handler: func(c *gin.Context) { c.JSON(http.StatusCreated, gin.H{"id": 1}) }, wantStatus: http.StatusCreated, wantCompleted: 1},
+		{name: "server error", handler: func(c *gin.Context) { c.Status(http.StatusInternalServerError) }, wantStatus: http.StatusInternalServerError, wantAborted: 1},
+		{name: "panic", handler: func(c *gin.Context) { panic("boom") }, wantStatus: http.StatusInternalServerError, wantAborted: 1},
+		// Сбой сохранения ответа: одна повторная попытка, затем ключ освобождается
+		{name: "complete retried", handler: func(c *gin.Context) { c.JSON(http.StatusCreated, gin.H{"id": 1}) }, failures: 1, wantStatus: http.StatusCreated, wantCompleted: 1},
+		{name: "complete failed", handler: func(c *gin.Context) { c.JSON(http.StatusCreated, gin.H{"id": 1}) }, failures: 2, wantStatus: http.StatusCreated, wantAborted: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &idempotencyService{completeFailures: tt.failures}
+			ctrl := NewController(service)
+			router := gin.New()
+			router.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, _ any) { c.AbortWithStatus(http.StatusInternalServerError) }))
+			router.POST("/orders", func(c *gin.Context) { c.Set(userIDCtx, 1) }, ctrl.idempotency, tt.handler)
+
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{}`))
+			req.Header.Set(idempotencyKeyHeader, "order-1")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus || service.completed != tt.wantCompleted || service.aborted != tt.wantAborted {
+				t.Errorf("status %d, completed %d, aborted %d; want %d, %d, %d",
+					w.Code, service.completed, service.aborted, tt.wantStatus, tt.wantCompleted, tt.wantAborted)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImageRequestBytes ограничивает тело запроса загрузки до разбора формы; точный лимит
+// размера файла (image_params.max_upload_mb) проверяет сервис.
+const maxImageRequestBytes = 64 << 20
+
+// UploadProductImageHandler godoc
+// @Summary Загрузить изображение товара
+// @Description Загружает JPEG, PNG или GIF (multipart/form-data, поле file). Формат определяется по содержимому. Первое изображение становится главным; миниатюры создаются в фоне, до этого status = processing (владелец магазина или админ)
+// @Tags images
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param file formData file true "Изображение"
+// @Param alt formData string false "Подпись"
+// @Param variant_id formData int false "ID варианта товара"
+// @Param primary formData bool false "Сделать главным"
+// @Success 201 {object} domain.ProductImage
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 413 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/products/{id}/images [post]
+func (ctrl *Controller) UploadProductImageHandler(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || productID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidProductID)
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImageRequestBytes)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctrl.handleError(c, errs.ErrImageTooLarge)
+			return
+		}
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	defer file.Close()
+	input := domain.UploadImageInput{Alt: c.PostForm("alt")}
+	if input.Data, err = io.ReadAll(file); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	if value := c.PostForm("variant_id"); value != "" {
+		variantID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || variantID < 0 {
+			ctrl.handleError(c, errs.ErrVariantNotFound)
+			return
+		}
+		input.VariantID = &variantID
+	}
+	if value := c.PostForm("primary"); value != "" {
+		if input.Primary, err = strconv.ParseBool(value); err != nil {
+			ctrl.handleError(c, errs.ErrInvalidRequestBody)
+			return
+		}
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	image, err := ctrl.service.UploadProductImage(productID, input, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, image)
+}
+
+// ListProductImagesHandler godoc
+// @Summary Изображения товара
+// @Description Возвращает изображения товара в порядке показа с адресами миниатюр
+// @Tags images
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {array} domain.ProductImage
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/products/{id}/images [get]
+func (ctrl *Controller) ListProductImagesHandler(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || productID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidProductID)
+		return
+	}
+	images, err := ctrl.service.ListProductImages(productID)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, images)
+}
+
+// ReorderProductImagesHandler godoc
+// @Summary Порядок изображений товара
+// @Description Задает порядок показа; image_ids - все изображения товара в новом порядке (владелец магазина или админ)
+// @Tags images
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param input body domain.ReorderProductImagesInput true "Новый порядок"
+// @Success 200 {array} domain.ProductImage
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/products/{id}/images/order [put]
+func (ctrl *Controller) ReorderProductImagesHandler(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || productID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidProductID)
+		return
+	}
+	var input domain.ReorderProductImagesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	images, err := ctrl.service.ReorderProductImages(productID, input, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, images)
+}
+
+// UpdateProductImageHandler godoc
+// @Summary Изменить изображение товара
+// @Description Меняет подпись, привязку к варианту (variant_id 0 - отвязать) или делает изображение главным (владелец магазина или админ)
+// @Tags images
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Image ID"
+// @Param input body domain.UpdateProductImageInput true "Изменения"
+// @Success 200 {object} domain.ProductImage
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/images/{id} [put]
+func (ctrl *Controller) UpdateProductImageHandler(c *gin.Context) {
+	imageID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || imageID <= 0 {
+		ctrl.handleError(c, errs.ErrImageNotFound)
+		return
+	}
+	var input domain.UpdateProductImageInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	image, err := ctrl.service.UpdateProductImage(imageID, input, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, image)
+}
+
+// DeleteProductImageHandler godoc
+// @Summary Удалить изображение товара
+// @Description Удаляет изображение; файлы удаляются из хранилища в фоне. Если удалено главное изображение, главным становится следующее (владелец магазина или админ)
+// @Tags images
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Image ID"
+// @Success 200 {object} CommonResponse
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/images/{id} [delete]
+func (ctrl *Controller) DeleteProductImageHandler(c *gin.Context) {
+	imageID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || imageID <= 0 {
+		ctrl.handleError(c, errs.ErrImageNotFound)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	if err := ctrl.service.DeleteProductImage(imageID, userIDUntyped.(int), c.GetString(userRoleCtx)); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, CommonResponse{Message: "image deleted successfully"})
+}
+
+// MediaHandler godoc
+// @Summary Файл изображения
+// @Description Отдает файл из хранилища по ключу. Ключи уникальны, поэтому ответ кешируется надолго
+// @Tags images
+// @Produce octet-stream
+// @Param key path string true "Ключ файла"
+// @Success 200 {file} file
+// @Failure 404 {object} CommonError
+// @Router /media/{key} [get]
+func (ctrl *Controller) MediaHandler(c *gin.Context) {
+	blob, err := ctrl.service.OpenBlob(c.Request.Context(), strings.TrimPrefix(c.Param("key"), "/"))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	defer blob.Body.Close()
+	contentType := blob.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.DataFromReader(http.StatusOK, blob.Size, contentType, blob.Body, map[string]string{
+		"Cache-Control":          "public, max-age=31536000, immutable",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
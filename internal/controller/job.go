@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListJobsHandler godoc
+// @Summary Список фоновых задач
+// @Description Возвращает задачи очереди с фильтром по статусу и типу (только админ)
+// @Tags jobs
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, running, completed, dead, cancelled"
+// @Param type query string false "Тип задачи"
+// @Param cursor query string false "Курсор следующей страницы (next_cursor)"
+// @Param limit query int false "Размер страницы (не больше max_page_size)" default(20)
+// @Param with_total query bool false "Посчитать total"
+// @Success 200 {object} domain.Page[domain.Job]
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Router /api/v1/admin/jobs [get]
+func (ctrl *Controller) ListJobsHandler(c *gin.Context) {
+	page, err := parsePageRequest(c)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	jobs, err := ctrl.service.ListJobs(domain.JobFilter{
+		Status:      c.Query("status"),
+		Type:        c.Query("type"),
+		PageRequest: page,
+	})
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, jobs)
+}
+
+// EnqueueJobHandler godoc
+// @Summary Поставить задачу в очередь
+// @Description Создает задачу указанного типа; без run_at задача выполняется сразу (только админ)
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body domain.EnqueueJobInput true "Задача"
+// @Success 201 {object} domain.Job
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/admin/jobs [post]
+func (ctrl *Controller) EnqueueJobHandler(c *gin.Context) {
+	var input domain.EnqueueJobInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	var runAt time.Time
+	if input.RunAt != nil {
+		runAt = *input.RunAt
+	}
+	var payload interface{}
+	if len(input.Payload) > 0 {
+		payload = input.Payload
+	}
+	job, err := ctrl.service.EnqueueJob(input.Type, payload, runAt)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, job)
+}
+
+// GetJobHandler godoc
+// @Summary Получить задачу
+// @Description Возвращает задачу с числом попыток и последней ошибкой (только админ)
+// @Tags jobs
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Job ID"
+// @Success 200 {object} domain.Job
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/admin/jobs/{id} [get]
+func (ctrl *Controller) GetJobHandler(c *gin.Context) {
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || jobID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	job, err := ctrl.service.GetJob(jobID)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// RetryJobHandler godoc
+// @Summary Повторить задачу
+// @Description Возвращает в очередь задачу в статусе dead или cancelled со сброшенным счетчиком попыток (только админ)
+// @Tags jobs
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Job ID"
+// @Success 200 {object} domain.Job
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 409 {object} CommonError
+// @Router /api/v1/admin/jobs/{id}/retry [post]
+func (ctrl *Controller) RetryJobHandler(c *gin.Context) {
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || jobID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	job, err := ctrl.service.RetryJob(jobID)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelJobHandler godoc
+// @Summary Отменить задачу
+// @Description Отменяет задачу в статусе pending; выполняющуюся задачу отменить нельзя (только админ)
+// @Tags jobs
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Job ID"
+// @Success 200 {object} domain.Job
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 409 {object} CommonError
+// @Router /api/v1/admin/jobs/{id}/cancel [post]
+func (ctrl *Controller) CancelJobHandler(c *gin.Context) {
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || jobID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	job, err := ctrl.service.CancelJob(jobID)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// ListJobSchedulesHandler godoc
+// @Summary Расписания задач
+// @Description Возвращает cron-расписания с временем следующего и последнего запуска (только админ)
+// @Tags jobs
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.JobSchedule
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Router /api/v1/admin/job-schedules [get]
+func (ctrl *Controller) ListJobSchedulesHandler(c *gin.Context) {
+	schedules, err := ctrl.service.ListJobSchedules()
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, schedules)
+}
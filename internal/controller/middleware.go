@@ -11,6 +11,7 @@ const (
 	authorizationHeader = "Authorization"
 	userIDCtx           = "userID"
 	userRoleCtx         = "userRole"
+	accessTokenQuery    = "access_token"
 )
 
 func (ctrl *Controller) checkUserAuthentication(c *gin.Context) {
@@ -19,6 +20,21 @@ func (ctrl *Controller) checkUserAuthentication(c *gin.Context) {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, CommonError{Error: err.Error()})
 		return
 	}
+	ctrl.authenticateToken(c, token)
+}
+
+// checkStreamAuthentication - проверка того же access-токена для потока обновлений. Браузерные
+// EventSource и WebSocket не умеют передавать заголовки, поэтому без Authorization токен берется
+// из параметра access_token.
+func (ctrl *Controller) checkStreamAuthentication(c *gin.Context) {
+	if c.GetHeader(authorizationHeader) == "" && c.Query(accessTokenQuery) != "" {
+		ctrl.authenticateToken(c, c.Query(accessTokenQuery))
+		return
+	}
+	ctrl.checkUserAuthentication(c)
+}
+
+func (ctrl *Controller) authenticateToken(c *gin.Context, token string) {
 	userID, isRefresh, userRole, err := pkg.ParseToken(token)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, CommonError{Error: err.Error()})
@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListNotificationsHandler godoc
+// @Summary Входящие уведомления
+// @Description Возвращает ленту уведомлений пользователя (новые первыми) и число непрочитанных
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Param unread query bool false "Только непрочитанные"
+// @Param cursor query string false "Курсор следующей страницы (next_cursor)"
+// @Param limit query int false "Размер страницы (не больше max_page_size)" default(20)
+// @Param with_total query bool false "Посчитать total"
+// @Success 200 {object} domain.NotificationInbox
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Router /api/v1/me/notifications [get]
+func (ctrl *Controller) ListNotificationsHandler(c *gin.Context) {
+	page, err := parsePageRequest(c)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	unreadOnly, _ := strconv.ParseBool(c.DefaultQuery("unread", "false"))
+	userIDUntyped, _ := c.Get(userIDCtx)
+	inbox, err := ctrl.service.ListNotifications(userIDUntyped.(int), unreadOnly, page)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, inbox)
+}
+
+// MarkNotificationReadHandler godoc
+// @Summary Отметить уведомление прочитанным
+// @Description Отмечает входящее уведомление пользователя прочитанным
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Notification ID"
+// @Success 200 {object} domain.Notification
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/me/notifications/{id}/read [post]
+func (ctrl *Controller) MarkNotificationReadHandler(c *gin.Context) {
+	notificationID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || notificationID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	notification, err := ctrl.service.MarkNotificationRead(notificationID, userIDUntyped.(int))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllNotificationsReadHandler godoc
+// @Summary Прочитать все уведомления
+// @Description Отмечает все входящие уведомления пользователя прочитанными
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} CommonResponse
+// @Failure 401 {object} CommonError
+// @Router /api/v1/me/notifications/read-all [post]
+func (ctrl *Controller) MarkAllNotificationsReadHandler(c *gin.Context) {
+	userIDUntyped, _ := c.Get(userIDCtx)
+	marked, err := ctrl.service.MarkAllNotificationsRead(userIDUntyped.(int))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, CommonResponse{Message: strconv.FormatInt(marked, 10) + " notifications marked as read"})
+}
+
+// GetNotificationPreferencesHandler godoc
+// @Summary Настройки уведомлений
+// @Description Возвращает язык и включенные каналы уведомлений (email, sms, in_app)
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.NotificationPreferences
+// @Failure 401 {object} CommonError
+// @Router /api/v1/me/notification-preferences [get]
+func (ctrl *Controller) GetNotificationPreferencesHandler(c *gin.Context) {
+	userIDUntyped, _ := c.Get(userIDCtx)
+	prefs, err := ctrl.service.GetNotificationPreferences(userIDUntyped.(int))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdateNotificationPreferencesHandler godoc
+// @Summary Изменить настройки уведомлений
+// @Description Задает язык уведомлений (двухбуквенный код) и включенные каналы; пустой язык - язык по умолчанию
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body domain.NotificationPreferences true "Настройки"
+// @Success 200 {object} domain.NotificationPreferences
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/me/notification-preferences [put]
+func (ctrl *Controller) UpdateNotificationPreferencesHandler(c *gin.Context) {
+	var prefs domain.NotificationPreferences
+	if err := c.ShouldBindJSON(&prefs); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	if err := ctrl.service.UpdateNotificationPreferences(userIDUntyped.(int), &prefs); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// ListNotificationTemplatesHandler godoc
+// @Summary Шаблоны уведомлений
+// @Description Возвращает шаблоны уведомлений с фильтром по типу события (только админ)
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Param event_type query string false "Тип события"
+// @Success 200 {array} domain.NotificationTemplate
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Router /api/v1/admin/notification-templates [get]
+func (ctrl *Controller) ListNotificationTemplatesHandler(c *gin.Context) {
+	templates, err := ctrl.service.ListNotificationTemplates(c.Query("event_type"))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, templates)
+}
+
+// SaveNotificationTemplateHandler godoc
+// @Summary Сохранить шаблон уведомления
+// @Description Создает или заменяет шаблон для типа события, канала и языка. Шаблон - Go text/template над данными события (только админ)
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body domain.NotificationTemplate true "Шаблон"
+// @Success 200 {object} domain.NotificationTemplate
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/admin/notification-templates [put]
+func (ctrl *Controller) SaveNotificationTemplateHandler(c *gin.Context) {
+	var tmpl domain.NotificationTemplate
+	if err := c.ShouldBindJSON(&tmpl); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	if err := ctrl.service.SaveNotificationTemplate(&tmpl); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tmpl)
+}
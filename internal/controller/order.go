@@ -1,7 +1,6 @@
 package controller
 
 import (
-	"errors"
 	"marketplace/internal/errs"
 	"marketplace/internal/models/domain"
 	"net/http"
@@ -21,6 +20,7 @@ import (
 // @Success      201  {object}  map[string]int64
 // @Failure      400  {object}  CommonError
 // @Failure      401  {object}  CommonError
+// @Failure      422  {object}  CommonError
 // @Failure      500  {object}  CommonError
 // @Router       /api/v1/orders [post]
 func (ctrl *Controller) CreateOrderHandler(c *gin.Context) {
@@ -48,12 +48,14 @@ func (ctrl *Controller) CreateOrderHandler(c *gin.Context) {
 
 // GetOrderHandler godoc
 // @Summary      Получить заказ по ID
-// @Description  Получает детали заказа по его ID
+// @Description  Получает детали заказа по его ID вместе с отправлениями и историей трекинга. Версия заказа отдается в ETag; с If-None-Match, совпадающим с ней, ответ 304 без тела
 // @Tags         orders
 // @Produce      json
 // @Security     BearerAuth
 // @Param        id path int true "Order ID"
+// @Param        If-None-Match header string false "ETag из предыдущего ответа"
 // @Success      200  {object}  domain.Order
+// @Success      304
 // @Failure      400  {object}  CommonError
 // @Failure      401  {object}  CommonError
 // @Failure      403  {object}  CommonError
@@ -77,11 +79,60 @@ func (ctrl *Controller) GetOrderHandler(c *gin.Context) {
 	userID := userIDUntyped.(int)
 
 	if userRole != domain.AdminRole && order.UserID != int64(userID) {
-		ctrl.handleError(c, errors.New("permission denied"))
+		ctrl.handleError(c, errs.ErrForbidden)
+		return
+	}
+	if notModified(c, order.Version) {
+		return
+	}
+
+	shipments, err := ctrl.service.GetOrderShipments(orderID)
+	if err != nil {
+		ctrl.handleError(c, err)
 		return
 	}
 
 	order.Items = items
+	order.Shipments = shipments
 
+	setETag(c, order.Version)
+	c.JSON(http.StatusOK, order)
+}
+
+// ConfirmOrderHandler godoc
+// @Summary      Подтвердить оплату заказа
+// @Description  Переводит заказ из pending в confirmed с номером платежа: смена статуса пишется в историю с автором и публикуется событием order.status_changed. Только подтвержденный заказ можно отгружать, и он не отменяется по сроку оплаты (только админ)
+// @Tags         orders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path int true "Order ID"
+// @Param        input body domain.ConfirmOrderInput true "Данные платежа"
+// @Success      200  {object}  domain.Order
+// @Failure      400  {object}  CommonError
+// @Failure      401  {object}  CommonError
+// @Failure      403  {object}  CommonError
+// @Failure      404  {object}  CommonError
+// @Failure      409  {object}  CommonError
+// @Failure      422  {object}  CommonError
+// @Router       /api/v1/admin/orders/{id}/confirm [post]
+func (ctrl *Controller) ConfirmOrderHandler(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	var input domain.ConfirmOrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	order, err := ctrl.service.ConfirmOrder(orderID, userIDUntyped.(int), input)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	setETag(c, order.Version)
 	c.JSON(http.StatusOK, order)
 }
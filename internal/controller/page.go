@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parsePageRequest разбирает общие параметры пагинации: cursor, limit и with_total.
+// Размер страницы по умолчанию и его верхнюю границу применяет сервис.
+func parsePageRequest(c *gin.Context) (domain.PageRequest, error) {
+	page := domain.PageRequest{Cursor: c.Query("cursor")}
+	if limitParam := c.Query("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			return page, errs.ErrInvalidFieldValue
+		}
+		page.Limit = limit
+	}
+	if withTotalParam := c.Query("with_total"); withTotalParam != "" {
+		withTotal, err := strconv.ParseBool(withTotalParam)
+		if err != nil {
+			return page, errs.ErrInvalidFieldValue
+		}
+		page.WithTotal = withTotal
+	}
+	return page, nil
+}
@@ -5,6 +5,7 @@ import (
 	"marketplace/internal/models/domain"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -39,11 +40,13 @@ func (ctrl *Controller) CreateProductHandler(c *gin.Context) {
 
 // GetProductByIDHandler godoc
 // @Summary Получить продукт по ID
-// @Description Получает информацию о продукте по его ID
+// @Description Получает информацию о продукте по его ID. Версия продукта отдается в ETag; с If-None-Match, совпадающим с ней, ответ 304 без тела (кроме ответов с display_price, которая зависит от курсов)
 // @Tags products
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param If-None-Match header string false "ETag из предыдущего ответа"
 // @Success 200 {object} domain.Product
+// @Success 304
 // @Failure 400 {object} CommonError
 // @Failure 404 {object} CommonError
 // @Router /api/v1/products/{id} [get]
@@ -63,23 +66,45 @@ func (ctrl *Controller) GetProductByIDHandler(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if err := ctrl.service.AttachProductCategories(product); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	if err := ctrl.service.AttachProductVariants(product); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	if err := ctrl.service.AttachProductImages(product); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	ctrl.service.ApplyDisplayCurrency(userIDUntyped.(int), product)
+	if product.DisplayPrice == nil && notModified(c, product.Version) {
+		return
+	}
+	setETag(c, product.Version)
 	c.JSON(http.StatusOK, product)
 }
 
 // UpdateProductHandler godoc
 // @Summary Обновить продукт
-// @Description Обновляет информацию о продукте (только для админов и владельцев магазинов)
+// @Description Заменяет продукт целиком: пропущенные поля обнуляются, поэтому продукт проверяется как при создании. shop_id и slug менять нельзя, slug пересчитывается из названия (только для админов и владельцев магазинов)
 // @Tags products
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Product ID"
+// @Param If-Match header string false "ETag продукта; при несовпадении версии - 412"
 // @Param input body domain.Product true "Данные для обновления"
 // @Success 200 {object} domain.Product
 // @Failure 400 {object} CommonError
 // @Failure 401 {object} CommonError
 // @Failure 403 {object} CommonError
 // @Failure 404 {object} CommonError
+// @Failure 409 {object} CommonError
+// @Failure 412 {object} CommonError
+// @Failure 422 {object} CommonError
 // @Router /api/v1/products/{id} [put]
 func (ctrl *Controller) UpdateProductHandler(c *gin.Context) {
 	var product domain.Product
@@ -93,17 +118,68 @@ func (ctrl *Controller) UpdateProductHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errs.ErrInvalidProductID.Error()})
 		return
 	}
-	product.ID = id
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
 	userIDUntyped, _ := c.Get(userIDCtx)
 	userRoleUntyped, _ := c.Get(userRoleCtx)
 	userID := userIDUntyped.(int)
 	userRole := userRoleUntyped.(string)
 
 	product.ID = id
-	if err := ctrl.service.UpdateProduct(&product, userID, userRole); err != nil {
+	if err := ctrl.service.UpdateProduct(&product, version, userID, userRole); err != nil {
 		ctrl.handleError(c, err)
 		return
 	}
+	setETag(c, product.Version)
+	c.JSON(http.StatusOK, product)
+}
+
+// PatchProductHandler godoc
+// @Summary Частично обновить продукт
+// @Description Применяет JSON Merge Patch (RFC 7386): меняются только переданные поля, null очищает sku, description и attributes, attributes сливаются по ключам. Можно менять sku, name, description, price, currency, quantity, tax_class, active, weight_grams, length_mm, width_mm, height_mm, attributes (только для админов и владельцев магазинов)
+// @Tags products
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param If-Match header string false "ETag продукта; при несовпадении версии - 412"
+// @Param input body object true "Изменяемые поля"
+// @Success 200 {object} domain.Product
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 409 {object} CommonError
+// @Failure 412 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/products/{id} [patch]
+func (ctrl *Controller) PatchProductHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidProductID)
+		return
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	patch, err := c.GetRawData()
+	if err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	product, err := ctrl.service.PatchProduct(id, patch, version, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	setETag(c, product.Version)
 	c.JSON(http.StatusOK, product)
 }
 
@@ -114,11 +190,13 @@ func (ctrl *Controller) UpdateProductHandler(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Product ID"
+// @Param If-Match header string false "ETag продукта; при несовпадении версии - 412"
 // @Success 200 {object} CommonResponse
 // @Failure 400 {object} CommonError
 // @Failure 401 {object} CommonError
 // @Failure 403 {object} CommonError
 // @Failure 404 {object} CommonError
+// @Failure 412 {object} CommonError
 // @Router /api/v1/products/{id} [delete]
 func (ctrl *Controller) DeleteProductHandler(c *gin.Context) {
 	idParam := c.Param("id")
@@ -128,12 +206,17 @@ func (ctrl *Controller) DeleteProductHandler(c *gin.Context) {
 		return
 	}
 
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
 	userIDUntyped, _ := c.Get(userIDCtx)
 	userRoleUntyped, _ := c.Get(userRoleCtx)
 	userID := userIDUntyped.(int)
 	userRole := userRoleUntyped.(string)
 
-	if err := ctrl.service.DeleteProduct(id, userID, userRole); err != nil {
+	if err := ctrl.service.DeleteProduct(id, version, userID, userRole); err != nil {
 		ctrl.handleError(c, err)
 		return
 	}
@@ -142,31 +225,110 @@ func (ctrl *Controller) DeleteProductHandler(c *gin.Context) {
 
 // ListProductsHandler godoc
 // @Summary Список продуктов
-// @Description Получает список продуктов магазина
+// @Description Каталог товаров с фильтрами, сортировкой и фасетами. Каждый фасет считается без собственного фильтра, чтобы были видны альтернативы
 // @Tags products
 // @Produce json
-// @Param shop_id query int true "Shop ID"
-// @Param limit query int false "Limit" default(20)
-// @Param offset query int false "Offset" default(0)
-// @Success 200 {array} domain.Product
+// @Security BearerAuth
+// @Param shop_id query int false "Shop ID"
+// @Param category_id query int false "Категория вместе с подкатегориями"
+// @Param currency query string false "Валюта товаров (ISO 4217); обязательна для диапазона цен"
+// @Param min_price query string false "Минимальная цена" example(10.00)
+// @Param max_price query string false "Максимальная цена" example(99.99)
+// @Param in_stock query bool false "true - в наличии, false - нет в наличии"
+// @Param active query bool false "Активность товара"
+// @Param attr[color] query string false "Значения характеристики через запятую (attr[<имя>]=a,b)"
+// @Param sort query string false "newest, price_asc, price_desc, name, popular" default(newest)
+// @Param price_buckets query string false "Границы интервалов фасета цен через запятую (в валюте currency)" example(10,50,100)
+// @Param cursor query string false "Курсор следующей страницы (next_cursor)"
+// @Param limit query int false "Размер страницы (не больше max_page_size)" default(20)
+// @Param with_total query bool false "Посчитать total"
+// @Success 200 {object} domain.ProductListResult
 // @Failure 400 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 422 {object} CommonError
 // @Failure 500 {object} CommonError
 // @Router /api/v1/products [get]
 func (ctrl *Controller) ListProductsHandler(c *gin.Context) {
-	shopIDParam := c.Query("shop_id")
-	limitParam := c.DefaultQuery("limit", "20")
-	offsetParam := c.DefaultQuery("offset", "0")
-	shopID, err := strconv.ParseInt(shopIDParam, 10, 64)
-	if err != nil || shopID <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errs.ErrInvalidFieldValue.Error()})
+	filter, err := parseProductFilter(c)
+	if err != nil {
+		ctrl.handleError(c, err)
 		return
 	}
-	limit, _ := strconv.Atoi(limitParam)
-	offset, _ := strconv.Atoi(offsetParam)
-	products, err := ctrl.service.ListProducts(shopID, limit, offset)
+	result, err := ctrl.service.ListProducts(filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctrl.handleError(c, err)
+		return
+	}
+	if err := ctrl.service.AttachProductCategories(result.Items...); err != nil {
+		ctrl.handleError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, products)
+	userIDUntyped, _ := c.Get(userIDCtx)
+	ctrl.service.ApplyDisplayCurrency(userIDUntyped.(int), result.Items...)
+	c.JSON(http.StatusOK, result)
+}
+
+// parseProductFilter разбирает общие параметры фильтрации каталога и поиска.
+func parseProductFilter(c *gin.Context) (domain.ProductFilter, error) {
+	filter := domain.ProductFilter{
+		Currency: strings.ToUpper(strings.TrimSpace(c.Query("currency"))),
+		Sort:     c.Query("sort"),
+	}
+	page, err := parsePageRequest(c)
+	if err != nil {
+		return filter, err
+	}
+	filter.PageRequest = page
+	if shopIDParam := c.Query("shop_id"); shopIDParam != "" {
+		shopID, err := strconv.ParseInt(shopIDParam, 10, 64)
+		if err != nil || shopID <= 0 {
+			return filter, errs.ErrInvalidShopID
+		}
+		filter.ShopID = shopID
+	}
+	if categoryIDParam := c.Query("category_id"); categoryIDParam != "" {
+		categoryID, err := strconv.ParseInt(categoryIDParam, 10, 64)
+		if err != nil || categoryID <= 0 {
+			return filter, errs.ErrCategoryNotFound
+		}
+		filter.CategoryID = categoryID
+	}
+	for param, target := range map[string]**bool{"in_stock": &filter.InStock, "active": &filter.Active} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		flag, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errs.ErrInvalidFieldValue
+		}
+		*target = &flag
+	}
+	for param, target := range map[string]**domain.Money{"min_price": &filter.MinPrice, "max_price": &filter.MaxPrice} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		price, err := domain.ParseMoney(value, filter.Currency)
+		if err != nil {
+			return filter, errs.ErrInvalidFieldValue
+		}
+		*target = &price
+	}
+	if attributes := c.QueryMap("attr"); len(attributes) > 0 {
+		filter.Attributes = make(map[string][]string, len(attributes))
+		for key, values := range attributes {
+			filter.Attributes[key] = strings.Split(values, ",")
+		}
+	}
+	if buckets := c.Query("price_buckets"); buckets != "" {
+		for _, bound := range strings.Split(buckets, ",") {
+			price, err := domain.ParseMoney(strings.TrimSpace(bound), filter.Currency)
+			if err != nil {
+				return filter, errs.ErrInvalidFieldValue
+			}
+			filter.PriceBuckets = append(filter.PriceBuckets, price)
+		}
+	}
+	return filter, nil
 }
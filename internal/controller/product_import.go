@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportRequestBytes ограничивает тело запроса импорта до разбора формы; точный лимит
+// (import_params.max_file_mb) проверяет сервис.
+const maxImportRequestBytes = 256 << 20
+
+var exportContentTypes = map[string]string{
+	domain.ImportFormatCSV:   "text/csv; charset=utf-8",
+	domain.ImportFormatJSONL: "application/x-ndjson",
+}
+
+// CreateProductImportHandler godoc
+// @Summary Импорт товаров
+// @Description Загружает CSV или JSONL (multipart/form-data, поле file) и ставит импорт в очередь. Строки проверяются по правилам создания товара; товар магазина с тем же sku обновляется, иначе создается. С dry_run=true строки только проверяются (владелец магазина или админ)
+// @Tags imports
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Shop ID"
+// @Param file formData file true "Файл CSV или JSONL"
+// @Param format formData string false "csv или jsonl; по умолчанию по расширению файла"
+// @Param dry_run formData bool false "Только проверить строки"
+// @Success 202 {object} domain.ProductImport
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 413 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/shops/{id}/imports [post]
+func (ctrl *Controller) CreateProductImportHandler(c *gin.Context) {
+	shopID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || shopID <= 0 {
+		ctrl.handleError(c, errs.ErrNotfound)
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportRequestBytes)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctrl.handleError(c, errs.ErrImportFileTooLarge)
+			return
+		}
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	defer file.Close()
+	input := domain.CreateProductImportInput{Filename: header.Filename, Format: c.PostForm("format")}
+	if input.Data, err = io.ReadAll(file); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	if value := c.PostForm("dry_run"); value != "" {
+		if input.DryRun, err = strconv.ParseBool(value); err != nil {
+			ctrl.handleError(c, errs.ErrInvalidRequestBody)
+			return
+		}
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	productImport, err := ctrl.service.CreateProductImport(shopID, input, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, productImport)
+}
+
+// GetProductImportHandler godoc
+// @Summary Статус импорта товаров
+// @Description Возвращает статус и счетчики импорта; errors_url появляется, если в файле были ошибочные строки (владелец магазина или админ)
+// @Tags imports
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Import ID"
+// @Success 200 {object} domain.ProductImport
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/imports/{id} [get]
+func (ctrl *Controller) GetProductImportHandler(c *gin.Context) {
+	importID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || importID <= 0 {
+		ctrl.handleError(c, errs.ErrImportNotFound)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	productImport, err := ctrl.service.GetProductImport(importID, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, productImport)
+}
+
+// GetProductImportErrorsHandler godoc
+// @Summary Ошибки импорта товаров
+// @Description Отдает CSV-отчет об ошибочных строках импорта: line, sku, error (владелец магазина или админ)
+// @Tags imports
+// @Produce text/csv
+// @Security BearerAuth
+// @Param id path int true "Import ID"
+// @Success 200 {file} file
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/imports/{id}/errors [get]
+func (ctrl *Controller) GetProductImportErrorsHandler(c *gin.Context) {
+	importID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || importID <= 0 {
+		ctrl.handleError(c, errs.ErrImportNotFound)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	report, err := ctrl.service.OpenProductImportErrors(importID, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	defer report.Body.Close()
+	c.DataFromReader(http.StatusOK, report.Size, exportContentTypes[domain.ImportFormatCSV], report.Body, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="import-%d-errors.csv"`, importID),
+	})
+}
+
+// ExportShopProductsHandler godoc
+// @Summary Экспорт товаров магазина
+// @Description Потоково выгружает все товары магазина (включая неактивные) в формате импорта: CSV или JSONL (владелец магазина или админ)
+// @Tags imports
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Param id path int true "Shop ID"
+// @Param format query string false "csv (по умолчанию) или jsonl"
+// @Success 200 {file} file
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/shops/{id}/products/export [get]
+func (ctrl *Controller) ExportShopProductsHandler(c *gin.Context) {
+	shopID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || shopID <= 0 {
+		ctrl.handleError(c, errs.ErrNotfound)
+		return
+	}
+	format := c.DefaultQuery("format", domain.ImportFormatCSV)
+	w := &exportWriter{c: c, format: format, shopID: shopID}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	err = ctrl.service.ExportShopProducts(shopID, format, userIDUntyped.(int), c.GetString(userRoleCtx), w)
+	if err == nil {
+		w.start()
+		return
+	}
+	if !w.started {
+		ctrl.handleError(c, err)
+		return
+	}
+	// Заголовки уже отправлены: обрываем ответ, чтобы клиент не принял неполный файл за целый.
+	_ = c.Error(err)
+	panic(http.ErrAbortHandler)
+}
+
+// exportWriter отправляет заголовки файла экспорта при первой записи: ошибка до нее
+// (нет доступа, неизвестный формат) возвращается обычным JSON-ответом.
+type exportWriter struct {
+	c       *gin.Context
+	format  string
+	shopID  int64
+	started bool
+}
+
+func (w *exportWriter) start() {
+	if w.started {
+		return
+	}
+	w.started = true
+	w.c.Header("Content-Type", exportContentTypes[w.format])
+	w.c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="shop-%d-products.%s"`, w.shopID, w.format))
+	w.c.Status(http.StatusOK)
+}
+
+func (w *exportWriter) Write(p []byte) (int, error) {
+	w.start()
+	return w.c.Writer.Write(p)
+}
+
+func (w *exportWriter) Flush() {
+	w.c.Writer.Flush()
+}
@@ -13,33 +13,120 @@ func (ctrl *Controller) InitRoutes() *gin.Engine {
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	r.GET("/ping", ctrl.ping)
 	r.GET("/health", ctrl.healthCheck)
+	r.GET("/media/*key", ctrl.MediaHandler)
 	authG := r.Group("/auth")
 	{
 		authG.POST("/sign-up", ctrl.SignUp)
 		authG.POST("/sign-in", ctrl.SignIn)
 		authG.GET("/refresh", ctrl.RefreshTokenPair)
 	}
+	streamG := r.Group("/api/v1/stream", ctrl.checkStreamAuthentication)
+	{
+		streamG.GET("", ctrl.StreamHandler)
+		streamG.GET("/ws", ctrl.StreamWebSocketHandler)
+	}
 	apiV1G := r.Group("/api/v1", ctrl.checkUserAuthentication)
 	adminG := apiV1G.Group("/admin", ctrl.checkRole(domain.AdminRole))
 	{
 		adminG.PUT("/users/:id/role", ctrl.SetUserRoleHandler)
+		adminG.POST("/exchange-rates", ctrl.SetExchangeRateHandler)
+		adminG.DELETE("/exchange-rates/:id", ctrl.DeleteExchangeRateHandler)
+		adminG.POST("/exchange-rates/refresh", ctrl.RefreshExchangeRatesHandler)
+		adminG.POST("/tax-rates", ctrl.CreateTaxRateHandler)
+		adminG.GET("/tax-rates", ctrl.ListTaxRatesHandler)
+		adminG.PUT("/tax-rates/:id", ctrl.UpdateTaxRateHandler)
+		adminG.DELETE("/tax-rates/:id", ctrl.DeleteTaxRateHandler)
+		adminG.GET("/jobs", ctrl.ListJobsHandler)
+		adminG.POST("/jobs", ctrl.EnqueueJobHandler)
+		adminG.GET("/jobs/:id", ctrl.GetJobHandler)
+		adminG.POST("/jobs/:id/retry", ctrl.RetryJobHandler)
+		adminG.POST("/jobs/:id/cancel", ctrl.CancelJobHandler)
+		adminG.GET("/job-schedules", ctrl.ListJobSchedulesHandler)
+		adminG.GET("/notification-templates", ctrl.ListNotificationTemplatesHandler)
+		adminG.PUT("/notification-templates", ctrl.SaveNotificationTemplateHandler)
+		adminG.POST("/categories", ctrl.CreateCategoryHandler)
+		adminG.PUT("/categories/:id", ctrl.UpdateCategoryHandler)
+		adminG.POST("/categories/:id/move", ctrl.MoveCategoryHandler)
+		adminG.DELETE("/categories/:id", ctrl.DeleteCategoryHandler)
+		adminG.PUT("/categories/:id/attributes", ctrl.SetCategoryAttributesHandler)
+		adminG.POST("/orders/:id/confirm", ctrl.ConfirmOrderHandler)
 	}
 	shopkeeperG := apiV1G.Group("", ctrl.checkRole(domain.AdminRole, domain.ShopkeperRole))
 	{
-		shopkeeperG.POST("/products", ctrl.CreateProductHandler)
+		shopkeeperG.POST("/products", ctrl.idempotency, ctrl.CreateProductHandler)
 		shopkeeperG.PUT("/products/:id", ctrl.UpdateProductHandler)
+		shopkeeperG.PATCH("/products/:id", ctrl.PatchProductHandler)
 		shopkeeperG.DELETE("/products/:id", ctrl.DeleteProductHandler)
+		shopkeeperG.PUT("/products/:id/categories", ctrl.SetProductCategoriesHandler)
+		shopkeeperG.PUT("/products/:id/options", ctrl.SetProductOptionsHandler)
+		shopkeeperG.POST("/products/:id/variants", ctrl.CreateProductVariantHandler)
+		shopkeeperG.PUT("/variants/:id", ctrl.UpdateProductVariantHandler)
+		shopkeeperG.DELETE("/variants/:id", ctrl.DeleteProductVariantHandler)
+		shopkeeperG.POST("/products/:id/images", ctrl.UploadProductImageHandler)
+		shopkeeperG.PUT("/products/:id/images/order", ctrl.ReorderProductImagesHandler)
+		shopkeeperG.PUT("/images/:id", ctrl.UpdateProductImageHandler)
+		shopkeeperG.DELETE("/images/:id", ctrl.DeleteProductImageHandler)
 		shopkeeperG.PUT("/shops/:id", ctrl.UpdateShopHandler)
+		shopkeeperG.PATCH("/shops/:id", ctrl.PatchShopHandler)
 		shopkeeperG.DELETE("/shops/:id", ctrl.DeleteShopHandler)
+		shopkeeperG.POST("/shops/:id/imports", ctrl.CreateProductImportHandler)
+		shopkeeperG.GET("/imports/:id", ctrl.GetProductImportHandler)
+		shopkeeperG.GET("/imports/:id/errors", ctrl.GetProductImportErrorsHandler)
+		shopkeeperG.GET("/shops/:id/products/export", ctrl.ExportShopProductsHandler)
+		shopkeeperG.POST("/orders/:id/shipments", ctrl.CreateShipmentHandler)
+		shopkeeperG.POST("/shipments/:id/events", ctrl.AddShipmentEventHandler)
+		shopkeeperG.POST("/shops/:id/shipping-zones", ctrl.CreateShippingZoneHandler)
+		shopkeeperG.DELETE("/shipping-zones/:id", ctrl.DeleteShippingZoneHandler)
+		shopkeeperG.POST("/shipping-zones/:id/methods", ctrl.CreateShippingMethodHandler)
+		shopkeeperG.PUT("/shipping-methods/:id", ctrl.UpdateShippingMethodHandler)
+		shopkeeperG.DELETE("/shipping-methods/:id", ctrl.DeleteShippingMethodHandler)
+		shopkeeperG.POST("/coupons", ctrl.CreateCouponHandler)
+		shopkeeperG.GET("/coupons", ctrl.ListCouponsHandler)
+		shopkeeperG.DELETE("/coupons/:id", ctrl.DeactivateCouponHandler)
+		shopkeeperG.POST("/products/:id/flash-sales", ctrl.CreateFlashSaleHandler)
+		shopkeeperG.GET("/flash-sales/:id", ctrl.GetFlashSaleHandler)
+		shopkeeperG.POST("/flash-sales/:id/finish", ctrl.FinishFlashSaleHandler)
+		shopkeeperG.POST("/shops/:id/webhooks", ctrl.CreateWebhookHandler)
+		shopkeeperG.GET("/shops/:id/webhooks", ctrl.ListWebhooksHandler)
+		shopkeeperG.PUT("/webhooks/:id", ctrl.UpdateWebhookHandler)
+		shopkeeperG.DELETE("/webhooks/:id", ctrl.DeleteWebhookHandler)
+		shopkeeperG.GET("/webhooks/:id/deliveries", ctrl.ListWebhookDeliveriesHandler)
+		shopkeeperG.GET("/webhook-deliveries/:id", ctrl.GetWebhookDeliveryHandler)
+		shopkeeperG.POST("/webhook-deliveries/:id/redeliver", ctrl.RedeliverWebhookHandler)
 	}
 	{
 		apiV1G.GET("/products/:id", ctrl.GetProductByIDHandler)
 		apiV1G.GET("/products", ctrl.ListProductsHandler)
-		apiV1G.POST("/shops", ctrl.CreateShopHandler)
+		apiV1G.GET("/search/products", ctrl.SearchProductsHandler)
+		apiV1G.GET("/categories", ctrl.ListCategoriesHandler)
+		apiV1G.GET("/categories/:id", ctrl.GetCategoryHandler)
+		apiV1G.GET("/categories/:id/attributes", ctrl.GetCategoryAttributesHandler)
+		apiV1G.GET("/products/:id/variants", ctrl.GetProductVariantsHandler)
+		apiV1G.GET("/products/:id/images", ctrl.ListProductImagesHandler)
+		apiV1G.POST("/shops", ctrl.idempotency, ctrl.CreateShopHandler)
 		apiV1G.GET("/shops/:id", ctrl.GetShopByIDHandler)
 		apiV1G.GET("/shops", ctrl.ListShopsHandler)
-		apiV1G.POST("/orders", ctrl.CreateOrderHandler)
+		apiV1G.POST("/orders", ctrl.idempotency, ctrl.CreateOrderHandler)
 		apiV1G.GET("/orders/:id", ctrl.GetOrderHandler)
+		apiV1G.GET("/shops/:id/shipping-zones", ctrl.ListShippingZonesHandler)
+		apiV1G.POST("/shipping/quote", ctrl.QuoteShippingHandler)
+		apiV1G.GET("/exchange-rates", ctrl.ListExchangeRatesHandler)
+	}
+	meG := apiV1G.Group("/me")
+	{
+		meG.GET("/addresses", ctrl.ListAddressesHandler)
+		meG.POST("/addresses", ctrl.CreateAddressHandler)
+		meG.GET("/addresses/:id", ctrl.GetAddressHandler)
+		meG.PUT("/addresses/:id", ctrl.UpdateAddressHandler)
+		meG.PUT("/addresses/:id/default", ctrl.SetDefaultAddressHandler)
+		meG.DELETE("/addresses/:id", ctrl.DeleteAddressHandler)
+		meG.GET("/preferences", ctrl.GetPreferencesHandler)
+		meG.PUT("/preferences", ctrl.UpdatePreferencesHandler)
+		meG.GET("/notifications", ctrl.ListNotificationsHandler)
+		meG.POST("/notifications/:id/read", ctrl.MarkNotificationReadHandler)
+		meG.POST("/notifications/read-all", ctrl.MarkAllNotificationsReadHandler)
+		meG.GET("/notification-preferences", ctrl.GetNotificationPreferencesHandler)
+		meG.PUT("/notification-preferences", ctrl.UpdateNotificationPreferencesHandler)
 	}
 	return r
 }
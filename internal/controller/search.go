@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"marketplace/internal/models/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchProductsHandler godoc
+// @Summary Поиск товаров
+// @Description Полнотекстовый поиск по названию, описанию и SKU всего каталога с учетом русской и английской морфологии и опечаток. Результаты упорядочены по релевантности, текст подсветки экранирован как HTML, совпадения обернуты в <mark>
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Поисковый запрос (поддерживает \"фразы\", OR и -исключение)"
+// @Param shop_id query int false "Shop ID"
+// @Param category_id query int false "Категория вместе с подкатегориями"
+// @Param currency query string false "Валюта товаров (ISO 4217); обязательна для диапазона цен"
+// @Param min_price query string false "Минимальная цена" example(10.00)
+// @Param max_price query string false "Максимальная цена" example(99.99)
+// @Param in_stock query bool false "true - в наличии, false - нет в наличии"
+// @Param attr[color] query string false "Значения характеристики через запятую (attr[<имя>]=a,b)"
+// @Param active query bool false "Активность товара" default(true)
+// @Param cursor query string false "Курсор следующей страницы (next_cursor)"
+// @Param limit query int false "Размер страницы (не больше max_page_size)" default(20)
+// @Param with_total query bool false "Посчитать total"
+// @Success 200 {object} domain.ProductSearchResult
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/search/products [get]
+func (ctrl *Controller) SearchProductsHandler(c *gin.Context) {
+	productFilter, err := parseProductFilter(c)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	filter := domain.ProductSearchFilter{Query: c.Query("q")}
+	filter.ProductFilter = productFilter
+	result, err := ctrl.service.SearchProducts(filter)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	products := make([]*domain.Product, len(result.Items))
+	for i := range result.Items {
+		products[i] = result.Items[i].Product
+	}
+	if err := ctrl.service.AttachProductCategories(products...); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	ctrl.service.ApplyDisplayCurrency(userIDUntyped.(int), products...)
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateShipmentHandler godoc
+// @Summary Создать отправление
+// @Description Создает отправление (в том числе частичное) по заказу (только для админов и владельцев магазинов)
+// @Tags shipments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Order ID"
+// @Param input body domain.CreateShipmentInput true "Данные отправления"
+// @Success 201 {object} domain.Shipment
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/orders/{id}/shipments [post]
+func (ctrl *Controller) CreateShipmentHandler(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || orderID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	var input domain.CreateShipmentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	userID := userIDUntyped.(int)
+	userRole := c.GetString(userRoleCtx)
+
+	shipment, err := ctrl.service.CreateShipment(orderID, userID, userRole, input)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, shipment)
+}
+
+// AddShipmentEventHandler godoc
+// @Summary Добавить событие трекинга
+// @Description Добавляет событие трекинга к отправлению и пересчитывает статус заказа
+// @Tags shipments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Shipment ID"
+// @Param input body domain.CreateShipmentEventInput true "Событие трекинга"
+// @Success 201 {object} domain.ShipmentEvent
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/shipments/{id}/events [post]
+func (ctrl *Controller) AddShipmentEventHandler(c *gin.Context) {
+	shipmentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || shipmentID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	var input domain.CreateShipmentEventInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	userID := userIDUntyped.(int)
+	userRole := c.GetString(userRoleCtx)
+
+	event, err := ctrl.service.AddShipmentEvent(shipmentID, userID, userRole, input)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, event)
+}
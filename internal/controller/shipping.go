@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateShippingZoneHandler godoc
+// @Summary Создать зону доставки
+// @Description Создает зону доставки магазина (пустой список стран - остальной мир)
+// @Tags shipping
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Shop ID"
+// @Param input body domain.ShippingZone true "Зона доставки"
+// @Success 201 {object} domain.ShippingZone
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/shops/{id}/shipping-zones [post]
+func (ctrl *Controller) CreateShippingZoneHandler(c *gin.Context) {
+	shopID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || shopID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidShopID)
+		return
+	}
+	var zone domain.ShippingZone
+	if err := c.ShouldBindJSON(&zone); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	zone.ShopID = shopID
+	userIDUntyped, _ := c.Get(userIDCtx)
+	if err := ctrl.service.CreateShippingZone(&zone, userIDUntyped.(int), c.GetString(userRoleCtx)); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, zone)
+}
+
+// ListShippingZonesHandler godoc
+// @Summary Зоны доставки магазина
+// @Description Возвращает зоны доставки магазина вместе со способами доставки
+// @Tags shipping
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Shop ID"
+// @Param cursor query string false "Курсор следующей страницы (next_cursor)"
+// @Param limit query int false "Размер страницы (не больше max_page_size)" default(20)
+// @Param with_total query bool false "Посчитать total"
+// @Success 200 {object} domain.Page[domain.ShippingZone]
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Router /api/v1/shops/{id}/shipping-zones [get]
+func (ctrl *Controller) ListShippingZonesHandler(c *gin.Context) {
+	shopID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || shopID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidShopID)
+		return
+	}
+	page, err := parsePageRequest(c)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	zones, err := ctrl.service.ListShippingZones(shopID, page)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, zones)
+}
+
+// DeleteShippingZoneHandler godoc
+// @Summary Удалить зону доставки
+// @Description Удаляет зону доставки вместе с ее способами
+// @Tags shipping
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Zone ID"
+// @Success 200 {object} CommonResponse
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/shipping-zones/{id} [delete]
+func (ctrl *Controller) DeleteShippingZoneHandler(c *gin.Context) {
+	zoneID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || zoneID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	if err := ctrl.service.DeleteShippingZone(zoneID, userIDUntyped.(int), c.GetString(userRoleCtx)); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, CommonResponse{Message: "shipping zone deleted successfully"})
+}
+
+// CreateShippingMethodHandler godoc
+// @Summary Создать способ доставки
+// @Description Создает способ доставки в зоне (flat_rate, weight_based, free_over_threshold, local_pickup)
+// @Tags shipping
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Zone ID"
+// @Param input body domain.ShippingMethod true "Способ доставки"
+// @Success 201 {object} domain.ShippingMethod
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/shipping-zones/{id}/methods [post]
+func (ctrl *Controller) CreateShippingMethodHandler(c *gin.Context) {
+	zoneID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || zoneID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	var method domain.ShippingMethod
+	if err := c.ShouldBindJSON(&method); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	method.ZoneID = zoneID
+	userIDUntyped, _ := c.Get(userIDCtx)
+	if err := ctrl.service.CreateShippingMethod(&method, userIDUntyped.(int), c.GetString(userRoleCtx)); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, method)
+}
+
+// UpdateShippingMethodHandler godoc
+// @Summary Обновить способ доставки
+// @Description Обновляет параметры способа доставки
+// @Tags shipping
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Method ID"
+// @Param input body domain.ShippingMethod true "Способ доставки"
+// @Success 200 {object} domain.ShippingMethod
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/shipping-methods/{id} [put]
+func (ctrl *Controller) UpdateShippingMethodHandler(c *gin.Context) {
+	methodID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || methodID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	var method domain.ShippingMethod
+	if err := c.ShouldBindJSON(&method); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	method.ID = methodID
+	userIDUntyped, _ := c.Get(userIDCtx)
+	if err := ctrl.service.UpdateShippingMethod(&method, userIDUntyped.(int), c.GetString(userRoleCtx)); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, method)
+}
+
+// DeleteShippingMethodHandler godoc
+// @Summary Удалить способ доставки
+// @Description Удаляет способ доставки
+// @Tags shipping
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Method ID"
+// @Success 200 {object} CommonResponse
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/shipping-methods/{id} [delete]
+func (ctrl *Controller) DeleteShippingMethodHandler(c *gin.Context) {
+	methodID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || methodID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	if err := ctrl.service.DeleteShippingMethod(methodID, userIDUntyped.(int), c.GetString(userRoleCtx)); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, CommonResponse{Message: "shipping method deleted successfully"})
+}
+
+// QuoteShippingHandler godoc
+// @Summary Расчет доставки
+// @Description Возвращает доступные способы доставки и их стоимость для корзины и адреса покупателя
+// @Tags shipping
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body domain.ShippingQuoteInput true "Корзина и адрес"
+// @Success 200 {array} domain.ShippingQuote
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/shipping/quote [post]
+func (ctrl *Controller) QuoteShippingHandler(c *gin.Context) {
+	var input domain.ShippingQuoteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	quotes, err := ctrl.service.QuoteShipping(userIDUntyped.(int), input)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, quotes)
+}
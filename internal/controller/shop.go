@@ -37,11 +37,13 @@ func (ctrl *Controller) CreateShopHandler(c *gin.Context) {
 
 // GetShopByIDHandler godoc
 // @Summary Получить магазин по ID
-// @Description Получает информацию о магазине по его ID
+// @Description Получает информацию о магазине по его ID. Версия магазина отдается в ETag; с If-None-Match, совпадающим с ней, ответ 304 без тела
 // @Tags shops
 // @Produce json
 // @Param id path int true "Shop ID"
+// @Param If-None-Match header string false "ETag из предыдущего ответа"
 // @Success 200 {object} domain.Shop
+// @Success 304
 // @Failure 400 {object} CommonError
 // @Failure 404 {object} CommonError
 // @Router /api/v1/shops/{id} [get]
@@ -57,23 +59,31 @@ func (ctrl *Controller) GetShopByIDHandler(c *gin.Context) {
 		ctrl.handleError(c, err)
 		return
 	}
+	if notModified(c, shop.Version) {
+		return
+	}
+	setETag(c, shop.Version)
 	c.JSON(http.StatusOK, shop)
 }
 
 // UpdateShopHandler godoc
 // @Summary Обновить магазин
-// @Description Обновляет информацию о магазине (только для админов и владельцев)
+// @Description Заменяет название и описание магазина. owner_id и slug менять нельзя, slug пересчитывается из названия (только для админов и владельцев)
 // @Tags shops
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Shop ID"
+// @Param If-Match header string false "ETag магазина; при несовпадении версии - 412"
 // @Param input body domain.Shop true "Данные для обновления"
 // @Success 200 {object} domain.Shop
 // @Failure 400 {object} CommonError
 // @Failure 401 {object} CommonError
 // @Failure 403 {object} CommonError
 // @Failure 404 {object} CommonError
+// @Failure 409 {object} CommonError
+// @Failure 412 {object} CommonError
+// @Failure 422 {object} CommonError
 // @Router /api/v1/shops/{id} [put]
 func (ctrl *Controller) UpdateShopHandler(c *gin.Context) {
 	idParam := c.Param("id")
@@ -86,16 +96,68 @@ func (ctrl *Controller) UpdateShopHandler(c *gin.Context) {
 	userRoleUntyped, _ := c.Get(userRoleCtx)
 	userID := userIDUntyped.(int)
 	userRole := userRoleUntyped.(string)
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
 	var shop domain.Shop
 	if err := c.ShouldBindJSON(&shop); err != nil {
 		ctrl.handleError(c, errs.ErrInvalidRequestBody)
 		return
 	}
 	shop.ID = id
-	if err := ctrl.service.UpdateShop(&shop, userID, userRole); err != nil {
+	if err := ctrl.service.UpdateShop(&shop, version, userID, userRole); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	setETag(c, shop.Version)
+	c.JSON(http.StatusOK, shop)
+}
+
+// PatchShopHandler godoc
+// @Summary Частично обновить магазин
+// @Description Применяет JSON Merge Patch (RFC 7386) к name и description; остальные поля менять нельзя (только для админов и владельцев)
+// @Tags shops
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Shop ID"
+// @Param If-Match header string false "ETag магазина; при несовпадении версии - 412"
+// @Param input body object true "Изменяемые поля"
+// @Success 200 {object} domain.Shop
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 409 {object} CommonError
+// @Failure 412 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/shops/{id} [patch]
+func (ctrl *Controller) PatchShopHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidShopID)
+		return
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
 		ctrl.handleError(c, err)
 		return
 	}
+	patch, err := c.GetRawData()
+	if err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	shop, err := ctrl.service.PatchShop(id, patch, version, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	setETag(c, shop.Version)
 	c.JSON(http.StatusOK, shop)
 }
 
@@ -106,11 +168,13 @@ func (ctrl *Controller) UpdateShopHandler(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Shop ID"
+// @Param If-Match header string false "ETag магазина; при несовпадении версии - 412"
 // @Success 200 {object} CommonResponse
 // @Failure 400 {object} CommonError
 // @Failure 401 {object} CommonError
 // @Failure 403 {object} CommonError
 // @Failure 404 {object} CommonError
+// @Failure 412 {object} CommonError
 // @Router /api/v1/shops/{id} [delete]
 func (ctrl *Controller) DeleteShopHandler(c *gin.Context) {
 	idParam := c.Param("id")
@@ -123,7 +187,12 @@ func (ctrl *Controller) DeleteShopHandler(c *gin.Context) {
 	userRoleUntyped, _ := c.Get(userRoleCtx)
 	userID := userIDUntyped.(int)
 	userRole := userRoleUntyped.(string)
-	if err := ctrl.service.DeleteShop(id, userID, userRole); err != nil {
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	if err := ctrl.service.DeleteShop(id, version, userID, userRole); err != nil {
 		ctrl.handleError(c, err)
 		return
 	}
@@ -132,13 +201,14 @@ func (ctrl *Controller) DeleteShopHandler(c *gin.Context) {
 
 // ListShopsHandler godoc
 // @Summary Список магазинов
-// @Description Получает список магазинов владельца
+// @Description Получает список магазинов владельца, новые первыми
 // @Tags shops
 // @Produce json
 // @Param owner_id query int true "Owner ID"
-// @Param limit query int false "Limit" default(20)
-// @Param offset query int false "Offset" default(0)
-// @Success 200 {array} domain.Shop
+// @Param cursor query string false "Курсор следующей страницы (next_cursor)"
+// @Param limit query int false "Размер страницы (не больше max_page_size)" default(20)
+// @Param with_total query bool false "Посчитать total"
+// @Success 200 {object} domain.Page[domain.Shop]
 // @Failure 400 {object} CommonError
 // @Failure 500 {object} CommonError
 // @Router /api/v1/shops [get]
@@ -149,19 +219,12 @@ func (ctrl *Controller) ListShopsHandler(c *gin.Context) {
 		ctrl.handleError(c, errs.ErrInvalidFieldValue)
 		return
 	}
-	limit := 20
-	offset := 0
-	if l := c.Query("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil {
-			limit = parsed
-		}
-	}
-	if o := c.Query("offset"); o != "" {
-		if parsed, err := strconv.Atoi(o); err == nil {
-			offset = parsed
-		}
+	page, err := parsePageRequest(c)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
 	}
-	shops, err := ctrl.service.ListShops(ownerID, limit, offset)
+	shops, err := ctrl.service.ListShops(ownerID, page)
 	if err != nil {
 		ctrl.handleError(c, err)
 		return
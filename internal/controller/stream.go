@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const streamHeartbeatInterval = 25 * time.Second
+
+// StreamHandler godoc
+// @Summary Поток обновлений (SSE)
+// @Description Server-Sent Events: смена статуса своих заказов, новые заказы и остатки своих магазинов, остатки товаров из products. Имя SSE-события - тип события, data - domain.StreamMessage. Токен можно передать в access_token
+// @Tags stream
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param products query string false "ID отслеживаемых товаров через запятую (до 100)"
+// @Param access_token query string false "Access-токен, если нельзя передать заголовок Authorization"
+// @Success 200 {object} domain.StreamMessage
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/stream [get]
+func (ctrl *Controller) StreamHandler(c *gin.Context) {
+	productIDs, err := parseIDList(c.Query("products"))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	sub, err := ctrl.service.OpenStream(userIDUntyped.(int), productIDs)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	defer sub.Close()
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+	c.Writer.Flush()
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-sub.Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		case message := <-sub.Events():
+			body, err := json.Marshal(message)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", message.EventID, message.Type, body)
+		}
+		c.Writer.Flush()
+	}
+}
+
+// StreamWebSocketHandler godoc
+// @Summary Поток обновлений (WebSocket)
+// @Description Те же события, что и в SSE-потоке, текстовыми JSON-сообщениями domain.StreamMessage; раз в 25 секунд приходит сообщение heartbeat. Токен можно передать в access_token
+// @Tags stream
+// @Security BearerAuth
+// @Param products query string false "ID отслеживаемых товаров через запятую (до 100)"
+// @Param access_token query string false "Access-токен, если нельзя передать заголовок Authorization"
+// @Success 101 {object} domain.StreamMessage
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/stream/ws [get]
+func (ctrl *Controller) StreamWebSocketHandler(c *gin.Context) {
+	productIDs, err := parseIDList(c.Query("products"))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	sub, err := ctrl.service.OpenStream(userIDUntyped.(int), productIDs)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	defer sub.Close()
+	server := websocket.Server{
+		// Соединение аутентифицировано токеном, проверка Origin не нужна
+		Handshake: func(config *websocket.Config, req *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			defer conn.Close()
+			closed := make(chan struct{})
+			go func() {
+				// Входящие сообщения не ожидаются; чтение нужно, чтобы заметить закрытие соединения
+				defer close(closed)
+				var discard string
+				for websocket.Message.Receive(conn, &discard) == nil {
+				}
+			}()
+			heartbeat := time.NewTicker(streamHeartbeatInterval)
+			defer heartbeat.Stop()
+			for {
+				var message domain.StreamMessage
+				select {
+				case <-closed:
+					return
+				case <-sub.Done():
+					return
+				case <-heartbeat.C:
+					message = domain.StreamMessage{Type: "heartbeat"}
+				case message = <-sub.Events():
+				}
+				if err := websocket.JSON.Send(conn, message); err != nil {
+					return
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// parseIDList разбирает список id через запятую.
+func parseIDList(value string) ([]int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parts := strings.Split(value, ",")
+	ids := make([]int64, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil || id <= 0 {
+			return nil, errs.ErrInvalidProductID
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
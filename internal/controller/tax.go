@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateTaxRateHandler godoc
+// @Summary Создать ставку налога
+// @Description Создает ставку налога для страны/региона и налогового класса (только для админов)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body domain.TaxRate true "Ставка налога"
+// @Success 201 {object} domain.TaxRate
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 409 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/admin/tax-rates [post]
+func (ctrl *Controller) CreateTaxRateHandler(c *gin.Context) {
+	var rate domain.TaxRate
+	if err := c.ShouldBindJSON(&rate); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	if err := ctrl.service.CreateTaxRate(&rate); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, rate)
+}
+
+// ListTaxRatesHandler godoc
+// @Summary Список ставок налога
+// @Description Возвращает ставки налога, опционально по стране (только для админов)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param country query string false "Country (ISO 3166-1 alpha-2)"
+// @Param cursor query string false "Курсор следующей страницы (next_cursor)"
+// @Param limit query int false "Размер страницы (не больше max_page_size)" default(20)
+// @Param with_total query bool false "Посчитать total"
+// @Success 200 {object} domain.Page[domain.TaxRate]
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Router /api/v1/admin/tax-rates [get]
+func (ctrl *Controller) ListTaxRatesHandler(c *gin.Context) {
+	page, err := parsePageRequest(c)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	rates, err := ctrl.service.ListTaxRates(c.Query("country"), page)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rates)
+}
+
+// UpdateTaxRateHandler godoc
+// @Summary Обновить ставку налога
+// @Description Обновляет ставку налога (только для админов)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tax rate ID"
+// @Param input body domain.TaxRate true "Ставка налога"
+// @Success 200 {object} domain.TaxRate
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 409 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/admin/tax-rates/{id} [put]
+func (ctrl *Controller) UpdateTaxRateHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	var rate domain.TaxRate
+	if err := c.ShouldBindJSON(&rate); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	rate.ID = id
+	if err := ctrl.service.UpdateTaxRate(&rate); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rate)
+}
+
+// DeleteTaxRateHandler godoc
+// @Summary Удалить ставку налога
+// @Description Удаляет ставку; сводки налогов в заказах сохраняются (только для админов)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tax rate ID"
+// @Success 200 {object} CommonResponse
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/admin/tax-rates/{id} [delete]
+func (ctrl *Controller) DeleteTaxRateHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	if err := ctrl.service.DeleteTaxRate(id); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, CommonResponse{Message: "tax rate deleted successfully"})
+}
@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetProductVariantsHandler godoc
+// @Summary Варианты товара
+// @Description Возвращает опции товара и его неудаленные варианты (включая неактивные)
+// @Tags variants
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {object} domain.ProductVariantList
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/products/{id}/variants [get]
+func (ctrl *Controller) GetProductVariantsHandler(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || productID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidProductID)
+		return
+	}
+	product, err := ctrl.service.GetProductVariants(productID)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	ctrl.service.ApplyDisplayCurrency(userIDUntyped.(int), product)
+	list := domain.ProductVariantList{Options: product.Options, Variants: product.Variants}
+	if list.Options == nil {
+		list.Options = []domain.ProductOption{}
+	}
+	if list.Variants == nil {
+		list.Variants = []domain.ProductVariant{}
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// SetProductOptionsHandler godoc
+// @Summary Опции товара
+// @Description Заменяет опции товара (размер, цвет). Существующие варианты должны остаться допустимыми (владелец магазина или админ)
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param input body domain.SetProductOptionsInput true "Опции"
+// @Success 200 {array} domain.ProductOption
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/products/{id}/options [put]
+func (ctrl *Controller) SetProductOptionsHandler(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || productID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidProductID)
+		return
+	}
+	var input domain.SetProductOptionsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	options, err := ctrl.service.SetProductOptions(productID, input, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, options)
+}
+
+// CreateProductVariantHandler godoc
+// @Summary Создать вариант товара
+// @Description Добавляет вариант со своими SKU, ценой (в валюте товара, по умолчанию цена товара) и остатком. Остаток товара становится суммой остатков активных вариантов
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param input body domain.ProductVariantInput true "Вариант"
+// @Success 201 {object} domain.ProductVariant
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 409 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/products/{id}/variants [post]
+func (ctrl *Controller) CreateProductVariantHandler(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || productID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidProductID)
+		return
+	}
+	var input domain.ProductVariantInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	variant, err := ctrl.service.CreateProductVariant(productID, input, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, variant)
+}
+
+// UpdateProductVariantHandler godoc
+// @Summary Обновить вариант товара
+// @Description Меняет переданные поля варианта (владелец магазина или админ)
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Variant ID"
+// @Param input body domain.ProductVariantInput true "Изменения"
+// @Success 200 {object} domain.ProductVariant
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 409 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/variants/{id} [put]
+func (ctrl *Controller) UpdateProductVariantHandler(c *gin.Context) {
+	variantID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || variantID <= 0 {
+		ctrl.handleError(c, errs.ErrVariantNotFound)
+		return
+	}
+	var input domain.ProductVariantInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	variant, err := ctrl.service.UpdateProductVariant(variantID, input, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, variant)
+}
+
+// DeleteProductVariantHandler godoc
+// @Summary Удалить вариант товара
+// @Description Мягко удаляет вариант; позиции заказов сохраняют ссылку на него (владелец магазина или админ)
+// @Tags variants
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Variant ID"
+// @Success 200 {object} CommonResponse
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/variants/{id} [delete]
+func (ctrl *Controller) DeleteProductVariantHandler(c *gin.Context) {
+	variantID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || variantID <= 0 {
+		ctrl.handleError(c, errs.ErrVariantNotFound)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	if err := ctrl.service.DeleteProductVariant(variantID, userIDUntyped.(int), c.GetString(userRoleCtx)); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, CommonResponse{Message: "variant deleted successfully"})
+}
+
+// GetCategoryAttributesHandler godoc
+// @Summary Схема характеристик категории
+// @Description Возвращает действующую схему: собственные характеристики категории и унаследованные от предков
+// @Tags categories
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Success 200 {array} domain.CategoryAttribute
+// @Failure 401 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/categories/{id}/attributes [get]
+func (ctrl *Controller) GetCategoryAttributesHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctrl.handleError(c, errs.ErrCategoryNotFound)
+		return
+	}
+	attributes, err := ctrl.service.GetCategoryAttributes(id)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, attributes)
+}
+
+// SetCategoryAttributesHandler godoc
+// @Summary Задать схему характеристик категории
+// @Description Заменяет собственную схему категории; подкатегории наследуют ее (только админ)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Param input body domain.SetCategoryAttributesInput true "Характеристики"
+// @Success 200 {array} domain.CategoryAttribute
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/admin/categories/{id}/attributes [put]
+func (ctrl *Controller) SetCategoryAttributesHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctrl.handleError(c, errs.ErrCategoryNotFound)
+		return
+	}
+	var input domain.SetCategoryAttributesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	attributes, err := ctrl.service.SetCategoryAttributes(id, input)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, attributes)
+}
@@ -0,0 +1,234 @@
+package controller
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWebhookHandler godoc
+// @Summary Создать вебхук магазина
+// @Description Подписывает URL на события магазина (order.created, order.status_changed, product.updated, stock.low). Секрет для проверки подписи X-Webhook-Signature возвращается только в этом ответе
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Shop ID"
+// @Param input body domain.WebhookEndpointInput true "Вебхук"
+// @Success 201 {object} domain.WebhookEndpoint
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/shops/{id}/webhooks [post]
+func (ctrl *Controller) CreateWebhookHandler(c *gin.Context) {
+	shopID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || shopID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidShopID)
+		return
+	}
+	var input domain.WebhookEndpointInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	endpoint, err := ctrl.service.CreateWebhook(shopID, input, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, endpoint)
+}
+
+// ListWebhooksHandler godoc
+// @Summary Вебхуки магазина
+// @Description Возвращает вебхуки магазина со статусом и числом неудачных доставок подряд
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Shop ID"
+// @Success 200 {array} domain.WebhookEndpoint
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Router /api/v1/shops/{id}/webhooks [get]
+func (ctrl *Controller) ListWebhooksHandler(c *gin.Context) {
+	shopID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || shopID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidShopID)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	endpoints, err := ctrl.service.ListWebhooks(shopID, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, endpoints)
+}
+
+// UpdateWebhookHandler godoc
+// @Summary Изменить вебхук
+// @Description Заменяет URL, события и описание; active=true включает отключенный вебхук и сбрасывает счетчик неудач
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param input body domain.WebhookEndpointInput true "Вебхук"
+// @Success 200 {object} domain.WebhookEndpoint
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/webhooks/{id} [put]
+func (ctrl *Controller) UpdateWebhookHandler(c *gin.Context) {
+	webhookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || webhookID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	var input domain.WebhookEndpointInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		ctrl.handleError(c, errs.ErrInvalidRequestBody)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	endpoint, err := ctrl.service.UpdateWebhook(webhookID, input, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// DeleteWebhookHandler godoc
+// @Summary Удалить вебхук
+// @Description Удаляет вебхук вместе с журналом доставок
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} CommonResponse
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/webhooks/{id} [delete]
+func (ctrl *Controller) DeleteWebhookHandler(c *gin.Context) {
+	webhookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || webhookID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	if err := ctrl.service.DeleteWebhook(webhookID, userIDUntyped.(int), c.GetString(userRoleCtx)); err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, CommonResponse{Message: "webhook deleted successfully"})
+}
+
+// ListWebhookDeliveriesHandler godoc
+// @Summary Журнал доставок вебхука
+// @Description Возвращает доставки вебхука, новые первыми, с фильтром по статусу
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param status query string false "pending, succeeded, failed"
+// @Param cursor query string false "Курсор следующей страницы (next_cursor)"
+// @Param limit query int false "Размер страницы (не больше max_page_size)" default(20)
+// @Param with_total query bool false "Посчитать total"
+// @Success 200 {object} domain.Page[domain.WebhookDelivery]
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 422 {object} CommonError
+// @Router /api/v1/webhooks/{id}/deliveries [get]
+func (ctrl *Controller) ListWebhookDeliveriesHandler(c *gin.Context) {
+	webhookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || webhookID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	page, err := parsePageRequest(c)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	deliveries, err := ctrl.service.ListWebhookDeliveries(domain.WebhookDeliveryFilter{
+		EndpointID:  webhookID,
+		Status:      c.Query("status"),
+		PageRequest: page,
+	}, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// GetWebhookDeliveryHandler godoc
+// @Summary Доставка вебхука
+// @Description Возвращает доставку с отправленным телом и журналом попыток (код ответа, тело ответа, ошибка, длительность)
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Delivery ID"
+// @Success 200 {object} domain.WebhookDelivery
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Router /api/v1/webhook-deliveries/{id} [get]
+func (ctrl *Controller) GetWebhookDeliveryHandler(c *gin.Context) {
+	deliveryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || deliveryID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	delivery, err := ctrl.service.GetWebhookDelivery(deliveryID, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, delivery)
+}
+
+// RedeliverWebhookHandler godoc
+// @Summary Повторить доставку вебхука
+// @Description Ставит завершенную доставку в очередь повторно с новым счетчиком попыток; тело запроса то же, подпись и метка времени новые
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Delivery ID"
+// @Success 202 {object} domain.WebhookDelivery
+// @Failure 400 {object} CommonError
+// @Failure 401 {object} CommonError
+// @Failure 403 {object} CommonError
+// @Failure 404 {object} CommonError
+// @Failure 409 {object} CommonError
+// @Router /api/v1/webhook-deliveries/{id}/redeliver [post]
+func (ctrl *Controller) RedeliverWebhookHandler(c *gin.Context) {
+	deliveryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || deliveryID <= 0 {
+		ctrl.handleError(c, errs.ErrInvalidID)
+		return
+	}
+	userIDUntyped, _ := c.Get(userIDCtx)
+	delivery, err := ctrl.service.RedeliverWebhook(deliveryID, userIDUntyped.(int), c.GetString(userRoleCtx))
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
@@ -9,10 +9,14 @@ import (
 	_ "github.com/lib/pq"
 )
 
-func InitConnection() (*sqlx.DB, error) {
+// DSN возвращает строку подключения к Postgres из настроек.
+func DSN() string {
 	connectionConfigs := configs.AppSettings.PostgresParams
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", connectionConfigs.Host, connectionConfigs.Port, connectionConfigs.User, os.Getenv("DB_PASSWORD"), connectionConfigs.Database)
-	dbConn, err := sqlx.Connect("postgres", dsn)
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", connectionConfigs.Host, connectionConfigs.Port, connectionConfigs.User, os.Getenv("DB_PASSWORD"), connectionConfigs.Database)
+}
+
+func InitConnection() (*sqlx.DB, error) {
+	dbConn, err := sqlx.Connect("postgres", DSN())
 	if err != nil {
 		return nil, err
 	}
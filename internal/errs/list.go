@@ -19,11 +19,79 @@ var (
 	ErrShopNotFound                = errors.New("shop not found")
 	ErrInvalidShopID               = errors.New("invalid shop id")
 	ErrInvalidShopName             = errors.New("invalid shop name, min 3 symbols")
-	ErrShopAlreadyExists           = errors.New("shoalid token")
-	ErrInsufficientStock           = errors.New("insp already exists for this owner")
-	ErrInvalidToken                = errors.New("invufficient stock")
+	ErrShopAlreadyExists           = errors.New("shop already exists for this owner")
+	ErrInsufficientStock           = errors.New("insufficient stock")
+	ErrInvalidToken                = errors.New("invalid token")
 	ErrOrderNotFound               = errors.New("order not found")
 	ErrInvalidEmailFormat          = errors.New("invalid email format")
 	ErrInvalidPassword             = errors.New("password must be at least 6 characters long")
 	ErrInvalidUsername             = errors.New("username must be at least 3 characters long")
+	ErrShipmentNotFound            = errors.New("shipment not found")
+	ErrInvalidShipmentStatus       = errors.New("invalid shipment status")
+	ErrOrderNotShippable           = errors.New("order cannot be shipped in its current status")
+	ErrOrderNotPending             = errors.New("order is not awaiting payment")
+	ErrAddressNotFound             = errors.New("address not found")
+	ErrShippingAddressRequired     = errors.New("shipping address is required: pass address_id or set a default address")
+	ErrShippingZoneNotFound        = errors.New("shipping zone not found")
+	ErrShippingMethodNotFound      = errors.New("shipping method not found")
+	ErrShippingMethodUnavailable   = errors.New("shipping method is not available for this address or cart")
+	ErrShippingMethodRequired      = errors.New("shipping method must be selected for every shop in the cart")
+	ErrCouponNotFound              = errors.New("coupon not found")
+	ErrCouponAlreadyExists         = errors.New("coupon with this code already exists")
+	ErrCouponNotApplicable         = errors.New("coupon is not applicable to this order")
+	ErrCouponUsageLimitReached     = errors.New("coupon usage limit reached")
+	ErrCouponsNotStackable         = errors.New("coupon cannot be combined with other coupons")
+	ErrInvalidCurrency             = errors.New("invalid currency: expected ISO 4217 code")
+	ErrExchangeRateNotFound        = errors.New("exchange rate not found")
+	ErrExchangeRateUnavailable     = errors.New("no exchange rate available for currency pair")
+	ErrRateSourceNotConfigured     = errors.New("exchange rate source is not configured")
+	ErrTaxRateNotFound             = errors.New("tax rate not found")
+	ErrTaxRateAlreadyExists        = errors.New("tax rate for this region and tax class already exists")
+	ErrInvalidIdempotencyKey       = errors.New("invalid Idempotency-Key header: expected 1-255 printable characters")
+	ErrIdempotencyKeyReused        = errors.New("Idempotency-Key was already used with a different request")
+	ErrIdempotencyKeyInProgress    = errors.New("request with this Idempotency-Key is still being processed")
+	ErrTxConflict                  = errors.New("concurrent update conflict, please retry")
+	ErrFlashSaleNotFound           = errors.New("flash sale not found")
+	ErrFlashSaleAlreadyExists      = errors.New("product already has an active flash sale")
+	ErrFlashSaleSoldOut            = errors.New("flash sale is sold out")
+	ErrFlashSaleLimitExceeded      = errors.New("flash sale per-user limit exceeded")
+	ErrFlashSaleBusy               = errors.New("flash sale queue is full, please retry later")
+	ErrJobNotFound                 = errors.New("job not found")
+	ErrJobStateConflict            = errors.New("job cannot be changed in its current status")
+	ErrInvalidJobStatus            = errors.New("invalid job status")
+	ErrWebhookNotFound             = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound     = errors.New("webhook delivery not found")
+	ErrWebhookDisabled             = errors.New("webhook is disabled, enable it before redelivering")
+	ErrWebhookDeliveryPending      = errors.New("webhook delivery is still pending")
+	ErrInvalidWebhookURL           = errors.New("invalid webhook url: expected absolute http or https url")
+	ErrWebhookTargetForbidden      = errors.New("webhook target is a loopback, private or link-local address")
+	ErrInvalidWebhookEvent         = errors.New("invalid webhook event type")
+	ErrNotificationNotFound        = errors.New("notification not found")
+	ErrInvalidNotificationTemplate = errors.New("invalid notification template")
+	ErrInvalidLocale               = errors.New("invalid locale: expected two-letter language code")
+	ErrInvalidSearchQuery          = errors.New("search query must be 1-200 characters long")
+	ErrInvalidCursor               = errors.New("invalid or expired page cursor")
+	ErrCategoryNotFound            = errors.New("category not found")
+	ErrCategoryAlreadyExists       = errors.New("category with this slug already exists")
+	ErrCategoryNotEmpty            = errors.New("category has subcategories")
+	ErrCategoryCycle               = errors.New("category cannot be moved into itself or its descendant")
+	ErrVariantNotFound             = errors.New("product variant not found")
+	ErrVariantAlreadyExists        = errors.New("product variant with this sku or options already exists")
+	ErrVariantRequired             = errors.New("product has variants: variant_id is required")
+	ErrInvalidVariantOptions       = errors.New("variant options do not match product options")
+	ErrInvalidAttribute            = errors.New("product attributes do not match category schema")
+	ErrBlobNotFound                = errors.New("file not found")
+	ErrBlobStoreNotConfigured      = errors.New("file storage is not configured")
+	ErrImageNotFound               = errors.New("product image not found")
+	ErrInvalidImage                = errors.New("invalid image: expected jpeg, png or gif")
+	ErrImageTooLarge               = errors.New("image is too large")
+	ErrImageLimitReached           = errors.New("product image limit reached")
+	ErrProductAlreadyExists        = errors.New("product with this slug or sku already exists")
+	ErrImportNotFound              = errors.New("product import not found")
+	ErrInvalidImportFile           = errors.New("invalid import file")
+	ErrImportFileTooLarge          = errors.New("import file is too large")
+	ErrImmutableField              = errors.New("field cannot be changed")
+	ErrShopSlugAlreadyExists       = errors.New("shop with this slug already exists")
+	ErrVersionMismatch             = errors.New("resource was modified: version does not match If-Match")
+	ErrForbidden                   = errors.New("permission denied")
 )
@@ -0,0 +1,129 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"marketplace/internal/contracts"
+	"marketplace/internal/models/domain"
+	"os"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// Handler обрабатывает доменное событие. Ошибка приводит к повторной доставке события.
+type Handler func(ctx context.Context, event domain.Event) error
+
+type subscription struct {
+	name    string
+	types   map[string]bool
+	handler Handler
+}
+
+// Dispatcher доставляет события из outbox подписчикам внутри процесса. Доставка - не менее
+// одного раза, поэтому успешная обработка отмечается по (event_id, подписчик), и при повторной
+// доставке подписчики, уже обработавшие событие, пропускаются.
+type Dispatcher struct {
+	service       contracts.ServiceI
+	mu            sync.RWMutex
+	subscriptions []subscription
+	logger        zerolog.Logger
+}
+
+func NewDispatcher(service contracts.ServiceI) *Dispatcher {
+	return &Dispatcher{
+		service: service,
+		logger:  zerolog.New(os.Stdout).With().Timestamp().Str("entity", "event_dispatcher").Logger(),
+	}
+}
+
+// Subscribe регистрирует обработчик событий указанных типов; без типов - всех событий.
+// name должно быть уникальным и не меняться между запусками: по нему отмечается обработка.
+func (d *Dispatcher) Subscribe(name string, handler Handler, eventTypes ...string) {
+	types := make(map[string]bool, len(eventTypes))
+	for _, eventType := range eventTypes {
+		types[eventType] = true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, sub := range d.subscriptions {
+		if sub.name == name {
+			panic(fmt.Sprintf("events: subscriber %q already registered", name))
+		}
+	}
+	d.subscriptions = append(d.subscriptions, subscription{name: name, types: types, handler: handler})
+}
+
+// Dispatch передает событие всем подходящим подписчикам. Ошибки подписчиков объединяются;
+// подписчики, обработавшие событие успешно, при повторе не вызываются.
+func (d *Dispatcher) Dispatch(ctx context.Context, event domain.Event) error {
+	d.mu.RLock()
+	subscriptions := d.subscriptions
+	d.mu.RUnlock()
+	var errList []error
+	for _, sub := range subscriptions {
+		if len(sub.types) > 0 && !sub.types[event.Type] {
+			continue
+		}
+		processed, err := d.service.IsEventProcessed(event.EventID, sub.name)
+		if err != nil {
+			errList = append(errList, err)
+			continue
+		}
+		if processed {
+			continue
+		}
+		if err := d.handle(ctx, sub, event); err != nil {
+			d.logger.Warn().Err(err).Str("subscriber", sub.name).Str("event_id", event.EventID).Str("type", event.Type).Msg("subscriber failed")
+			errList = append(errList, fmt.Errorf("subscriber %s: %w", sub.name, err))
+			continue
+		}
+		if err := d.service.MarkEventProcessed(event.EventID, sub.name); err != nil {
+			errList = append(errList, err)
+		}
+	}
+	return errors.Join(errList...)
+}
+
+func (d *Dispatcher) handle(ctx context.Context, sub subscription, event domain.Event) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("subscriber panicked: %v", p)
+		}
+	}()
+	return sub.handler(ctx, event)
+}
+
+// LogHandler пишет каждое событие в журнал приложения.
+func LogHandler(logger zerolog.Logger) Handler {
+	return func(ctx context.Context, event domain.Event) error {
+		logger.Info().Str("event_id", event.EventID).Str("type", event.Type).
+			Str("aggregate_type", event.AggregateType).Int64("aggregate_id", event.AggregateID).
+			RawJSON("payload", event.Payload).Msg("domain event")
+		return nil
+	}
+}
+
+// WebhookHandler создает доставки события на вебхуки магазинов; отправка выполняется очередью задач.
+func WebhookHandler(service contracts.ServiceI) Handler {
+	return func(ctx context.Context, event domain.Event) error {
+		_, err := service.EnqueueWebhookDeliveries(event)
+		return err
+	}
+}
+
+// StreamHandler рассылает событие подписчикам потока обновлений (SSE, WebSocket) на всех экземплярах.
+func StreamHandler(service contracts.ServiceI) Handler {
+	return func(ctx context.Context, event domain.Event) error {
+		return service.PublishStreamEvent(event)
+	}
+}
+
+// NotificationHandler создает уведомления получателям события; email и SMS отправляются очередью задач.
+func NotificationHandler(service contracts.ServiceI) Handler {
+	return func(ctx context.Context, event domain.Event) error {
+		_, err := service.CreateNotifications(event)
+		return err
+	}
+}
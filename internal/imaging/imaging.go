@@ -0,0 +1,116 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+
+	_ "image/gif"
+)
+
+// Поддерживаемые форматы изображений (имена image.Decode)
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+)
+
+const jpegQuality = 85
+
+// ContentTypes - MIME-типы поддерживаемых форматов
+var ContentTypes = map[string]string{
+	FormatJPEG: "image/jpeg",
+	FormatPNG:  "image/png",
+	FormatGIF:  "image/gif",
+}
+
+// Extensions - расширения файлов поддерживаемых форматов
+var Extensions = map[string]string{
+	FormatJPEG: ".jpg",
+	FormatPNG:  ".png",
+	FormatGIF:  ".gif",
+}
+
+// DecodeConfig читает формат и размеры изображения без декодирования пикселей.
+func DecodeConfig(data []byte) (image.Config, string, error) {
+	return image.DecodeConfig(bytes.NewReader(data))
+}
+
+func Decode(data []byte) (image.Image, string, error) {
+	return image.Decode(bytes.NewReader(data))
+}
+
+// Thumbnail уменьшает изображение, чтобы большая сторона была не больше size, сохраняя
+// пропорции. Каждый пиксель результата - среднее накрытого им прямоугольника исходника,
+// поэтому сильное уменьшение не дает муара. Меньшие изображения не увеличиваются.
+func Thumbnail(src image.Image, size int) *image.RGBA {
+	rgba := toRGBA(src)
+	w, h := rgba.Rect.Dx(), rgba.Rect.Dy()
+	tw, th := w, h
+	if w > size || h > size {
+		if w >= h {
+			tw, th = size, max(1, h*size/w)
+		} else {
+			tw, th = max(1, w*size/h), size
+		}
+	}
+	if tw == w && th == h {
+		return rgba
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		sy0, sy1 := y*h/th, max(y*h/th+1, (y+1)*h/th)
+		for x := 0; x < tw; x++ {
+			sx0, sx1 := x*w/tw, max(x*w/tw+1, (x+1)*w/tw)
+			var sum [4]uint64
+			for sy := sy0; sy < sy1; sy++ {
+				offset := sy*rgba.Stride + sx0*4
+				for sx := sx0; sx < sx1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += uint64(rgba.Pix[offset+c])
+					}
+					offset += 4
+				}
+			}
+			n := uint64((sy1 - sy0) * (sx1 - sx0))
+			offset := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
+
+// Encode кодирует миниатюру: PNG для исходников с прозрачностью (png, gif), иначе JPEG.
+// Возвращает данные, MIME-тип и расширение файла.
+func Encode(img image.Image, sourceFormat string) ([]byte, string, string, error) {
+	var buf bytes.Buffer
+	format := FormatJPEG
+	if sourceFormat == FormatPNG || sourceFormat == FormatGIF {
+		format = FormatPNG
+	}
+	var err error
+	if format == FormatPNG {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return nil, "", "", fmt.Errorf("encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), ContentTypes[format], Extensions[format], nil
+}
+
+func toRGBA(src image.Image) *image.RGBA {
+	if rgba, ok := src.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Rect, src, bounds.Min, draw.Src)
+	return rgba
+}
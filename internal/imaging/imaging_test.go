@@ -0,0 +1,64 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestThumbnailAveragesPixels(t *testing.T) {
+	// Шахматная доска 4x4 из черных и белых пикселей уменьшается до равномерно серого 2x2
+	src := image.NewGray(image.Rect(0, 0, 4, 4))
+	for y := range 4 {
+		for x := range 4 {
+			if (x+y)%2 == 0 {
+				src.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	thumbnail := Thumbnail(src, 2)
+	if thumbnail.Rect.Dx() != 2 || thumbnail.Rect.Dy() != 2 {
+		t.Fatalf("thumbnail size %v, want 2x2", thumbnail.Rect)
+	}
+	for y := range 2 {
+		for x := range 2 {
+			if c := thumbnail.RGBAAt(x, y); c.R != 127 || c.G != 127 || c.B != 127 || c.A != 255 {
+				t.Errorf("pixel (%d,%d) = %v, want gray 127", x, y, c)
+			}
+		}
+	}
+}
+
+func TestThumbnailSize(t *testing.T) {
+	tests := []struct {
+		width, height, size int
+		wantW, wantH        int
+	}{
+		{width: 800, height: 600, size: 160, wantW: 160, wantH: 120},
+		{width: 600, height: 800, size: 160, wantW: 120, wantH: 160},
+		{width: 1000, height: 1, size: 100, wantW: 100, wantH: 1},
+		{width: 100, height: 50, size: 480, wantW: 100, wantH: 50}, // меньшие не увеличиваются
+	}
+	for _, tt := range tests {
+		// Исходник со смещенными границами (например, подизображение) тоже поддерживается
+		src := image.NewRGBA(image.Rect(10, 10, 10+tt.width, 10+tt.height))
+		bounds := Thumbnail(src, tt.size).Rect
+		if bounds.Min != (image.Point{}) || bounds.Dx() != tt.wantW || bounds.Dy() != tt.wantH {
+			t.Errorf("Thumbnail(%dx%d, %d) = %v, want %dx%d", tt.width, tt.height, tt.size, bounds, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestEncodeFormat(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for source, want := range map[string]string{FormatJPEG: "image/jpeg", FormatPNG: "image/png", FormatGIF: "image/png"} {
+		data, contentType, ext, err := Encode(img, source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, format, err := DecodeConfig(data)
+		if err != nil || contentType != want || ContentTypes[format] != want || ext != Extensions[format] {
+			t.Errorf("Encode(%s) = %s %s (decoded %s, %v), want %s", source, contentType, ext, format, err, want)
+		}
+	}
+}
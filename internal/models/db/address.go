@@ -0,0 +1,69 @@
+package db
+
+import (
+	"marketplace/internal/models/domain"
+	"time"
+)
+
+type Address struct {
+	ID            int64      `db:"id"`
+	UserID        int64      `db:"user_id"`
+	Label         *string    `db:"label"`
+	RecipientName string     `db:"recipient_name"`
+	Phone         *string    `db:"phone"`
+	Country       string     `db:"country"`
+	Region        *string    `db:"region"`
+	City          string     `db:"city"`
+	PostalCode    *string    `db:"postal_code"`
+	Line1         string     `db:"line1"`
+	Line2         *string    `db:"line2"`
+	IsDefault     bool       `db:"is_default"`
+	CreatedAt     time.Time  `db:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at"`
+	DeletedAt     *time.Time `db:"deleted_at"`
+}
+
+func (a *Address) ToDomain() *domain.Address {
+	return &domain.Address{
+		ID:            a.ID,
+		UserID:        a.UserID,
+		Label:         derefString(a.Label),
+		RecipientName: a.RecipientName,
+		Phone:         derefString(a.Phone),
+		Country:       a.Country,
+		Region:        derefString(a.Region),
+		City:          a.City,
+		PostalCode:    derefString(a.PostalCode),
+		Line1:         a.Line1,
+		Line2:         derefString(a.Line2),
+		IsDefault:     a.IsDefault,
+		CreatedAt:     a.CreatedAt,
+		UpdatedAt:     a.UpdatedAt,
+		DeletedAt:     a.DeletedAt,
+	}
+}
+
+func (a *Address) FromDomain(d *domain.Address) {
+	a.ID = d.ID
+	a.UserID = d.UserID
+	a.Label = &d.Label
+	a.RecipientName = d.RecipientName
+	a.Phone = &d.Phone
+	a.Country = d.Country
+	a.Region = &d.Region
+	a.City = d.City
+	a.PostalCode = &d.PostalCode
+	a.Line1 = d.Line1
+	a.Line2 = &d.Line2
+	a.IsDefault = d.IsDefault
+	a.CreatedAt = d.CreatedAt
+	a.UpdatedAt = d.UpdatedAt
+	a.DeletedAt = d.DeletedAt
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
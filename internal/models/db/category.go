@@ -0,0 +1,38 @@
+package db
+
+import (
+	"marketplace/internal/models/domain"
+	"time"
+)
+
+type Category struct {
+	ID        int64     `db:"id"`
+	ParentID  *int64    `db:"parent_id"`
+	Name      string    `db:"name"`
+	Slug      string    `db:"slug"`
+	Position  int       `db:"position"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (c *Category) ToDomain() *domain.Category {
+	return &domain.Category{
+		ID:        c.ID,
+		ParentID:  c.ParentID,
+		Name:      c.Name,
+		Slug:      c.Slug,
+		Position:  c.Position,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+// CategoryPathNode - звено пути от категории товара к корню
+type CategoryPathNode struct {
+	ProductID  int64  `db:"product_id"`
+	CategoryID int64  `db:"category_id"`
+	ID         int64  `db:"id"`
+	Name       string `db:"name"`
+	Slug       string `db:"slug"`
+	Depth      int    `db:"depth"`
+}
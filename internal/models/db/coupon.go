@@ -0,0 +1,136 @@
+package db
+
+import (
+	"marketplace/internal/models/domain"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type Coupon struct {
+	ID                int64         `db:"id"`
+	Code              string        `db:"code"`
+	Type              string        `db:"type"`
+	Value             string        `db:"value"`
+	Amount            *string       `db:"amount"`
+	Currency          *string       `db:"currency"`
+	Scope             string        `db:"scope"`
+	ShopID            *int64        `db:"shop_id"`
+	ProductIDs        pq.Int64Array `db:"product_ids"`
+	MinOrderValue     *string       `db:"min_order_value"`
+	StartsAt          *time.Time    `db:"starts_at"`
+	EndsAt            *time.Time    `db:"ends_at"`
+	UsageLimit        *int          `db:"usage_limit"`
+	UsageLimitPerUser *int          `db:"usage_limit_per_user"`
+	TimesUsed         int           `db:"times_used"`
+	Stackable         bool          `db:"stackable"`
+	Active            bool          `db:"active"`
+	CreatedBy         int64         `db:"created_by"`
+	CreatedAt         time.Time     `db:"created_at"`
+	UpdatedAt         time.Time     `db:"updated_at"`
+}
+
+func (c *Coupon) ToDomain() (*domain.Coupon, error) {
+	var money moneyParser
+	value, err := domain.ParseBasisPoints(c.Value)
+	if err != nil {
+		return nil, err
+	}
+	coupon := &domain.Coupon{
+		ID:                c.ID,
+		Code:              c.Code,
+		Type:              c.Type,
+		Value:             value,
+		Currency:          derefString(c.Currency),
+		Scope:             c.Scope,
+		ShopID:            c.ShopID,
+		ProductIDs:        []int64(c.ProductIDs),
+		StartsAt:          c.StartsAt,
+		EndsAt:            c.EndsAt,
+		UsageLimit:        c.UsageLimit,
+		UsageLimitPerUser: c.UsageLimitPerUser,
+		TimesUsed:         c.TimesUsed,
+		Stackable:         c.Stackable,
+		Active:            c.Active,
+		CreatedBy:         c.CreatedBy,
+		CreatedAt:         c.CreatedAt,
+		UpdatedAt:         c.UpdatedAt,
+	}
+	if c.Amount != nil {
+		amount := money.parse(*c.Amount, coupon.Currency)
+		coupon.Amount = &amount
+	}
+	if c.MinOrderValue != nil {
+		minOrderValue := money.parse(*c.MinOrderValue, coupon.Currency)
+		coupon.MinOrderValue = &minOrderValue
+	}
+	if money.err != nil {
+		return nil, money.err
+	}
+	return coupon, nil
+}
+
+func (c *Coupon) FromDomain(d *domain.Coupon) {
+	c.ID = d.ID
+	c.Code = d.Code
+	c.Type = d.Type
+	c.Value = d.Value.Decimal()
+	c.Currency = nil
+	if d.Currency != "" {
+		c.Currency = &d.Currency
+	}
+	c.Scope = d.Scope
+	c.ShopID = d.ShopID
+	c.ProductIDs = pq.Int64Array(d.ProductIDs)
+	if c.ProductIDs == nil {
+		c.ProductIDs = pq.Int64Array{}
+	}
+	c.Amount = nil
+	if d.Amount != nil {
+		amount := d.Amount.Decimal()
+		c.Amount = &amount
+	}
+	c.MinOrderValue = nil
+	if d.MinOrderValue != nil {
+		minOrderValue := d.MinOrderValue.Decimal()
+		c.MinOrderValue = &minOrderValue
+	}
+	c.StartsAt = d.StartsAt
+	c.EndsAt = d.EndsAt
+	c.UsageLimit = d.UsageLimit
+	c.UsageLimitPerUser = d.UsageLimitPerUser
+	c.TimesUsed = d.TimesUsed
+	c.Stackable = d.Stackable
+	c.Active = d.Active
+	c.CreatedBy = d.CreatedBy
+	c.CreatedAt = d.CreatedAt
+	c.UpdatedAt = d.UpdatedAt
+}
+
+type OrderDiscount struct {
+	ID        int64     `db:"id"`
+	OrderID   int64     `db:"order_id"`
+	CouponID  *int64    `db:"coupon_id"`
+	Code      string    `db:"code"`
+	Type      string    `db:"type"`
+	Amount    string    `db:"amount"`
+	Currency  string    `db:"currency"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (d *OrderDiscount) ToDomain() (*domain.OrderDiscount, error) {
+	var money moneyParser
+	discount := &domain.OrderDiscount{
+		ID:        d.ID,
+		OrderID:   d.OrderID,
+		CouponID:  d.CouponID,
+		Code:      d.Code,
+		Type:      d.Type,
+		Amount:    money.parse(d.Amount, d.Currency),
+		CreatedAt: d.CreatedAt,
+	}
+	if money.err != nil {
+		return nil, money.err
+	}
+	return discount, nil
+}
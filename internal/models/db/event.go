@@ -0,0 +1,33 @@
+package db
+
+import (
+	"marketplace/internal/models/domain"
+	"time"
+)
+
+type Event struct {
+	ID            int64      `db:"id"`
+	EventID       string     `db:"event_id"`
+	Type          string     `db:"type"`
+	AggregateType string     `db:"aggregate_type"`
+	AggregateID   int64      `db:"aggregate_id"`
+	Payload       []byte     `db:"payload"`
+	Attempts      int        `db:"attempts"`
+	NextAttemptAt time.Time  `db:"next_attempt_at"`
+	LastError     *string    `db:"last_error"`
+	CreatedAt     time.Time  `db:"created_at"`
+	PublishedAt   *time.Time `db:"published_at"`
+}
+
+func (e *Event) ToDomain() *domain.Event {
+	return &domain.Event{
+		ID:            e.ID,
+		EventID:       e.EventID,
+		Type:          e.Type,
+		AggregateType: e.AggregateType,
+		AggregateID:   e.AggregateID,
+		Payload:       e.Payload,
+		Attempts:      e.Attempts,
+		CreatedAt:     e.CreatedAt,
+	}
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"marketplace/internal/models/domain"
+	"time"
+)
+
+type ExchangeRate struct {
+	ID            int64     `db:"id"`
+	BaseCurrency  string    `db:"base_currency"`
+	QuoteCurrency string    `db:"quote_currency"`
+	Rate          string    `db:"rate"`
+	Source        string    `db:"source"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+func (r *ExchangeRate) ToDomain() *domain.ExchangeRate {
+	return &domain.ExchangeRate{
+		ID:            r.ID,
+		BaseCurrency:  r.BaseCurrency,
+		QuoteCurrency: r.QuoteCurrency,
+		Rate:          normalizeRate(r.Rate),
+		Source:        r.Source,
+		UpdatedAt:     r.UpdatedAt,
+	}
+}
+
+type OrderExchangeRate struct {
+	ID           int64     `db:"id"`
+	OrderID      int64     `db:"order_id"`
+	FromCurrency string    `db:"from_currency"`
+	ToCurrency   string    `db:"to_currency"`
+	Rate         string    `db:"rate"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+func (r *OrderExchangeRate) ToDomain() *domain.OrderExchangeRate {
+	return &domain.OrderExchangeRate{
+		ID:           r.ID,
+		OrderID:      r.OrderID,
+		FromCurrency: r.FromCurrency,
+		ToCurrency:   r.ToCurrency,
+		Rate:         normalizeRate(r.Rate),
+		CreatedAt:    r.CreatedAt,
+	}
+}
+
+// normalizeRate убирает хвостовые нули NUMERIC(20, 10)
+func normalizeRate(rate string) string {
+	parsed, err := domain.ParseRate(rate)
+	if err != nil {
+		return rate
+	}
+	return domain.FormatRate(parsed)
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"marketplace/internal/models/domain"
+	"time"
+)
+
+type FlashSale struct {
+	ID                int64      `db:"id"`
+	ProductID         int64      `db:"product_id"`
+	AllocatedQuantity int        `db:"allocated_quantity"`
+	SlotCount         int        `db:"slot_count"`
+	PerUserLimit      int        `db:"per_user_limit"`
+	Status            string     `db:"status"`
+	StartsAt          time.Time  `db:"starts_at"`
+	EndsAt            time.Time  `db:"ends_at"`
+	ReturnedQuantity  int        `db:"returned_quantity"`
+	CreatedBy         int64      `db:"created_by"`
+	CreatedAt         time.Time  `db:"created_at"`
+	FinishedAt        *time.Time `db:"finished_at"`
+}
+
+func (f *FlashSale) ToDomain() *domain.FlashSale {
+	return &domain.FlashSale{
+		ID:                f.ID,
+		ProductID:         f.ProductID,
+		AllocatedQuantity: f.AllocatedQuantity,
+		SlotCount:         f.SlotCount,
+		PerUserLimit:      f.PerUserLimit,
+		Status:            f.Status,
+		StartsAt:          f.StartsAt,
+		EndsAt:            f.EndsAt,
+		ReturnedQuantity:  f.ReturnedQuantity,
+		CreatedBy:         f.CreatedBy,
+		CreatedAt:         f.CreatedAt,
+		FinishedAt:        f.FinishedAt,
+	}
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"marketplace/internal/models/domain"
+	"time"
+)
+
+type IdempotencyRecord struct {
+	ID             int64     `db:"id"`
+	UserID         int64     `db:"user_id"`
+	Key            string    `db:"key"`
+	Method         string    `db:"method"`
+	Path           string    `db:"path"`
+	Fingerprint    string    `db:"fingerprint"`
+	Status         string    `db:"status"`
+	ResponseStatus *int      `db:"response_status"`
+	ResponseBody   []byte    `db:"response_body"`
+	ContentType    *string   `db:"content_type"`
+	CreatedAt      time.Time `db:"created_at"`
+	ExpiresAt      time.Time `db:"expires_at"`
+}
+
+func (r *IdempotencyRecord) ToDomain() *domain.IdempotencyRecord {
+	record := &domain.IdempotencyRecord{
+		ID:           r.ID,
+		UserID:       r.UserID,
+		Key:          r.Key,
+		Method:       r.Method,
+		Path:         r.Path,
+		Fingerprint:  r.Fingerprint,
+		Status:       r.Status,
+		ResponseBody: r.ResponseBody,
+		ContentType:  derefString(r.ContentType),
+		CreatedAt:    r.CreatedAt,
+		ExpiresAt:    r.ExpiresAt,
+	}
+	if r.ResponseStatus != nil {
+		record.ResponseStatus = *r.ResponseStatus
+	}
+	return record
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"encoding/json"
+	"marketplace/internal/models/domain"
+	"time"
+)
+
+type ProductImage struct {
+	ID          int64     `db:"id"`
+	ProductID   int64     `db:"product_id"`
+	VariantID   *int64    `db:"variant_id"`
+	BlobKey     string    `db:"blob_key"`
+	ContentType string    `db:"content_type"`
+	SizeBytes   int64     `db:"size_bytes"`
+	Width       int       `db:"width"`
+	Height      int       `db:"height"`
+	Thumbnails  []byte    `db:"thumbnails"`
+	Status      string    `db:"status"`
+	Position    int       `db:"position"`
+	IsPrimary   bool      `db:"is_primary"`
+	Alt         string    `db:"alt"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// ImageThumbnail - элемент JSONB-колонки thumbnails
+type ImageThumbnail struct {
+	Size   int    `json:"size"`
+	Key    string `json:"key"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+func (i *ProductImage) ToDomain() *domain.ProductImage {
+	image := &domain.ProductImage{
+		ID:          i.ID,
+		ProductID:   i.ProductID,
+		VariantID:   i.VariantID,
+		Key:         i.BlobKey,
+		ContentType: i.ContentType,
+		SizeBytes:   i.SizeBytes,
+		Width:       i.Width,
+		Height:      i.Height,
+		Thumbnails:  []domain.ImageThumbnail{},
+		Status:      i.Status,
+		Position:    i.Position,
+		Primary:     i.IsPrimary,
+		Alt:         i.Alt,
+		CreatedAt:   i.CreatedAt,
+		UpdatedAt:   i.UpdatedAt,
+	}
+	var thumbnails []ImageThumbnail
+	if len(i.Thumbnails) > 0 {
+		_ = json.Unmarshal(i.Thumbnails, &thumbnails)
+	}
+	for _, t := range thumbnails {
+		image.Thumbnails = append(image.Thumbnails, domain.ImageThumbnail{Size: t.Size, Key: t.Key, Width: t.Width, Height: t.Height})
+	}
+	return image
+}
+
+func (i *ProductImage) FromDomain(d *domain.ProductImage) {
+	i.ID = d.ID
+	i.ProductID = d.ProductID
+	i.VariantID = d.VariantID
+	i.BlobKey = d.Key
+	i.ContentType = d.ContentType
+	i.SizeBytes = d.SizeBytes
+	i.Width = d.Width
+	i.Height = d.Height
+	i.Thumbnails = MarshalThumbnails(d.Thumbnails)
+	i.Status = d.Status
+	i.Position = d.Position
+	i.IsPrimary = d.Primary
+	i.Alt = d.Alt
+	i.CreatedAt = d.CreatedAt
+	i.UpdatedAt = d.UpdatedAt
+}
+
+// MarshalThumbnails кодирует миниатюры для колонки thumbnails вместе с ключами файлов.
+func MarshalThumbnails(thumbnails []domain.ImageThumbnail) []byte {
+	items := make([]ImageThumbnail, len(thumbnails))
+	for i, t := range thumbnails {
+		items[i] = ImageThumbnail{Size: t.Size, Key: t.Key, Width: t.Width, Height: t.Height}
+	}
+	data, _ := json.Marshal(items)
+	return data
+}
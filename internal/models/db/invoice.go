@@ -1,15 +1,45 @@
 package db
 
-import "time"
+import (
+	"marketplace/internal/models/domain"
+	"time"
+)
 
 type Invoice struct {
-	ID        int64      `db:"id"`
-	OrderID   int64      `db:"order_id"`
-	Amount    float64    `db:"amount"`
-	Currency  string     `db:"currency"`
-	Paid      bool       `db:"paid"`
-	Method    *string    `db:"method"`
-	CreatedAt time.Time  `db:"created_at"`
-	UpdatedAt time.Time  `db:"updated_at"`
-	PaidAt    *time.Time `db:"paid_at"`
+	ID             int64      `db:"id"`
+	OrderID        int64      `db:"order_id"`
+	Amount         string     `db:"amount"`
+	ShippingAmount string     `db:"shipping_amount"`
+	TaxAmount      string     `db:"tax_amount"`
+	Currency       string     `db:"currency"`
+	Paid           bool       `db:"paid"`
+	Method         *string    `db:"method"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+	PaidAt         *time.Time `db:"paid_at"`
+}
+
+func (i *Invoice) ToDomain() (*domain.Invoice, error) {
+	var money moneyParser
+	invoice := &domain.Invoice{
+		ID:             i.ID,
+		OrderID:        i.OrderID,
+		Amount:         money.parse(i.Amount, i.Currency),
+		ShippingAmount: money.parse(i.ShippingAmount, i.Currency),
+		TaxAmount:      money.parse(i.TaxAmount, i.Currency),
+		Currency:       i.Currency,
+		Paid:           i.Paid,
+		CreatedAt:      i.CreatedAt,
+		UpdatedAt:      i.UpdatedAt,
+	}
+	if i.Method != nil {
+		invoice.Method = *i.Method
+	}
+	if i.PaidAt != nil {
+		invoice.PaidAt = i.PaidAt.Format(time.RFC3339)
+	}
+	if money.err != nil {
+		return nil, money.err
+	}
+	return invoice, nil
 }
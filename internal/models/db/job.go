@@ -0,0 +1,70 @@
+package db
+
+import (
+	"marketplace/internal/models/domain"
+	"time"
+)
+
+type Job struct {
+	ID          int64      `db:"id"`
+	Type        string     `db:"type"`
+	Payload     []byte     `db:"payload"`
+	Status      string     `db:"status"`
+	Attempts    int        `db:"attempts"`
+	MaxAttempts int        `db:"max_attempts"`
+	RunAt       time.Time  `db:"run_at"`
+	LockedAt    *time.Time `db:"locked_at"`
+	LockedBy    *string    `db:"locked_by"`
+	LastError   *string    `db:"last_error"`
+	ScheduleID  *int64     `db:"schedule_id"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+	CompletedAt *time.Time `db:"completed_at"`
+}
+
+func (j *Job) ToDomain() *domain.Job {
+	return &domain.Job{
+		ID:          j.ID,
+		Type:        j.Type,
+		Payload:     j.Payload,
+		Status:      j.Status,
+		Attempts:    j.Attempts,
+		MaxAttempts: j.MaxAttempts,
+		RunAt:       j.RunAt,
+		LockedAt:    j.LockedAt,
+		LockedBy:    derefString(j.LockedBy),
+		LastError:   derefString(j.LastError),
+		ScheduleID:  j.ScheduleID,
+		CreatedAt:   j.CreatedAt,
+		UpdatedAt:   j.UpdatedAt,
+		CompletedAt: j.CompletedAt,
+	}
+}
+
+type JobSchedule struct {
+	ID        int64      `db:"id"`
+	Name      string     `db:"name"`
+	JobType   string     `db:"job_type"`
+	Payload   []byte     `db:"payload"`
+	Cron      string     `db:"cron"`
+	Active    bool       `db:"active"`
+	NextRunAt time.Time  `db:"next_run_at"`
+	LastRunAt *time.Time `db:"last_run_at"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at"`
+}
+
+func (s *JobSchedule) ToDomain() *domain.JobSchedule {
+	return &domain.JobSchedule{
+		ID:        s.ID,
+		Name:      s.Name,
+		JobType:   s.JobType,
+		Payload:   s.Payload,
+		Cron:      s.Cron,
+		Active:    s.Active,
+		NextRunAt: s.NextRunAt,
+		LastRunAt: s.LastRunAt,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
@@ -0,0 +1,16 @@
+package db
+
+import "marketplace/internal/models/domain"
+
+// moneyParser разбирает суммы NUMERIC в ToDomain и запоминает первую ошибку разбора.
+type moneyParser struct {
+	err error
+}
+
+func (p *moneyParser) parse(decimal, currency string) domain.Money {
+	money, err := domain.MoneyFromDecimal(decimal, currency)
+	if err != nil && p.err == nil {
+		p.err = err
+	}
+	return money
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"marketplace/internal/models/domain"
+	"time"
+)
+
+type Notification struct {
+	ID        int64      `db:"id"`
+	UserID    int64      `db:"user_id"`
+	EventID   *string    `db:"event_id"`
+	EventType string     `db:"event_type"`
+	Channel   string     `db:"channel"`
+	Subject   string     `db:"subject"`
+	Body      string     `db:"body"`
+	Status    string     `db:"status"`
+	LastError *string    `db:"last_error"`
+	DigestID  *int64     `db:"digest_id"`
+	ReadAt    *time.Time `db:"read_at"`
+	SentAt    *time.Time `db:"sent_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+func (n *Notification) ToDomain() *domain.Notification {
+	return &domain.Notification{
+		ID:        n.ID,
+		UserID:    n.UserID,
+		EventID:   derefString(n.EventID),
+		EventType: n.EventType,
+		Channel:   n.Channel,
+		Subject:   n.Subject,
+		Body:      n.Body,
+		Status:    n.Status,
+		LastError: derefString(n.LastError),
+		DigestID:  n.DigestID,
+		ReadAt:    n.ReadAt,
+		SentAt:    n.SentAt,
+		CreatedAt: n.CreatedAt,
+	}
+}
+
+func (n *Notification) FromDomain(d *domain.Notification) {
+	n.ID = d.ID
+	n.UserID = d.UserID
+	if d.EventID != "" {
+		n.EventID = &d.EventID
+	}
+	n.EventType = d.EventType
+	n.Channel = d.Channel
+	n.Subject = d.Subject
+	n.Body = d.Body
+	n.Status = d.Status
+	if d.LastError != "" {
+		n.LastError = &d.LastError
+	}
+	n.DigestID = d.DigestID
+	n.ReadAt = d.ReadAt
+	n.SentAt = d.SentAt
+	n.CreatedAt = d.CreatedAt
+}
+
+type NotificationTemplate struct {
+	ID        int64     `db:"id"`
+	EventType string    `db:"event_type"`
+	Channel   string    `db:"channel"`
+	Locale    string    `db:"locale"`
+	Subject   string    `db:"subject"`
+	Body      string    `db:"body"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (t *NotificationTemplate) ToDomain() *domain.NotificationTemplate {
+	return &domain.NotificationTemplate{
+		ID:        t.ID,
+		EventType: t.EventType,
+		Channel:   t.Channel,
+		Locale:    t.Locale,
+		Subject:   t.Subject,
+		Body:      t.Body,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
+
+type NotificationPreferences struct {
+	UserID int64  `db:"user_id"`
+	Locale string `db:"locale"`
+	Email  bool   `db:"email"`
+	SMS    bool   `db:"sms"`
+	InApp  bool   `db:"in_app"`
+}
+
+func (p *NotificationPreferences) ToDomain() *domain.NotificationPreferences {
+	return &domain.NotificationPreferences{
+		Locale: p.Locale,
+		Email:  p.Email,
+		SMS:    p.SMS,
+		InApp:  p.InApp,
+	}
+}
+
+type NotificationDigestGroup struct {
+	UserID  int64  `db:"user_id"`
+	Channel string `db:"channel"`
+}
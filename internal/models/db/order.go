@@ -1,48 +1,84 @@
 package db
 
 import (
+	"encoding/json"
 	"marketplace/internal/models/domain"
 	"time"
 )
 
 type Order struct {
-	ID        int64      `db:"id"`
-	UserID    int64      `db:"user_id"`
-	ShopID    *int64     `db:"shop_id"`
-	Total     float64    `db:"total"`
-	Currency  string     `db:"currency"`
-	Status    string     `db:"status"`
-	Note      *string    `db:"note"`
-	CreatedAt time.Time  `db:"created_at"`
-	UpdatedAt time.Time  `db:"updated_at"`
-	DeletedAt *time.Time `db:"deleted_at"`
+	ID              int64      `db:"id"`
+	UserID          int64      `db:"user_id"`
+	ShopID          *int64     `db:"shop_id"`
+	Subtotal        string     `db:"subtotal"`
+	ShippingTotal   string     `db:"shipping_total"`
+	DiscountTotal   string     `db:"discount_total"`
+	TaxTotal        string     `db:"tax_total"`
+	Total           string     `db:"total"`
+	Currency        string     `db:"currency"`
+	Status          string     `db:"status"`
+	CancelReason    *string    `db:"cancel_reason"`
+	CancelledAt     *time.Time `db:"cancelled_at"`
+	Note            *string    `db:"note"`
+	ShippingAddress []byte     `db:"shipping_address"`
+	Version         int64      `db:"version"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+	DeletedAt       *time.Time `db:"deleted_at"`
 }
 
-func (o *Order) ToDomain() *domain.Order {
+func (o *Order) ToDomain() (*domain.Order, error) {
+	var money moneyParser
 	domainOrder := &domain.Order{
-		ID:        o.ID,
-		UserID:    o.UserID,
-		ShopID:    o.ShopID,
-		Total:     o.Total,
-		Currency:  o.Currency,
-		Status:    o.Status,
-		CreatedAt: o.CreatedAt,
-		UpdatedAt: o.UpdatedAt,
-		DeletedAt: o.DeletedAt,
+		ID:            o.ID,
+		UserID:        o.UserID,
+		ShopID:        o.ShopID,
+		Subtotal:      money.parse(o.Subtotal, o.Currency),
+		ShippingTotal: money.parse(o.ShippingTotal, o.Currency),
+		DiscountTotal: money.parse(o.DiscountTotal, o.Currency),
+		TaxTotal:      money.parse(o.TaxTotal, o.Currency),
+		Total:         money.parse(o.Total, o.Currency),
+		Currency:      o.Currency,
+		Status:        o.Status,
+		CancelReason:  derefString(o.CancelReason),
+		CancelledAt:   o.CancelledAt,
+		Version:       o.Version,
+		CreatedAt:     o.CreatedAt,
+		UpdatedAt:     o.UpdatedAt,
+		DeletedAt:     o.DeletedAt,
 	}
 	if o.Note != nil {
 		domainOrder.Note = *o.Note
 	}
-	return domainOrder
+	if len(o.ShippingAddress) > 0 {
+		var address domain.AddressSnapshot
+		if err := json.Unmarshal(o.ShippingAddress, &address); err == nil {
+			domainOrder.ShippingAddress = &address
+		}
+	}
+	if money.err != nil {
+		return nil, money.err
+	}
+	return domainOrder, nil
 }
 
 func (o *Order) FromDomain(d *domain.Order) {
 	o.ID = d.ID
 	o.UserID = d.UserID
 	o.ShopID = d.ShopID
-	o.Total = d.Total
+	o.Subtotal = d.Subtotal.Decimal()
+	o.ShippingTotal = d.ShippingTotal.Decimal()
+	o.DiscountTotal = d.DiscountTotal.Decimal()
+	o.TaxTotal = d.TaxTotal.Decimal()
+	o.Total = d.Total.Decimal()
+	o.Currency = d.Currency
 	o.Status = d.Status
 	o.Note = &d.Note
+	o.ShippingAddress = nil
+	if d.ShippingAddress != nil {
+		o.ShippingAddress, _ = json.Marshal(d.ShippingAddress)
+	}
+	o.Version = d.Version
 	o.CreatedAt = d.CreatedAt
 	o.UpdatedAt = d.UpdatedAt
 	o.DeletedAt = d.DeletedAt
@@ -6,41 +6,76 @@ import (
 )
 
 type OrderItem struct {
-	ID         int64     `db:"id"`
-	OrderID    int64     `db:"order_id"`
-	ProductID  int64     `db:"product_id"`
-	Name       string    `db:"name"`
-	SKU        string    `db:"sku"`
-	UnitPrice  float64   `db:"unit_price"`
-	Quantity   int       `db:"quantity"`
-	TotalPrice float64   `db:"total_price"`
-	CreatedAt  time.Time `db:"created_at"`
-	UpdatedAt  time.Time `db:"updated_at"`
+	ID                int64     `db:"id"`
+	OrderID           int64     `db:"order_id"`
+	ProductID         int64     `db:"product_id"`
+	VariantID         *int64    `db:"variant_id"`
+	Name              string    `db:"name"`
+	SKU               string    `db:"sku"`
+	UnitPrice         string    `db:"unit_price"`
+	Quantity          int       `db:"quantity"`
+	TotalPrice        string    `db:"total_price"`
+	TaxAmount         string    `db:"tax_amount"`
+	TaxRate           float64   `db:"tax_rate"`
+	TaxInclusive      bool      `db:"tax_inclusive"`
+	Currency          string    `db:"currency"`
+	OriginalUnitPrice *string   `db:"original_unit_price"`
+	OriginalCurrency  *string   `db:"original_currency"`
+	FlashSaleID       *int64    `db:"flash_sale_id"`
+	CreatedAt         time.Time `db:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at"`
 }
 
-func (o *OrderItem) ToDomain() *domain.OrderItem {
-	return &domain.OrderItem{
-		ID:         o.ID,
-		OrderID:    o.OrderID,
-		ProductID:  o.ProductID,
-		Name:       o.Name,
-		SKU:        o.SKU,
-		UnitPrice:  o.UnitPrice,
-		Quantity:   o.Quantity,
-		TotalPrice: o.TotalPrice,
-		CreatedAt:  o.CreatedAt,
-		UpdatedAt:  o.UpdatedAt,
+func (o *OrderItem) ToDomain() (*domain.OrderItem, error) {
+	var money moneyParser
+	item := &domain.OrderItem{
+		ID:           o.ID,
+		OrderID:      o.OrderID,
+		ProductID:    o.ProductID,
+		VariantID:    o.VariantID,
+		Name:         o.Name,
+		SKU:          o.SKU,
+		UnitPrice:    money.parse(o.UnitPrice, o.Currency),
+		Quantity:     o.Quantity,
+		TotalPrice:   money.parse(o.TotalPrice, o.Currency),
+		TaxAmount:    money.parse(o.TaxAmount, o.Currency),
+		TaxRate:      o.TaxRate,
+		TaxInclusive: o.TaxInclusive,
+		FlashSaleID:  o.FlashSaleID,
+		CreatedAt:    o.CreatedAt,
+		UpdatedAt:    o.UpdatedAt,
 	}
+	if o.OriginalUnitPrice != nil && o.OriginalCurrency != nil {
+		original := money.parse(*o.OriginalUnitPrice, *o.OriginalCurrency)
+		item.OriginalUnitPrice = &original
+	}
+	if money.err != nil {
+		return nil, money.err
+	}
+	return item, nil
 }
 func (o *OrderItem) FromDomain(d *domain.OrderItem) {
 	o.ID = d.ID
 	o.OrderID = d.OrderID
 	o.ProductID = d.ProductID
+	o.VariantID = d.VariantID
 	o.Name = d.Name
 	o.SKU = d.SKU
-	o.UnitPrice = d.UnitPrice
+	o.UnitPrice = d.UnitPrice.Decimal()
 	o.Quantity = d.Quantity
-	o.TotalPrice = d.TotalPrice
+	o.TotalPrice = d.TotalPrice.Decimal()
+	o.TaxAmount = d.TaxAmount.Decimal()
+	o.TaxRate = d.TaxRate
+	o.TaxInclusive = d.TaxInclusive
+	o.FlashSaleID = d.FlashSaleID
+	o.Currency = d.UnitPrice.Currency
+	o.OriginalUnitPrice = nil
+	o.OriginalCurrency = nil
+	if d.OriginalUnitPrice != nil {
+		price := d.OriginalUnitPrice.Decimal()
+		o.OriginalUnitPrice = &price
+		o.OriginalCurrency = &d.OriginalUnitPrice.Currency
+	}
 	o.CreatedAt = d.CreatedAt
 	o.UpdatedAt = d.UpdatedAt
 }
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"encoding/json"
 	"marketplace/internal/models/domain"
 	"time"
 )
@@ -11,32 +12,53 @@ type Product struct {
 	Name        string     `db:"name"`
 	Slug        string     `db:"slug"`
 	Description *string    `db:"description"`
-	Price       float64    `db:"price"`
+	Price       string     `db:"price"`
 	Currency    string     `db:"currency"`
 	Quantity    int        `db:"quantity"`
+	TaxClass    string     `db:"tax_class"`
 	ShopID      int64      `db:"shop_id"`
 	Active      bool       `db:"active"`
+	WeightGrams int        `db:"weight_grams"`
+	LengthMM    int        `db:"length_mm"`
+	WidthMM     int        `db:"width_mm"`
+	HeightMM    int        `db:"height_mm"`
+	Attributes  []byte     `db:"attributes"`
+	Version     int64      `db:"version"`
 	CreatedAt   time.Time  `db:"created_at"`
 	UpdatedAt   time.Time  `db:"updated_at"`
 	DeletedAt   *time.Time `db:"deleted_at"`
 }
 
-func (p *Product) ToDomain() *domain.Product {
-	return &domain.Product{
+func (p *Product) ToDomain() (*domain.Product, error) {
+	var money moneyParser
+	product := &domain.Product{
 		ID:          p.ID,
 		SKU:         p.SKU,
 		Name:        p.Name,
 		Slug:        p.Slug,
 		Description: p.Description,
-		Price:       p.Price,
+		Price:       money.parse(p.Price, p.Currency),
 		Currency:    p.Currency,
 		Quantity:    p.Quantity,
+		TaxClass:    p.TaxClass,
 		ShopID:      p.ShopID,
 		Active:      p.Active,
+		WeightGrams: p.WeightGrams,
+		LengthMM:    p.LengthMM,
+		WidthMM:     p.WidthMM,
+		HeightMM:    p.HeightMM,
+		Version:     p.Version,
 		CreatedAt:   p.CreatedAt,
 		UpdatedAt:   p.UpdatedAt,
 		DeletedAt:   p.DeletedAt,
 	}
+	if len(p.Attributes) > 0 {
+		_ = json.Unmarshal(p.Attributes, &product.Attributes)
+	}
+	if money.err != nil {
+		return nil, money.err
+	}
+	return product, nil
 }
 func (p *Product) FromDomain(d *domain.Product) {
 	p.ID = d.ID
@@ -44,11 +66,21 @@ func (p *Product) FromDomain(d *domain.Product) {
 	p.Name = d.Name
 	p.Slug = d.Slug
 	p.Description = d.Description
-	p.Price = d.Price
+	p.Price = d.Price.Decimal()
 	p.Currency = d.Currency
 	p.Quantity = d.Quantity
+	p.TaxClass = d.TaxClass
 	p.ShopID = d.ShopID
 	p.Active = d.Active
+	p.WeightGrams = d.WeightGrams
+	p.LengthMM = d.LengthMM
+	p.WidthMM = d.WidthMM
+	p.HeightMM = d.HeightMM
+	p.Attributes = nil
+	if d.Attributes != nil {
+		p.Attributes, _ = json.Marshal(d.Attributes)
+	}
+	p.Version = d.Version
 	p.CreatedAt = d.CreatedAt
 	p.UpdatedAt = d.UpdatedAt
 	p.DeletedAt = d.DeletedAt
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"marketplace/internal/models/domain"
+	"time"
+)
+
+type ProductImport struct {
+	ID           int64      `db:"id"`
+	ShopID       int64      `db:"shop_id"`
+	UserID       int64      `db:"user_id"`
+	Format       string     `db:"format"`
+	DryRun       bool       `db:"dry_run"`
+	Status       string     `db:"status"`
+	InputKey     string     `db:"input_key"`
+	ErrorsKey    *string    `db:"errors_key"`
+	TotalRows    int        `db:"total_rows"`
+	CreatedCount int        `db:"created_count"`
+	UpdatedCount int        `db:"updated_count"`
+	FailedCount  int        `db:"failed_count"`
+	Error        string     `db:"error"`
+	CreatedAt    time.Time  `db:"created_at"`
+	StartedAt    *time.Time `db:"started_at"`
+	FinishedAt   *time.Time `db:"finished_at"`
+}
+
+func (i *ProductImport) ToDomain() *domain.ProductImport {
+	productImport := &domain.ProductImport{
+		ID:         i.ID,
+		ShopID:     i.ShopID,
+		UserID:     i.UserID,
+		Format:     i.Format,
+		DryRun:     i.DryRun,
+		Status:     i.Status,
+		InputKey:   i.InputKey,
+		TotalRows:  i.TotalRows,
+		Created:    i.CreatedCount,
+		Updated:    i.UpdatedCount,
+		Failed:     i.FailedCount,
+		Error:      i.Error,
+		CreatedAt:  i.CreatedAt,
+		StartedAt:  i.StartedAt,
+		FinishedAt: i.FinishedAt,
+	}
+	if i.ErrorsKey != nil {
+		productImport.ErrorsKey = *i.ErrorsKey
+	}
+	return productImport
+}
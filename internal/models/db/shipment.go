@@ -0,0 +1,100 @@
+package db
+
+import (
+	"marketplace/internal/models/domain"
+	"time"
+)
+
+type Shipment struct {
+	ID             int64      `db:"id"`
+	OrderID        int64      `db:"order_id"`
+	ShopID         int64      `db:"shop_id"`
+	Carrier        string     `db:"carrier"`
+	TrackingNumber string     `db:"tracking_number"`
+	Status         string     `db:"status"`
+	ShippedAt      *time.Time `db:"shipped_at"`
+	DeliveredAt    *time.Time `db:"delivered_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+}
+
+func (s *Shipment) ToDomain() *domain.Shipment {
+	return &domain.Shipment{
+		ID:             s.ID,
+		OrderID:        s.OrderID,
+		ShopID:         s.ShopID,
+		Carrier:        s.Carrier,
+		TrackingNumber: s.TrackingNumber,
+		Status:         s.Status,
+		ShippedAt:      s.ShippedAt,
+		DeliveredAt:    s.DeliveredAt,
+		CreatedAt:      s.CreatedAt,
+		UpdatedAt:      s.UpdatedAt,
+	}
+}
+
+func (s *Shipment) FromDomain(d *domain.Shipment) {
+	s.ID = d.ID
+	s.OrderID = d.OrderID
+	s.ShopID = d.ShopID
+	s.Carrier = d.Carrier
+	s.TrackingNumber = d.TrackingNumber
+	s.Status = d.Status
+	s.ShippedAt = d.ShippedAt
+	s.DeliveredAt = d.DeliveredAt
+	s.CreatedAt = d.CreatedAt
+	s.UpdatedAt = d.UpdatedAt
+}
+
+type ShipmentItem struct {
+	ID          int64 `db:"id"`
+	ShipmentID  int64 `db:"shipment_id"`
+	OrderItemID int64 `db:"order_item_id"`
+	Quantity    int   `db:"quantity"`
+}
+
+func (s *ShipmentItem) ToDomain() *domain.ShipmentItem {
+	return &domain.ShipmentItem{
+		ID:          s.ID,
+		ShipmentID:  s.ShipmentID,
+		OrderItemID: s.OrderItemID,
+		Quantity:    s.Quantity,
+	}
+}
+
+type ShipmentEvent struct {
+	ID          int64     `db:"id"`
+	ShipmentID  int64     `db:"shipment_id"`
+	Status      string    `db:"status"`
+	Location    *string   `db:"location"`
+	Description *string   `db:"description"`
+	OccurredAt  time.Time `db:"occurred_at"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+func (e *ShipmentEvent) ToDomain() *domain.ShipmentEvent {
+	domainEvent := &domain.ShipmentEvent{
+		ID:         e.ID,
+		ShipmentID: e.ShipmentID,
+		Status:     e.Status,
+		OccurredAt: e.OccurredAt,
+		CreatedAt:  e.CreatedAt,
+	}
+	if e.Location != nil {
+		domainEvent.Location = *e.Location
+	}
+	if e.Description != nil {
+		domainEvent.Description = *e.Description
+	}
+	return domainEvent
+}
+
+func (e *ShipmentEvent) FromDomain(d *domain.ShipmentEvent) {
+	e.ID = d.ID
+	e.ShipmentID = d.ShipmentID
+	e.Status = d.Status
+	e.Location = &d.Location
+	e.Description = &d.Description
+	e.OccurredAt = d.OccurredAt
+	e.CreatedAt = d.CreatedAt
+}
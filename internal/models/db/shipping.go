@@ -0,0 +1,129 @@
+package db
+
+import (
+	"marketplace/internal/models/domain"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type ShippingZone struct {
+	ID        int64          `db:"id"`
+	ShopID    int64          `db:"shop_id"`
+	Name      string         `db:"name"`
+	Countries pq.StringArray `db:"countries"`
+	CreatedAt time.Time      `db:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at"`
+}
+
+func (z *ShippingZone) ToDomain() *domain.ShippingZone {
+	return &domain.ShippingZone{
+		ID:        z.ID,
+		ShopID:    z.ShopID,
+		Name:      z.Name,
+		Countries: []string(z.Countries),
+		CreatedAt: z.CreatedAt,
+		UpdatedAt: z.UpdatedAt,
+	}
+}
+
+func (z *ShippingZone) FromDomain(d *domain.ShippingZone) {
+	z.ID = d.ID
+	z.ShopID = d.ShopID
+	z.Name = d.Name
+	z.Countries = pq.StringArray(d.Countries)
+	z.CreatedAt = d.CreatedAt
+	z.UpdatedAt = d.UpdatedAt
+}
+
+type ShippingMethod struct {
+	ID             int64     `db:"id"`
+	ZoneID         int64     `db:"zone_id"`
+	ShopID         int64     `db:"shop_id"`
+	Name           string    `db:"name"`
+	Type           string    `db:"type"`
+	Rate           string    `db:"rate"`
+	PerKgRate      string    `db:"per_kg_rate"`
+	FreeThreshold  *string   `db:"free_threshold"`
+	MaxWeightGrams *int      `db:"max_weight_grams"`
+	Currency       string    `db:"currency"`
+	Active         bool      `db:"active"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+func (m *ShippingMethod) ToDomain() (*domain.ShippingMethod, error) {
+	var money moneyParser
+	method := &domain.ShippingMethod{
+		ID:             m.ID,
+		ZoneID:         m.ZoneID,
+		ShopID:         m.ShopID,
+		Name:           m.Name,
+		Type:           m.Type,
+		Rate:           money.parse(m.Rate, m.Currency),
+		PerKgRate:      money.parse(m.PerKgRate, m.Currency),
+		MaxWeightGrams: m.MaxWeightGrams,
+		Currency:       m.Currency,
+		Active:         m.Active,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+	if m.FreeThreshold != nil {
+		threshold := money.parse(*m.FreeThreshold, m.Currency)
+		method.FreeThreshold = &threshold
+	}
+	if money.err != nil {
+		return nil, money.err
+	}
+	return method, nil
+}
+
+func (m *ShippingMethod) FromDomain(d *domain.ShippingMethod) {
+	m.ID = d.ID
+	m.ZoneID = d.ZoneID
+	m.ShopID = d.ShopID
+	m.Name = d.Name
+	m.Type = d.Type
+	m.Rate = d.Rate.Decimal()
+	m.PerKgRate = d.PerKgRate.Decimal()
+	m.FreeThreshold = nil
+	if d.FreeThreshold != nil {
+		threshold := d.FreeThreshold.Decimal()
+		m.FreeThreshold = &threshold
+	}
+	m.MaxWeightGrams = d.MaxWeightGrams
+	m.Currency = d.Currency
+	m.Active = d.Active
+	m.CreatedAt = d.CreatedAt
+	m.UpdatedAt = d.UpdatedAt
+}
+
+type OrderShippingLine struct {
+	ID               int64     `db:"id"`
+	OrderID          int64     `db:"order_id"`
+	ShopID           int64     `db:"shop_id"`
+	ShippingMethodID *int64    `db:"shipping_method_id"`
+	Name             string    `db:"name"`
+	Type             string    `db:"type"`
+	Amount           string    `db:"amount"`
+	Currency         string    `db:"currency"`
+	CreatedAt        time.Time `db:"created_at"`
+}
+
+func (l *OrderShippingLine) ToDomain() (*domain.OrderShippingLine, error) {
+	var money moneyParser
+	line := &domain.OrderShippingLine{
+		ID:               l.ID,
+		OrderID:          l.OrderID,
+		ShopID:           l.ShopID,
+		ShippingMethodID: l.ShippingMethodID,
+		Name:             l.Name,
+		Type:             l.Type,
+		Amount:           money.parse(l.Amount, l.Currency),
+		CreatedAt:        l.CreatedAt,
+	}
+	if money.err != nil {
+		return nil, money.err
+	}
+	return line, nil
+}
@@ -11,6 +11,7 @@ type Shop struct {
 	Slug        string     `db:"slug"`
 	OwnerID     int64      `db:"owner_id"`
 	Description *string    `db:"description"`
+	Version     int64      `db:"version"`
 	CreatedAt   time.Time  `db:"created_at"`
 	UpdatedAt   time.Time  `db:"updated_at"`
 	DeletedAt   *time.Time `db:"deleted_at"` // ИСПРАВЛЕНО: указатель
@@ -22,6 +23,7 @@ func (sh *Shop) ToDomain() *domain.Shop {
 		Name:      sh.Name,
 		Slug:      sh.Slug,
 		OwnerID:   sh.OwnerID,
+		Version:   sh.Version,
 		CreatedAt: sh.CreatedAt,
 		UpdatedAt: sh.UpdatedAt,
 	}
@@ -42,6 +44,7 @@ func (sh *Shop) FromDomain(d *domain.Shop) {
 	sh.Slug = d.Slug
 	sh.OwnerID = d.OwnerID
 	sh.Description = &d.Description
+	sh.Version = d.Version
 	sh.CreatedAt = d.CreatedAt
 	sh.UpdatedAt = d.UpdatedAt
 
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"marketplace/internal/models/domain"
+	"time"
+)
+
+type TaxRate struct {
+	ID        int64     `db:"id"`
+	Country   string    `db:"country"`
+	Region    string    `db:"region"`
+	TaxClass  string    `db:"tax_class"`
+	Name      string    `db:"name"`
+	Rate      float64   `db:"rate"`
+	Inclusive bool      `db:"inclusive"`
+	Active    bool      `db:"active"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (t *TaxRate) ToDomain() *domain.TaxRate {
+	return &domain.TaxRate{
+		ID:        t.ID,
+		Country:   t.Country,
+		Region:    t.Region,
+		TaxClass:  t.TaxClass,
+		Name:      t.Name,
+		Rate:      t.Rate,
+		Inclusive: t.Inclusive,
+		Active:    t.Active,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
+
+func (t *TaxRate) FromDomain(d *domain.TaxRate) {
+	t.ID = d.ID
+	t.Country = d.Country
+	t.Region = d.Region
+	t.TaxClass = d.TaxClass
+	t.Name = d.Name
+	t.Rate = d.Rate
+	t.Inclusive = d.Inclusive
+	t.Active = d.Active
+	t.CreatedAt = d.CreatedAt
+	t.UpdatedAt = d.UpdatedAt
+}
+
+type OrderTaxLine struct {
+	ID            int64     `db:"id"`
+	OrderID       int64     `db:"order_id"`
+	TaxRateID     *int64    `db:"tax_rate_id"`
+	Name          string    `db:"name"`
+	Country       string    `db:"country"`
+	Region        string    `db:"region"`
+	TaxClass      string    `db:"tax_class"`
+	Rate          float64   `db:"rate"`
+	Inclusive     bool      `db:"inclusive"`
+	TaxableAmount string    `db:"taxable_amount"`
+	Amount        string    `db:"amount"`
+	Currency      string    `db:"currency"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+func (l *OrderTaxLine) ToDomain() (*domain.OrderTaxLine, error) {
+	var money moneyParser
+	line := &domain.OrderTaxLine{
+		ID:            l.ID,
+		OrderID:       l.OrderID,
+		TaxRateID:     l.TaxRateID,
+		Name:          l.Name,
+		Country:       l.Country,
+		Region:        l.Region,
+		TaxClass:      l.TaxClass,
+		Rate:          l.Rate,
+		Inclusive:     l.Inclusive,
+		TaxableAmount: money.parse(l.TaxableAmount, l.Currency),
+		Amount:        money.parse(l.Amount, l.Currency),
+		CreatedAt:     l.CreatedAt,
+	}
+	if money.err != nil {
+		return nil, money.err
+	}
+	return line, nil
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"encoding/json"
+	"marketplace/internal/models/domain"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type ProductOption struct {
+	ID        int64          `db:"id"`
+	ProductID int64          `db:"product_id"`
+	Name      string         `db:"name"`
+	Position  int            `db:"position"`
+	Values    pq.StringArray `db:"values"`
+}
+
+func (o *ProductOption) ToDomain() domain.ProductOption {
+	return domain.ProductOption{
+		ID:        o.ID,
+		ProductID: o.ProductID,
+		Name:      o.Name,
+		Position:  o.Position,
+		Values:    []string(o.Values),
+	}
+}
+
+// ProductVariant - вариант товара; валюта цены берется из товара
+type ProductVariant struct {
+	ID        int64      `db:"id"`
+	ProductID int64      `db:"product_id"`
+	SKU       *string    `db:"sku"`
+	Options   []byte     `db:"options"`
+	Price     string     `db:"price"`
+	Currency  string     `db:"currency"`
+	Quantity  int        `db:"quantity"`
+	Active    bool       `db:"active"`
+	Position  int        `db:"position"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at"`
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+func (v *ProductVariant) ToDomain() (*domain.ProductVariant, error) {
+	var money moneyParser
+	variant := &domain.ProductVariant{
+		ID:        v.ID,
+		ProductID: v.ProductID,
+		SKU:       v.SKU,
+		Options:   map[string]string{},
+		Price:     money.parse(v.Price, v.Currency),
+		Quantity:  v.Quantity,
+		Active:    v.Active,
+		Position:  v.Position,
+		CreatedAt: v.CreatedAt,
+		UpdatedAt: v.UpdatedAt,
+	}
+	if len(v.Options) > 0 {
+		_ = json.Unmarshal(v.Options, &variant.Options)
+	}
+	if money.err != nil {
+		return nil, money.err
+	}
+	return variant, nil
+}
+
+func (v *ProductVariant) FromDomain(d *domain.ProductVariant) {
+	v.ID = d.ID
+	v.ProductID = d.ProductID
+	v.SKU = d.SKU
+	v.Options, _ = json.Marshal(d.Options)
+	v.Price = d.Price.Decimal()
+	v.Currency = d.Price.Currency
+	v.Quantity = d.Quantity
+	v.Active = d.Active
+	v.Position = d.Position
+	v.CreatedAt = d.CreatedAt
+	v.UpdatedAt = d.UpdatedAt
+}
+
+type CategoryAttribute struct {
+	ID         int64          `db:"id"`
+	CategoryID int64          `db:"category_id"`
+	Key        string         `db:"key"`
+	Name       string         `db:"name"`
+	Type       string         `db:"type"`
+	Values     pq.StringArray `db:"values"`
+	Required   bool           `db:"required"`
+	Filterable bool           `db:"filterable"`
+	Position   int            `db:"position"`
+}
+
+func (a *CategoryAttribute) ToDomain() domain.CategoryAttribute {
+	return domain.CategoryAttribute{
+		ID:         a.ID,
+		CategoryID: a.CategoryID,
+		Key:        a.Key,
+		Name:       a.Name,
+		Type:       a.Type,
+		Values:     []string(a.Values),
+		Required:   a.Required,
+		Filterable: a.Filterable,
+		Position:   a.Position,
+	}
+}
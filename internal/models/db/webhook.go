@@ -0,0 +1,115 @@
+package db
+
+import (
+	"marketplace/internal/models/domain"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type WebhookEndpoint struct {
+	ID                  int64          `db:"id"`
+	ShopID              int64          `db:"shop_id"`
+	URL                 string         `db:"url"`
+	Secret              string         `db:"secret"`
+	EventTypes          pq.StringArray `db:"event_types"`
+	Description         *string        `db:"description"`
+	Active              bool           `db:"active"`
+	ConsecutiveFailures int            `db:"consecutive_failures"`
+	DisabledAt          *time.Time     `db:"disabled_at"`
+	DisabledReason      *string        `db:"disabled_reason"`
+	CreatedAt           time.Time      `db:"created_at"`
+	UpdatedAt           time.Time      `db:"updated_at"`
+}
+
+func (w *WebhookEndpoint) ToDomain() *domain.WebhookEndpoint {
+	return &domain.WebhookEndpoint{
+		ID:                  w.ID,
+		ShopID:              w.ShopID,
+		URL:                 w.URL,
+		Secret:              w.Secret,
+		EventTypes:          []string(w.EventTypes),
+		Description:         derefString(w.Description),
+		Active:              w.Active,
+		ConsecutiveFailures: w.ConsecutiveFailures,
+		DisabledAt:          w.DisabledAt,
+		DisabledReason:      derefString(w.DisabledReason),
+		CreatedAt:           w.CreatedAt,
+		UpdatedAt:           w.UpdatedAt,
+	}
+}
+
+func (w *WebhookEndpoint) FromDomain(d *domain.WebhookEndpoint) {
+	w.ID = d.ID
+	w.ShopID = d.ShopID
+	w.URL = d.URL
+	w.Secret = d.Secret
+	w.EventTypes = pq.StringArray(d.EventTypes)
+	if d.Description != "" {
+		w.Description = &d.Description
+	}
+	w.Active = d.Active
+	w.ConsecutiveFailures = d.ConsecutiveFailures
+	w.DisabledAt = d.DisabledAt
+	if d.DisabledReason != "" {
+		w.DisabledReason = &d.DisabledReason
+	}
+	w.CreatedAt = d.CreatedAt
+	w.UpdatedAt = d.UpdatedAt
+}
+
+type WebhookDelivery struct {
+	ID                 int64      `db:"id"`
+	EndpointID         int64      `db:"endpoint_id"`
+	EventID            string     `db:"event_id"`
+	EventType          string     `db:"event_type"`
+	Payload            []byte     `db:"payload"`
+	Status             string     `db:"status"`
+	Attempts           int        `db:"attempts"`
+	LastResponseStatus *int       `db:"last_response_status"`
+	LastError          *string    `db:"last_error"`
+	CreatedAt          time.Time  `db:"created_at"`
+	UpdatedAt          time.Time  `db:"updated_at"`
+	DeliveredAt        *time.Time `db:"delivered_at"`
+}
+
+func (d *WebhookDelivery) ToDomain() *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		ID:                 d.ID,
+		EndpointID:         d.EndpointID,
+		EventID:            d.EventID,
+		EventType:          d.EventType,
+		Payload:            d.Payload,
+		Status:             d.Status,
+		Attempts:           d.Attempts,
+		LastResponseStatus: d.LastResponseStatus,
+		LastError:          derefString(d.LastError),
+		CreatedAt:          d.CreatedAt,
+		UpdatedAt:          d.UpdatedAt,
+		DeliveredAt:        d.DeliveredAt,
+	}
+}
+
+type WebhookDeliveryAttempt struct {
+	ID             int64     `db:"id"`
+	DeliveryID     int64     `db:"delivery_id"`
+	Attempt        int       `db:"attempt"`
+	ResponseStatus *int      `db:"response_status"`
+	ResponseBody   *string   `db:"response_body"`
+	Error          *string   `db:"error"`
+	DurationMs     int       `db:"duration_ms"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+func (a *WebhookDeliveryAttempt) ToDomain() *domain.WebhookDeliveryAttempt {
+	return &domain.WebhookDeliveryAttempt{
+		ID:             a.ID,
+		DeliveryID:     a.DeliveryID,
+		Attempt:        a.Attempt,
+		ResponseStatus: a.ResponseStatus,
+		ResponseBody:   derefString(a.ResponseBody),
+		Error:          derefString(a.Error),
+		DurationMs:     a.DurationMs,
+		CreatedAt:      a.CreatedAt,
+	}
+}
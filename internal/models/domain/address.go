@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+// Address represents an entry of the user's address book
+// @Description Address book entry
+type Address struct {
+	ID            int64      `json:"id" example:"1"`
+	UserID        int64      `json:"user_id" example:"1"`
+	Label         string     `json:"label,omitempty" example:"Home"`
+	RecipientName string     `json:"recipient_name" example:"John Doe"`
+	Phone         string     `json:"phone,omitempty" example:"+992900000000"`
+	Country       string     `json:"country" example:"TJ"`
+	Region        string     `json:"region,omitempty" example:"Sughd"`
+	City          string     `json:"city" example:"Dushanbe"`
+	PostalCode    string     `json:"postal_code,omitempty" example:"734000"`
+	Line1         string     `json:"line1" example:"Rudaki ave. 10"`
+	Line2         string     `json:"line2,omitempty" example:"apt. 5"`
+	IsDefault     bool       `json:"is_default" example:"true"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+}
+
+// AddressSnapshot is an immutable copy of an address stored with an order
+// @Description Shipping address snapshot
+type AddressSnapshot struct {
+	AddressID     int64  `json:"address_id,omitempty"`
+	RecipientName string `json:"recipient_name"`
+	Phone         string `json:"phone,omitempty"`
+	Country       string `json:"country"`
+	Region        string `json:"region,omitempty"`
+	City          string `json:"city"`
+	PostalCode    string `json:"postal_code,omitempty"`
+	Line1         string `json:"line1"`
+	Line2         string `json:"line2,omitempty"`
+}
+
+func (a *Address) Snapshot() *AddressSnapshot {
+	return &AddressSnapshot{
+		AddressID:     a.ID,
+		RecipientName: a.RecipientName,
+		Phone:         a.Phone,
+		Country:       a.Country,
+		Region:        a.Region,
+		City:          a.City,
+		PostalCode:    a.PostalCode,
+		Line1:         a.Line1,
+		Line2:         a.Line2,
+	}
+}
@@ -0,0 +1,73 @@
+package domain
+
+const (
+	ProductSortNewest    = "newest"
+	ProductSortPriceAsc  = "price_asc"
+	ProductSortPriceDesc = "price_desc"
+	ProductSortName      = "name"
+	ProductSortPopular   = "popular"
+)
+
+// ProductSorts - допустимые сортировки каталога
+var ProductSorts = []string{ProductSortNewest, ProductSortPriceAsc, ProductSortPriceDesc, ProductSortName, ProductSortPopular}
+
+// ProductFilter - фильтры и сортировка выборки товаров каталога
+type ProductFilter struct {
+	ShopID int64
+	// CategoryID - категория вместе со всеми подкатегориями
+	CategoryID int64
+	Currency   string
+	MinPrice   *Money
+	MaxPrice   *Money
+	// InStock: true - только в наличии, false - только отсутствующие, nil - все
+	InStock *bool
+	Active  *bool
+	// Attributes - значения характеристик: внутри характеристики любое из значений, между характеристиками - все
+	Attributes map[string][]string
+	Sort       string
+	// PriceBuckets - верхние границы интервалов фасета цен в одной валюте, по возрастанию
+	PriceBuckets []Money
+	PageRequest
+}
+
+// FacetValue represents a facet value
+// @Description Facet value with the number of matching products
+type FacetValue struct {
+	Value string `json:"value" example:"red"`
+	Label string `json:"label,omitempty" example:"Смартфоны"`
+	Count int    `json:"count" example:"12"`
+}
+
+// PriceBucket represents a price range facet
+// @Description Price range [from, to) with the number of matching products; open ends are omitted
+type PriceBucket struct {
+	From  *Money `json:"from,omitempty"`
+	To    *Money `json:"to,omitempty"`
+	Count int    `json:"count" example:"7"`
+}
+
+// AvailabilityFacet represents stock availability counts
+// @Description Number of matching products in and out of stock
+type AvailabilityFacet struct {
+	InStock    int `json:"in_stock" example:"30"`
+	OutOfStock int `json:"out_of_stock" example:"4"`
+}
+
+// ProductFacets represents facet counts of a product listing
+// @Description Facet counts; every facet ignores its own filter so that alternatives stay visible. categories counts products by subcategories of the selected category (by root categories without it), value is the category id
+type ProductFacets struct {
+	Price        []PriceBucket           `json:"price"`
+	Availability AvailabilityFacet       `json:"availability"`
+	Shops        []FacetValue            `json:"shops"`
+	Attributes   map[string][]FacetValue `json:"attributes"`
+	Categories   []FacetValue            `json:"categories"`
+}
+
+// ProductListResult represents a product listing page
+// @Description Page of products matching the filters with facet counts; total is present only when requested
+type ProductListResult struct {
+	Items      []*Product    `json:"items"`
+	NextCursor string        `json:"next_cursor,omitempty" example:"eyJzIjoibmV3ZXN0IiwiayI6IjIwMjUtMDEtMDEiLCJpZCI6NDJ9"`
+	Total      *int          `json:"total,omitempty" example:"34"`
+	Facets     ProductFacets `json:"facets"`
+}
@@ -0,0 +1,60 @@
+package domain
+
+import "time"
+
+// Category represents a node of the product category tree
+// @Description Product category; children are ordered by position
+type Category struct {
+	ID        int64       `json:"id" example:"2"`
+	ParentID  *int64      `json:"parent_id,omitempty" example:"1"`
+	Name      string      `json:"name" example:"Смартфоны"`
+	Slug      string      `json:"slug" example:"smartphones"`
+	Position  int         `json:"position" example:"0"`
+	Children  []*Category `json:"children,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// CreateCategoryInput represents input for creating a category
+// @Description Input for a category; without parent_id the category is a root, without position it is appended after its siblings. slug is generated from name when omitted
+type CreateCategoryInput struct {
+	Name     string `json:"name" binding:"required" example:"Смартфоны"`
+	Slug     string `json:"slug,omitempty" example:"smartphones"`
+	ParentID *int64 `json:"parent_id,omitempty" example:"1"`
+	Position *int   `json:"position,omitempty" example:"0"`
+}
+
+// UpdateCategoryInput represents input for renaming a category
+// @Description Input for renaming a category; empty fields are left unchanged
+type UpdateCategoryInput struct {
+	Name string `json:"name,omitempty" example:"Смартфоны и телефоны"`
+	Slug string `json:"slug,omitempty" example:"phones"`
+}
+
+// MoveCategoryInput represents input for moving a category
+// @Description New place of a category: parent (null - root) and position among the siblings
+type MoveCategoryInput struct {
+	ParentID *int64 `json:"parent_id" example:"1"`
+	Position int    `json:"position" example:"0"`
+}
+
+// CategoryRef represents a category in a breadcrumb path
+// @Description Category reference
+type CategoryRef struct {
+	ID   int64  `json:"id" example:"1"`
+	Name string `json:"name" example:"Электроника"`
+	Slug string `json:"slug" example:"electronics"`
+}
+
+// ProductCategory represents a category of a product with its breadcrumb path
+// @Description Category of a product; breadcrumbs go from the root to the category itself
+type ProductCategory struct {
+	CategoryRef
+	Breadcrumbs []CategoryRef `json:"breadcrumbs"`
+}
+
+// SetProductCategoriesInput represents input for assigning product categories
+// @Description Full list of product categories; replaces the current assignment
+type SetProductCategoriesInput struct {
+	CategoryIDs []int64 `json:"category_ids" example:"2,5"`
+}
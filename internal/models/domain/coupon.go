@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+const (
+	CouponTypePercentage   = "percentage"
+	CouponTypeFixedAmount  = "fixed_amount"
+	CouponTypeFreeShipping = "free_shipping"
+
+	CouponScopeMarketplace = "marketplace"
+	CouponScopeShop        = "shop"
+	CouponScopeProducts    = "products"
+)
+
+// Coupon represents a promotion code
+// @Description Coupon information
+type Coupon struct {
+	ID                int64       `json:"id" example:"1"`
+	Code              string      `json:"code" example:"SPRING10"`
+	Type              string      `json:"type" example:"percentage"`
+	Value             BasisPoints `json:"value" swaggertype:"number" example:"10"`
+	Amount            *Money      `json:"amount,omitempty"`
+	Currency          string      `json:"currency,omitempty" example:"USD"`
+	Scope             string      `json:"scope" example:"shop"`
+	ShopID            *int64      `json:"shop_id,omitempty" example:"1"`
+	ProductIDs        []int64     `json:"product_ids,omitempty"`
+	MinOrderValue     *Money      `json:"min_order_value,omitempty"`
+	StartsAt          *time.Time  `json:"starts_at,omitempty"`
+	EndsAt            *time.Time  `json:"ends_at,omitempty"`
+	UsageLimit        *int        `json:"usage_limit,omitempty" example:"1000"`
+	UsageLimitPerUser *int        `json:"usage_limit_per_user,omitempty" example:"1"`
+	TimesUsed         int         `json:"times_used" example:"0"`
+	Stackable         bool        `json:"stackable" example:"false"`
+	Active            bool        `json:"active" example:"true"`
+	CreatedBy         int64       `json:"created_by"`
+	CreatedAt         time.Time   `json:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+}
+
+// OrderDiscount represents a discount line applied to an order
+// @Description Order discount line
+type OrderDiscount struct {
+	ID        int64     `json:"id"`
+	OrderID   int64     `json:"order_id"`
+	CouponID  *int64    `json:"coupon_id,omitempty"`
+	Code      string    `json:"code"`
+	Type      string    `json:"type"`
+	Amount    Money     `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
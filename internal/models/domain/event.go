@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventOrderCreated       = "order.created"
+	EventOrderStatusChanged = "order.status_changed"
+	EventProductUpdated     = "product.updated"
+	EventStockLow           = "stock.low"
+	EventStockChanged       = "stock.changed"
+	EventUserRegistered     = "user.registered"
+	EventShopCreated        = "shop.created"
+
+	AggregateOrder   = "order"
+	AggregateProduct = "product"
+	AggregateUser    = "user"
+	AggregateShop    = "shop"
+
+	JobTypePurgeOutboxEvents = "outbox.purge"
+
+	// StreamNotifyChannel - канал LISTEN/NOTIFY, через который события потока обновлений
+	// доходят до всех экземпляров приложения
+	StreamNotifyChannel = "marketplace_stream"
+)
+
+// Event represents a domain event
+// @Description Domain event stored in the transactional outbox
+type Event struct {
+	ID            int64           `json:"-"`
+	EventID       string          `json:"event_id" example:"6f1c2a7e-4b1d-4c5e-9a3f-2d8e7b6a5c4d"`
+	Type          string          `json:"type" example:"order.created"`
+	AggregateType string          `json:"aggregate_type" example:"order"`
+	AggregateID   int64           `json:"aggregate_id" example:"1"`
+	Payload       json.RawMessage `json:"payload" swaggertype:"object"`
+	Attempts      int             `json:"-"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// OrderCreatedPayload - данные события order.created
+type OrderCreatedPayload struct {
+	OrderID   int64   `json:"order_id"`
+	UserID    int64   `json:"user_id"`
+	ShopIDs   []int64 `json:"shop_ids"`
+	Total     Money   `json:"total"`
+	Currency  string  `json:"currency"`
+	ItemCount int     `json:"item_count"`
+}
+
+// OrderStatusChangedPayload - данные события order.status_changed
+type OrderStatusChangedPayload struct {
+	OrderID    int64   `json:"order_id"`
+	UserID     int64   `json:"user_id"`
+	ShopIDs    []int64 `json:"shop_ids"`
+	FromStatus string  `json:"from_status"`
+	ToStatus   string  `json:"to_status"`
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// ProductUpdatedPayload - данные события product.updated
+type ProductUpdatedPayload struct {
+	ProductID int64  `json:"product_id"`
+	ShopID    int64  `json:"shop_id"`
+	Name      string `json:"name"`
+	Price     Money  `json:"price"`
+	Quantity  int    `json:"quantity"`
+	Active    bool   `json:"active"`
+}
+
+// StockLowPayload - данные события stock.low: остаток опустился до порога или ниже
+type StockLowPayload struct {
+	ProductID int64  `json:"product_id"`
+	ShopID    int64  `json:"shop_id"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	Threshold int    `json:"threshold"`
+}
+
+// StockChangedPayload - данные события stock.changed: новый остаток товара
+type StockChangedPayload struct {
+	ProductID int64 `json:"product_id"`
+	ShopID    int64 `json:"shop_id"`
+	Quantity  int   `json:"quantity"`
+}
+
+// UserRegisteredPayload - данные события user.registered
+type UserRegisteredPayload struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// ShopCreatedPayload - данные события shop.created
+type ShopCreatedPayload struct {
+	ShopID  int64  `json:"shop_id"`
+	OwnerID int64  `json:"owner_id"`
+	Name    string `json:"name"`
+	Slug    string `json:"slug"`
+}
+
+// StreamEvent - событие для клиентов потока обновлений (SSE, WebSocket). Поля получателей
+// используются только для маршрутизации и клиентам не отправляются
+type StreamEvent struct {
+	EventID    string          `json:"event_id"`
+	Type       string          `json:"type"`
+	Data       json.RawMessage `json:"data"`
+	UserIDs    []int64         `json:"user_ids,omitempty"`
+	ShopIDs    []int64         `json:"shop_ids,omitempty"`
+	ProductIDs []int64         `json:"product_ids,omitempty"`
+}
+
+// StreamMessage represents a message of the real-time stream
+// @Description Real-time stream message (SSE event or WebSocket text frame)
+type StreamMessage struct {
+	EventID string          `json:"event_id,omitempty" example:"6f1c2a7e-4b1d-4c5e-9a3f-2d8e7b6a5c4d"`
+	Type    string          `json:"type" example:"order.status_changed"`
+	Data    json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	ExchangeRateSourceManual = "manual"
+	// ExchangeRatePrecision - число знаков после запятой, с которым курс хранится и применяется
+	ExchangeRatePrecision = 10
+)
+
+var ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+
+// ExchangeRate represents a currency exchange rate
+// @Description 1 unit of base currency equals rate units of quote currency
+type ExchangeRate struct {
+	ID            int64     `json:"id" example:"1"`
+	BaseCurrency  string    `json:"base_currency" example:"USD"`
+	QuoteCurrency string    `json:"quote_currency" example:"EUR"`
+	Rate          string    `json:"rate" example:"0.92"`
+	Source        string    `json:"source" example:"manual"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// OrderExchangeRate represents a rate snapshot used to convert order lines
+// @Description Exchange rate applied to an order
+type OrderExchangeRate struct {
+	ID           int64     `json:"id"`
+	OrderID      int64     `json:"order_id"`
+	FromCurrency string    `json:"from_currency" example:"EUR"`
+	ToCurrency   string    `json:"to_currency" example:"USD"`
+	Rate         string    `json:"rate" example:"1.0869565217"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// UserPreferences represents buyer preferences
+// @Description Buyer preferences
+type UserPreferences struct {
+	DisplayCurrency string `json:"display_currency,omitempty" example:"EUR"`
+}
+
+// ParseRate разбирает положительный десятичный курс.
+func ParseRate(rate string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(rate))
+	if !ok || r.Sign() <= 0 || strings.ContainsAny(rate, "/eE") {
+		return nil, ErrInvalidExchangeRate
+	}
+	return r, nil
+}
+
+// FormatRate округляет курс до ExchangeRatePrecision знаков и убирает незначащие нули.
+func FormatRate(rate *big.Rat) string {
+	s := rate.FloatString(ExchangeRatePrecision)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
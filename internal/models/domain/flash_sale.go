@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+const (
+	FlashSaleStatusActive   = "active"
+	FlashSaleStatusFinished = "finished"
+)
+
+// FlashSale represents a flash sale of a high-demand product
+// @Description Flash sale: stock allocated from the product and split into slots
+type FlashSale struct {
+	ID                int64      `json:"id" example:"1"`
+	ProductID         int64      `json:"product_id" example:"1"`
+	AllocatedQuantity int        `json:"allocated_quantity" example:"500"`
+	SlotCount         int        `json:"slot_count" example:"16"`
+	PerUserLimit      int        `json:"per_user_limit" example:"2"`
+	Status            string     `json:"status" example:"active"`
+	StartsAt          time.Time  `json:"starts_at"`
+	EndsAt            time.Time  `json:"ends_at"`
+	ReturnedQuantity  int        `json:"returned_quantity" example:"0"`
+	CreatedBy         int64      `json:"created_by"`
+	CreatedAt         time.Time  `json:"created_at"`
+	FinishedAt        *time.Time `json:"finished_at,omitempty"`
+}
+
+// CreateFlashSaleInput represents input for starting a flash sale
+// @Description Input for flash sale
+type CreateFlashSaleInput struct {
+	Quantity     int       `json:"quantity" example:"500"`
+	SlotCount    int       `json:"slot_count" example:"16"`
+	PerUserLimit int       `json:"per_user_limit" example:"2"`
+	StartsAt     time.Time `json:"starts_at"`
+	EndsAt       time.Time `json:"ends_at"`
+}
+
+// FlashSaleReport represents stock reconciliation of a flash sale
+// @Description Flash sale stock reconciliation: allocated = sold + remaining + returned
+type FlashSaleReport struct {
+	FlashSale
+	SoldQuantity      int  `json:"sold_quantity" example:"120"`
+	RemainingQuantity int  `json:"remaining_quantity" example:"380"`
+	Buyers            int  `json:"buyers" example:"80"`
+	Consistent        bool `json:"consistent" example:"true"`
+}
+
+// Active сообщает, принимает ли распродажа покупки в момент now.
+func (f *FlashSale) Active(now time.Time) bool {
+	return f.Status == FlashSaleStatusActive && !now.Before(f.StartsAt) && now.Before(f.EndsAt)
+}
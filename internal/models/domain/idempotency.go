@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+const (
+	IdempotencyStatusProcessing = "processing"
+	IdempotencyStatusCompleted  = "completed"
+)
+
+// IdempotencyRecord - сохраненный результат запроса с заголовком Idempotency-Key
+type IdempotencyRecord struct {
+	ID             int64
+	UserID         int64
+	Key            string
+	Method         string
+	Path           string
+	Fingerprint    string
+	Status         string
+	ResponseStatus int
+	ResponseBody   []byte
+	ContentType    string
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+}
@@ -0,0 +1,81 @@
+package domain
+
+import "time"
+
+// Статусы обработки изображения
+const (
+	ImageStatusProcessing = "processing"
+	ImageStatusReady      = "ready"
+	ImageStatusFailed     = "failed"
+)
+
+// Задачи изображений
+const (
+	JobTypeGenerateThumbnails = "images.generate_thumbnails"
+	JobTypePurgeBlobs         = "images.purge_blobs"
+)
+
+// ProductImage represents an image of a product
+// @Description Product image; thumbnails are generated in background while status is processing
+type ProductImage struct {
+	ID          int64            `json:"id" example:"1"`
+	ProductID   int64            `json:"product_id" example:"1"`
+	VariantID   *int64           `json:"variant_id,omitempty" example:"3"`
+	Key         string           `json:"-"`
+	URL         string           `json:"url" example:"/media/products/1/3f2a.jpg"`
+	ContentType string           `json:"content_type" example:"image/jpeg"`
+	SizeBytes   int64            `json:"size_bytes" example:"204800"`
+	Width       int              `json:"width" example:"1600"`
+	Height      int              `json:"height" example:"1200"`
+	Thumbnails  []ImageThumbnail `json:"thumbnails"`
+	Status      string           `json:"status" example:"ready"`
+	Position    int              `json:"position" example:"0"`
+	Primary     bool             `json:"primary" example:"true"`
+	Alt         string           `json:"alt,omitempty" example:"Вид спереди"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// ImageThumbnail represents a thumbnail of a product image
+// @Description Thumbnail whose larger side is at most size pixels
+type ImageThumbnail struct {
+	Size   int    `json:"size" example:"480"`
+	Key    string `json:"-"`
+	URL    string `json:"url,omitempty" example:"/media/products/1/3f2a_480.jpg"`
+	Width  int    `json:"width" example:"480"`
+	Height int    `json:"height" example:"360"`
+}
+
+// UploadImageInput represents an uploaded image file with form fields
+type UploadImageInput struct {
+	Data      []byte
+	Alt       string
+	VariantID *int64
+	Primary   bool
+}
+
+// UpdateProductImageInput represents input for updating an image
+// @Description Omitted fields are left unchanged; variant_id 0 unlinks the image from a variant
+type UpdateProductImageInput struct {
+	Alt       *string `json:"alt,omitempty" example:"Вид сзади"`
+	VariantID *int64  `json:"variant_id,omitempty" example:"3"`
+	Primary   *bool   `json:"primary,omitempty" example:"true"`
+}
+
+// ReorderProductImagesInput represents a new order of product images
+// @Description All image ids of the product in the new order
+type ReorderProductImagesInput struct {
+	ImageIDs []int64 `json:"image_ids" binding:"required"`
+}
+
+// ImageJob - payload задачи генерации миниатюр
+type ImageJob struct {
+	ImageID int64 `json:"image_id"`
+}
+
+// BlobDeletion - файл в очереди на удаление из хранилища
+type BlobDeletion struct {
+	ID       int64
+	Key      string
+	Attempts int
+}
@@ -3,13 +3,16 @@ package domain
 import "time"
 
 type Invoice struct {
-	ID        int64     `json:"id"`
-	OrderID   int64     `json:"order_id"`
-	Amount    float64   `json:"amount"`
-	Currency  string    `json:"currency"`
-	Paid      bool      `json:"paid"`
-	Method    string    `json:"method,omitempty"`
-	PaidAt    string    `json:"paid_at,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             int64          `json:"id"`
+	OrderID        int64          `json:"order_id"`
+	Amount         Money          `json:"amount"`
+	ShippingAmount Money          `json:"shipping_amount"`
+	TaxAmount      Money          `json:"tax_amount"`
+	Taxes          []OrderTaxLine `json:"taxes,omitempty"`
+	Currency       string         `json:"currency"`
+	Paid           bool           `json:"paid"`
+	Method         string         `json:"method,omitempty"`
+	PaidAt         string         `json:"paid_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
 }
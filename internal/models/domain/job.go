@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusDead      = "dead"
+	JobStatusCancelled = "cancelled"
+
+	JobTypePurgeIdempotencyKeys = "idempotency_keys.purge"
+	JobTypeFinishFlashSales     = "flash_sales.finish_ended"
+)
+
+// Job represents a background job
+// @Description Background job from the durable queue
+type Job struct {
+	ID          int64           `json:"id" example:"1"`
+	Type        string          `json:"type" example:"idempotency_keys.purge"`
+	Payload     json.RawMessage `json:"payload" swaggertype:"object"`
+	Status      string          `json:"status" example:"pending"`
+	Attempts    int             `json:"attempts" example:"0"`
+	MaxAttempts int             `json:"max_attempts" example:"5"`
+	RunAt       time.Time       `json:"run_at"`
+	LockedAt    *time.Time      `json:"locked_at,omitempty"`
+	LockedBy    string          `json:"locked_by,omitempty"`
+	LastError   string          `json:"last_error,omitempty"`
+	ScheduleID  *int64          `json:"schedule_id,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+}
+
+// EnqueueJobInput represents input for enqueueing a job
+// @Description Input for enqueueing a job
+type EnqueueJobInput struct {
+	Type    string          `json:"type" example:"idempotency_keys.purge"`
+	Payload json.RawMessage `json:"payload,omitempty" swaggertype:"object"`
+	RunAt   *time.Time      `json:"run_at,omitempty"`
+}
+
+// JobFilter represents filters for the job list
+// @Description Job list filters
+type JobFilter struct {
+	Status string
+	Type   string
+	PageRequest
+}
+
+// JobSchedule represents a recurring job schedule
+// @Description Cron schedule that enqueues a job
+type JobSchedule struct {
+	ID        int64           `json:"id" example:"1"`
+	Name      string          `json:"name" example:"purge-idempotency-keys"`
+	JobType   string          `json:"job_type" example:"idempotency_keys.purge"`
+	Payload   json.RawMessage `json:"payload" swaggertype:"object"`
+	Cron      string          `json:"cron" example:"15 * * * *"`
+	Active    bool            `json:"active" example:"true"`
+	NextRunAt time.Time       `json:"next_run_at"`
+	LastRunAt *time.Time      `json:"last_run_at,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,350 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidMoney = errors.New("invalid money amount")
+	// ErrCurrencyMismatch - арифметика над суммами в разных валютах: суммы должны быть
+	// сконвертированы в одну валюту до вычислений.
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// Money - точная денежная сумма в minor units валюты ISO 4217 (центы для USD, иены для JPY).
+// Нулевое значение Money{} нейтрально при сложении и принимает валюту второго операнда.
+//
+// В JSON сумма передается объектом {"amount": "99.99", "currency": "USD"}; amount - десятичная
+// строка или число, разбираемое без float64. В БД хранится как NUMERIC.
+type Money struct {
+	Amount   int64  `swaggertype:"string" example:"99.99"`
+	Currency string `example:"USD"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney разбирает десятичную строку строго: дробных знаков не больше, чем у валюты
+// (лишние нули допускаются).
+func ParseMoney(decimal, currency string) (Money, error) {
+	amount, err := parseDecimal(decimal, CurrencyExponent(currency), false)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w for %s", err, currency)
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// MoneyFromDecimal разбирает значение NUMERIC из БД, округляя лишние знаки
+// по правилу half away from zero.
+func MoneyFromDecimal(decimal, currency string) (Money, error) {
+	amount, err := parseDecimal(decimal, CurrencyExponent(currency), true)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w for %s", err, currency)
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// parseDecimal переводит десятичную строку в целое число единиц 10^-exp. Лишние значащие
+// знаки округляются half away from zero при round, иначе это ошибка.
+func parseDecimal(decimal string, exp int, round bool) (int64, error) {
+	s := strings.TrimSpace(decimal)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" || !isDigits(intPart) || (fracPart != "" && !isDigits(fracPart)) || strings.HasSuffix(s, ".") {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, decimal)
+	}
+	var roundUp bool
+	if len(fracPart) > exp {
+		extra := fracPart[exp:]
+		fracPart = fracPart[:exp]
+		if strings.Trim(extra, "0") != "" {
+			if !round {
+				return 0, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidMoney, decimal, exp)
+			}
+			roundUp = extra[0] >= '5'
+		}
+	}
+	fracPart += strings.Repeat("0", exp-len(fracPart))
+	amount, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil || (roundUp && amount == math.MaxInt64) {
+		return 0, fmt.Errorf("%w: %q is out of range", ErrInvalidMoney, decimal)
+	}
+	if roundUp {
+		amount++
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Decimal форматирует сумму с числом знаков, соответствующим валюте: 1999 USD -> "19.99".
+func (m Money) Decimal() string {
+	return formatDecimal(m.Amount, CurrencyExponent(m.Currency))
+}
+
+func formatDecimal(amount int64, exp int) string {
+	digits := strconv.FormatInt(amount, 10)
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		digits = digits[1:]
+	}
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) SameCurrency(o Money) bool {
+	return m.Currency == o.Currency
+}
+
+// Add складывает суммы одной валюты.
+func (m Money) Add(o Money) (Money, error) {
+	currency, err := m.commonCurrency(o)
+	if err != nil {
+		return Money{}, err
+	}
+	sum := m.Amount + o.Amount
+	if (o.Amount > 0 && sum < m.Amount) || (o.Amount < 0 && sum > m.Amount) {
+		return Money{}, fmt.Errorf("%w: %s + %s is out of range", ErrInvalidMoney, m, o)
+	}
+	return Money{Amount: sum, Currency: currency}, nil
+}
+
+// Sub вычитает сумму той же валюты.
+func (m Money) Sub(o Money) (Money, error) {
+	currency, err := m.commonCurrency(o)
+	if err != nil {
+		return Money{}, err
+	}
+	diff := m.Amount - o.Amount
+	if (o.Amount > 0 && diff > m.Amount) || (o.Amount < 0 && diff < m.Amount) {
+		return Money{}, fmt.Errorf("%w: %s - %s is out of range", ErrInvalidMoney, m, o)
+	}
+	return Money{Amount: diff, Currency: currency}, nil
+}
+
+// Sum складывает суммы одной валюты; сумма пустого списка - Money{}.
+func Sum(amounts ...Money) (Money, error) {
+	var total Money
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// Mul умножает сумму на целое количество - точно или с ошибкой, если произведение не помещается в int64.
+func (m Money) Mul(quantity int) (Money, error) {
+	q := int64(quantity)
+	product := m.Amount * q
+	if m.Amount != 0 && (product/m.Amount != q || (m.Amount == -1 && q == math.MinInt64)) {
+		return Money{}, fmt.Errorf("%w: %s * %d is out of range", ErrInvalidMoney, m, quantity)
+	}
+	return Money{Amount: product, Currency: m.Currency}, nil
+}
+
+// MulRat умножает сумму на num/den с округлением half away from zero.
+func (m Money) MulRat(num, den int64) (Money, error) {
+	if den == 0 {
+		return Money{}, fmt.Errorf("%w: division by zero", ErrInvalidMoney)
+	}
+	product := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(num))
+	amount, err := divRound(product, big.NewInt(den))
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: m.Currency}, nil
+}
+
+// Percent возвращает долю суммы в базисных пунктах (сотых долях процента): 1250 - 12.5%.
+func (m Money) Percent(basisPoints int64) (Money, error) {
+	return m.MulRat(basisPoints, 100*BasisPointsPerPercent)
+}
+
+func divRound(n, d *big.Int) (int64, error) {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	// |2r| >= |d| -> округляем от нуля
+	if new(big.Int).Abs(new(big.Int).Mul(r, big.NewInt(2))).Cmp(new(big.Int).Abs(d)) >= 0 {
+		if (n.Sign() < 0) != (d.Sign() < 0) {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	if !q.IsInt64() {
+		return 0, fmt.Errorf("%w: %s is out of range", ErrInvalidMoney, q)
+	}
+	return q.Int64(), nil
+}
+
+// Cmp сравнивает суммы одной валюты: -1, 0 или 1.
+func (m Money) Cmp(o Money) (int, error) {
+	if _, err := m.commonCurrency(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m Money) Min(o Money) (Money, error) {
+	cmp, err := m.Cmp(o)
+	if err != nil {
+		return Money{}, err
+	}
+	if cmp <= 0 {
+		return m.withCurrency(o.Currency), nil
+	}
+	return o.withCurrency(m.Currency), nil
+}
+
+func (m Money) withCurrency(fallback string) Money {
+	if m.Currency == "" {
+		m.Currency = fallback
+	}
+	return m
+}
+
+// commonCurrency возвращает общую валюту операндов; нулевая сумма без валюты совместима с любой.
+func (m Money) commonCurrency(o Money) (string, error) {
+	switch {
+	case m.Currency == o.Currency:
+		return m.Currency, nil
+	case m.Currency == "" && m.Amount == 0:
+		return o.Currency, nil
+	case o.Currency == "" && o.Amount == 0:
+		return m.Currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+}
+
+// Value передает сумму в БД как десятичную строку для колонок NUMERIC.
+func (m Money) Value() (driver.Value, error) {
+	return m.Decimal(), nil
+}
+
+type moneyJSON struct {
+	Amount   json.Number `json:"amount"`
+	Currency string      `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw moneyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("%w: expected {\"amount\": \"0.00\", \"currency\": \"USD\"}", ErrInvalidMoney)
+	}
+	currency := strings.ToUpper(strings.TrimSpace(raw.Currency))
+	if !IsValidCurrency(currency) {
+		return fmt.Errorf("%w: unknown currency %q", ErrInvalidMoney, raw.Currency)
+	}
+	parsed, err := ParseMoney(raw.Amount.String(), currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Convert переводит сумму в валюту currency по курсу rate (1 единица m.Currency = rate единиц currency)
+// с учетом экспонент обеих валют и округлением half away from zero.
+func (m Money) Convert(rate *big.Rat, currency string) (Money, error) {
+	num := new(big.Int).Mul(big.NewInt(m.Amount), rate.Num())
+	num.Mul(num, pow10(CurrencyExponent(currency)))
+	den := new(big.Int).Mul(rate.Denom(), pow10(CurrencyExponent(m.Currency)))
+	amount, err := divRound(num, den)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
+
+// BasisPointsPerPercent - базисных пунктов в одном проценте.
+const BasisPointsPerPercent = 100
+
+// BasisPoints - доля в базисных пунктах (сотых долях процента): 1250 - 12.5%. В JSON передается
+// числом процентов (12.5) и разбирается без float64, в БД хранится как NUMERIC процентов.
+type BasisPoints int64
+
+// ParseBasisPoints разбирает проценты с точностью до сотых: "12.5" -> 1250.
+func ParseBasisPoints(decimal string) (BasisPoints, error) {
+	points, err := parseDecimal(decimal, 2, false)
+	if err != nil {
+		return 0, fmt.Errorf("%w (percent)", err)
+	}
+	return BasisPoints(points), nil
+}
+
+// Decimal форматирует долю в процентах: 1250 -> "12.50".
+func (p BasisPoints) Decimal() string {
+	return formatDecimal(int64(p), 2)
+}
+
+func (p BasisPoints) MarshalJSON() ([]byte, error) {
+	return []byte(p.Decimal()), nil
+}
+
+func (p *BasisPoints) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("%w: expected percent number", ErrInvalidMoney)
+	}
+	parsed, err := ParseBasisPoints(number.String())
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
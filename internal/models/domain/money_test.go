@@ -0,0 +1,227 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// quickLine - позиция заказа для property-тестов; значения ограничены, чтобы сумма
+// не выходила за int64.
+type quickLine struct {
+	UnitPrice uint32
+	Quantity  uint16
+}
+
+func TestOrderTotalEqualsSumOfLines(t *testing.T) {
+	property := func(lines []quickLine) bool {
+		var expected int64
+		items := make([]Money, len(lines))
+		for i, line := range lines {
+			var err error
+			if items[i], err = NewMoney(int64(line.UnitPrice), "USD").Mul(int(line.Quantity)); err != nil {
+				return false
+			}
+			expected += int64(line.UnitPrice) * int64(line.Quantity)
+		}
+		total, err := Sum(items...)
+		if err != nil {
+			return false
+		}
+		if len(lines) == 0 {
+			return total.IsZero()
+		}
+		return total.Amount == expected && total.Currency == "USD"
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// Mul либо точен (совпадает с умножением в big.Int), либо возвращает ошибку - без переполнения.
+func TestMulOverflow(t *testing.T) {
+	property := func(amount int64, quantity int64) bool {
+		product, err := NewMoney(amount, "USD").Mul(int(quantity))
+		exact := new(big.Int).Mul(big.NewInt(amount), big.NewInt(quantity))
+		if !exact.IsInt64() {
+			return errors.Is(err, ErrInvalidMoney)
+		}
+		return err == nil && product.Amount == exact.Int64() && product.Currency == "USD"
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+	for _, tt := range []struct {
+		amount   int64
+		quantity int
+		ok       bool
+	}{
+		{amount: math.MaxInt64, quantity: 1, ok: true},
+		{amount: math.MaxInt64, quantity: 2},
+		{amount: math.MaxInt64, quantity: -1, ok: true},
+		{amount: math.MinInt64, quantity: -1},
+		{amount: -1, quantity: math.MinInt64},
+		{amount: 1 << 32, quantity: 1 << 30, ok: true},
+		{amount: 1 << 32, quantity: 1 << 31},
+		{amount: 0, quantity: math.MaxInt64, ok: true},
+	} {
+		if _, err := NewMoney(tt.amount, "USD").Mul(tt.quantity); (err == nil) != tt.ok {
+			t.Errorf("%d * %d: error = %v, want ok = %v", tt.amount, tt.quantity, err, tt.ok)
+		}
+	}
+}
+
+func TestDiscountAndRemainderAddUpToTotal(t *testing.T) {
+	property := func(amount uint32, basisPoints uint16) bool {
+		total := NewMoney(int64(amount), "EUR")
+		discount, err := total.Percent(int64(basisPoints % 10001))
+		if err != nil {
+			return false
+		}
+		rest, err := total.Sub(discount)
+		if err != nil {
+			return false
+		}
+		sum, err := discount.Add(rest)
+		return err == nil && sum == total && !rest.IsNegative()
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDecimalRoundTrip(t *testing.T) {
+	for _, currency := range []string{"JPY", "USD", "KWD"} {
+		property := func(amount int64) bool {
+			m := NewMoney(amount, currency)
+			parsed, err := ParseMoney(m.Decimal(), currency)
+			return err == nil && parsed == m
+		}
+		if err := quick.Check(property, nil); err != nil {
+			t.Errorf("%s: %v", currency, err)
+		}
+	}
+}
+
+// moneyColumnScale - число знаков после запятой денежных колонок NUMERIC(19, 4)
+const moneyColumnScale = 4
+
+// TestMoneyColumnRoundTrip проверяет, что сумма в любой поддерживаемой валюте переживает запись
+// в NUMERIC(19, 4) и чтение обратно: Postgres отдает значение с четырьмя знаками после запятой.
+func TestMoneyColumnRoundTrip(t *testing.T) {
+	for currency, exp := range currencyExponents {
+		if exp > moneyColumnScale {
+			t.Fatalf("%s has %d minor digits, money columns keep %d", currency, exp, moneyColumnScale)
+		}
+		property := func(amount int64) bool {
+			m := NewMoney(amount/10000, currency) // NUMERIC(19, 4) вмещает 15 целых цифр
+			stored := m.Decimal()
+			if exp == 0 {
+				stored += "."
+			}
+			stored += strings.Repeat("0", moneyColumnScale-exp)
+			read, err := MoneyFromDecimal(stored, currency)
+			return err == nil && read == m
+		}
+		if err := quick.Check(property, nil); err != nil {
+			t.Errorf("%s: %v", currency, err)
+		}
+	}
+}
+
+func TestMoneyFromDecimal(t *testing.T) {
+	tests := []struct {
+		decimal  string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{decimal: "19.99", currency: "USD", want: 1999},
+		{decimal: "1.005", currency: "USD", want: 101},
+		{decimal: "-1.005", currency: "USD", want: -101},
+		{decimal: "1.004", currency: "USD", want: 100},
+		{decimal: "100.50", currency: "JPY", want: 101},
+		{decimal: "NaN", currency: "USD", wantErr: true},
+		{decimal: "", currency: "USD", wantErr: true},
+		{decimal: "99999999999999999999", currency: "USD", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := MoneyFromDecimal(tt.decimal, tt.currency)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidMoney) {
+				t.Errorf("MoneyFromDecimal(%q) error = %v, want ErrInvalidMoney", tt.decimal, err)
+			}
+			continue
+		}
+		if err != nil || got.Amount != tt.want || got.Currency != tt.currency {
+			t.Errorf("MoneyFromDecimal(%q, %s) = %v, %v; want %d", tt.decimal, tt.currency, got, err, tt.want)
+		}
+	}
+}
+
+func TestMoneyErrors(t *testing.T) {
+	usd, eur := NewMoney(100, "USD"), NewMoney(100, "EUR")
+	if _, err := usd.Add(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add error = %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := usd.Sub(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub error = %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := usd.Cmp(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp error = %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := usd.MulRat(1, 0); !errors.Is(err, ErrInvalidMoney) {
+		t.Errorf("MulRat error = %v, want ErrInvalidMoney", err)
+	}
+	if _, err := NewMoney(math.MaxInt64, "USD").Add(NewMoney(1, "USD")); !errors.Is(err, ErrInvalidMoney) {
+		t.Errorf("Add overflow error = %v, want ErrInvalidMoney", err)
+	}
+	if _, err := NewMoney(math.MaxInt64, "USD").MulRat(3, 2); !errors.Is(err, ErrInvalidMoney) {
+		t.Errorf("MulRat overflow error = %v, want ErrInvalidMoney", err)
+	}
+	// Нулевая сумма без валюты нейтральна
+	if sum, err := (Money{}).Add(usd); err != nil || sum != usd {
+		t.Errorf("Money{}.Add = %v, %v; want %v", sum, err, usd)
+	}
+}
+
+func TestPercentRounding(t *testing.T) {
+	tests := []struct {
+		amount      int64
+		basisPoints int64
+		want        int64
+	}{
+		{amount: 1000, basisPoints: 1250, want: 125},
+		{amount: 999, basisPoints: 1000, want: 100},
+		{amount: 5, basisPoints: 1000, want: 1},
+		{amount: -5, basisPoints: 1000, want: -1},
+		{amount: 4, basisPoints: 1000, want: 0},
+		{amount: 1999, basisPoints: 10000, want: 1999},
+	}
+	for _, tt := range tests {
+		got, err := NewMoney(tt.amount, "USD").Percent(tt.basisPoints)
+		if err != nil || got.Amount != tt.want {
+			t.Errorf("Percent(%d, %d) = %v, %v; want %d", tt.amount, tt.basisPoints, got, err, tt.want)
+		}
+	}
+}
+
+func TestBasisPointsJSON(t *testing.T) {
+	var coupon struct {
+		Value BasisPoints `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(`{"value": 12.5}`), &coupon); err != nil || coupon.Value != 1250 {
+		t.Fatalf("unmarshal 12.5 = %d, %v; want 1250", coupon.Value, err)
+	}
+	data, err := json.Marshal(coupon)
+	if err != nil || string(data) != `{"value":12.50}` {
+		t.Errorf("marshal = %s, %v", data, err)
+	}
+	if err := json.Unmarshal([]byte(`{"value": 12.555}`), &coupon); !errors.Is(err, ErrInvalidMoney) {
+		t.Errorf("unmarshal 12.555 error = %v, want ErrInvalidMoney", err)
+	}
+}
@@ -0,0 +1,116 @@
+package domain
+
+import "time"
+
+const (
+	NotificationChannelEmail = "email"
+	NotificationChannelSMS   = "sms"
+	NotificationChannelInApp = "in_app"
+
+	NotificationStatusPending  = "pending"
+	NotificationStatusDigest   = "digest"
+	NotificationStatusDigested = "digested"
+	NotificationStatusSent     = "sent"
+	NotificationStatusFailed   = "failed"
+
+	// EventNotificationDigest - тип шаблона сводки уведомлений
+	EventNotificationDigest = "notification.digest"
+
+	JobTypeSendNotification        = "notifications.send"
+	JobTypeSendNotificationDigests = "notifications.send_digests"
+)
+
+// NotificationChannels - каналы доставки уведомлений
+var NotificationChannels = []string{NotificationChannelEmail, NotificationChannelSMS, NotificationChannelInApp}
+
+// NotificationEventTypes - события, о которых уведомляются пользователи
+var NotificationEventTypes = []string{EventOrderCreated, EventOrderStatusChanged, EventStockLow, EventUserRegistered}
+
+// Notification represents a notification
+// @Description Notification; in_app notifications form the user's inbox
+type Notification struct {
+	ID        int64      `json:"id" example:"1"`
+	UserID    int64      `json:"user_id" example:"1"`
+	EventID   string     `json:"event_id,omitempty" example:"6f1c2a7e-4b1d-4c5e-9a3f-2d8e7b6a5c4d"`
+	EventType string     `json:"event_type" example:"order.status_changed"`
+	Channel   string     `json:"channel" example:"in_app"`
+	Subject   string     `json:"subject" example:"Order #1: shipped"`
+	Body      string     `json:"body" example:"Order #1 status changed: processing → shipped."`
+	Status    string     `json:"status" example:"sent"`
+	LastError string     `json:"last_error,omitempty"`
+	DigestID  *int64     `json:"digest_id,omitempty"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NotificationTemplate represents a notification template
+// @Description Notification template for an event type, channel and locale (Go text/template over the event payload)
+type NotificationTemplate struct {
+	ID        int64     `json:"id" example:"1"`
+	EventType string    `json:"event_type" example:"order.status_changed"`
+	Channel   string    `json:"channel" example:"email"`
+	Locale    string    `json:"locale" example:"ru"`
+	Subject   string    `json:"subject" example:"Заказ #{{.order_id}}: {{.to_status}}"`
+	Body      string    `json:"body" example:"Статус заказа #{{.order_id}} изменился: {{.from_status}} → {{.to_status}}."`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NotificationPreferences represents notification settings of a user
+// @Description Notification channels and locale of a user
+type NotificationPreferences struct {
+	Locale string `json:"locale" example:"ru"`
+	Email  bool   `json:"email" example:"true"`
+	SMS    bool   `json:"sms" example:"false"`
+	InApp  bool   `json:"in_app" example:"true"`
+}
+
+// Enabled сообщает, включен ли у пользователя канал channel.
+func (p *NotificationPreferences) Enabled(channel string) bool {
+	switch channel {
+	case NotificationChannelEmail:
+		return p.Email
+	case NotificationChannelSMS:
+		return p.SMS
+	case NotificationChannelInApp:
+		return p.InApp
+	}
+	return false
+}
+
+// NotificationDigestGroup - пользователь и канал, у которых есть уведомления для сводки
+type NotificationDigestGroup struct {
+	UserID  int64
+	Channel string
+}
+
+// NotificationMessage - сообщение, передаваемое отправителю канала
+type NotificationMessage struct {
+	NotificationID int64  `json:"notification_id"`
+	Channel        string `json:"channel"`
+	To             string `json:"to"`
+	Subject        string `json:"subject,omitempty"`
+	Body           string `json:"body"`
+}
+
+// NotificationJob - payload задачи отправки уведомления
+type NotificationJob struct {
+	NotificationID int64 `json:"notification_id"`
+}
+
+// NotificationFilter - параметры выборки входящих уведомлений пользователя
+type NotificationFilter struct {
+	UserID     int64
+	UnreadOnly bool
+	PageRequest
+}
+
+// NotificationInbox represents the in-app inbox of a user
+// @Description In-app notifications of the user, newest first, with the unread counter
+type NotificationInbox struct {
+	Unread     int            `json:"unread" example:"3"`
+	Items      []Notification `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty" example:"eyJpZCI6NDJ9"`
+	Total      *int           `json:"total,omitempty" example:"120"`
+}
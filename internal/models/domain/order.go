@@ -2,32 +2,83 @@ package domain
 
 import "time"
 
+const (
+	OrderStatusPending    = "pending"
+	OrderStatusConfirmed  = "confirmed"
+	OrderStatusProcessing = "processing"
+	OrderStatusShipped    = "shipped"
+	OrderStatusDelivered  = "delivered"
+	OrderStatusCancelled  = "cancelled"
+
+	// OrderCancelReasonExpired - системная причина отмены неоплаченного заказа по истечении срока
+	OrderCancelReasonExpired = "expired: payment not received in time"
+	// OrderConfirmReasonPayment - причина подтверждения заказа, к ней добавляется номер платежа
+	OrderConfirmReasonPayment = "payment confirmed"
+)
+
 // Order represents an order
 // @Description Order information
 type Order struct {
-	ID        int64       `json:"id"`
-	UserID    int64       `json:"user_id"`
-	ShopID    *int64      `json:"shop_id,omitempty"`
-	Total     float64     `json:"total"`
-	Currency  string      `json:"currency"`
-	Status    string      `json:"status"`
-	Note      string      `json:"note,omitempty"`
-	Items     []OrderItem `json:"items,omitempty"`
-	CreatedAt time.Time   `json:"created_at"`
-	UpdatedAt time.Time   `json:"updated_at"`
-	DeletedAt *time.Time  `json:"deleted_at,omitempty"`
+	ID              int64               `json:"id"`
+	UserID          int64               `json:"user_id"`
+	ShopID          *int64              `json:"shop_id,omitempty"`
+	Subtotal        Money               `json:"subtotal"`
+	ShippingTotal   Money               `json:"shipping_total"`
+	DiscountTotal   Money               `json:"discount_total"`
+	TaxTotal        Money               `json:"tax_total"`
+	Total           Money               `json:"total"`
+	Currency        string              `json:"currency"`
+	Status          string              `json:"status"`
+	CancelReason    string              `json:"cancel_reason,omitempty"`
+	CancelledAt     *time.Time          `json:"cancelled_at,omitempty"`
+	Note            string              `json:"note,omitempty"`
+	ShippingAddress *AddressSnapshot    `json:"shipping_address,omitempty"`
+	Items           []OrderItem         `json:"items,omitempty"`
+	ShippingLines   []OrderShippingLine `json:"shipping_lines,omitempty"`
+	Discounts       []OrderDiscount     `json:"discounts,omitempty"`
+	ExchangeRates   []OrderExchangeRate `json:"exchange_rates,omitempty"`
+	Taxes           []OrderTaxLine      `json:"taxes,omitempty"`
+	Shipments       []Shipment          `json:"shipments,omitempty"`
+	Version         int64               `json:"version"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+	DeletedAt       *time.Time          `json:"deleted_at,omitempty"`
 }
 
 // CreateOrderInput represents input for creating an order
 // @Description Input for creating an order
 type CreateOrderInput struct {
-	Note  string                 `json:"note"`
-	Items []CreateOrderItemInput `json:"items"`
+	Note              string                 `json:"note"`
+	Currency          string                 `json:"currency,omitempty" example:"USD"`
+	AddressID         *int64                 `json:"address_id,omitempty" example:"1"`
+	ShippingMethodIDs []int64                `json:"shipping_method_ids,omitempty" example:"1"`
+	CouponCodes       []string               `json:"coupon_codes,omitempty" example:"SPRING10"`
+	Items             []CreateOrderItemInput `json:"items"`
 }
 
 // CreateOrderItemInput represents input for order item
 // @Description Input for order item
 type CreateOrderItemInput struct {
 	ProductID int64 `json:"product_id"`
-	Quantity  int   `json:"quantity"`
+	// VariantID обязателен для товаров с вариантами
+	VariantID *int64 `json:"variant_id,omitempty" example:"3"`
+	Quantity  int    `json:"quantity"`
+}
+
+// ConfirmOrderInput represents input for confirming payment of an order
+// @Description Payment confirmation: moves a pending order to confirmed
+type ConfirmOrderInput struct {
+	PaymentReference string `json:"payment_reference" example:"pi_3PqK2xLkdIwHu7ix0Yz1"`
+}
+
+// OrderStatusChange represents a record of order status history
+// @Description Order status change
+type OrderStatusChange struct {
+	ID         int64     `json:"id"`
+	OrderID    int64     `json:"order_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason,omitempty"`
+	ChangedBy  *int64    `json:"changed_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
 }
@@ -5,14 +5,20 @@ import "time"
 // OrderItem represents an item in an order
 // @Description Order item information
 type OrderItem struct {
-	ID         int64     `json:"id"`
-	ProductID  int64     `json:"product_id"`
-	OrderID    int64     `json:"order_id"`
-	Name       string    `json:"name,omitempty"`
-	SKU        string    `json:"sku,omitempty"`
-	UnitPrice  float64   `json:"unit_price"`
-	Quantity   int       `json:"quantity"`
-	TotalPrice float64   `json:"total_price"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID                int64     `json:"id"`
+	ProductID         int64     `json:"product_id"`
+	VariantID         *int64    `json:"variant_id,omitempty"`
+	OrderID           int64     `json:"order_id"`
+	Name              string    `json:"name,omitempty"`
+	SKU               string    `json:"sku,omitempty"`
+	UnitPrice         Money     `json:"unit_price"`
+	OriginalUnitPrice *Money    `json:"original_unit_price,omitempty"`
+	Quantity          int       `json:"quantity"`
+	TotalPrice        Money     `json:"total_price"`
+	TaxAmount         Money     `json:"tax_amount"`
+	TaxRate           float64   `json:"tax_rate"`
+	TaxInclusive      bool      `json:"tax_inclusive"`
+	FlashSaleID       *int64    `json:"flash_sale_id,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
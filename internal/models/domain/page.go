@@ -0,0 +1,16 @@
+package domain
+
+// PageRequest - параметры страницы курсорной пагинации
+type PageRequest struct {
+	Cursor    string // непрозрачный курсор из next_cursor предыдущей страницы; пустой - первая страница
+	Limit     int
+	WithTotal bool // посчитать общее число строк по фильтру (отдельный COUNT)
+}
+
+// Page represents one page of a cursor-paginated listing
+// @Description Page of a listing; pass next_cursor as the cursor parameter to get the next page. next_cursor is absent on the last page
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJpZCI6NDJ9"`
+	Total      *int   `json:"total,omitempty" example:"120"`
+}
@@ -2,20 +2,44 @@ package domain
 
 import "time"
 
+// ProductPatchFields - поля товара, которые можно менять через PATCH. id, shop_id, slug и
+// служебные поля только для чтения; slug пересчитывается из name.
+var ProductPatchFields = []string{
+	"sku", "name", "description", "price", "currency", "quantity", "tax_class", "active",
+	"weight_grams", "length_mm", "width_mm", "height_mm", "attributes",
+}
+
 // Product represents a product
 // @Description Product information
 type Product struct {
-	ID          int64      `json:"id" example:"1"`
-	SKU         *string    `json:"sku,omitempty" example:"SKU123"`
-	Name        string     `json:"name" example:"Product Name"`
-	Slug        string     `json:"slug" example:"product-name"`
-	Description *string    `json:"description,omitempty" example:"Product description"`
-	Price       float64    `json:"price" example:"99.99"`
-	Currency    string     `json:"currency" example:"USD"`
-	Quantity    int        `json:"quantity" example:"10"`
-	ShopID      int64      `json:"shop_id" example:"1"`
-	Active      bool       `json:"active" example:"true"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	ID           int64   `json:"id" example:"1"`
+	SKU          *string `json:"sku,omitempty" example:"SKU123"`
+	Name         string  `json:"name" example:"Product Name"`
+	Slug         string  `json:"slug" example:"product-name"`
+	Description  *string `json:"description,omitempty" example:"Product description"`
+	Price        Money   `json:"price"`
+	DisplayPrice *Money  `json:"display_price,omitempty"`
+	Currency     string  `json:"currency" example:"USD"`
+	Quantity     int     `json:"quantity" example:"10"`
+	TaxClass     string  `json:"tax_class" example:"standard"`
+	ShopID       int64   `json:"shop_id" example:"1"`
+	Active       bool    `json:"active" example:"true"`
+	WeightGrams  int     `json:"weight_grams" example:"500"`
+	LengthMM     int     `json:"length_mm" example:"300"`
+	WidthMM      int     `json:"width_mm" example:"200"`
+	HeightMM     int     `json:"height_mm" example:"100"`
+	// Attributes - характеристики товара для фильтров каталога
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// Categories - категории товара с хлебными крошками; заполняются только в ответах API
+	Categories []ProductCategory `json:"categories,omitempty"`
+	// Options и Variants - опции и варианты товара; заполняются в карточке товара
+	Options  []ProductOption  `json:"options,omitempty"`
+	Variants []ProductVariant `json:"variants,omitempty"`
+	// Images - изображения товара в порядке показа; заполняются в карточке товара
+	Images []ProductImage `json:"images,omitempty"`
+	// Version - версия товара для оптимистичной блокировки, отдается в ETag
+	Version   int64      `json:"version" example:"1"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
@@ -0,0 +1,84 @@
+package domain
+
+import "time"
+
+// Форматы файлов импорта и экспорта товаров
+const (
+	ImportFormatCSV   = "csv"
+	ImportFormatJSONL = "jsonl"
+)
+
+var ImportFormats = []string{ImportFormatCSV, ImportFormatJSONL}
+
+// Статусы импорта товаров
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// Задачи импорта товаров
+const (
+	JobTypeImportProducts      = "products.import"
+	JobTypePurgeProductImports = "products.purge_imports"
+)
+
+// ProductImportColumns - колонки CSV и ключи JSONL импорта и экспорта в порядке экспорта.
+// attributes в CSV - JSON-объект строкой.
+var ProductImportColumns = []string{
+	"sku", "name", "description", "price", "currency", "quantity", "tax_class", "active",
+	"weight_grams", "length_mm", "width_mm", "height_mm", "attributes",
+}
+
+// ProductImport represents a bulk product import
+// @Description Asynchronous product import; counters are updated while the import is running
+type ProductImport struct {
+	ID         int64      `json:"id" example:"1"`
+	ShopID     int64      `json:"shop_id" example:"1"`
+	UserID     int64      `json:"user_id" example:"1"`
+	Format     string     `json:"format" example:"csv"`
+	DryRun     bool       `json:"dry_run" example:"false"`
+	Status     string     `json:"status" example:"completed"`
+	InputKey   string     `json:"-"`
+	ErrorsKey  string     `json:"-"`
+	TotalRows  int        `json:"total_rows" example:"1200"`
+	Created    int        `json:"created" example:"1000"`
+	Updated    int        `json:"updated" example:"195"`
+	Failed     int        `json:"failed" example:"5"`
+	ErrorsURL  string     `json:"errors_url,omitempty" example:"/api/v1/imports/1/errors"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// CreateProductImportInput represents an uploaded import file
+type CreateProductImportInput struct {
+	Data     []byte
+	Filename string
+	Format   string
+	DryRun   bool
+}
+
+// ProductImportJob - payload задачи импорта
+type ProductImportJob struct {
+	ImportID int64 `json:"import_id"`
+}
+
+// ProductExportRow - строка экспорта в JSONL; совпадает с форматом импорта
+type ProductExportRow struct {
+	SKU         string            `json:"sku"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Price       string            `json:"price"`
+	Currency    string            `json:"currency"`
+	Quantity    int               `json:"quantity"`
+	TaxClass    string            `json:"tax_class"`
+	Active      bool              `json:"active"`
+	WeightGrams int               `json:"weight_grams"`
+	LengthMM    int               `json:"length_mm"`
+	WidthMM     int               `json:"width_mm"`
+	HeightMM    int               `json:"height_mm"`
+	Attributes  map[string]string `json:"attributes"`
+}
@@ -0,0 +1,26 @@
+package domain
+
+// ProductSearchFilter - параметры полнотекстового поиска товаров
+type ProductSearchFilter struct {
+	ProductFilter
+	Query string
+	// Language - конфигурация текстового поиска для подсветки (russian, english)
+	Language string
+}
+
+// ProductSearchHit represents a product found by search
+// @Description Product with search relevance and highlighted fragments: HTML-escaped text, matches wrapped in <mark>
+type ProductSearchHit struct {
+	*Product
+	Rank          float64 `json:"rank" example:"0.42"`
+	NameHighlight string  `json:"name_highlight" example:"Смартфон <mark>Galaxy</mark> S24"`
+	Snippet       string  `json:"snippet,omitempty" example:"… флагманский <mark>смартфон</mark> с камерой 200 Мп …"`
+}
+
+// ProductSearchResult represents a page of search results
+// @Description Search results ordered by relevance; total is present only when requested
+type ProductSearchResult struct {
+	Items      []ProductSearchHit `json:"items"`
+	NextCursor string             `json:"next_cursor,omitempty" example:"eyJzIjoicmVsZXZhbmNlIiwiayI6IjAuNDIiLCJpZCI6NDJ9"`
+	Total      *int               `json:"total,omitempty" example:"42"`
+}
@@ -0,0 +1,83 @@
+package domain
+
+import "time"
+
+const (
+	ShipmentStatusLabelCreated   = "label_created"
+	ShipmentStatusInTransit      = "in_transit"
+	ShipmentStatusOutForDelivery = "out_for_delivery"
+	ShipmentStatusDelivered      = "delivered"
+	ShipmentStatusException      = "exception"
+	ShipmentStatusReturned       = "returned"
+)
+
+// Shipment represents a (possibly partial) shipment of an order
+// @Description Shipment information with tracking timeline
+type Shipment struct {
+	ID             int64           `json:"id"`
+	OrderID        int64           `json:"order_id"`
+	ShopID         int64           `json:"shop_id"`
+	Carrier        string          `json:"carrier" example:"DHL"`
+	TrackingNumber string          `json:"tracking_number" example:"JD014600006281230484"`
+	Status         string          `json:"status" example:"in_transit"`
+	Items          []ShipmentItem  `json:"items,omitempty"`
+	Events         []ShipmentEvent `json:"events,omitempty"`
+	ShippedAt      *time.Time      `json:"shipped_at,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// ShipmentItem represents an order item quantity included in a shipment
+// @Description Shipment item information
+type ShipmentItem struct {
+	ID          int64 `json:"id"`
+	ShipmentID  int64 `json:"shipment_id"`
+	OrderItemID int64 `json:"order_item_id"`
+	Quantity    int   `json:"quantity"`
+}
+
+// ShipmentEvent represents a tracking event of a shipment
+// @Description Shipment tracking event
+type ShipmentEvent struct {
+	ID          int64     `json:"id"`
+	ShipmentID  int64     `json:"shipment_id"`
+	Status      string    `json:"status" example:"in_transit"`
+	Location    string    `json:"location,omitempty" example:"Dushanbe"`
+	Description string    `json:"description,omitempty" example:"Departed from sorting center"`
+	OccurredAt  time.Time `json:"occurred_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreateShipmentInput represents input for creating a shipment
+// @Description Input for creating a shipment
+type CreateShipmentInput struct {
+	Carrier        string                    `json:"carrier" example:"DHL"`
+	TrackingNumber string                    `json:"tracking_number" example:"JD014600006281230484"`
+	Items          []CreateShipmentItemInput `json:"items"`
+}
+
+// CreateShipmentItemInput represents input for shipment item
+// @Description Input for shipment item
+type CreateShipmentItemInput struct {
+	OrderItemID int64 `json:"order_item_id"`
+	Quantity    int   `json:"quantity"`
+}
+
+// CreateShipmentEventInput represents input for a tracking event
+// @Description Input for a tracking event
+type CreateShipmentEventInput struct {
+	Status      string     `json:"status" example:"in_transit"`
+	Location    string     `json:"location" example:"Dushanbe"`
+	Description string     `json:"description" example:"Departed from sorting center"`
+	OccurredAt  *time.Time `json:"occurred_at,omitempty"`
+}
+
+func IsValidShipmentStatus(status string) bool {
+	switch status {
+	case ShipmentStatusLabelCreated, ShipmentStatusInTransit, ShipmentStatusOutForDelivery,
+		ShipmentStatusDelivered, ShipmentStatusException, ShipmentStatusReturned:
+		return true
+	}
+	return false
+}
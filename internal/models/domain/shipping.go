@@ -0,0 +1,85 @@
+package domain
+
+import "time"
+
+const (
+	ShippingMethodFlatRate          = "flat_rate"
+	ShippingMethodWeightBased       = "weight_based"
+	ShippingMethodFreeOverThreshold = "free_over_threshold"
+	ShippingMethodLocalPickup       = "local_pickup"
+)
+
+// ShippingZone represents a set of destination countries of a shop
+// @Description Shipping zone; empty countries list means "rest of the world"
+type ShippingZone struct {
+	ID        int64            `json:"id" example:"1"`
+	ShopID    int64            `json:"shop_id" example:"1"`
+	Name      string           `json:"name" example:"Central Asia"`
+	Countries []string         `json:"countries" example:"TJ,UZ,KZ"`
+	Methods   []ShippingMethod `json:"methods,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// ShippingMethod represents a shipping method of a zone
+// @Description Shipping method
+type ShippingMethod struct {
+	ID             int64     `json:"id" example:"1"`
+	ZoneID         int64     `json:"zone_id" example:"1"`
+	ShopID         int64     `json:"shop_id" example:"1"`
+	Name           string    `json:"name" example:"Courier"`
+	Type           string    `json:"type" example:"weight_based"`
+	Rate           Money     `json:"rate"`
+	PerKgRate      Money     `json:"per_kg_rate"`
+	FreeThreshold  *Money    `json:"free_threshold,omitempty"`
+	MaxWeightGrams *int      `json:"max_weight_grams,omitempty" example:"30000"`
+	Currency       string    `json:"currency" example:"USD"`
+	Active         bool      `json:"active" example:"true"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ShippingQuoteInput represents a request for shipping options
+// @Description Input for shipping quote
+type ShippingQuoteInput struct {
+	AddressID *int64                 `json:"address_id,omitempty" example:"1"`
+	Currency  string                 `json:"currency,omitempty" example:"USD"`
+	Items     []CreateOrderItemInput `json:"items"`
+}
+
+// ShippingQuote lists available shipping options of one shop in the cart
+// @Description Shipping options of a shop
+type ShippingQuote struct {
+	ShopID  int64            `json:"shop_id" example:"1"`
+	Options []ShippingOption `json:"options"`
+}
+
+// ShippingOption represents a priced shipping method
+// @Description Priced shipping method
+type ShippingOption struct {
+	MethodID int64  `json:"method_id" example:"1"`
+	Name     string `json:"name" example:"Courier"`
+	Type     string `json:"type" example:"weight_based"`
+	Cost     Money  `json:"cost"`
+}
+
+// OrderShippingLine represents the shipping cost charged for one shop in an order
+// @Description Order shipping line
+type OrderShippingLine struct {
+	ID               int64     `json:"id"`
+	OrderID          int64     `json:"order_id"`
+	ShopID           int64     `json:"shop_id"`
+	ShippingMethodID *int64    `json:"shipping_method_id,omitempty"`
+	Name             string    `json:"name"`
+	Type             string    `json:"type"`
+	Amount           Money     `json:"amount"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func IsValidShippingMethodType(methodType string) bool {
+	switch methodType {
+	case ShippingMethodFlatRate, ShippingMethodWeightBased, ShippingMethodFreeOverThreshold, ShippingMethodLocalPickup:
+		return true
+	}
+	return false
+}
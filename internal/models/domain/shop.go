@@ -2,6 +2,9 @@ package domain
 
 import "time"
 
+// ShopPatchFields - поля магазина, которые можно менять через PATCH; slug пересчитывается из name.
+var ShopPatchFields = []string{"name", "description"}
+
 // Shop represents a shop
 // @Description Shop information
 type Shop struct {
@@ -10,6 +13,7 @@ type Shop struct {
 	Slug        string    `json:"slug" example:"my-shop"`
 	OwnerID     int64     `json:"owner_id" example:"123"`
 	Description string    `json:"description" example:"Shop description"`
+	Version     int64     `json:"version" example:"1"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	DeletedAt   time.Time `json:"deleted_at"`
@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+const TaxClassStandard = "standard"
+
+// TaxRate represents a tax rate for a region and tax class
+// @Description Tax rate; inclusive means catalog prices already contain the tax
+type TaxRate struct {
+	ID        int64     `json:"id" example:"1"`
+	Country   string    `json:"country" example:"DE"`
+	Region    string    `json:"region,omitempty" example:"BY"`
+	TaxClass  string    `json:"tax_class" example:"standard"`
+	Name      string    `json:"name" example:"VAT"`
+	Rate      float64   `json:"rate" example:"19"`
+	Inclusive bool      `json:"inclusive" example:"true"`
+	Active    bool      `json:"active" example:"true"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OrderTaxLine represents a tax summary line of an order
+// @Description Tax applied to an order, grouped by rate
+type OrderTaxLine struct {
+	ID            int64     `json:"id"`
+	OrderID       int64     `json:"order_id"`
+	TaxRateID     *int64    `json:"tax_rate_id,omitempty"`
+	Name          string    `json:"name" example:"VAT"`
+	Country       string    `json:"country" example:"DE"`
+	Region        string    `json:"region,omitempty"`
+	TaxClass      string    `json:"tax_class" example:"standard"`
+	Rate          float64   `json:"rate" example:"19"`
+	Inclusive     bool      `json:"inclusive"`
+	TaxableAmount Money     `json:"taxable_amount"`
+	Amount        Money     `json:"amount"`
+	CreatedAt     time.Time `json:"created_at"`
+}
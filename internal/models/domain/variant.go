@@ -0,0 +1,103 @@
+package domain
+
+import "time"
+
+// ProductOption represents an option type of a product
+// @Description Option type of a product (size, color) with allowed values in display order
+type ProductOption struct {
+	ID        int64    `json:"id" example:"1"`
+	ProductID int64    `json:"product_id" example:"1"`
+	Name      string   `json:"name" example:"size"`
+	Position  int      `json:"position" example:"0"`
+	Values    []string `json:"values" example:"S,M,L,XL"`
+}
+
+// ProductOptionInput represents input for an option type
+// @Description Option type with allowed values
+type ProductOptionInput struct {
+	Name   string   `json:"name" example:"size"`
+	Values []string `json:"values" example:"S,M,L,XL"`
+}
+
+// ProductVariant represents a purchasable variant of a product
+// @Description Product variant with its own SKU, price (in the product currency) and stock
+type ProductVariant struct {
+	ID           int64             `json:"id" example:"1"`
+	ProductID    int64             `json:"product_id" example:"1"`
+	SKU          *string           `json:"sku,omitempty" example:"TSHIRT-M-RED"`
+	Options      map[string]string `json:"options"`
+	Price        Money             `json:"price"`
+	DisplayPrice *Money            `json:"display_price,omitempty"`
+	Quantity     int               `json:"quantity" example:"10"`
+	Active       bool              `json:"active" example:"true"`
+	Position     int               `json:"position" example:"0"`
+	Images       []ProductImage    `json:"images,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// ProductVariantInput represents input for creating or updating a variant
+// @Description Input for a variant: options must give a value of every product option; omitted fields are left unchanged on update
+type ProductVariantInput struct {
+	SKU      *string           `json:"sku,omitempty" example:"TSHIRT-M-RED"`
+	Options  map[string]string `json:"options,omitempty"`
+	Price    *Money            `json:"price,omitempty"`
+	Quantity *int              `json:"quantity,omitempty" example:"10"`
+	Active   *bool             `json:"active,omitempty" example:"true"`
+	Position *int              `json:"position,omitempty" example:"0"`
+}
+
+// Типы значений характеристик схемы категории
+const (
+	AttributeTypeString  = "string"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+	AttributeTypeEnum    = "enum"
+)
+
+// AttributeTypes - допустимые типы характеристик
+var AttributeTypes = []string{AttributeTypeString, AttributeTypeNumber, AttributeTypeBoolean, AttributeTypeEnum}
+
+// CategoryAttribute represents an attribute definition of a category schema
+// @Description Attribute of a category schema; subcategories inherit the schema of their ancestors. filterable attributes are shown as facets
+type CategoryAttribute struct {
+	ID         int64    `json:"id" example:"1"`
+	CategoryID int64    `json:"category_id" example:"2"`
+	Key        string   `json:"key" example:"screen_size"`
+	Name       string   `json:"name" example:"Диагональ экрана"`
+	Type       string   `json:"type" example:"number"`
+	Values     []string `json:"values,omitempty" example:"4G,5G"`
+	Required   bool     `json:"required" example:"false"`
+	Filterable bool     `json:"filterable" example:"true"`
+	Position   int      `json:"position" example:"0"`
+}
+
+// SetProductOptionsInput represents input for replacing product options
+// @Description Full list of product option types in display order
+type SetProductOptionsInput struct {
+	Options []ProductOptionInput `json:"options"`
+}
+
+// CategoryAttributeInput represents input for a category attribute
+// @Description Attribute definition; values are required for enum, filterable defaults to true
+type CategoryAttributeInput struct {
+	Key        string   `json:"key" binding:"required" example:"screen_size"`
+	Name       string   `json:"name" binding:"required" example:"Диагональ экрана"`
+	Type       string   `json:"type" binding:"required" example:"number"`
+	Values     []string `json:"values,omitempty"`
+	Required   bool     `json:"required" example:"false"`
+	Filterable *bool    `json:"filterable,omitempty" example:"true"`
+}
+
+// SetCategoryAttributesInput represents input for replacing the own schema of a category
+// @Description Full list of own attributes of a category in display order
+type SetCategoryAttributesInput struct {
+	Attributes []CategoryAttributeInput `json:"attributes"`
+}
+
+// ProductVariantList represents options and variants of a product
+// @Description Options and live variants of a product
+type ProductVariantList struct {
+	Options  []ProductOption  `json:"options"`
+	Variants []ProductVariant `json:"variants"`
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+
+	JobTypeDeliverWebhook = "webhooks.deliver"
+)
+
+// WebhookEventTypes - события, на которые может подписаться магазин
+var WebhookEventTypes = []string{EventOrderCreated, EventOrderStatusChanged, EventProductUpdated, EventStockLow}
+
+// WebhookEndpoint represents a webhook of a shop
+// @Description Webhook endpoint; secret is returned only on creation
+type WebhookEndpoint struct {
+	ID                  int64      `json:"id" example:"1"`
+	ShopID              int64      `json:"shop_id" example:"1"`
+	URL                 string     `json:"url" example:"https://example.com/hooks/marketplace"`
+	Secret              string     `json:"secret,omitempty" example:"whsec_5f2b..."`
+	EventTypes          []string   `json:"event_types" example:"order.created,order.status_changed"`
+	Description         string     `json:"description,omitempty" example:"ERP integration"`
+	Active              bool       `json:"active" example:"true"`
+	ConsecutiveFailures int        `json:"consecutive_failures" example:"0"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason      string     `json:"disabled_reason,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// WebhookEndpointInput represents input for creating or updating a webhook
+// @Description Input for a webhook endpoint; active=true re-enables a disabled webhook
+type WebhookEndpointInput struct {
+	URL         string   `json:"url" example:"https://example.com/hooks/marketplace"`
+	EventTypes  []string `json:"event_types" example:"order.created,order.status_changed"`
+	Description string   `json:"description,omitempty" example:"ERP integration"`
+	Active      *bool    `json:"active,omitempty" example:"true"`
+}
+
+// WebhookDelivery represents a delivery of one event to a webhook
+// @Description Webhook delivery with its attempt log
+type WebhookDelivery struct {
+	ID                 int64                    `json:"id" example:"1"`
+	EndpointID         int64                    `json:"endpoint_id" example:"1"`
+	EventID            string                   `json:"event_id" example:"6f1c2a7e-4b1d-4c5e-9a3f-2d8e7b6a5c4d"`
+	EventType          string                   `json:"event_type" example:"order.created"`
+	Payload            json.RawMessage          `json:"payload" swaggertype:"object"`
+	Status             string                   `json:"status" example:"succeeded"`
+	Attempts           int                      `json:"attempts" example:"1"`
+	LastResponseStatus *int                     `json:"last_response_status,omitempty" example:"200"`
+	LastError          string                   `json:"last_error,omitempty"`
+	Log                []WebhookDeliveryAttempt `json:"log,omitempty"`
+	CreatedAt          time.Time                `json:"created_at"`
+	UpdatedAt          time.Time                `json:"updated_at"`
+	DeliveredAt        *time.Time               `json:"delivered_at,omitempty"`
+}
+
+// WebhookDeliveryAttempt represents one HTTP attempt of a delivery
+// @Description Webhook delivery attempt
+type WebhookDeliveryAttempt struct {
+	ID             int64     `json:"id" example:"1"`
+	DeliveryID     int64     `json:"delivery_id" example:"1"`
+	Attempt        int       `json:"attempt" example:"1"`
+	ResponseStatus *int      `json:"response_status,omitempty" example:"200"`
+	ResponseBody   string    `json:"response_body,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	DurationMs     int       `json:"duration_ms" example:"120"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// WebhookDeliveryFilter - фильтр журнала доставок вебхука
+type WebhookDeliveryFilter struct {
+	EndpointID int64
+	Status     string
+	PageRequest
+}
+
+// WebhookDeliveryJob - payload задачи доставки вебхука
+type WebhookDeliveryJob struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+// WebhookMessage - тело запроса, отправляемого на вебхук
+type WebhookMessage struct {
+	EventID   string          `json:"event_id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"marketplace/internal/contracts"
+	"marketplace/internal/models/domain"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	SenderLog  = "log"
+	SenderFile = "file"
+)
+
+// NewSender создает отправителя канала по имени из настроек. Реальные провайдеры email и SMS
+// подключаются отдельной реализацией contracts.NotificationSender.
+func NewSender(kind, channel, dir string) (contracts.NotificationSender, error) {
+	switch kind {
+	case "", SenderLog:
+		return NewLogSender(channel), nil
+	case SenderFile:
+		return NewFileSender(channel, dir), nil
+	}
+	return nil, fmt.Errorf("unknown %s notification sender %q", channel, kind)
+}
+
+// LogSender пишет сообщения в журнал приложения вместо отправки.
+type LogSender struct {
+	logger zerolog.Logger
+}
+
+func NewLogSender(channel string) *LogSender {
+	return &LogSender{
+		logger: zerolog.New(os.Stdout).With().Timestamp().Str("entity", "notify").Str("channel", channel).Logger(),
+	}
+}
+
+func (s *LogSender) Name() string {
+	return SenderLog
+}
+
+func (s *LogSender) Send(ctx context.Context, message domain.NotificationMessage) error {
+	s.logger.Info().Int64("notification_id", message.NotificationID).Str("to", message.To).
+		Str("subject", message.Subject).Str("body", message.Body).Msg("notification sent")
+	return nil
+}
+
+// FileSender дописывает сообщения JSON-строками в файл <dir>/<channel>.jsonl - локальный
+// почтовый ящик для разработки.
+type FileSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileSender(channel, dir string) *FileSender {
+	return &FileSender{path: filepath.Join(dir, channel+".jsonl")}
+}
+
+func (s *FileSender) Name() string {
+	return SenderFile
+}
+
+func (s *FileSender) Send(ctx context.Context, message domain.NotificationMessage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	line, err := json.Marshal(struct {
+		domain.NotificationMessage
+		SentAt time.Time `json:"sent_at"`
+	}{message, time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("encode notification: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("create notification outbox dir: %w", err)
+	}
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open notification outbox: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write notification outbox: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package rates
+
+import (
+	"context"
+	"fmt"
+	"marketplace/internal/models/domain"
+	"os"
+)
+
+// FileSource читает курсы из локального JSON-файла.
+type FileSource struct {
+	path string
+}
+
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+func (s *FileSource) Name() string {
+	return SourceFile
+}
+
+func (s *FileSource) FetchRates(ctx context.Context) ([]domain.ExchangeRate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("open rates file: %w", err)
+	}
+	defer file.Close()
+	return decodePayload(file, s.Name())
+}
@@ -0,0 +1,67 @@
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"marketplace/internal/contracts"
+	"marketplace/internal/models/domain"
+	"net/http"
+)
+
+// HTTPSource получает курсы из внешнего API в формате ratesPayload.
+type HTTPSource struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPSource(url string, client *http.Client) *HTTPSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPSource{url: url, client: client}
+}
+
+func (s *HTTPSource) Name() string {
+	return SourceHTTP
+}
+
+func (s *HTTPSource) FetchRates(ctx context.Context) ([]domain.ExchangeRate, error) {
+	if s.url == "" {
+		return nil, fmt.Errorf("rates url is not configured")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch rates: unexpected status %d", resp.StatusCode)
+	}
+	return decodePayload(resp.Body, s.Name())
+}
+
+// StubHandler отдает курсы другого источника в формате HTTP API.
+// Используется как локальная заглушка внешнего сервиса курсов при разработке.
+func StubHandler(source contracts.RateSource) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		list, err := source.FetchRates(r.Context())
+		if err != nil || len(list) == 0 {
+			http.Error(w, "rates are not available", http.StatusServiceUnavailable)
+			return
+		}
+		payload := map[string]interface{}{"base": list[0].BaseCurrency}
+		quotes := make(map[string]string, len(list))
+		for _, rate := range list {
+			quotes[rate.QuoteCurrency] = rate.Rate
+		}
+		payload["rates"] = quotes
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(payload)
+	})
+}
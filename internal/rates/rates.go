@@ -0,0 +1,80 @@
+package rates
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"marketplace/internal/configs"
+	"marketplace/internal/contracts"
+	"marketplace/internal/models/domain"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SourceManual = "manual"
+	SourceFile   = "file"
+	SourceHTTP   = "http"
+)
+
+// ratesPayload - общий формат файла курсов и ответа HTTP-источника:
+// {"base": "USD", "rates": {"EUR": "0.92"}}
+type ratesPayload struct {
+	Base  string                 `json:"base"`
+	Rates map[string]json.Number `json:"rates"`
+}
+
+// NewSource создает источник курсов по настройкам. Для ручного режима источник не нужен
+// и возвращается nil - курсы вводятся только через админский API.
+func NewSource(params configs.CurrencyParams) (contracts.RateSource, error) {
+	switch params.RateSource {
+	case "", SourceManual:
+		return nil, nil
+	case SourceFile:
+		return NewFileSource(params.RatesFile), nil
+	case SourceHTTP:
+		timeout := time.Duration(params.RequestTimeoutSeconds) * time.Second
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		return NewHTTPSource(params.RatesURL, &http.Client{Timeout: timeout}), nil
+	}
+	return nil, fmt.Errorf("unknown exchange rate source %q", params.RateSource)
+}
+
+func decodePayload(r io.Reader, source string) ([]domain.ExchangeRate, error) {
+	var payload ratesPayload
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("decode rates: %w", err)
+	}
+	base := strings.ToUpper(strings.TrimSpace(payload.Base))
+	if !domain.IsValidCurrency(base) {
+		return nil, fmt.Errorf("decode rates: invalid base currency %q", payload.Base)
+	}
+	result := make([]domain.ExchangeRate, 0, len(payload.Rates))
+	for quote, value := range payload.Rates {
+		quote = strings.ToUpper(strings.TrimSpace(quote))
+		if quote == base {
+			continue
+		}
+		if !domain.IsValidCurrency(quote) {
+			return nil, fmt.Errorf("decode rates: invalid currency %q", quote)
+		}
+		rate, err := domain.ParseRate(value.String())
+		if err != nil {
+			return nil, fmt.Errorf("decode rates: %s: %w", quote, err)
+		}
+		result = append(result, domain.ExchangeRate{
+			BaseCurrency:  base,
+			QuoteCurrency: quote,
+			Rate:          domain.FormatRate(rate),
+			Source:        source,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].QuoteCurrency < result[j].QuoteCurrency })
+	return result, nil
+}
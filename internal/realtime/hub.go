@@ -0,0 +1,133 @@
+package realtime
+
+import (
+	"marketplace/internal/models/domain"
+	"sync"
+)
+
+const subscriptionBuffer = 64
+
+// Subscription - подписка одного соединения на поток обновлений.
+type Subscription struct {
+	userID     int64
+	shopIDs    map[int64]bool
+	productIDs map[int64]bool
+	events     chan domain.StreamMessage
+	done       chan struct{}
+	closeOnce  sync.Once
+	hub        *Hub
+}
+
+// Events возвращает канал сообщений подписки.
+func (s *Subscription) Events() <-chan domain.StreamMessage {
+	return s.events
+}
+
+// Done закрывается, когда подписка отключена: клиентом через Close, хабом из-за переполнения буфера
+// или при остановке сервера через CloseAll.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+func (s *Subscription) matches(event domain.StreamEvent) bool {
+	for _, id := range event.UserIDs {
+		if id == s.userID {
+			return true
+		}
+	}
+	for _, id := range event.ShopIDs {
+		if s.shopIDs[id] {
+			return true
+		}
+	}
+	for _, id := range event.ProductIDs {
+		if s.productIDs[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// Hub раздает события подпискам соединений текущего экземпляра приложения.
+type Hub struct {
+	mu            sync.RWMutex
+	subscriptions map[*Subscription]struct{}
+	closed        bool
+}
+
+func NewHub() *Hub {
+	return &Hub{subscriptions: make(map[*Subscription]struct{})}
+}
+
+// Subscribe подписывает пользователя на события его заказов, магазинов shopIDs и товаров productIDs.
+func (h *Hub) Subscribe(userID int64, shopIDs, productIDs []int64) *Subscription {
+	sub := &Subscription{
+		userID:     userID,
+		shopIDs:    make(map[int64]bool, len(shopIDs)),
+		productIDs: make(map[int64]bool, len(productIDs)),
+		events:     make(chan domain.StreamMessage, subscriptionBuffer),
+		done:       make(chan struct{}),
+		hub:        h,
+	}
+	for _, id := range shopIDs {
+		sub.shopIDs[id] = true
+	}
+	for _, id := range productIDs {
+		sub.productIDs[id] = true
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		// Соединение, открытое во время остановки, сразу получает закрытую подписку
+		close(sub.done)
+		return sub
+	}
+	h.subscriptions[sub] = struct{}{}
+	return sub
+}
+
+// CloseAll отключает все подписки и больше не принимает новые. Вызывается при остановке
+// сервера: обработчики потоков завершаются, и Shutdown не ждет их до таймаута.
+func (h *Hub) CloseAll() {
+	h.mu.Lock()
+	h.closed = true
+	subscriptions := h.subscriptions
+	h.subscriptions = make(map[*Subscription]struct{})
+	h.mu.Unlock()
+	for sub := range subscriptions {
+		sub.closeOnce.Do(func() { close(sub.done) })
+	}
+}
+
+// Broadcast передает событие подходящим подпискам. Подписка, не успевающая читать
+// (буфер заполнен), отключается, чтобы медленный клиент не задерживал остальных.
+func (h *Hub) Broadcast(event domain.StreamEvent) {
+	message := domain.StreamMessage{EventID: event.EventID, Type: event.Type, Data: event.Data}
+	var slow []*Subscription
+	h.mu.RLock()
+	for sub := range h.subscriptions {
+		if !sub.matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- message:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	h.mu.RUnlock()
+	for _, sub := range slow {
+		h.remove(sub)
+	}
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	h.mu.Lock()
+	delete(h.subscriptions, sub)
+	h.mu.Unlock()
+	sub.closeOnce.Do(func() { close(sub.done) })
+}
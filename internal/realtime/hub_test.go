@@ -0,0 +1,28 @@
+package realtime
+
+import (
+	"marketplace/internal/models/domain"
+	"testing"
+)
+
+func TestHubCloseAll(t *testing.T) {
+	hub := NewHub()
+	subs := []*Subscription{hub.Subscribe(1, nil, nil), hub.Subscribe(2, []int64{10}, nil)}
+	subs[0].Close()
+	hub.CloseAll()
+	for i, sub := range subs {
+		select {
+		case <-sub.Done():
+		default:
+			t.Errorf("subscription %d is still open", i)
+		}
+	}
+	// Повторное закрытие подписки и рассылка после остановки безопасны
+	subs[1].Close()
+	hub.Broadcast(domain.StreamEvent{UserIDs: []int64{2}, ShopIDs: []int64{10}})
+	select {
+	case <-hub.Subscribe(3, nil, nil).Done():
+	default:
+		t.Error("subscription opened after CloseAll is open")
+	}
+}
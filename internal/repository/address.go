@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+)
+
+const addressColumns = `id, user_id, label, recipient_name, phone, country, region, city, postal_code, line1, line2, is_default, created_at, updated_at, deleted_at`
+
+func (r *Repository) CreateAddress(address *domain.Address) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateAddress").Logger()
+	dbAddress := db.Address{}
+	dbAddress.FromDomain(address)
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return r.translateError(err)
+	}
+	defer tx.Rollback()
+	if dbAddress.IsDefault {
+		if err := r.clearDefaultAddressWithTx(tx, dbAddress.UserID); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	query := `INSERT INTO addresses (user_id, label, recipient_name, phone, country, region, city, postal_code, line1, line2, is_default, created_at, updated_at)
+	          VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13) RETURNING id, created_at, updated_at`
+	err = tx.QueryRow(query, dbAddress.UserID, dbAddress.Label, dbAddress.RecipientName, dbAddress.Phone, dbAddress.Country, dbAddress.Region,
+		dbAddress.City, dbAddress.PostalCode, dbAddress.Line1, dbAddress.Line2, dbAddress.IsDefault, now, now).
+		Scan(&dbAddress.ID, &dbAddress.CreatedAt, &dbAddress.UpdatedAt)
+	if err != nil {
+		logger.Error().Err(err).Int64("user_id", dbAddress.UserID).Msg("failed to create address")
+		return r.translateError(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return r.translateError(err)
+	}
+	address.ID = dbAddress.ID
+	address.CreatedAt = dbAddress.CreatedAt
+	address.UpdatedAt = dbAddress.UpdatedAt
+	logger.Info().Int64("address_id", address.ID).Msg("address created successfully")
+	return nil
+}
+
+func (r *Repository) GetAddressByID(id int64) (*domain.Address, error) {
+	var dbAddress db.Address
+	query := `SELECT ` + addressColumns + ` FROM addresses WHERE id = $1 AND deleted_at IS NULL`
+	if err := r.db.Get(&dbAddress, query, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbAddress.ToDomain(), nil
+}
+
+func (r *Repository) GetDefaultAddress(userID int64) (*domain.Address, error) {
+	var dbAddress db.Address
+	query := `SELECT ` + addressColumns + ` FROM addresses WHERE user_id = $1 AND is_default AND deleted_at IS NULL`
+	if err := r.db.Get(&dbAddress, query, userID); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbAddress.ToDomain(), nil
+}
+
+func (r *Repository) ListAddresses(userID int64) ([]*domain.Address, error) {
+	var dbAddresses []db.Address
+	query := `SELECT ` + addressColumns + ` FROM addresses WHERE user_id = $1 AND deleted_at IS NULL ORDER BY is_default DESC, created_at DESC`
+	if err := r.db.Select(&dbAddresses, query, userID); err != nil {
+		return nil, r.translateError(err)
+	}
+	addresses := make([]*domain.Address, 0, len(dbAddresses))
+	for _, a := range dbAddresses {
+		addresses = append(addresses, a.ToDomain())
+	}
+	return addresses, nil
+}
+
+func (r *Repository) UpdateAddress(address *domain.Address) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "UpdateAddress").Logger()
+	dbAddress := db.Address{}
+	dbAddress.FromDomain(address)
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return r.translateError(err)
+	}
+	defer tx.Rollback()
+	if dbAddress.IsDefault {
+		if err := r.clearDefaultAddressWithTx(tx, dbAddress.UserID); err != nil {
+			return err
+		}
+	}
+	query := `UPDATE addresses SET label = $1, recipient_name = $2, phone = $3, country = $4, region = $5, city = $6, postal_code = $7,
+	          line1 = $8, line2 = $9, is_default = $10, updated_at = $11 WHERE id = $12 AND user_id = $13 AND deleted_at IS NULL`
+	result, err := tx.Exec(query, dbAddress.Label, dbAddress.RecipientName, dbAddress.Phone, dbAddress.Country, dbAddress.Region, dbAddress.City,
+		dbAddress.PostalCode, dbAddress.Line1, dbAddress.Line2, dbAddress.IsDefault, time.Now(), dbAddress.ID, dbAddress.UserID)
+	if err != nil {
+		logger.Error().Err(err).Int64("id", dbAddress.ID).Msg("failed to update address")
+		return r.translateError(err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return r.translateError(err)
+	} else if rowsAffected == 0 {
+		return errs.ErrAddressNotFound
+	}
+	return r.translateError(tx.Commit())
+}
+
+func (r *Repository) SetDefaultAddress(userID, addressID int64) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return r.translateError(err)
+	}
+	defer tx.Rollback()
+	if err := r.clearDefaultAddressWithTx(tx, userID); err != nil {
+		return err
+	}
+	query := `UPDATE addresses SET is_default = true, updated_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	result, err := tx.Exec(query, addressID, userID)
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return r.translateError(err)
+	} else if rowsAffected == 0 {
+		return errs.ErrAddressNotFound
+	}
+	return r.translateError(tx.Commit())
+}
+
+// DeleteAddress мягко удаляет адрес; если он был адресом по умолчанию,
+// им становится самый новый из оставшихся.
+func (r *Repository) DeleteAddress(userID, addressID int64) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return r.translateError(err)
+	}
+	defer tx.Rollback()
+	var wasDefault bool
+	query := `SELECT is_default FROM addresses WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.Get(&wasDefault, query, addressID, userID); err != nil {
+		return r.translateError(err)
+	}
+	if _, err := tx.Exec(`UPDATE addresses SET deleted_at = NOW(), is_default = false WHERE id = $1`, addressID); err != nil {
+		return r.translateError(err)
+	}
+	if wasDefault {
+		promote := `UPDATE addresses SET is_default = true, updated_at = NOW()
+		            WHERE id = (SELECT id FROM addresses WHERE user_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC LIMIT 1)`
+		if _, err := tx.Exec(promote, userID); err != nil {
+			return r.translateError(err)
+		}
+	}
+	return r.translateError(tx.Commit())
+}
+
+func (r *Repository) clearDefaultAddressWithTx(tx *sqlx.Tx, userID int64) error {
+	_, err := tx.Exec(`UPDATE addresses SET is_default = false, updated_at = NOW() WHERE user_id = $1 AND is_default AND deleted_at IS NULL`, userID)
+	return r.translateError(err)
+}
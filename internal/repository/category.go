@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"errors"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+const categoryColumns = `id, parent_id, name, slug, position, created_at, updated_at`
+
+// categorySubtree - подзапрос id категории $n и всех ее потомков.
+func categorySubtree(param string) string {
+	return `WITH RECURSIVE subtree AS (
+	            SELECT id FROM categories WHERE id = ` + param + `
+	            UNION ALL
+	            SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+	        ) SELECT id FROM subtree`
+}
+
+// LockCategoryTreeWithTx блокирует изменения дерева до конца транзакции: проверка на цикл
+// и перенумерация соседей иначе могут разойтись с параллельным переносом.
+func (r *Repository) LockCategoryTreeWithTx(tx *sqlx.Tx) error {
+	if _, err := tx.Exec(`LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+func (r *Repository) CreateCategoryWithTx(tx *sqlx.Tx, category *domain.Category) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateCategoryWithTx").Logger()
+	now := time.Now()
+	query := `INSERT INTO categories (parent_id, name, slug, position, created_at, updated_at)
+	          VALUES ($1, $2, $3, $4, $5, $5) RETURNING id, created_at, updated_at`
+	err := tx.QueryRow(query, category.ParentID, category.Name, category.Slug, category.Position, now).
+		Scan(&category.ID, &category.CreatedAt, &category.UpdatedAt)
+	if err != nil {
+		logger.Error().Err(err).Str("slug", category.Slug).Msg("failed to create category")
+		return r.translateCategoryError(err)
+	}
+	return nil
+}
+
+func (r *Repository) GetCategoryByID(id int64) (*domain.Category, error) {
+	var dbCategory db.Category
+	if err := r.db.Get(&dbCategory, `SELECT `+categoryColumns+` FROM categories WHERE id = $1`, id); err != nil {
+		return nil, r.translateCategoryError(err)
+	}
+	return dbCategory.ToDomain(), nil
+}
+
+func (r *Repository) GetCategoryByIDWithTx(tx *sqlx.Tx, id int64) (*domain.Category, error) {
+	var dbCategory db.Category
+	if err := tx.Get(&dbCategory, `SELECT `+categoryColumns+` FROM categories WHERE id = $1`, id); err != nil {
+		return nil, r.translateCategoryError(err)
+	}
+	return dbCategory.ToDomain(), nil
+}
+
+// ListCategories возвращает все категории в порядке обхода соседей (parent_id, position, id).
+func (r *Repository) ListCategories() ([]*domain.Category, error) {
+	var dbCategories []db.Category
+	query := `SELECT ` + categoryColumns + ` FROM categories ORDER BY parent_id NULLS FIRST, position, id`
+	if err := r.db.Select(&dbCategories, query); err != nil {
+		return nil, r.translateError(err)
+	}
+	categories := make([]*domain.Category, len(dbCategories))
+	for i, c := range dbCategories {
+		categories[i] = c.ToDomain()
+	}
+	return categories, nil
+}
+
+// categoryProducts - неудаленные товары, привязанные к категориям поддерева param. Их карточки
+// содержат breadcrumbs, поэтому переименование, перенос или удаление категории увеличивает их версию.
+func categoryProducts(param string) string {
+	return `SELECT product_id FROM product_categories WHERE category_id IN (` + categorySubtree(param) + `)`
+}
+
+// UpdateCategory переименовывает категорию и в том же запросе увеличивает версию товаров ее поддерева.
+func (r *Repository) UpdateCategory(category *domain.Category) error {
+	query := `WITH updated AS (
+	              UPDATE categories SET name = $1, slug = $2, updated_at = $3 WHERE id = $4 RETURNING updated_at
+	          ), touched AS (
+	              UPDATE products SET version = version + 1
+	              WHERE deleted_at IS NULL AND id IN (` + categoryProducts("$4") + `)
+	          )
+	          SELECT updated_at FROM updated`
+	if err := r.db.QueryRow(query, category.Name, category.Slug, time.Now(), category.ID).Scan(&category.UpdatedAt); err != nil {
+		return r.translateCategoryError(err)
+	}
+	return nil
+}
+
+// IsCategoryInSubtreeWithTx проверяет, что id - сама категория rootID или ее потомок.
+func (r *Repository) IsCategoryInSubtreeWithTx(tx *sqlx.Tx, rootID, id int64) (bool, error) {
+	var found bool
+	query := `SELECT EXISTS (` + categorySubtree("$1") + ` WHERE id = $2)`
+	if err := tx.Get(&found, query, rootID, id); err != nil {
+		return false, r.translateError(err)
+	}
+	return found, nil
+}
+
+// ListCategoryChildIDsWithTx возвращает id детей родителя (nil - корни) в текущем порядке.
+func (r *Repository) ListCategoryChildIDsWithTx(tx *sqlx.Tx, parentID *int64) ([]int64, error) {
+	var ids []int64
+	query := `SELECT id FROM categories WHERE parent_id IS NOT DISTINCT FROM $1 ORDER BY position, id`
+	if err := tx.Select(&ids, query, parentID); err != nil {
+		return nil, r.translateError(err)
+	}
+	return ids, nil
+}
+
+// MoveCategoryWithTx меняет родителя категории и увеличивает версию товаров ее поддерева;
+// позицию выставляет SetCategoryPositionsWithTx.
+func (r *Repository) MoveCategoryWithTx(tx *sqlx.Tx, id int64, parentID *int64) error {
+	result, err := tx.Exec(`UPDATE categories SET parent_id = $1, updated_at = $2 WHERE id = $3`, parentID, time.Now(), id)
+	if err != nil {
+		return r.translateCategoryError(err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return r.translateError(err)
+	} else if rowsAffected == 0 {
+		return errs.ErrCategoryNotFound
+	}
+	query := `UPDATE products SET version = version + 1 WHERE deleted_at IS NULL AND id IN (` + categoryProducts("$1") + `)`
+	if _, err := tx.Exec(query, id); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+// SetCategoryPositionsWithTx нумерует категории по порядку ids: 0, 1, 2, ...
+func (r *Repository) SetCategoryPositionsWithTx(tx *sqlx.Tx, ids []int64) error {
+	query := `UPDATE categories c SET position = v.position - 1
+	          FROM unnest($1::bigint[]) WITH ORDINALITY AS v(id, position)
+	          WHERE c.id = v.id AND c.position <> v.position - 1`
+	if _, err := tx.Exec(query, pq.Array(ids)); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+// DeleteCategory удаляет категорию без подкатегорий; привязки товаров удаляются каскадно, а версия
+// этих товаров увеличивается в том же запросе.
+func (r *Repository) DeleteCategory(id int64) error {
+	query := `WITH touched AS (
+	              UPDATE products SET version = version + 1 WHERE deleted_at IS NULL AND id IN (` + categoryProducts("$1") + `)
+	          )
+	          DELETE FROM categories WHERE id = $1`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return errs.ErrCategoryNotEmpty
+		}
+		return r.translateError(err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return r.translateError(err)
+	} else if rowsAffected == 0 {
+		return errs.ErrCategoryNotFound
+	}
+	return nil
+}
+
+// SetProductCategoriesWithTx заменяет набор категорий товара.
+func (r *Repository) SetProductCategoriesWithTx(tx *sqlx.Tx, productID int64, categoryIDs []int64) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "SetProductCategoriesWithTx").Logger()
+	if _, err := tx.Exec(`DELETE FROM product_categories WHERE product_id = $1 AND NOT (category_id = ANY($2))`,
+		productID, pq.Array(categoryIDs)); err != nil {
+		logger.Error().Err(err).Int64("product_id", productID).Msg("failed to unlink product categories")
+		return r.translateError(err)
+	}
+	query := `INSERT INTO product_categories (product_id, category_id)
+	          SELECT $1, unnest($2::bigint[]) ON CONFLICT DO NOTHING`
+	if _, err := tx.Exec(query, productID, pq.Array(categoryIDs)); err != nil {
+		logger.Error().Err(err).Int64("product_id", productID).Msg("failed to link product categories")
+		return r.translateCategoryError(err)
+	}
+	return nil
+}
+
+// ListProductCategories возвращает категории товаров с путями от корня одним рекурсивным запросом.
+func (r *Repository) ListProductCategories(productIDs []int64) (map[int64][]domain.ProductCategory, error) {
+	query := `WITH RECURSIVE path AS (
+	              SELECT pc.product_id, pc.category_id, c.id, c.parent_id, c.name, c.slug, 0 AS depth
+	              FROM product_categories pc JOIN categories c ON c.id = pc.category_id
+	              WHERE pc.product_id = ANY($1)
+	              UNION ALL
+	              SELECT path.product_id, path.category_id, c.id, c.parent_id, c.name, c.slug, path.depth + 1
+	              FROM path JOIN categories c ON c.id = path.parent_id
+	          )
+	          SELECT product_id, category_id, id, name, slug, depth FROM path
+	          ORDER BY product_id, category_id, depth DESC`
+	var nodes []db.CategoryPathNode
+	if err := r.db.Select(&nodes, query, pq.Array(productIDs)); err != nil {
+		return nil, r.translateError(err)
+	}
+	categories := make(map[int64][]domain.ProductCategory)
+	for i, node := range nodes {
+		ref := domain.CategoryRef{ID: node.ID, Name: node.Name, Slug: node.Slug}
+		if i == 0 || nodes[i-1].ProductID != node.ProductID || nodes[i-1].CategoryID != node.CategoryID {
+			categories[node.ProductID] = append(categories[node.ProductID], domain.ProductCategory{})
+		}
+		list := categories[node.ProductID]
+		last := &list[len(list)-1]
+		last.Breadcrumbs = append(last.Breadcrumbs, ref)
+		if node.Depth == 0 {
+			last.CategoryRef = ref
+		}
+	}
+	return categories, nil
+}
+
+// translateCategoryError переводит нарушения ограничений categories в ошибки домена.
+func (r *Repository) translateCategoryError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505":
+			return errs.ErrCategoryAlreadyExists
+		case "23503":
+			return errs.ErrCategoryNotFound
+		}
+	}
+	if err = r.translateError(err); errors.Is(err, errs.ErrNotfound) {
+		return errs.ErrCategoryNotFound
+	}
+	return err
+}
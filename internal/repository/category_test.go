@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"fmt"
+	"marketplace/internal/models/domain"
+	"testing"
+	"time"
+)
+
+// Breadcrumbs входят в карточку товара, поэтому изменения дерева увеличивают версию товаров поддерева
+func TestCategoryChangesTouchProducts(t *testing.T) {
+	r := testRepository(t)
+	_, productID := testProduct(t, r, 1)
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	tx, err := r.BeginTx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := &domain.Category{Name: "Root", Slug: "test-root-" + suffix}
+	if err := r.CreateCategoryWithTx(tx, root); err != nil {
+		t.Fatal(err)
+	}
+	child := &domain.Category{ParentID: &root.ID, Name: "Child", Slug: "test-child-" + suffix}
+	if err := r.CreateCategoryWithTx(tx, child); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetProductCategoriesWithTx(tx, productID, []int64{child.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = r.db.Exec(`DELETE FROM categories WHERE id = $1`, child.ID)
+		_, _ = r.db.Exec(`DELETE FROM categories WHERE id = $1`, root.ID)
+	})
+	version := func() int64 {
+		t.Helper()
+		var v int64
+		if err := r.db.Get(&v, `SELECT version FROM products WHERE id = $1`, productID); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	before := version()
+
+	root.Name = "Renamed root"
+	if err := r.UpdateCategory(root); err != nil {
+		t.Fatal(err)
+	}
+	if v := version(); v != before+1 {
+		t.Fatalf("version after renaming the parent %d, want %d", v, before+1)
+	}
+	tx, err = r.BeginTx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if err := r.MoveCategoryWithTx(tx, child.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if v := version(); v != before+2 {
+		t.Fatalf("version after moving the category %d, want %d", v, before+2)
+	}
+	if err := r.DeleteCategory(child.ID); err != nil {
+		t.Fatal(err)
+	}
+	if v := version(); v != before+3 {
+		t.Fatalf("version after deleting the category %d, want %d", v, before+3)
+	}
+}
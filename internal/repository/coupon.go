@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+const couponColumns = `id, code, type, value, amount, currency, scope, shop_id, product_ids, min_order_value, starts_at, ends_at, usage_limit, usage_limit_per_user, times_used, stackable, active, created_by, created_at, updated_at`
+
+func (r *Repository) CreateCoupon(coupon *domain.Coupon) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateCoupon").Logger()
+	dbCoupon := db.Coupon{}
+	dbCoupon.FromDomain(coupon)
+	now := time.Now()
+	query := `INSERT INTO coupons (code, type, value, amount, currency, scope, shop_id, product_ids, min_order_value, starts_at, ends_at, usage_limit, usage_limit_per_user, stackable, active, created_by, created_at, updated_at)
+	          VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18) RETURNING id, created_at, updated_at`
+	err := r.db.QueryRow(query, dbCoupon.Code, dbCoupon.Type, dbCoupon.Value, dbCoupon.Amount, dbCoupon.Currency, dbCoupon.Scope, dbCoupon.ShopID, dbCoupon.ProductIDs,
+		dbCoupon.MinOrderValue, dbCoupon.StartsAt, dbCoupon.EndsAt, dbCoupon.UsageLimit, dbCoupon.UsageLimitPerUser, dbCoupon.Stackable, dbCoupon.Active,
+		dbCoupon.CreatedBy, now, now).Scan(&dbCoupon.ID, &dbCoupon.CreatedAt, &dbCoupon.UpdatedAt)
+	if err != nil {
+		logger.Error().Err(err).Str("code", dbCoupon.Code).Msg("failed to create coupon")
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return errs.ErrCouponAlreadyExists
+		}
+		return r.translateError(err)
+	}
+	coupon.ID = dbCoupon.ID
+	coupon.CreatedAt = dbCoupon.CreatedAt
+	coupon.UpdatedAt = dbCoupon.UpdatedAt
+	return nil
+}
+
+func (r *Repository) GetCouponByID(id int64) (*domain.Coupon, error) {
+	var dbCoupon db.Coupon
+	query := `SELECT ` + couponColumns + ` FROM coupons WHERE id = $1`
+	if err := r.db.Get(&dbCoupon, query, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbCoupon.ToDomain()
+}
+
+// ListCoupons возвращает страницу купонов магазина, новые первыми (по убыванию id); при
+// shopID == nil - купоны всего маркетплейса.
+func (r *Repository) ListCoupons(shopID *int64, page domain.PageRequest) (*domain.Page[*domain.Coupon], error) {
+	cursor, err := decodeCursor(page.Cursor, "")
+	if err != nil {
+		return nil, err
+	}
+	const filter = `(shop_id = $1 OR ($1::bigint IS NULL AND scope = 'marketplace'))`
+	var dbCoupons []db.Coupon
+	query := `SELECT ` + couponColumns + ` FROM coupons WHERE ` + filter + ` AND ($2 = 0 OR id < $2) ORDER BY id DESC LIMIT $3`
+	if err := r.db.Select(&dbCoupons, query, shopID, cursorID(cursor), page.Limit+1); err != nil {
+		return nil, r.translateError(err)
+	}
+	dbCoupons, nextCursor := trimPage(dbCoupons, page.Limit, func(c db.Coupon) pageCursor { return pageCursor{ID: c.ID} })
+	result := &domain.Page[*domain.Coupon]{Items: make([]*domain.Coupon, 0, len(dbCoupons)), NextCursor: nextCursor}
+	for _, c := range dbCoupons {
+		coupon, err := c.ToDomain()
+		if err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, coupon)
+	}
+	if result.Total, err = r.countTotal(page.WithTotal, `SELECT COUNT(*) FROM coupons WHERE `+filter, shopID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *Repository) DeactivateCoupon(id int64) error {
+	result, err := r.db.Exec(`UPDATE coupons SET active = false, updated_at = NOW() WHERE id = $1`, id)
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return r.translateError(err)
+	} else if rowsAffected == 0 {
+		return errs.ErrCouponNotFound
+	}
+	return nil
+}
+
+// GetCouponsByCodesWithTx блокирует купоны в порядке id, чтобы параллельные заказы
+// с одинаковыми купонами не получали взаимоблокировку.
+func (r *Repository) GetCouponsByCodesWithTx(tx *sqlx.Tx, codes []string) ([]*domain.Coupon, error) {
+	var dbCoupons []db.Coupon
+	query := `SELECT ` + couponColumns + ` FROM coupons WHERE code = ANY($1) ORDER BY id FOR UPDATE`
+	if err := tx.Select(&dbCoupons, query, pq.Array(codes)); err != nil {
+		return nil, r.translateError(err)
+	}
+	coupons := make([]*domain.Coupon, 0, len(dbCoupons))
+	for _, c := range dbCoupons {
+		coupon, err := c.ToDomain()
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, coupon)
+	}
+	return coupons, nil
+}
+
+func (r *Repository) CountCouponRedemptionsWithTx(tx *sqlx.Tx, couponID, userID int64) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id = $1 AND user_id = $2`
+	if err := tx.Get(&count, query, couponID, userID); err != nil {
+		return 0, r.translateError(err)
+	}
+	return count, nil
+}
+
+func (r *Repository) RedeemCouponWithTx(tx *sqlx.Tx, couponID, userID, orderID int64, amount domain.Money) error {
+	result, err := tx.Exec(`UPDATE coupons SET times_used = times_used + 1, updated_at = NOW()
+	                        WHERE id = $1 AND (usage_limit IS NULL OR times_used < usage_limit)`, couponID)
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return r.translateError(err)
+	} else if rowsAffected == 0 {
+		return errs.ErrCouponUsageLimitReached
+	}
+	query := `INSERT INTO coupon_redemptions (coupon_id, user_id, order_id, amount) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.Exec(query, couponID, userID, orderID, amount.Decimal()); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+// ReleaseCouponRedemptionsWithTx отменяет погашения купонов заказов: удаляет их из coupon_redemptions
+// (по ним считается лимит на пользователя) и уменьшает times_used. Купоны блокируются в порядке id,
+// как в GetCouponsByCodesWithTx. Возвращает число отмененных погашений.
+func (r *Repository) ReleaseCouponRedemptionsWithTx(tx *sqlx.Tx, orderIDs []int64) (int, error) {
+	query := `SELECT id FROM coupons WHERE id IN (SELECT coupon_id FROM coupon_redemptions WHERE order_id = ANY($1))
+	          ORDER BY id FOR UPDATE`
+	var couponIDs []int64
+	if err := tx.Select(&couponIDs, query, pq.Array(orderIDs)); err != nil {
+		return 0, r.translateError(err)
+	}
+	if len(couponIDs) == 0 {
+		return 0, nil
+	}
+	query = `WITH released AS (
+	             DELETE FROM coupon_redemptions WHERE order_id = ANY($1) RETURNING coupon_id
+	         ), counts AS (
+	             SELECT coupon_id, COUNT(*) AS released FROM released GROUP BY coupon_id
+	         ), updated AS (
+	             UPDATE coupons c SET times_used = GREATEST(c.times_used - counts.released, 0), updated_at = NOW()
+	             FROM counts WHERE c.id = counts.coupon_id
+	         )
+	         SELECT COALESCE(SUM(released), 0) FROM counts`
+	var released int
+	if err := tx.Get(&released, query, pq.Array(orderIDs)); err != nil {
+		return 0, r.translateError(err)
+	}
+	return released, nil
+}
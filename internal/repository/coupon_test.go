@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"marketplace/internal/models/domain"
+	"testing"
+)
+
+func TestReleaseCouponRedemptions(t *testing.T) {
+	r := testRepository(t)
+	userID, _ := testProduct(t, r, 0)
+	var couponID, orderID int64
+	if err := r.db.QueryRow(`INSERT INTO coupons (code, type, value, scope, usage_limit, created_by) VALUES ('TEST' || $1, 'percentage', 10, 'marketplace', 1, $1) RETURNING id`,
+		userID).Scan(&couponID); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _, _ = r.db.Exec(`DELETE FROM coupons WHERE id = $1`, couponID) })
+	if err := r.db.QueryRow(`INSERT INTO orders (user_id, total, currency, status) VALUES ($1, 10, 'USD', 'pending') RETURNING id`,
+		userID).Scan(&orderID); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := r.BeginTx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if err := r.RedeemCouponWithTx(tx, couponID, userID, orderID, domain.NewMoney(100, "USD")); err != nil {
+		t.Fatal(err)
+	}
+	released, err := r.ReleaseCouponRedemptionsWithTx(tx, []int64{orderID})
+	if err != nil || released != 1 {
+		t.Fatalf("released %d, %v; want 1", released, err)
+	}
+	if count, err := r.CountCouponRedemptionsWithTx(tx, couponID, userID); err != nil || count != 0 {
+		t.Fatalf("redemptions %d, %v; want 0", count, err)
+	}
+	// Лимит в одно использование снова доступен
+	if err := r.RedeemCouponWithTx(tx, couponID, userID, orderID, domain.NewMoney(100, "USD")); err != nil {
+		t.Fatalf("redeem after release: %v", err)
+	}
+	if released, err := r.ReleaseCouponRedemptionsWithTx(tx, []int64{0}); err != nil || released != 0 {
+		t.Fatalf("released %d, %v for an order without coupons", released, err)
+	}
+}
+
+func TestListCouponsPage(t *testing.T) {
+	r := testRepository(t)
+	userID, productID := testProduct(t, r, 0)
+	var shopID int64
+	if err := r.db.Get(&shopID, `SELECT shop_id FROM products WHERE id = $1`, productID); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 3 {
+		if _, err := r.db.Exec(`INSERT INTO coupons (code, type, value, scope, shop_id, created_by) VALUES ('PAGE' || $1 || '_' || $3, 'percentage', 10, 'shop', $2, $1)`,
+			userID, shopID, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { _, _ = r.db.Exec(`DELETE FROM coupons WHERE shop_id = $1`, shopID) })
+
+	first, err := r.ListCoupons(&shopID, domain.PageRequest{Limit: 2, WithTotal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Items) != 2 || first.NextCursor == "" || first.Total == nil || *first.Total != 3 {
+		t.Fatalf("first page: %d items, cursor %q, total %v", len(first.Items), first.NextCursor, first.Total)
+	}
+	second, err := r.ListCoupons(&shopID, domain.PageRequest{Limit: 2, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second.Items) != 1 || second.NextCursor != "" || second.Items[0].ID >= first.Items[1].ID {
+		t.Fatalf("second page: %d items, cursor %q", len(second.Items), second.NextCursor)
+	}
+}
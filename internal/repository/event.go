@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+)
+
+const eventColumns = `id, event_id, type, aggregate_type, aggregate_id, payload, attempts, next_attempt_at, last_error, created_at, published_at`
+
+// AddOutboxEventWithTx записывает событие в outbox в транзакции изменения: событие будет
+// опубликовано, только если транзакция зафиксирована.
+func (r *Repository) AddOutboxEventWithTx(tx *sqlx.Tx, event *domain.Event) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "AddOutboxEventWithTx").Logger()
+	query := `INSERT INTO outbox_events (event_id, type, aggregate_type, aggregate_id, payload)
+	          VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`
+	err := tx.QueryRow(query, event.EventID, event.Type, event.AggregateType, event.AggregateID, jsonParam(event.Payload)).
+		Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		logger.Error().Err(err).Str("type", event.Type).Int64("aggregate_id", event.AggregateID).Msg("failed to add outbox event")
+		return r.translateError(err)
+	}
+	return nil
+}
+
+// LockPendingOutboxEventsWithTx блокирует до limit неопубликованных событий в порядке записи.
+// События, которые уже публикует другой экземпляр, пропускаются.
+func (r *Repository) LockPendingOutboxEventsWithTx(tx *sqlx.Tx, limit int) ([]domain.Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM outbox_events
+	          WHERE published_at IS NULL AND next_attempt_at <= NOW()
+	          ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED`
+	var dbEvents []db.Event
+	if err := tx.Select(&dbEvents, query, limit); err != nil {
+		return nil, r.translateError(err)
+	}
+	events := make([]domain.Event, len(dbEvents))
+	for i, e := range dbEvents {
+		events[i] = *e.ToDomain()
+	}
+	return events, nil
+}
+
+func (r *Repository) MarkOutboxEventPublishedWithTx(tx *sqlx.Tx, id int64) error {
+	query := `UPDATE outbox_events SET published_at = NOW(), attempts = attempts + 1, last_error = NULL WHERE id = $1`
+	if _, err := tx.Exec(query, id); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+// MarkOutboxEventFailedWithTx сохраняет ошибку публикации и откладывает следующую попытку до nextAttemptAt.
+func (r *Repository) MarkOutboxEventFailedWithTx(tx *sqlx.Tx, id int64, lastError string, nextAttemptAt time.Time) error {
+	query := `UPDATE outbox_events SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2 WHERE id = $3`
+	if _, err := tx.Exec(query, lastError, nextAttemptAt, id); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+func (r *Repository) IsEventProcessed(eventID, subscriber string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM processed_events WHERE event_id = $1 AND subscriber = $2)`
+	if err := r.db.Get(&exists, query, eventID, subscriber); err != nil {
+		return false, r.translateError(err)
+	}
+	return exists, nil
+}
+
+func (r *Repository) MarkEventProcessed(eventID, subscriber string) error {
+	query := `INSERT INTO processed_events (event_id, subscriber) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	if _, err := r.db.Exec(query, eventID, subscriber); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+// PurgeOutboxEvents удаляет опубликованные события и отметки обработки старше before.
+func (r *Repository) PurgeOutboxEvents(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM outbox_events WHERE published_at < $1`, before)
+	if err != nil {
+		return 0, r.translateError(err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, r.translateError(err)
+	}
+	if _, err := r.db.Exec(`DELETE FROM processed_events WHERE processed_at < $1`, before); err != nil {
+		return 0, r.translateError(err)
+	}
+	return deleted, nil
+}
+
+// NotifyStreamEvent отправляет событие потока обновлений всем экземплярам через NOTIFY.
+func (r *Repository) NotifyStreamEvent(payload string) error {
+	if _, err := r.db.Exec(`SELECT pg_notify($1, $2)`, domain.StreamNotifyChannel, payload); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
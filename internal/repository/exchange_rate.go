@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+
+	"github.com/rs/zerolog"
+)
+
+const exchangeRateColumns = `id, base_currency, quote_currency, rate, source, created_at, updated_at`
+
+func (r *Repository) UpsertExchangeRate(rate *domain.ExchangeRate) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "UpsertExchangeRate").Logger()
+	query := `INSERT INTO exchange_rates (base_currency, quote_currency, rate, source) VALUES ($1, $2, $3, $4)
+	          ON CONFLICT (base_currency, quote_currency) DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source, updated_at = NOW()
+	          RETURNING id, updated_at`
+	err := r.db.QueryRow(query, rate.BaseCurrency, rate.QuoteCurrency, rate.Rate, rate.Source).Scan(&rate.ID, &rate.UpdatedAt)
+	if err != nil {
+		logger.Error().Err(err).Str("base", rate.BaseCurrency).Str("quote", rate.QuoteCurrency).Msg("failed to upsert exchange rate")
+		return r.translateError(err)
+	}
+	return nil
+}
+
+func (r *Repository) ListExchangeRates() ([]domain.ExchangeRate, error) {
+	var dbRates []db.ExchangeRate
+	query := `SELECT ` + exchangeRateColumns + ` FROM exchange_rates ORDER BY base_currency, quote_currency`
+	if err := r.db.Select(&dbRates, query); err != nil {
+		return nil, r.translateError(err)
+	}
+	rates := make([]domain.ExchangeRate, 0, len(dbRates))
+	for _, rate := range dbRates {
+		rates = append(rates, *rate.ToDomain())
+	}
+	return rates, nil
+}
+
+// ListExchangeRatesPage возвращает страницу курсов, новые первыми (по убыванию id).
+func (r *Repository) ListExchangeRatesPage(page domain.PageRequest) (*domain.Page[domain.ExchangeRate], error) {
+	cursor, err := decodeCursor(page.Cursor, "")
+	if err != nil {
+		return nil, err
+	}
+	var dbRates []db.ExchangeRate
+	query := `SELECT ` + exchangeRateColumns + ` FROM exchange_rates WHERE $1 = 0 OR id < $1 ORDER BY id DESC LIMIT $2`
+	if err := r.db.Select(&dbRates, query, cursorID(cursor), page.Limit+1); err != nil {
+		return nil, r.translateError(err)
+	}
+	dbRates, nextCursor := trimPage(dbRates, page.Limit, func(rate db.ExchangeRate) pageCursor { return pageCursor{ID: rate.ID} })
+	result := &domain.Page[domain.ExchangeRate]{Items: make([]domain.ExchangeRate, 0, len(dbRates)), NextCursor: nextCursor}
+	for _, rate := range dbRates {
+		result.Items = append(result.Items, *rate.ToDomain())
+	}
+	if result.Total, err = r.countTotal(page.WithTotal, `SELECT COUNT(*) FROM exchange_rates`); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *Repository) DeleteExchangeRate(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM exchange_rates WHERE id = $1`, id)
+	if err != nil {
+		return r.translateError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected == 0 {
+		return errs.ErrExchangeRateNotFound
+	}
+	return nil
+}
+
+func (r *Repository) GetUserDisplayCurrency(userID int) (string, error) {
+	var currency *string
+	if err := r.db.Get(&currency, `SELECT display_currency FROM users WHERE id = $1`, userID); err != nil {
+		return "", r.translateError(err)
+	}
+	if currency == nil {
+		return "", nil
+	}
+	return *currency, nil
+}
+
+func (r *Repository) SetUserDisplayCurrency(userID int, currency string) error {
+	var value *string
+	if currency != "" {
+		value = &currency
+	}
+	result, err := r.db.Exec(`UPDATE users SET display_currency = $1, updated_at = NOW() WHERE id = $2`, value, userID)
+	if err != nil {
+		return r.translateError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected == 0 {
+		return errs.ErrUserNotFound
+	}
+	return nil
+}
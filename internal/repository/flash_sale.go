@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+const flashSaleColumns = `id, product_id, allocated_quantity, slot_count, per_user_limit, status, starts_at, ends_at, returned_quantity, created_by, created_at, finished_at`
+
+// CreateFlashSaleWithTx создает распродажу и делит выделенный остаток на слоты поровну.
+// Остаток товара должен быть списан в той же транзакции.
+func (r *Repository) CreateFlashSaleWithTx(tx *sqlx.Tx, sale *domain.FlashSale) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateFlashSaleWithTx").Logger()
+	query := `INSERT INTO flash_sales (product_id, allocated_quantity, slot_count, per_user_limit, status, starts_at, ends_at, created_by)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at`
+	err := tx.QueryRow(query, sale.ProductID, sale.AllocatedQuantity, sale.SlotCount, sale.PerUserLimit, sale.Status, sale.StartsAt, sale.EndsAt, sale.CreatedBy).
+		Scan(&sale.ID, &sale.CreatedAt)
+	if err != nil {
+		logger.Error().Err(err).Int64("product_id", sale.ProductID).Msg("failed to create flash sale")
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return errs.ErrFlashSaleAlreadyExists
+		}
+		return r.translateError(err)
+	}
+	slotsQuery := `INSERT INTO flash_sale_slots (flash_sale_id, slot, remaining)
+	               SELECT $1, s, $2 / $3 + CASE WHEN s < $2 % $3 THEN 1 ELSE 0 END FROM generate_series(0, $3 - 1) AS s`
+	if _, err := tx.Exec(slotsQuery, sale.ID, sale.AllocatedQuantity, sale.SlotCount); err != nil {
+		logger.Error().Err(err).Int64("flash_sale_id", sale.ID).Msg("failed to create flash sale slots")
+		return r.translateError(err)
+	}
+	return nil
+}
+
+func (r *Repository) GetFlashSaleByID(id int64) (*domain.FlashSale, error) {
+	var dbSale db.FlashSale
+	if err := r.db.Get(&dbSale, `SELECT `+flashSaleColumns+` FROM flash_sales WHERE id = $1`, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbSale.ToDomain(), nil
+}
+
+func (r *Repository) GetFlashSaleByIDForUpdateWithTx(tx *sqlx.Tx, id int64) (*domain.FlashSale, error) {
+	var dbSale db.FlashSale
+	if err := tx.Get(&dbSale, `SELECT `+flashSaleColumns+` FROM flash_sales WHERE id = $1 FOR UPDATE`, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbSale.ToDomain(), nil
+}
+
+// ListActiveFlashSales возвращает незавершенные распродажи товаров.
+func (r *Repository) ListActiveFlashSales(productIDs []int64) ([]*domain.FlashSale, error) {
+	var dbSales []db.FlashSale
+	query := `SELECT ` + flashSaleColumns + ` FROM flash_sales WHERE product_id = ANY($1) AND status = 'active'`
+	if err := r.db.Select(&dbSales, query, pq.Array(productIDs)); err != nil {
+		return nil, r.translateError(err)
+	}
+	sales := make([]*domain.FlashSale, 0, len(dbSales))
+	for _, s := range dbSales {
+		sales = append(sales, s.ToDomain())
+	}
+	return sales, nil
+}
+
+type flashSaleSlot struct {
+	Slot      int `db:"slot"`
+	Remaining int `db:"remaining"`
+}
+
+// ReserveFlashSaleStockWithTx списывает quantity из слотов распродажи. Сначала берутся свободные
+// слоты в случайном порядке (SKIP LOCKED), и только если их не хватило - все слоты с ожиданием
+// блокировки в порядке номеров. false означает, что остатка распродажи недостаточно.
+func (r *Repository) ReserveFlashSaleStockWithTx(tx *sqlx.Tx, saleID int64, quantity int) (bool, error) {
+	if _, err := tx.Exec(`SAVEPOINT flash_sale_slots`); err != nil {
+		return false, r.translateError(err)
+	}
+	var free []flashSaleSlot
+	query := `SELECT slot, remaining FROM flash_sale_slots
+	          WHERE flash_sale_id = $1 AND remaining > 0 ORDER BY random() LIMIT $2 FOR UPDATE SKIP LOCKED`
+	// В каждом слоте остаток не меньше 1, поэтому больше quantity слотов не понадобится.
+	if err := tx.Select(&free, query, saleID, quantity); err != nil {
+		return false, r.translateError(err)
+	}
+	taken := make(map[int]int)
+	need := takeFromSlots(free, quantity, taken)
+	if need > 0 {
+		// Ждать чужие слоты, удерживая взятые в случайном порядке, - риск взаимоблокировки
+		// двух покупателей. Откат к точке сохранения снимает блокировки первого прохода, и
+		// слоты блокируются заново в одном порядке, как товары в LockProductsWithTx.
+		if _, err := tx.Exec(`ROLLBACK TO SAVEPOINT flash_sale_slots`); err != nil {
+			return false, r.translateError(err)
+		}
+		var all []flashSaleSlot
+		query := `SELECT slot, remaining FROM flash_sale_slots
+		          WHERE flash_sale_id = $1 AND remaining > 0 ORDER BY slot FOR UPDATE`
+		if err := tx.Select(&all, query, saleID); err != nil {
+			return false, r.translateError(err)
+		}
+		taken = make(map[int]int)
+		need = takeFromSlots(all, quantity, taken)
+	}
+	if _, err := tx.Exec(`RELEASE SAVEPOINT flash_sale_slots`); err != nil {
+		return false, r.translateError(err)
+	}
+	if need > 0 {
+		return false, nil
+	}
+	slots := make([]int64, 0, len(taken))
+	amounts := make([]int64, 0, len(taken))
+	for slot, amount := range taken {
+		slots = append(slots, int64(slot))
+		amounts = append(amounts, int64(amount))
+	}
+	update := `UPDATE flash_sale_slots f SET remaining = f.remaining - s.amount
+	           FROM unnest($2::int[], $3::int[]) AS s(slot, amount)
+	           WHERE f.flash_sale_id = $1 AND f.slot = s.slot`
+	if _, err := tx.Exec(update, saleID, pq.Array(slots), pq.Array(amounts)); err != nil {
+		return false, r.translateError(err)
+	}
+	return true, nil
+}
+
+// takeFromSlots распределяет need по слотам и возвращает недостающее количество.
+func takeFromSlots(slots []flashSaleSlot, need int, taken map[int]int) int {
+	for _, slot := range slots {
+		if need == 0 {
+			break
+		}
+		amount := min(slot.Remaining, need)
+		taken[slot.Slot] += amount
+		need -= amount
+	}
+	return need
+}
+
+// AddFlashSaleUserQuantityWithTx атомарно увеличивает купленное пользователем количество,
+// если оно не превысит limit. false означает, что лимит на покупателя исчерпан.
+func (r *Repository) AddFlashSaleUserQuantityWithTx(tx *sqlx.Tx, saleID, userID int64, quantity, limit int) (bool, error) {
+	query := `INSERT INTO flash_sale_user_totals AS t (flash_sale_id, user_id, quantity)
+	          SELECT $1::int, $2::int, $3::int WHERE $3::int <= $4::int
+	          ON CONFLICT (flash_sale_id, user_id) DO UPDATE SET quantity = t.quantity + EXCLUDED.quantity
+	          WHERE t.quantity + EXCLUDED.quantity <= $4::int`
+	result, err := tx.Exec(query, saleID, userID, quantity, limit)
+	if err != nil {
+		return false, r.translateError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, r.translateError(err)
+	}
+	return rowsAffected == 1, nil
+}
+
+// FinishFlashSaleWithTx завершает распродажу и возвращает нераспроданный остаток слотов
+// в products.quantity. Строка распродажи должна быть заблокирована вызывающим.
+func (r *Repository) FinishFlashSaleWithTx(tx *sqlx.Tx, sale *domain.FlashSale) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "FinishFlashSaleWithTx").Logger()
+	var returned int
+	query := `SELECT COALESCE(SUM(remaining), 0) FROM (SELECT remaining FROM flash_sale_slots WHERE flash_sale_id = $1 FOR UPDATE) s`
+	if err := tx.Get(&returned, query, sale.ID); err != nil {
+		logger.Error().Err(err).Int64("flash_sale_id", sale.ID).Msg("failed to lock flash sale slots")
+		return r.translateError(err)
+	}
+	if _, err := tx.Exec(`UPDATE flash_sale_slots SET remaining = 0 WHERE flash_sale_id = $1`, sale.ID); err != nil {
+		return r.translateError(err)
+	}
+	if _, err := tx.Exec(`UPDATE products SET quantity = quantity + $1, version = version + 1 WHERE id = $2`, returned, sale.ProductID); err != nil {
+		return r.translateError(err)
+	}
+	err := tx.QueryRow(`UPDATE flash_sales SET status = $1, returned_quantity = returned_quantity + $2, finished_at = NOW() WHERE id = $3 RETURNING finished_at`,
+		domain.FlashSaleStatusFinished, returned, sale.ID).Scan(&sale.FinishedAt)
+	if err != nil {
+		return r.translateError(err)
+	}
+	sale.Status = domain.FlashSaleStatusFinished
+	sale.ReturnedQuantity += returned
+	return nil
+}
+
+// ReturnFlashSaleStockWithTx возвращает количество отмененной покупки: в слоты идущей распродажи
+// или в products.quantity, если распродажа уже завершена. Купленное пользователем уменьшается.
+func (r *Repository) ReturnFlashSaleStockWithTx(tx *sqlx.Tx, saleID, userID int64, quantity int) error {
+	var sale db.FlashSale
+	// FOR SHARE сериализует возврат с завершением распродажи (FOR UPDATE)
+	if err := tx.Get(&sale, `SELECT `+flashSaleColumns+` FROM flash_sales WHERE id = $1 FOR SHARE`, saleID); err != nil {
+		return r.translateError(err)
+	}
+	_, err := tx.Exec(`UPDATE flash_sale_user_totals SET quantity = GREATEST(quantity - $1, 0) WHERE flash_sale_id = $2 AND user_id = $3`,
+		quantity, saleID, userID)
+	if err != nil {
+		return r.translateError(err)
+	}
+	if sale.Status == domain.FlashSaleStatusActive {
+		query := `UPDATE flash_sale_slots SET remaining = remaining + $1
+		          WHERE flash_sale_id = $2 AND slot = (SELECT MIN(slot) FROM flash_sale_slots WHERE flash_sale_id = $2)`
+		if _, err := tx.Exec(query, quantity, saleID); err != nil {
+			return r.translateError(err)
+		}
+		return nil
+	}
+	if _, err := tx.Exec(`UPDATE flash_sales SET returned_quantity = returned_quantity + $1 WHERE id = $2`, quantity, saleID); err != nil {
+		return r.translateError(err)
+	}
+	if _, err := tx.Exec(`UPDATE products SET quantity = quantity + $1, version = version + 1 WHERE id = $2`, quantity, sale.ProductID); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+// GetFlashSaleReport сверяет остаток распродажи: выделено = продано + в слотах + возвращено.
+func (r *Repository) GetFlashSaleReport(id int64) (*domain.FlashSaleReport, error) {
+	sale, err := r.GetFlashSaleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	report := &domain.FlashSaleReport{FlashSale: *sale}
+	var totals struct {
+		Sold   int `db:"sold"`
+		Buyers int `db:"buyers"`
+	}
+	if err := r.db.Get(&totals, `SELECT COALESCE(SUM(quantity), 0) AS sold, COUNT(*) FILTER (WHERE quantity > 0) AS buyers FROM flash_sale_user_totals WHERE flash_sale_id = $1`, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	if err := r.db.Get(&report.RemainingQuantity, `SELECT COALESCE(SUM(remaining), 0) FROM flash_sale_slots WHERE flash_sale_id = $1`, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	report.SoldQuantity = totals.Sold
+	report.Buyers = totals.Buyers
+	report.Consistent = report.AllocatedQuantity == report.SoldQuantity+report.RemainingQuantity+report.ReturnedQuantity
+	return report, nil
+}
+
+// ListEndedFlashSaleIDs возвращает незавершенные распродажи, у которых истек ends_at.
+func (r *Repository) ListEndedFlashSaleIDs() ([]int64, error) {
+	var ids []int64
+	if err := r.db.Select(&ids, `SELECT id FROM flash_sales WHERE status = 'active' AND ends_at <= NOW() ORDER BY id`); err != nil {
+		return nil, r.translateError(err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"marketplace/internal/models/domain"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testFlashSale(tb testing.TB, r *Repository, quantity, slots int) int64 {
+	tb.Helper()
+	userID, productID := testProduct(tb, r, 0)
+	sale := &domain.FlashSale{
+		ProductID:         productID,
+		AllocatedQuantity: quantity,
+		SlotCount:         slots,
+		PerUserLimit:      quantity,
+		Status:            domain.FlashSaleStatusActive,
+		StartsAt:          time.Now().Add(-time.Minute),
+		EndsAt:            time.Now().Add(time.Hour),
+		CreatedBy:         userID,
+	}
+	tx, err := r.BeginTx()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := r.CreateFlashSaleWithTx(tx, sale); err != nil {
+		tb.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		tb.Fatal(err)
+	}
+	return sale.ID
+}
+
+// Покупки больше одного слота уходят во второй проход с ожиданием блокировок. Встречные
+// покупки не должны взаимоблокироваться, а остаток - расходиться с проданным.
+func TestReserveFlashSaleStockConcurrent(t *testing.T) {
+	r := testRepository(t)
+	const slots, perSlot, buyers, perBuyer = 8, 10, 16, 5
+	saleID := testFlashSale(t, r, slots*perSlot, slots)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var sold int
+	for range buyers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx, err := r.BeginTx()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer tx.Rollback()
+			ok, err := r.ReserveFlashSaleStockWithTx(tx, saleID, perBuyer)
+			if err != nil {
+				t.Errorf("reserve: %v", err)
+				return
+			}
+			// Держим блокировки, чтобы покупатели пересекались на слотах
+			time.Sleep(20 * time.Millisecond)
+			if err := tx.Commit(); err != nil {
+				t.Errorf("commit: %v", err)
+				return
+			}
+			if ok {
+				mu.Lock()
+				sold += perBuyer
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var remaining int
+	if err := r.db.Get(&remaining, `SELECT SUM(remaining) FROM flash_sale_slots WHERE flash_sale_id = $1`, saleID); err != nil {
+		t.Fatal(err)
+	}
+	if sold+remaining != slots*perSlot {
+		t.Errorf("sold %d + remaining %d != allocated %d", sold, remaining, slots*perSlot)
+	}
+	if sold != min(buyers*perBuyer, slots*perSlot) {
+		t.Errorf("sold %d, want %d", sold, min(buyers*perBuyer, slots*perSlot))
+	}
+}
+
+// BenchmarkReserveStock сравнивает списание из слотов распродажи со списанием из строки товара
+// (LockProductsWithTx + DecreaseProductQuantitiesWithTx) при параллельных покупках одного товара.
+func BenchmarkReserveStock(b *testing.B) {
+	r := testRepository(b)
+	const stock = 1 << 30
+
+	b.Run("FlashSaleSlots", func(b *testing.B) {
+		saleID := testFlashSale(b, r, stock, 16)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				tx, err := r.BeginTx()
+				if err != nil {
+					b.Error(err)
+					return
+				}
+				if ok, err := r.ReserveFlashSaleStockWithTx(tx, saleID, 1); err != nil || !ok {
+					b.Errorf("reserve: %v, %v", ok, err)
+				}
+				if err := tx.Commit(); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+	})
+
+	b.Run("LockProductsWithTx", func(b *testing.B) {
+		_, productID := testProduct(b, r, stock)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				tx, err := r.BeginTx()
+				if err != nil {
+					b.Error(err)
+					return
+				}
+				if _, err := r.LockProductsWithTx(tx, []int64{productID}); err != nil {
+					b.Errorf("lock: %v", err)
+				}
+				if err := r.DecreaseProductQuantitiesWithTx(tx, map[int64]int{productID: 1}); err != nil {
+					b.Errorf("decrease: %v", err)
+				}
+				if err := tx.Commit(); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+
+	"github.com/rs/zerolog"
+)
+
+// CreateIdempotencyKey резервирует ключ за запросом. Просроченный ключ с тем же значением
+// удаляется; false означает, что действующий ключ уже существует.
+func (r *Repository) CreateIdempotencyKey(record *domain.IdempotencyRecord) (bool, error) {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateIdempotencyKey").Logger()
+	if _, err := r.db.Exec(`DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND expires_at <= NOW()`, record.UserID, record.Key); err != nil {
+		return false, r.translateError(err)
+	}
+	query := `INSERT INTO idempotency_keys (user_id, key, method, path, fingerprint, status, expires_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7)
+	          ON CONFLICT (user_id, key) DO NOTHING
+	          RETURNING id, created_at`
+	err := r.db.QueryRow(query, record.UserID, record.Key, record.Method, record.Path, record.Fingerprint, record.Status, record.ExpiresAt).
+		Scan(&record.ID, &record.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		logger.Error().Err(err).Int64("user_id", record.UserID).Msg("failed to create idempotency key")
+		return false, r.translateError(err)
+	}
+	return true, nil
+}
+
+func (r *Repository) GetIdempotencyKey(userID int64, key string) (*domain.IdempotencyRecord, error) {
+	var dbRecord db.IdempotencyRecord
+	query := `SELECT id, user_id, key, method, path, fingerprint, status, response_status, response_body, content_type, created_at, expires_at
+	          FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND expires_at > NOW()`
+	if err := r.db.Get(&dbRecord, query, userID, key); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbRecord.ToDomain(), nil
+}
+
+func (r *Repository) CompleteIdempotencyKey(id int64, status int, body []byte, contentType string) error {
+	query := `UPDATE idempotency_keys SET status = $1, response_status = $2, response_body = $3, content_type = $4 WHERE id = $5`
+	if _, err := r.db.Exec(query, domain.IdempotencyStatusCompleted, status, body, contentType, id); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+func (r *Repository) DeleteIdempotencyKey(id int64) error {
+	if _, err := r.db.Exec(`DELETE FROM idempotency_keys WHERE id = $1`, id); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+// PurgeExpiredIdempotencyKeys удаляет истекшие ключи и возвращает их количество.
+func (r *Repository) PurgeExpiredIdempotencyKeys() (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, r.translateError(err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, r.translateError(err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"errors"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+const imageColumns = `id, product_id, variant_id, blob_key, content_type, size_bytes, width, height, thumbnails, status, position, is_primary, alt, created_at, updated_at`
+
+// queueDeletedImageBlobs ставит в очередь удаления оригиналы и миниатюры строк CTE deleted.
+const queueDeletedImageBlobs = `INSERT INTO blob_deletions (blob_key)
+	SELECT blob_key FROM deleted
+	UNION ALL
+	SELECT t->>'key' FROM deleted, jsonb_array_elements(deleted.thumbnails) t WHERE t->>'key' IS NOT NULL`
+
+func (r *Repository) CreateProductImageWithTx(tx *sqlx.Tx, image *domain.ProductImage) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateProductImageWithTx").Logger()
+	dbImage := db.ProductImage{}
+	dbImage.FromDomain(image)
+	query := `INSERT INTO product_images (product_id, variant_id, blob_key, content_type, size_bytes, width, height, thumbnails,
+	          status, position, is_primary, alt, created_at, updated_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $13) RETURNING id, created_at, updated_at`
+	err := tx.QueryRow(query, dbImage.ProductID, dbImage.VariantID, dbImage.BlobKey, dbImage.ContentType, dbImage.SizeBytes,
+		dbImage.Width, dbImage.Height, jsonParam(dbImage.Thumbnails), dbImage.Status, dbImage.Position, dbImage.IsPrimary,
+		dbImage.Alt, time.Now()).Scan(&image.ID, &image.CreatedAt, &image.UpdatedAt)
+	if err != nil {
+		logger.Error().Err(err).Int64("product_id", image.ProductID).Msg("failed to create product image")
+		return r.translateError(err)
+	}
+	return nil
+}
+
+// ListProductImages возвращает изображения товаров в порядке position.
+func (r *Repository) ListProductImages(productIDs []int64) (map[int64][]domain.ProductImage, error) {
+	var dbImages []db.ProductImage
+	query := `SELECT ` + imageColumns + ` FROM product_images WHERE product_id = ANY($1) ORDER BY product_id, position, id`
+	if err := r.db.Select(&dbImages, query, pq.Array(productIDs)); err != nil {
+		return nil, r.translateError(err)
+	}
+	images := make(map[int64][]domain.ProductImage)
+	for _, i := range dbImages {
+		images[i.ProductID] = append(images[i.ProductID], *i.ToDomain())
+	}
+	return images, nil
+}
+
+// LockProductImagesWithTx блокирует изображения товара и возвращает их в порядке position.
+func (r *Repository) LockProductImagesWithTx(tx *sqlx.Tx, productID int64) ([]*domain.ProductImage, error) {
+	var dbImages []db.ProductImage
+	query := `SELECT ` + imageColumns + ` FROM product_images WHERE product_id = $1 ORDER BY position, id FOR UPDATE`
+	if err := tx.Select(&dbImages, query, productID); err != nil {
+		return nil, r.translateError(err)
+	}
+	images := make([]*domain.ProductImage, len(dbImages))
+	for i, image := range dbImages {
+		images[i] = image.ToDomain()
+	}
+	return images, nil
+}
+
+func (r *Repository) GetProductImageByID(id int64) (*domain.ProductImage, error) {
+	var dbImage db.ProductImage
+	query := `SELECT ` + imageColumns + ` FROM product_images WHERE id = $1`
+	if err := r.db.Get(&dbImage, query, id); err != nil {
+		return nil, r.translateImageError(err)
+	}
+	return dbImage.ToDomain(), nil
+}
+
+// UpdateProductImageWithTx сохраняет alt и привязку к варианту.
+func (r *Repository) UpdateProductImageWithTx(tx *sqlx.Tx, image *domain.ProductImage) error {
+	query := `UPDATE product_images SET alt = $1, variant_id = $2, updated_at = $3 WHERE id = $4 RETURNING updated_at`
+	if err := tx.QueryRow(query, image.Alt, image.VariantID, time.Now(), image.ID).Scan(&image.UpdatedAt); err != nil {
+		return r.translateImageError(err)
+	}
+	return nil
+}
+
+// SetPrimaryProductImageWithTx делает изображение главным. Прежнее главное сбрасывается первым
+// запросом: уникальный индекс по главному изображению проверяется построчно.
+func (r *Repository) SetPrimaryProductImageWithTx(tx *sqlx.Tx, productID, imageID int64) error {
+	now := time.Now()
+	if _, err := tx.Exec(`UPDATE product_images SET is_primary = false, updated_at = $1 WHERE product_id = $2 AND is_primary AND id <> $3`,
+		now, productID, imageID); err != nil {
+		return r.translateError(err)
+	}
+	result, err := tx.Exec(`UPDATE product_images SET is_primary = true, updated_at = $1 WHERE product_id = $2 AND id = $3`, now, productID, imageID)
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return r.translateError(err)
+	} else if rowsAffected == 0 {
+		return errs.ErrImageNotFound
+	}
+	return nil
+}
+
+// SetProductImagePositionsWithTx нумерует изображения по порядку ids.
+func (r *Repository) SetProductImagePositionsWithTx(tx *sqlx.Tx, ids []int64) error {
+	query := `UPDATE product_images i SET position = s.position - 1, updated_at = $2
+	          FROM unnest($1::bigint[]) WITH ORDINALITY AS s(id, position)
+	          WHERE i.id = s.id`
+	if _, err := tx.Exec(query, pq.Array(ids), time.Now()); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+// SetProductImageThumbnails сохраняет миниатюры и статус обработки. Если изображение уже удалено,
+// возвращается ErrImageNotFound.
+func (r *Repository) SetProductImageThumbnails(id int64, thumbnails []domain.ImageThumbnail, status string) error {
+	// Миниатюры входят в карточку товара, поэтому версия товара тоже увеличивается.
+	query := `WITH updated AS (
+	              UPDATE product_images SET thumbnails = $1, status = $2, updated_at = $3 WHERE id = $4 RETURNING product_id
+	          ), touched AS (
+	              UPDATE products SET version = version + 1 WHERE id IN (SELECT product_id FROM updated)
+	          )
+	          SELECT COUNT(*) FROM updated`
+	var updated int
+	if err := r.db.Get(&updated, query, jsonParam(db.MarshalThumbnails(thumbnails)), status, time.Now(), id); err != nil {
+		return r.translateError(err)
+	}
+	if updated == 0 {
+		return errs.ErrImageNotFound
+	}
+	return nil
+}
+
+// DeleteProductImageWithTx удаляет изображение и ставит его файлы в очередь удаления.
+func (r *Repository) DeleteProductImageWithTx(tx *sqlx.Tx, id int64) error {
+	query := `WITH deleted AS (DELETE FROM product_images WHERE id = $1 RETURNING blob_key, thumbnails) ` + queueDeletedImageBlobs
+	result, err := tx.Exec(query, id)
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return r.translateError(err)
+	} else if rowsAffected == 0 {
+		return errs.ErrImageNotFound
+	}
+	return nil
+}
+
+// DeleteProductImagesWithTx удаляет все изображения товара и ставит их файлы в очередь удаления.
+func (r *Repository) DeleteProductImagesWithTx(tx *sqlx.Tx, productID int64) (int64, error) {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "DeleteProductImagesWithTx").Logger()
+	query := `WITH deleted AS (DELETE FROM product_images WHERE product_id = $1 RETURNING blob_key, thumbnails) ` + queueDeletedImageBlobs
+	result, err := tx.Exec(query, productID)
+	if err != nil {
+		logger.Error().Err(err).Int64("product_id", productID).Msg("failed to delete product images")
+		return 0, r.translateError(err)
+	}
+	queued, err := result.RowsAffected()
+	if err != nil {
+		return 0, r.translateError(err)
+	}
+	return queued, nil
+}
+
+// QueueBlobDeletion ставит файлы в очередь удаления вне транзакции.
+func (r *Repository) QueueBlobDeletion(keys []string) error {
+	query := `INSERT INTO blob_deletions (blob_key) SELECT unnest($1::text[])`
+	if _, err := r.db.Exec(query, pq.Array(keys)); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+// ListBlobDeletions возвращает до limit файлов из очереди удаления, начиная с реже неудачных.
+func (r *Repository) ListBlobDeletions(limit, maxAttempts int) ([]domain.BlobDeletion, error) {
+	var rows []struct {
+		ID       int64  `db:"id"`
+		Key      string `db:"blob_key"`
+		Attempts int    `db:"attempts"`
+	}
+	query := `SELECT id, blob_key, attempts FROM blob_deletions WHERE attempts < $1 ORDER BY attempts, id LIMIT $2`
+	if err := r.db.Select(&rows, query, maxAttempts, limit); err != nil {
+		return nil, r.translateError(err)
+	}
+	deletions := make([]domain.BlobDeletion, len(rows))
+	for i, row := range rows {
+		deletions[i] = domain.BlobDeletion{ID: row.ID, Key: row.Key, Attempts: row.Attempts}
+	}
+	return deletions, nil
+}
+
+func (r *Repository) DeleteBlobDeletions(ids []int64) error {
+	if _, err := r.db.Exec(`DELETE FROM blob_deletions WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+func (r *Repository) IncrementBlobDeletionAttempts(ids []int64) error {
+	if _, err := r.db.Exec(`UPDATE blob_deletions SET attempts = attempts + 1 WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+func (r *Repository) translateImageError(err error) error {
+	if err = r.translateError(err); errors.Is(err, errs.ErrNotfound) {
+		return errs.ErrImageNotFound
+	}
+	return err
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+)
+
+const jobColumns = `id, type, payload, status, attempts, max_attempts, run_at, locked_at, locked_by, last_error, schedule_id, created_at, updated_at, completed_at`
+
+const jobScheduleColumns = `id, name, job_type, payload, cron, active, next_run_at, last_run_at, created_at, updated_at`
+
+func (r *Repository) CreateJob(job *domain.Job) error {
+	return r.createJob(r.db, job)
+}
+
+// CreateJobWithTx ставит задачу в очередь в транзакции вызывающего: задача появится,
+// только если транзакция зафиксирована.
+func (r *Repository) CreateJobWithTx(tx *sqlx.Tx, job *domain.Job) error {
+	return r.createJob(tx, job)
+}
+
+func (r *Repository) createJob(q sqlx.Queryer, job *domain.Job) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateJob").Logger()
+	query := `INSERT INTO jobs (type, payload, status, max_attempts, run_at, schedule_id)
+	          VALUES ($1, $2, $3, $4, $5, $6) RETURNING ` + jobColumns
+	var dbJob db.Job
+	err := sqlx.Get(q, &dbJob, query, job.Type, jsonParam(job.Payload), job.Status, job.MaxAttempts, job.RunAt, job.ScheduleID)
+	if err != nil {
+		logger.Error().Err(err).Str("type", job.Type).Msg("failed to create job")
+		return r.translateError(err)
+	}
+	*job = *dbJob.ToDomain()
+	return nil
+}
+
+// ClaimJobs забирает до limit готовых задач воркеру workerID. Задачи, зависшие в running дольше
+// lockTimeout (упавший экземпляр), забираются повторно. SKIP LOCKED не дает двум воркерам взять одну задачу.
+func (r *Repository) ClaimJobs(workerID string, limit int, lockTimeout time.Duration) ([]domain.Job, error) {
+	query := `UPDATE jobs SET status = 'running', attempts = attempts + 1, locked_at = NOW(), locked_by = $1, updated_at = NOW()
+	          WHERE id IN (
+	              SELECT id FROM jobs
+	              WHERE (status = 'pending' AND run_at <= NOW())
+	                 OR (status = 'running' AND locked_at < NOW() - make_interval(secs => $2))
+	              ORDER BY run_at LIMIT $3
+	              FOR UPDATE SKIP LOCKED)
+	          RETURNING ` + jobColumns
+	var dbJobs []db.Job
+	if err := r.db.Select(&dbJobs, query, workerID, lockTimeout.Seconds(), limit); err != nil {
+		return nil, r.translateError(err)
+	}
+	jobs := make([]domain.Job, len(dbJobs))
+	for i, j := range dbJobs {
+		jobs[i] = *j.ToDomain()
+	}
+	return jobs, nil
+}
+
+// CompleteJob отмечает задачу выполненной, если она все еще принадлежит воркеру.
+func (r *Repository) CompleteJob(id int64, workerID string) error {
+	query := `UPDATE jobs SET status = 'completed', completed_at = NOW(), locked_at = NULL, locked_by = NULL, last_error = NULL, updated_at = NOW()
+	          WHERE id = $1 AND status = 'running' AND locked_by = $2`
+	if _, err := r.db.Exec(query, id, workerID); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+// FailJob сохраняет ошибку задачи: при retryAt != nil задача вернется в очередь в это время,
+// иначе переводится в dead.
+func (r *Repository) FailJob(id int64, workerID, lastError string, retryAt *time.Time) error {
+	status := domain.JobStatusDead
+	runAt := time.Now()
+	if retryAt != nil {
+		status = domain.JobStatusPending
+		runAt = *retryAt
+	}
+	query := `UPDATE jobs SET status = $1, run_at = $2, last_error = $3, locked_at = NULL, locked_by = NULL, updated_at = NOW()
+	          WHERE id = $4 AND status = 'running' AND locked_by = $5`
+	if _, err := r.db.Exec(query, status, runAt, lastError, id, workerID); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+func (r *Repository) GetJobByID(id int64) (*domain.Job, error) {
+	var dbJob db.Job
+	if err := r.db.Get(&dbJob, `SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbJob.ToDomain(), nil
+}
+
+func (r *Repository) ListJobs(filter domain.JobFilter) (*domain.Page[domain.Job], error) {
+	cursor, err := decodeCursor(filter.Cursor, "")
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + jobColumns + ` FROM jobs
+	          WHERE ($1 = '' OR status = $1) AND ($2 = '' OR type = $2) AND ($3 = 0 OR id < $3)
+	          ORDER BY id DESC LIMIT $4`
+	var dbJobs []db.Job
+	if err := r.db.Select(&dbJobs, query, filter.Status, filter.Type, cursorID(cursor), filter.Limit+1); err != nil {
+		return nil, r.translateError(err)
+	}
+	dbJobs, nextCursor := trimPage(dbJobs, filter.Limit, func(j db.Job) pageCursor { return pageCursor{ID: j.ID} })
+	page := &domain.Page[domain.Job]{Items: make([]domain.Job, len(dbJobs)), NextCursor: nextCursor}
+	for i, j := range dbJobs {
+		page.Items[i] = *j.ToDomain()
+	}
+	page.Total, err = r.countTotal(filter.WithTotal, `SELECT COUNT(*) FROM jobs WHERE ($1 = '' OR status = $1) AND ($2 = '' OR type = $2)`,
+		filter.Status, filter.Type)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// RetryJob возвращает мертвую или отмененную задачу в очередь с новым счетчиком попыток.
+func (r *Repository) RetryJob(id int64) (*domain.Job, error) {
+	query := `UPDATE jobs SET status = 'pending', attempts = 0, run_at = NOW(), last_error = NULL, updated_at = NOW()
+	          WHERE id = $1 AND status IN ('dead', 'cancelled') RETURNING ` + jobColumns
+	var dbJob db.Job
+	if err := r.db.Get(&dbJob, query, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbJob.ToDomain(), nil
+}
+
+// CancelJob отменяет задачу, ожидающую выполнения; выполняющуюся задачу отменить нельзя.
+func (r *Repository) CancelJob(id int64) (*domain.Job, error) {
+	query := `UPDATE jobs SET status = 'cancelled', updated_at = NOW()
+	          WHERE id = $1 AND status = 'pending' RETURNING ` + jobColumns
+	var dbJob db.Job
+	if err := r.db.Get(&dbJob, query, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbJob.ToDomain(), nil
+}
+
+func (r *Repository) ListJobSchedules() ([]domain.JobSchedule, error) {
+	var dbSchedules []db.JobSchedule
+	if err := r.db.Select(&dbSchedules, `SELECT `+jobScheduleColumns+` FROM job_schedules ORDER BY name`); err != nil {
+		return nil, r.translateError(err)
+	}
+	schedules := make([]domain.JobSchedule, len(dbSchedules))
+	for i, s := range dbSchedules {
+		schedules[i] = *s.ToDomain()
+	}
+	return schedules, nil
+}
+
+// ListDueJobSchedulesWithTx блокирует активные расписания, время запуска которых наступило.
+// Расписание, уже обрабатываемое другим экземпляром, пропускается.
+func (r *Repository) ListDueJobSchedulesWithTx(tx *sqlx.Tx) ([]domain.JobSchedule, error) {
+	var dbSchedules []db.JobSchedule
+	query := `SELECT ` + jobScheduleColumns + ` FROM job_schedules
+	          WHERE active AND next_run_at <= NOW() ORDER BY next_run_at FOR UPDATE SKIP LOCKED`
+	if err := tx.Select(&dbSchedules, query); err != nil {
+		return nil, r.translateError(err)
+	}
+	schedules := make([]domain.JobSchedule, len(dbSchedules))
+	for i, s := range dbSchedules {
+		schedules[i] = *s.ToDomain()
+	}
+	return schedules, nil
+}
+
+// UpdateJobScheduleRunWithTx сдвигает расписание на следующий запуск; nextRunAt == nil отключает его.
+func (r *Repository) UpdateJobScheduleRunWithTx(tx *sqlx.Tx, id int64, lastRunAt time.Time, nextRunAt *time.Time) error {
+	query := `UPDATE job_schedules SET last_run_at = $1, next_run_at = COALESCE($2, next_run_at), active = $2 IS NOT NULL, updated_at = NOW()
+	          WHERE id = $3`
+	result, err := tx.Exec(query, lastRunAt, nextRunAt, id)
+	if err != nil {
+		return r.translateError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected == 0 {
+		return errs.ErrNotfound
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+const notificationColumns = `id, user_id, event_id, event_type, channel, subject, body, status, last_error, digest_id, read_at, sent_at, created_at`
+
+const notificationTemplateColumns = `id, event_type, channel, locale, subject, body, created_at, updated_at`
+
+// CreateNotificationWithTx сохраняет уведомление. Повторная обработка того же события не создает
+// дубликат для пользователя и канала: возвращается false.
+func (r *Repository) CreateNotificationWithTx(tx *sqlx.Tx, notification *domain.Notification) (bool, error) {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateNotificationWithTx").Logger()
+	dbNotification := db.Notification{}
+	dbNotification.FromDomain(notification)
+	query := `INSERT INTO notifications (user_id, event_id, event_type, channel, subject, body, status, sent_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	          ON CONFLICT (user_id, event_id, channel) DO NOTHING RETURNING ` + notificationColumns
+	var created db.Notification
+	err := tx.Get(&created, query, dbNotification.UserID, dbNotification.EventID, dbNotification.EventType, dbNotification.Channel,
+		dbNotification.Subject, dbNotification.Body, dbNotification.Status, dbNotification.SentAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		logger.Error().Err(err).Int64("user_id", dbNotification.UserID).Str("event_type", dbNotification.EventType).Msg("failed to create notification")
+		return false, r.translateError(err)
+	}
+	*notification = *created.ToDomain()
+	return true, nil
+}
+
+func (r *Repository) GetNotificationByID(id int64) (*domain.Notification, error) {
+	var dbNotification db.Notification
+	if err := r.db.Get(&dbNotification, `SELECT `+notificationColumns+` FROM notifications WHERE id = $1`, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbNotification.ToDomain(), nil
+}
+
+// ListUserNotifications возвращает входящие (in_app) уведомления пользователя, новые первыми.
+func (r *Repository) ListUserNotifications(filter domain.NotificationFilter) (*domain.Page[domain.Notification], error) {
+	cursor, err := decodeCursor(filter.Cursor, "")
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + notificationColumns + ` FROM notifications
+	          WHERE user_id = $1 AND channel = 'in_app' AND (NOT $2 OR read_at IS NULL) AND ($3 = 0 OR id < $3)
+	          ORDER BY id DESC LIMIT $4`
+	var dbNotifications []db.Notification
+	if err := r.db.Select(&dbNotifications, query, filter.UserID, filter.UnreadOnly, cursorID(cursor), filter.Limit+1); err != nil {
+		return nil, r.translateError(err)
+	}
+	dbNotifications, nextCursor := trimPage(dbNotifications, filter.Limit, func(n db.Notification) pageCursor { return pageCursor{ID: n.ID} })
+	page := &domain.Page[domain.Notification]{Items: make([]domain.Notification, len(dbNotifications)), NextCursor: nextCursor}
+	for i, n := range dbNotifications {
+		page.Items[i] = *n.ToDomain()
+	}
+	page.Total, err = r.countTotal(filter.WithTotal, `SELECT COUNT(*) FROM notifications
+	          WHERE user_id = $1 AND channel = 'in_app' AND (NOT $2 OR read_at IS NULL)`, filter.UserID, filter.UnreadOnly)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (r *Repository) CountUnreadNotifications(userID int64) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND channel = 'in_app' AND read_at IS NULL`
+	if err := r.db.Get(&count, query, userID); err != nil {
+		return 0, r.translateError(err)
+	}
+	return count, nil
+}
+
+// MarkNotificationRead отмечает входящее уведомление прочитанным; чужое уведомление не найдется.
+func (r *Repository) MarkNotificationRead(id, userID int64) (*domain.Notification, error) {
+	query := `UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+	          WHERE id = $1 AND user_id = $2 AND channel = 'in_app' RETURNING ` + notificationColumns
+	var dbNotification db.Notification
+	if err := r.db.Get(&dbNotification, query, id, userID); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbNotification.ToDomain(), nil
+}
+
+func (r *Repository) MarkAllNotificationsRead(userID int64) (int64, error) {
+	query := `UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND channel = 'in_app' AND read_at IS NULL`
+	result, err := r.db.Exec(query, userID)
+	if err != nil {
+		return 0, r.translateError(err)
+	}
+	marked, err := result.RowsAffected()
+	if err != nil {
+		return 0, r.translateError(err)
+	}
+	return marked, nil
+}
+
+// UpdateNotificationStatus сохраняет результат отправки; при статусе sent заполняется sent_at.
+func (r *Repository) UpdateNotificationStatus(id int64, status, lastError string) error {
+	var lastErrorParam *string
+	if lastError != "" {
+		lastErrorParam = &lastError
+	}
+	query := `UPDATE notifications SET status = $1, last_error = $2,
+	              sent_at = CASE WHEN $1 = 'sent' THEN NOW() ELSE sent_at END
+	          WHERE id = $3`
+	result, err := r.db.Exec(query, status, lastErrorParam, id)
+	if err != nil {
+		return r.translateError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected == 0 {
+		return errs.ErrNotfound
+	}
+	return nil
+}
+
+// CountRecentNotificationsWithTx считает уведомления пользователя в канале, созданные после since.
+func (r *Repository) CountRecentNotificationsWithTx(tx *sqlx.Tx, userID int64, channel string, since time.Time) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND channel = $2 AND created_at >= $3`
+	if err := tx.Get(&count, query, userID, channel, since); err != nil {
+		return 0, r.translateError(err)
+	}
+	return count, nil
+}
+
+// ListNotificationDigestGroups возвращает пользователей и каналы, у которых есть уведомления для сводки.
+func (r *Repository) ListNotificationDigestGroups() ([]domain.NotificationDigestGroup, error) {
+	var dbGroups []db.NotificationDigestGroup
+	query := `SELECT DISTINCT user_id, channel FROM notifications WHERE status = 'digest' ORDER BY user_id, channel`
+	if err := r.db.Select(&dbGroups, query); err != nil {
+		return nil, r.translateError(err)
+	}
+	groups := make([]domain.NotificationDigestGroup, len(dbGroups))
+	for i, g := range dbGroups {
+		groups[i] = domain.NotificationDigestGroup{UserID: g.UserID, Channel: g.Channel}
+	}
+	return groups, nil
+}
+
+// LockDigestNotificationsWithTx блокирует уведомления пользователя, ожидающие сводки. Уведомления,
+// которые уже собирает другой экземпляр, пропускаются.
+func (r *Repository) LockDigestNotificationsWithTx(tx *sqlx.Tx, userID int64, channel string) ([]domain.Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications
+	          WHERE user_id = $1 AND channel = $2 AND status = 'digest'
+	          ORDER BY id FOR UPDATE SKIP LOCKED`
+	var dbNotifications []db.Notification
+	if err := tx.Select(&dbNotifications, query, userID, channel); err != nil {
+		return nil, r.translateError(err)
+	}
+	notifications := make([]domain.Notification, len(dbNotifications))
+	for i, n := range dbNotifications {
+		notifications[i] = *n.ToDomain()
+	}
+	return notifications, nil
+}
+
+// MarkNotificationsDigestedWithTx связывает уведомления со сводкой, в которой они отправлены.
+func (r *Repository) MarkNotificationsDigestedWithTx(tx *sqlx.Tx, ids []int64, digestID int64) error {
+	query := `UPDATE notifications SET status = 'digested', digest_id = $1 WHERE id = ANY($2)`
+	if _, err := tx.Exec(query, digestID, pq.Array(ids)); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+func (r *Repository) ListNotificationTemplates(eventType string) ([]domain.NotificationTemplate, error) {
+	query := `SELECT ` + notificationTemplateColumns + ` FROM notification_templates
+	          WHERE ($1 = '' OR event_type = $1) ORDER BY event_type, channel, locale`
+	var dbTemplates []db.NotificationTemplate
+	if err := r.db.Select(&dbTemplates, query, eventType); err != nil {
+		return nil, r.translateError(err)
+	}
+	templates := make([]domain.NotificationTemplate, len(dbTemplates))
+	for i, t := range dbTemplates {
+		templates[i] = *t.ToDomain()
+	}
+	return templates, nil
+}
+
+// UpsertNotificationTemplate создает или заменяет шаблон для типа события, канала и языка.
+func (r *Repository) UpsertNotificationTemplate(template *domain.NotificationTemplate) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "UpsertNotificationTemplate").Logger()
+	query := `INSERT INTO notification_templates (event_type, channel, locale, subject, body)
+	          VALUES ($1, $2, $3, $4, $5)
+	          ON CONFLICT (event_type, channel, locale) DO UPDATE
+	          SET subject = EXCLUDED.subject, body = EXCLUDED.body, updated_at = NOW()
+	          RETURNING ` + notificationTemplateColumns
+	var dbTemplate db.NotificationTemplate
+	err := r.db.Get(&dbTemplate, query, template.EventType, template.Channel, template.Locale, template.Subject, template.Body)
+	if err != nil {
+		logger.Error().Err(err).Str("event_type", template.EventType).Str("channel", template.Channel).Msg("failed to save notification template")
+		return r.translateError(err)
+	}
+	*template = *dbTemplate.ToDomain()
+	return nil
+}
+
+func (r *Repository) GetNotificationPreferences(userID int64) (*domain.NotificationPreferences, error) {
+	var dbPrefs db.NotificationPreferences
+	query := `SELECT user_id, locale, email, sms, in_app FROM notification_preferences WHERE user_id = $1`
+	if err := r.db.Get(&dbPrefs, query, userID); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbPrefs.ToDomain(), nil
+}
+
+func (r *Repository) SaveNotificationPreferences(userID int64, prefs *domain.NotificationPreferences) error {
+	query := `INSERT INTO notification_preferences (user_id, locale, email, sms, in_app)
+	          VALUES ($1, $2, $3, $4, $5)
+	          ON CONFLICT (user_id) DO UPDATE
+	          SET locale = EXCLUDED.locale, email = EXCLUDED.email, sms = EXCLUDED.sms, in_app = EXCLUDED.in_app, updated_at = NOW()`
+	if _, err := r.db.Exec(query, userID, prefs.Locale, prefs.Email, prefs.SMS, prefs.InApp); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
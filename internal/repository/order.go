@@ -1,23 +1,79 @@
 package repository
 
 import (
-	"database/sql"
+	"marketplace/internal/errs"
 	"marketplace/internal/models/db"
 	"marketplace/internal/models/domain"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
+const orderColumns = `id, user_id, subtotal, shipping_total, discount_total, tax_total, total, currency, status, cancel_reason, cancelled_at, note, shipping_address, version, created_at, updated_at`
+
+// Суммы позиций хранятся без валюты, поэтому она берется из заказа
+const orderItemColumns = `oi.id, oi.order_id, oi.product_id, oi.variant_id, oi.name, COALESCE(oi.sku, '') AS sku, oi.unit_price, oi.quantity, oi.total_price, oi.tax_amount, oi.tax_rate, oi.tax_inclusive, o.currency, oi.original_unit_price, oi.original_currency, oi.flash_sale_id, oi.created_at, oi.updated_at`
+
 func (r *Repository) CreateOrderWithTx(tx *sqlx.Tx, order *domain.Order, items []domain.OrderItem) (int64, error) {
 	var orderID int64
-	orderQuery := `INSERT INTO orders (user_id, total, currency, status, note) VALUES ($1, $2, $3, $4, $5) RETURNING id`
-	err := tx.Get(&orderID, orderQuery, order.UserID, order.Total, order.Currency, order.Status, order.Note)
+	dbOrder := db.Order{}
+	dbOrder.FromDomain(order)
+	orderQuery := `INSERT INTO orders (user_id, subtotal, shipping_total, discount_total, tax_total, total, currency, status, note, shipping_address) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, version`
+	err := tx.QueryRowx(orderQuery, dbOrder.UserID, dbOrder.Subtotal, dbOrder.ShippingTotal, dbOrder.DiscountTotal, dbOrder.TaxTotal, dbOrder.Total, dbOrder.Currency, dbOrder.Status, order.Note, jsonParam(dbOrder.ShippingAddress)).
+		Scan(&orderID, &order.Version)
 	if err != nil {
 		return 0, r.translateError(err)
 	}
-	itemQuery := `INSERT INTO order_items (order_id, product_id, name, unit_price, quantity, total_price) VALUES ($1, $2, $3, $4, $5, $6)`
-	for _, item := range items {
-		_, err = tx.Exec(itemQuery, orderID, item.ProductID, item.Name, item.UnitPrice, item.Quantity, item.TotalPrice)
+	if len(items) > 0 {
+		// Все позиции вставляются одним запросом
+		const itemColumnCount = 14
+		placeholders := make([]string, 0, len(items))
+		args := make([]interface{}, 0, len(items)*itemColumnCount)
+		for i, item := range items {
+			dbItem := db.OrderItem{}
+			dbItem.FromDomain(&item)
+			params := make([]string, itemColumnCount)
+			for j := range params {
+				params[j] = "$" + strconv.Itoa(i*itemColumnCount+j+1)
+			}
+			placeholders = append(placeholders, "("+strings.Join(params, ", ")+")")
+			args = append(args, orderID, dbItem.ProductID, dbItem.VariantID, dbItem.Name, nullString(dbItem.SKU), dbItem.UnitPrice, dbItem.Quantity, dbItem.TotalPrice, dbItem.OriginalUnitPrice, dbItem.OriginalCurrency,
+				dbItem.TaxAmount, dbItem.TaxRate, dbItem.TaxInclusive, dbItem.FlashSaleID)
+		}
+		itemQuery := `INSERT INTO order_items (order_id, product_id, variant_id, name, sku, unit_price, quantity, total_price, original_unit_price, original_currency, tax_amount, tax_rate, tax_inclusive, flash_sale_id)
+		              VALUES ` + strings.Join(placeholders, ", ")
+		if _, err = tx.Exec(itemQuery, args...); err != nil {
+			return 0, r.translateError(err)
+		}
+	}
+	shippingQuery := `INSERT INTO order_shipping_lines (order_id, shop_id, shipping_method_id, name, type, amount) VALUES ($1, $2, $3, $4, $5, $6)`
+	for _, line := range order.ShippingLines {
+		_, err = tx.Exec(shippingQuery, orderID, line.ShopID, line.ShippingMethodID, line.Name, line.Type, line.Amount.Decimal())
+		if err != nil {
+			return 0, r.translateError(err)
+		}
+	}
+	discountQuery := `INSERT INTO order_discounts (order_id, coupon_id, code, type, amount) VALUES ($1, $2, $3, $4, $5)`
+	for _, discount := range order.Discounts {
+		_, err = tx.Exec(discountQuery, orderID, discount.CouponID, discount.Code, discount.Type, discount.Amount.Decimal())
+		if err != nil {
+			return 0, r.translateError(err)
+		}
+	}
+	rateQuery := `INSERT INTO order_exchange_rates (order_id, from_currency, to_currency, rate) VALUES ($1, $2, $3, $4)`
+	for _, rate := range order.ExchangeRates {
+		_, err = tx.Exec(rateQuery, orderID, rate.FromCurrency, rate.ToCurrency, rate.Rate)
+		if err != nil {
+			return 0, r.translateError(err)
+		}
+	}
+	taxQuery := `INSERT INTO order_tax_lines (order_id, tax_rate_id, name, country, region, tax_class, rate, inclusive, taxable_amount, amount)
+	             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	for _, line := range order.Taxes {
+		_, err = tx.Exec(taxQuery, orderID, line.TaxRateID, line.Name, line.Country, line.Region, line.TaxClass, line.Rate, line.Inclusive,
+			line.TaxableAmount.Decimal(), line.Amount.Decimal())
 		if err != nil {
 			return 0, r.translateError(err)
 		}
@@ -26,21 +82,172 @@ func (r *Repository) CreateOrderWithTx(tx *sqlx.Tx, order *domain.Order, items [
 }
 func (r *Repository) GetOrderByID(orderID int64) (*domain.Order, []domain.OrderItem, error) {
 	var dbOrder db.Order
-	queryOrder := `SELECT id, user_id, total, currency, status, note, created_at, updated_at FROM orders WHERE id=$1 AND deleted_at IS NULL`
+	queryOrder := `SELECT ` + orderColumns + ` FROM orders WHERE id=$1 AND deleted_at IS NULL`
 	if err := r.db.Get(&dbOrder, queryOrder, orderID); err != nil {
 		return nil, nil, r.translateError(err)
 	}
 	var dbItems []db.OrderItem
-	queryItems := `SELECT id, order_id, product_id, name, unit_price, quantity, total_price, created_at, updated_at FROM order_items WHERE order_id=$1`
+	queryItems := `SELECT ` + orderItemColumns + ` FROM order_items oi JOIN orders o ON o.id = oi.order_id WHERE oi.order_id=$1`
 	if err := r.db.Select(&dbItems, queryItems, orderID); err != nil {
-		if err == sql.ErrNoRows {
-			return dbOrder.ToDomain(), []domain.OrderItem{}, nil
+		return nil, nil, r.translateError(err)
+	}
+	domainItems, err := orderItemsToDomain(dbItems)
+	if err != nil {
+		return nil, nil, err
+	}
+	order, err := dbOrder.ToDomain()
+	if err != nil {
+		return nil, nil, err
+	}
+	var dbLines []db.OrderShippingLine
+	queryLines := `SELECT l.id, l.order_id, l.shop_id, l.shipping_method_id, l.name, l.type, l.amount, o.currency, l.created_at FROM order_shipping_lines l JOIN orders o ON o.id = l.order_id WHERE l.order_id=$1 ORDER BY l.id`
+	if err := r.db.Select(&dbLines, queryLines, orderID); err != nil {
+		return nil, nil, r.translateError(err)
+	}
+	for _, line := range dbLines {
+		domainOrderShippingLine, err := line.ToDomain()
+		if err != nil {
+			return nil, nil, err
+		}
+		order.ShippingLines = append(order.ShippingLines, *domainOrderShippingLine)
+	}
+	var dbDiscounts []db.OrderDiscount
+	queryDiscounts := `SELECT d.id, d.order_id, d.coupon_id, d.code, d.type, d.amount, o.currency, d.created_at FROM order_discounts d JOIN orders o ON o.id = d.order_id WHERE d.order_id=$1 ORDER BY d.id`
+	if err := r.db.Select(&dbDiscounts, queryDiscounts, orderID); err != nil {
+		return nil, nil, r.translateError(err)
+	}
+	for _, discount := range dbDiscounts {
+		domainOrderDiscount, err := discount.ToDomain()
+		if err != nil {
+			return nil, nil, err
 		}
+		order.Discounts = append(order.Discounts, *domainOrderDiscount)
+	}
+	var dbRates []db.OrderExchangeRate
+	queryRates := `SELECT id, order_id, from_currency, to_currency, rate, created_at FROM order_exchange_rates WHERE order_id=$1 ORDER BY from_currency`
+	if err := r.db.Select(&dbRates, queryRates, orderID); err != nil {
+		return nil, nil, r.translateError(err)
+	}
+	for _, rate := range dbRates {
+		order.ExchangeRates = append(order.ExchangeRates, *rate.ToDomain())
+	}
+	var dbTaxes []db.OrderTaxLine
+	queryTaxes := `SELECT t.id, t.order_id, t.tax_rate_id, t.name, t.country, t.region, t.tax_class, t.rate, t.inclusive, t.taxable_amount, t.amount,
+	               o.currency, t.created_at FROM order_tax_lines t JOIN orders o ON o.id = t.order_id WHERE t.order_id=$1 ORDER BY t.id`
+	if err := r.db.Select(&dbTaxes, queryTaxes, orderID); err != nil {
 		return nil, nil, r.translateError(err)
 	}
-	domainItems := make([]domain.OrderItem, len(dbItems))
-	for i, item := range dbItems {
-		domainItems[i] = *item.ToDomain()
+	for _, line := range dbTaxes {
+		domainOrderTaxLine, err := line.ToDomain()
+		if err != nil {
+			return nil, nil, err
+		}
+		order.Taxes = append(order.Taxes, *domainOrderTaxLine)
 	}
-	return dbOrder.ToDomain(), domainItems, nil
+	return order, domainItems, nil
+}
+
+func (r *Repository) GetOrderByIDWithTx(tx *sqlx.Tx, orderID int64) (*domain.Order, []domain.OrderItem, error) {
+	var dbOrder db.Order
+	queryOrder := `SELECT ` + orderColumns + ` FROM orders WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.Get(&dbOrder, queryOrder, orderID); err != nil {
+		return nil, nil, r.translateError(err)
+	}
+	var dbItems []db.OrderItem
+	queryItems := `SELECT ` + orderItemColumns + ` FROM order_items oi JOIN orders o ON o.id = oi.order_id WHERE oi.order_id=$1 ORDER BY oi.id`
+	if err := tx.Select(&dbItems, queryItems, orderID); err != nil {
+		return nil, nil, r.translateError(err)
+	}
+	domainItems, err := orderItemsToDomain(dbItems)
+	if err != nil {
+		return nil, nil, err
+	}
+	order, err := dbOrder.ToDomain()
+	if err != nil {
+		return nil, nil, err
+	}
+	return order, domainItems, nil
+}
+
+func orderItemsToDomain(dbItems []db.OrderItem) ([]domain.OrderItem, error) {
+	items := make([]domain.OrderItem, len(dbItems))
+	for i, dbItem := range dbItems {
+		item, err := dbItem.ToDomain()
+		if err != nil {
+			return nil, err
+		}
+		items[i] = *item
+	}
+	return items, nil
+}
+
+func (r *Repository) GetOrderItemShopIDsWithTx(tx *sqlx.Tx, orderID int64) (map[int64]int64, error) {
+	var rows []struct {
+		OrderItemID int64 `db:"order_item_id"`
+		ShopID      int64 `db:"shop_id"`
+	}
+	query := `SELECT oi.id AS order_item_id, p.shop_id FROM order_items oi JOIN products p ON p.id = oi.product_id WHERE oi.order_id = $1`
+	if err := tx.Select(&rows, query, orderID); err != nil {
+		return nil, r.translateError(err)
+	}
+	shopIDs := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		shopIDs[row.OrderItemID] = row.ShopID
+	}
+	return shopIDs, nil
+}
+
+func (r *Repository) UpdateOrderStatusWithTx(tx *sqlx.Tx, orderID int64, status string) error {
+	query := `UPDATE orders SET status = $1, updated_at = NOW(), version = version + 1 WHERE id = $2 AND deleted_at IS NULL`
+	result, err := tx.Exec(query, status, orderID)
+	if err != nil {
+		return r.translateError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected == 0 {
+		return errs.ErrOrderNotFound
+	}
+	return nil
+}
+
+// ListExpiredPendingOrderIDsWithTx блокирует до limit заказов, ожидающих оплаты (pending) и созданных
+// раньше before; подтвержденные заказы не выбираются. Заказы, уже заблокированные другим экземпляром
+// приложения или подтверждением оплаты, пропускаются (SKIP LOCKED).
+func (r *Repository) ListExpiredPendingOrderIDsWithTx(tx *sqlx.Tx, before time.Time, limit int) ([]int64, error) {
+	var ids []int64
+	query := `SELECT id FROM orders
+	          WHERE status = $1 AND created_at < $2 AND deleted_at IS NULL
+	          ORDER BY created_at LIMIT $3 FOR UPDATE SKIP LOCKED`
+	if err := tx.Select(&ids, query, domain.OrderStatusPending, before, limit); err != nil {
+		return nil, r.translateError(err)
+	}
+	return ids, nil
+}
+
+func (r *Repository) CancelOrderWithTx(tx *sqlx.Tx, orderID int64, reason string) error {
+	query := `UPDATE orders SET status = $1, cancel_reason = $2, cancelled_at = NOW(), updated_at = NOW(), version = version + 1
+	          WHERE id = $3 AND status <> $1 AND deleted_at IS NULL`
+	result, err := tx.Exec(query, domain.OrderStatusCancelled, reason, orderID)
+	if err != nil {
+		return r.translateError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected == 0 {
+		return errs.ErrOrderNotFound
+	}
+	return nil
+}
+
+func (r *Repository) AddOrderStatusChangeWithTx(tx *sqlx.Tx, change *domain.OrderStatusChange) error {
+	query := `INSERT INTO order_status_history (order_id, from_status, to_status, reason, changed_by)
+	          VALUES ($1, $2, $3, NULLIF($4, ''), $5) RETURNING id, created_at`
+	err := tx.QueryRow(query, change.OrderID, change.FromStatus, change.ToStatus, change.Reason, change.ChangedBy).
+		Scan(&change.ID, &change.CreatedAt)
+	return r.translateError(err)
 }
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"marketplace/internal/errs"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// pageCursor - содержимое курсора страницы: сортировка, значение ключа сортировки и id
+// последней строки. Клиенту курсор отдается непрозрачной строкой base64url.
+type pageCursor struct {
+	Sort string `json:"s,omitempty"`
+	Key  string `json:"k,omitempty"`
+	ID   int64  `json:"id"`
+}
+
+func encodeCursor(cursor pageCursor) string {
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor разбирает курсор; пустая строка - первая страница (nil). Курсор, выданный
+// для другой сортировки, не принимается: его ключ нельзя сравнить с текущим порядком.
+func decodeCursor(token, sort string) (*pageCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errs.ErrInvalidCursor
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.Sort != sort || cursor.ID <= 0 {
+		return nil, errs.ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// cursorID возвращает id курсора или 0 для первой страницы.
+func cursorID(cursor *pageCursor) int64 {
+	if cursor == nil {
+		return 0
+	}
+	return cursor.ID
+}
+
+// trimPage отрезает от выборки из limit+1 строк лишнюю строку и возвращает курсор следующей
+// страницы: он есть, только если лишняя строка нашлась.
+func trimPage[T any](rows []T, limit int, cursor func(T) pageCursor) ([]T, string) {
+	if len(rows) <= limit {
+		return rows, ""
+	}
+	rows = rows[:limit]
+	return rows, encodeCursor(cursor(rows[limit-1]))
+}
+
+// countTotal считает total, только если он запрошен.
+func (r *Repository) countTotal(withTotal bool, query string, args ...interface{}) (*int, error) {
+	if !withTotal {
+		return nil, nil
+	}
+	var total int
+	if err := r.db.Get(&total, query, args...); err != nil {
+		return nil, r.translateError(err)
+	}
+	return &total, nil
+}
+
+// translateCursorError превращает ошибку приведения подделанного ключа курсора (класс 22 -
+// data exception) в ErrInvalidCursor.
+func (r *Repository) translateCursorError(err error, cursor *pageCursor) error {
+	var pqErr *pq.Error
+	if cursor != nil && errors.As(err, &pqErr) && strings.HasPrefix(string(pqErr.Code), "22") {
+		return errs.ErrInvalidCursor
+	}
+	return r.translateError(err)
+}
@@ -2,29 +2,43 @@ package repository
 
 import (
 	"errors"
+	"marketplace/internal/errs"
 	"marketplace/internal/models/db"
 	"marketplace/internal/models/domain"
 	"os"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/rs/zerolog"
 )
 
+const productColumns = `id, sku, name, slug, description, price, currency, quantity, tax_class, shop_id, active, weight_grams, length_mm, width_mm, height_mm, attributes, version, created_at, updated_at, deleted_at`
+
 func (r *Repository) CreateProduct(product *domain.Product) error {
+	return r.createProduct(r.db, product)
+}
+
+func (r *Repository) CreateProductWithTx(tx *sqlx.Tx, product *domain.Product) error {
+	return r.createProduct(tx, product)
+}
+
+func (r *Repository) createProduct(q sqlx.Queryer, product *domain.Product) error {
 	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateProduct").Logger()
 	dbProduct := db.Product{}
 	dbProduct.FromDomain(product)
-	query := `INSERT INTO products (sku, name, slug, description, price, currency, quantity, shop_id, active, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) RETURNING id, created_at, updated_at`
+	query := `INSERT INTO products (sku, name, slug, description, price, currency, quantity, shop_id, active, weight_grams, length_mm, width_mm, height_mm, tax_class, attributes, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,COALESCE($15::jsonb, '{}'),$16,$17) RETURNING id, version, created_at, updated_at`
 	now := time.Now()
-	err := r.db.QueryRow(
-		query, dbProduct.SKU, dbProduct.Name, dbProduct.Slug, dbProduct.Description, dbProduct.Price, dbProduct.Currency, dbProduct.Quantity, dbProduct.ShopID, dbProduct.Active, now, now).Scan(&dbProduct.ID, &dbProduct.CreatedAt, &dbProduct.UpdatedAt)
+	err := q.QueryRowx(
+		query, dbProduct.SKU, dbProduct.Name, dbProduct.Slug, dbProduct.Description, dbProduct.Price, dbProduct.Currency, dbProduct.Quantity, dbProduct.ShopID, dbProduct.Active,
+		dbProduct.WeightGrams, dbProduct.LengthMM, dbProduct.WidthMM, dbProduct.HeightMM, dbProduct.TaxClass, jsonParam(dbProduct.Attributes), now, now).Scan(&dbProduct.ID, &dbProduct.Version, &dbProduct.CreatedAt, &dbProduct.UpdatedAt)
 
 	if err != nil {
 		logger.Error().Err(err).Msg("failed to create product")
-		return r.translateError(err)
+		return r.translateProductError(err)
 	}
 	product.ID = dbProduct.ID
+	product.Version = dbProduct.Version
 	product.CreatedAt = dbProduct.CreatedAt
 	product.UpdatedAt = dbProduct.UpdatedAt
 	logger.Info().Int64("product_id", product.ID).Msg("product created successfully")
@@ -34,21 +48,23 @@ func (r *Repository) CreateProduct(product *domain.Product) error {
 func (r *Repository) GetProductByID(id int64) (*domain.Product, error) {
 	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "GetProductByID").Logger()
 	var dbProduct db.Product
-	query := `SELECT id, sku, name, slug, description, price, currency, quantity, shop_id, active, created_at, updated_at, deleted_at FROM products WHERE id = $1 AND deleted_at IS NULL`
+	query := `SELECT ` + productColumns + ` FROM products WHERE id = $1 AND deleted_at IS NULL`
 	err := r.db.Get(&dbProduct, query, id)
 	if err != nil {
 		logger.Error().Err(err).Int64("id", id).Msg("failed to get product by id")
 		return nil, r.translateError(err)
 	}
-	return dbProduct.ToDomain(), nil
+	return dbProduct.ToDomain()
 }
-func (r *Repository) UpdateProduct(product *domain.Product) error {
-	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "UpdateProduct").Logger()
+
+// UpdateProductWithTx сохраняет товар. product.Version - прочитанная версия товара, после
+// обновления в нее записывается новая.
+func (r *Repository) UpdateProductWithTx(tx *sqlx.Tx, product *domain.Product) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "UpdateProductWithTx").Logger()
 	dbProduct := db.Product{}
 	dbProduct.FromDomain(product)
-	query := `UPDATE products SET sku = $1, name = $2, slug = $3, description = $4, price = $5, currency = $6, quantity = $7, shop_id = $8, active = $9, updated_at = $10 WHERE id = $11 AND deleted_at IS NULL`
-	_, err := r.db.Exec(
-		query,
+	set := `sku = $1, name = $2, slug = $3, description = $4, price = $5, currency = $6, quantity = $7, active = $8, weight_grams = $9, length_mm = $10, width_mm = $11, height_mm = $12, tax_class = $13, attributes = COALESCE($14::jsonb, attributes), updated_at = $15`
+	version, err := updateVersioned(tx, "products", dbProduct.ID, dbProduct.Version, set,
 		dbProduct.SKU,
 		dbProduct.Name,
 		dbProduct.Slug,
@@ -56,22 +72,28 @@ func (r *Repository) UpdateProduct(product *domain.Product) error {
 		dbProduct.Price,
 		dbProduct.Currency,
 		dbProduct.Quantity,
-		dbProduct.ShopID,
 		dbProduct.Active,
+		dbProduct.WeightGrams,
+		dbProduct.LengthMM,
+		dbProduct.WidthMM,
+		dbProduct.HeightMM,
+		dbProduct.TaxClass,
+		jsonParam(dbProduct.Attributes),
 		time.Now(),
-		dbProduct.ID,
 	)
 	if err != nil {
 		logger.Error().Err(err).Int64("id", dbProduct.ID).Msg("failed to update product")
-		return r.translateError(err)
+		return r.translateProductError(err)
 	}
+	product.Version = version
 	logger.Info().Int64("product_id", dbProduct.ID).Msg("product updated successfully")
 	return nil
 }
-func (r *Repository) DeleteProduct(id int64) error {
-	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "DeleteProduct").Logger()
-	query := `UPDATE products SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`
-	_, err := r.db.Exec(query, time.Now(), id)
+
+// DeleteProductWithTx помечает товар удаленным; expectedVersion - как в UpdateProductWithTx.
+func (r *Repository) DeleteProductWithTx(tx *sqlx.Tx, id, expectedVersion int64) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "DeleteProductWithTx").Logger()
+	_, err := updateVersioned(tx, "products", id, expectedVersion, `deleted_at = $1`, time.Now())
 	if err != nil {
 		logger.Error().Err(err).Int64("id", id).Msg("failed to delete product")
 		return r.translateError(err)
@@ -79,31 +101,8 @@ func (r *Repository) DeleteProduct(id int64) error {
 	logger.Info().Int64("product_id", id).Msg("product soft deleted successfully")
 	return nil
 }
-func (r *Repository) ListProducts(shopID int64, limit, offset int) ([]*domain.Product, error) {
-	logger := zerolog.New(os.Stdout).With().
-		Timestamp().Str("func", "ListProducts").Logger()
-	var dbProducts []db.Product
-	query := `SELECT id, sku, name, slug, description, price, currency, quantity, shop_id, active, created_at, updated_at, deleted_at 
-              FROM products 
-              WHERE shop_id = $1 AND deleted_at IS NULL 
-              ORDER BY created_at DESC 
-              LIMIT $2 OFFSET $3`
-
-	err := r.db.Select(&dbProducts, query, shopID, limit, offset)
-	if err != nil {
-		logger.Error().Err(err).Msg("failed to list products")
-		return nil, r.translateError(err)
-	}
-
-	products := make([]*domain.Product, 0, len(dbProducts))
-	for _, p := range dbProducts {
-		products = append(products, p.ToDomain())
-	}
-	logger.Info().Int("count", len(products)).Msg("products listed successfully")
-	return products, nil
-}
 func (r *Repository) DecreaseProductQuantity(productID int64, quantity int) error {
-	query := `UPDATE products SET quantity = quantity - $1 WHERE id = $2 AND quantity >= $1`
+	query := `UPDATE products SET quantity = quantity - $1, version = version + 1 WHERE id = $2 AND quantity >= $1`
 	result, err := r.db.Exec(query, quantity, productID)
 	if err != nil {
 		return r.translateError(err)
@@ -113,26 +112,86 @@ func (r *Repository) DecreaseProductQuantity(productID int64, quantity int) erro
 		return r.translateError(err)
 	}
 	if rowsAffected == 0 {
-		return errors.New("not enough stock")
+		return errs.ErrInsufficientStock
 	}
 	return nil
 }
 func (r *Repository) GetProductByIDWithTx(tx *sqlx.Tx, id int64) (*domain.Product, error) {
 	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "GetProductByIDWithTx").Logger()
 	var dbProduct db.Product
-	query := `SELECT id, sku, name, slug, description, price, currency, quantity, shop_id, active, created_at, updated_at, deleted_at 
+	query := `SELECT ` + productColumns + ` 
 	          FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
 	err := tx.Get(&dbProduct, query, id)
 	if err != nil {
 		logger.Error().Err(err).Int64("id", id).Msg("failed to get product by id with tx")
 		return nil, r.translateError(err)
 	}
-	return dbProduct.ToDomain(), nil
+	return dbProduct.ToDomain()
+}
+
+// LockProductsWithTx блокирует строки товаров одним запросом в порядке возрастания id:
+// общий порядок блокировок исключает взаимоблокировку заказов с одинаковыми товарами.
+// Отсутствующие и удаленные товары в результат не попадают.
+func (r *Repository) LockProductsWithTx(tx *sqlx.Tx, ids []int64) ([]*domain.Product, error) {
+	return r.selectProductsByIDs(tx, ids, "LockProductsWithTx", ` FOR UPDATE`)
+}
+
+// GetProductsByIDsWithTx читает товары без блокировки - для товаров на флеш-распродаже,
+// остаток которых списывается из слотов.
+func (r *Repository) GetProductsByIDsWithTx(tx *sqlx.Tx, ids []int64) ([]*domain.Product, error) {
+	return r.selectProductsByIDs(tx, ids, "GetProductsByIDsWithTx", "")
+}
+
+func (r *Repository) selectProductsByIDs(tx *sqlx.Tx, ids []int64, funcName, lock string) ([]*domain.Product, error) {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", funcName).Logger()
+	var dbProducts []db.Product
+	query := `SELECT ` + productColumns + `
+	          FROM products WHERE id = ANY($1) AND deleted_at IS NULL ORDER BY id` + lock
+	if err := tx.Select(&dbProducts, query, pq.Array(ids)); err != nil {
+		logger.Error().Err(err).Int("count", len(ids)).Msg("failed to select products by ids with tx")
+		return nil, r.translateError(err)
+	}
+	products := make([]*domain.Product, 0, len(dbProducts))
+	for _, p := range dbProducts {
+		product, err := p.ToDomain()
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, nil
+}
+
+// IncreaseProductQuantitiesWithTx возвращает остатки нескольких товаров одним UPDATE.
+func (r *Repository) IncreaseProductQuantitiesWithTx(tx *sqlx.Tx, quantities map[int64]int) error {
+	ids := make([]int64, 0, len(quantities))
+	amounts := make([]int64, 0, len(quantities))
+	for id, quantity := range quantities {
+		ids = append(ids, id)
+		amounts = append(amounts, int64(quantity))
+	}
+	query := `UPDATE products p SET quantity = p.quantity + s.quantity, version = p.version + 1
+	          FROM unnest($1::bigint[], $2::int[]) AS s(id, quantity)
+	          WHERE p.id = s.id`
+	if _, err := tx.Exec(query, pq.Array(ids), pq.Array(amounts)); err != nil {
+		return r.translateError(err)
+	}
+	return nil
 }
 
-func (r *Repository) DecreaseProductQuantityWithTx(tx *sqlx.Tx, productID int64, quantity int) error {
-	query := `UPDATE products SET quantity = quantity - $1 WHERE id = $2 AND quantity >= $1`
-	result, err := tx.Exec(query, quantity, productID)
+// DecreaseProductQuantitiesWithTx списывает остатки нескольких товаров одним UPDATE.
+// Если хотя бы одного товара не хватает, возвращается ошибка и транзакцию нужно откатить.
+func (r *Repository) DecreaseProductQuantitiesWithTx(tx *sqlx.Tx, quantities map[int64]int) error {
+	ids := make([]int64, 0, len(quantities))
+	amounts := make([]int64, 0, len(quantities))
+	for id, quantity := range quantities {
+		ids = append(ids, id)
+		amounts = append(amounts, int64(quantity))
+	}
+	query := `UPDATE products p SET quantity = p.quantity - s.quantity, version = p.version + 1
+	          FROM unnest($1::bigint[], $2::int[]) AS s(id, quantity)
+	          WHERE p.id = s.id AND p.quantity >= s.quantity`
+	result, err := tx.Exec(query, pq.Array(ids), pq.Array(amounts))
 	if err != nil {
 		return r.translateError(err)
 	}
@@ -140,8 +199,17 @@ func (r *Repository) DecreaseProductQuantityWithTx(tx *sqlx.Tx, productID int64,
 	if err != nil {
 		return r.translateError(err)
 	}
-	if rowsAffected == 0 {
-		return errors.New("not enough stock")
+	if rowsAffected != int64(len(quantities)) {
+		return errs.ErrInsufficientStock
 	}
 	return nil
 }
+
+// translateProductError переводит нарушение уникальности slug или SKU в магазине.
+func (r *Repository) translateProductError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return errs.ErrProductAlreadyExists
+	}
+	return r.translateError(err)
+}
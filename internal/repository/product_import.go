@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"errors"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+)
+
+const productImportColumns = `id, shop_id, user_id, format, dry_run, status, input_key, errors_key, total_rows, created_count, updated_count, failed_count, error, created_at, started_at, finished_at`
+
+func (r *Repository) CreateProductImportWithTx(tx *sqlx.Tx, productImport *domain.ProductImport) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateProductImportWithTx").Logger()
+	query := `INSERT INTO product_imports (shop_id, user_id, format, dry_run, status, input_key)
+	          VALUES ($1, $2, $3, $4, $5, $6) RETURNING ` + productImportColumns
+	var dbImport db.ProductImport
+	err := tx.Get(&dbImport, query, productImport.ShopID, productImport.UserID, productImport.Format, productImport.DryRun,
+		domain.ImportStatusPending, productImport.InputKey)
+	if err != nil {
+		logger.Error().Err(err).Int64("shop_id", productImport.ShopID).Msg("failed to create product import")
+		return r.translateError(err)
+	}
+	*productImport = *dbImport.ToDomain()
+	return nil
+}
+
+func (r *Repository) GetProductImportByID(id int64) (*domain.ProductImport, error) {
+	var dbImport db.ProductImport
+	if err := r.db.Get(&dbImport, `SELECT `+productImportColumns+` FROM product_imports WHERE id = $1`, id); err != nil {
+		if err = r.translateError(err); errors.Is(err, errs.ErrNotfound) {
+			return nil, errs.ErrImportNotFound
+		}
+		return nil, err
+	}
+	return dbImport.ToDomain(), nil
+}
+
+// StartProductImport переводит импорт в running и обнуляет счетчики: повтор задачи после сбоя
+// обрабатывает файл заново. Завершенный импорт не перезапускается - возвращается false.
+func (r *Repository) StartProductImport(id int64) (bool, error) {
+	query := `UPDATE product_imports SET status = $1, started_at = $2, total_rows = 0, created_count = 0, updated_count = 0,
+	          failed_count = 0, error = '' WHERE id = $3 AND status IN ($4, $1)`
+	result, err := r.db.Exec(query, domain.ImportStatusRunning, time.Now(), id, domain.ImportStatusPending)
+	if err != nil {
+		return false, r.translateError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, r.translateError(err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// UpdateProductImportProgress сохраняет счетчики выполняющегося импорта.
+func (r *Repository) UpdateProductImportProgress(productImport *domain.ProductImport) error {
+	query := `UPDATE product_imports SET total_rows = $1, created_count = $2, updated_count = $3, failed_count = $4 WHERE id = $5`
+	if _, err := r.db.Exec(query, productImport.TotalRows, productImport.Created, productImport.Updated, productImport.Failed, productImport.ID); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+// FinishProductImport сохраняет итог импорта: статус, счетчики, ошибку и ключ отчета.
+func (r *Repository) FinishProductImport(productImport *domain.ProductImport) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "FinishProductImport").Logger()
+	now := time.Now()
+	query := `UPDATE product_imports SET status = $1, total_rows = $2, created_count = $3, updated_count = $4, failed_count = $5,
+	          error = $6, errors_key = $7, finished_at = $8 WHERE id = $9`
+	_, err := r.db.Exec(query, productImport.Status, productImport.TotalRows, productImport.Created, productImport.Updated,
+		productImport.Failed, productImport.Error, nullString(productImport.ErrorsKey), now, productImport.ID)
+	if err != nil {
+		logger.Error().Err(err).Int64("import_id", productImport.ID).Msg("failed to finish product import")
+		return r.translateError(err)
+	}
+	productImport.FinishedAt = &now
+	return nil
+}
+
+// PurgeProductImports удаляет импорты, завершенные до before, и ставит их файлы в очередь удаления.
+func (r *Repository) PurgeProductImports(before time.Time) (int64, error) {
+	query := `WITH deleted AS (DELETE FROM product_imports WHERE finished_at < $1 RETURNING id, input_key, errors_key),
+	          queued AS (INSERT INTO blob_deletions (blob_key)
+	                     SELECT k FROM deleted, unnest(ARRAY[input_key, errors_key]) AS k WHERE k IS NOT NULL)
+	          SELECT COUNT(*) FROM deleted`
+	var deleted int64
+	if err := r.db.Get(&deleted, query, before); err != nil {
+		return 0, r.translateError(err)
+	}
+	return deleted, nil
+}
+
+// FindShopProductBySKU возвращает неудаленный товар магазина по SKU.
+func (r *Repository) FindShopProductBySKU(shopID int64, sku string) (*domain.Product, error) {
+	var dbProduct db.Product
+	query := `SELECT ` + productColumns + ` FROM products WHERE shop_id = $1 AND sku = $2 AND deleted_at IS NULL`
+	if err := r.db.Get(&dbProduct, query, shopID, sku); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbProduct.ToDomain()
+}
+
+// LockShopProductBySKUWithTx блокирует неудаленный товар магазина по SKU.
+func (r *Repository) LockShopProductBySKUWithTx(tx *sqlx.Tx, shopID int64, sku string) (*domain.Product, error) {
+	var dbProduct db.Product
+	query := `SELECT ` + productColumns + ` FROM products WHERE shop_id = $1 AND sku = $2 AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.Get(&dbProduct, query, shopID, sku); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbProduct.ToDomain()
+}
+
+// ListShopProductsAfter возвращает до limit неудаленных товаров магазина с id больше afterID
+// по возрастанию id, включая неактивные.
+func (r *Repository) ListShopProductsAfter(shopID, afterID int64, limit int) ([]*domain.Product, error) {
+	var dbProducts []db.Product
+	query := `SELECT ` + productColumns + ` FROM products WHERE shop_id = $1 AND id > $2 AND deleted_at IS NULL ORDER BY id LIMIT $3`
+	if err := r.db.Select(&dbProducts, query, shopID, afterID, limit); err != nil {
+		return nil, r.translateError(err)
+	}
+	products := make([]*domain.Product, len(dbProducts))
+	for i, p := range dbProducts {
+		product, err := p.ToDomain()
+		if err != nil {
+			return nil, err
+		}
+		products[i] = product
+	}
+	return products, nil
+}
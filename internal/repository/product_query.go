@@ -0,0 +1,341 @@
+package repository
+
+import (
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+const (
+	maxShopFacetValues      = 20
+	maxAttributeFacetValues = 20
+	maxAttributeFacetRows   = 1000
+)
+
+// productFacet - фасет, при подсчете которого не применяется его собственный фильтр:
+// иначе выбранное значение осталось бы единственным вариантом.
+type productFacet int
+
+const (
+	facetNone productFacet = iota
+	facetPrice
+	facetAvailability
+	facetShop
+	facetAttributes
+	facetCategory
+)
+
+// productSort - сортировка каталога: выражение ключа, его тип для значения из курсора и
+// направление. Вторым ключом всегда идет p.id в том же направлении, поэтому порядок полный
+// и страница продолжается условием (ключ, id) < (ключ, id) из курсора.
+type productSort struct {
+	column  string
+	keyType string
+	desc    bool
+}
+
+// productSorts - сортировки каталога; в текст запроса попадают только эти выражения.
+var productSorts = map[string]productSort{
+	domain.ProductSortNewest:    {column: "p.created_at", keyType: "timestamptz", desc: true},
+	domain.ProductSortPriceAsc:  {column: "p.price", keyType: "numeric"},
+	domain.ProductSortPriceDesc: {column: "p.price", keyType: "numeric", desc: true},
+	domain.ProductSortName:      {column: "p.name", keyType: "text"},
+	domain.ProductSortPopular:   {column: "sold.quantity", keyType: "bigint", desc: true},
+}
+
+func (s productSort) direction() (string, string) {
+	if s.desc {
+		return "DESC", "<"
+	}
+	return "ASC", ">"
+}
+
+func (s productSort) orderBy() string {
+	dir, _ := s.direction()
+	return s.column + " " + dir + ", p.id " + dir
+}
+
+// after добавляет условие продолжения страницы после строки курсора.
+func (s productSort) after(q *productQuery, cursor *pageCursor) {
+	_, op := s.direction()
+	q.where("(" + s.column + ", p.id) " + op + " (" + q.arg(cursor.Key) + "::" + s.keyType + ", " + q.arg(cursor.ID) + ")")
+}
+
+// popularityJoin добавляет к товару число проданных единиц в неотмененных заказах.
+const popularityJoin = ` LEFT JOIN LATERAL (
+	              SELECT COALESCE(SUM(oi.quantity), 0) AS quantity
+	              FROM order_items oi JOIN orders o ON o.id = oi.order_id
+	              WHERE oi.product_id = p.id AND o.status <> 'cancelled'
+	          ) sold ON true`
+
+// productQuery собирает условия выборки товаров (таблица под псевдонимом p). Значения фильтров
+// передаются только параметрами запроса.
+type productQuery struct {
+	conditions []string
+	args       []interface{}
+}
+
+// newProductQuery строит условия по фильтру, пропуская фильтр фасета skip. args - параметры,
+// уже занятые вызывающим ($1, $2, ...).
+func newProductQuery(filter domain.ProductFilter, skip productFacet, args ...interface{}) *productQuery {
+	q := &productQuery{args: args}
+	q.where("p.deleted_at IS NULL")
+	if filter.CategoryID > 0 && skip != facetCategory {
+		q.where("EXISTS (SELECT 1 FROM product_categories pc WHERE pc.product_id = p.id AND pc.category_id IN (" +
+			categorySubtree(q.arg(filter.CategoryID)) + "))")
+	}
+	if filter.ShopID > 0 && skip != facetShop {
+		q.where("p.shop_id = " + q.arg(filter.ShopID))
+	}
+	if skip != facetPrice {
+		if filter.Currency != "" {
+			q.where("p.currency = " + q.arg(filter.Currency))
+		}
+		if filter.MinPrice != nil {
+			q.where("p.price >= " + q.arg(filter.MinPrice.Decimal()) + "::numeric")
+		}
+		if filter.MaxPrice != nil {
+			q.where("p.price <= " + q.arg(filter.MaxPrice.Decimal()) + "::numeric")
+		}
+	}
+	if filter.InStock != nil && skip != facetAvailability {
+		if *filter.InStock {
+			q.where("p.quantity > 0")
+		} else {
+			q.where("p.quantity = 0")
+		}
+	}
+	if filter.Active != nil {
+		q.where("p.active = " + q.arg(*filter.Active))
+	}
+	if skip != facetAttributes {
+		keys := make([]string, 0, len(filter.Attributes))
+		for key := range filter.Attributes {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			q.where("p.attributes ->> " + q.arg(key) + " = ANY(" + q.arg(pq.StringArray(filter.Attributes[key])) + ")")
+		}
+	}
+	return q
+}
+
+// arg добавляет параметр и возвращает его плейсхолдер.
+func (q *productQuery) arg(v interface{}) string {
+	q.args = append(q.args, v)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+func (q *productQuery) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+func (q *productQuery) whereSQL() string {
+	return strings.Join(q.conditions, " AND ")
+}
+
+type productListRow struct {
+	db.Product
+	SortKey string `db:"sort_key"`
+}
+
+// ListProducts возвращает страницу товаров по фильтрам и сортировке вместе с фасетами.
+// Страницы листаются по ключу сортировки (keyset), поэтому глубокие страницы не дороже первой.
+func (r *Repository) ListProducts(filter domain.ProductFilter) (*domain.ProductListResult, error) {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "ListProducts").Logger()
+	sort, ok := productSorts[filter.Sort]
+	if !ok {
+		filter.Sort = domain.ProductSortNewest
+		sort = productSorts[filter.Sort]
+	}
+	cursor, err := decodeCursor(filter.Cursor, filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+	q := newProductQuery(filter, facetNone)
+	result := &domain.ProductListResult{}
+	result.Total, err = r.countTotal(filter.WithTotal, `SELECT COUNT(*) FROM products p WHERE `+q.whereSQL(), q.args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to count products")
+		return nil, err
+	}
+	if cursor != nil {
+		sort.after(q, cursor)
+	}
+	from := `products p`
+	if filter.Sort == domain.ProductSortPopular {
+		from += popularityJoin
+	}
+	query := `SELECT ` + prefixColumns("p", productColumns) + `, ` + sort.column + `::text AS sort_key
+	          FROM ` + from + `
+	          WHERE ` + q.whereSQL() + `
+	          ORDER BY ` + sort.orderBy() + `
+	          LIMIT ` + q.arg(filter.Limit+1)
+	var rows []productListRow
+	if err := r.db.Select(&rows, query, q.args...); err != nil {
+		logger.Error().Err(err).Msg("failed to list products")
+		return nil, r.translateCursorError(err, cursor)
+	}
+	rows, result.NextCursor = trimPage(rows, filter.Limit, func(row productListRow) pageCursor {
+		return pageCursor{Sort: filter.Sort, Key: row.SortKey, ID: row.ID}
+	})
+	result.Items = make([]*domain.Product, len(rows))
+	for i, row := range rows {
+		if result.Items[i], err = row.Product.ToDomain(); err != nil {
+			return nil, err
+		}
+	}
+	facets, err := r.productFacets(filter)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to count product facets")
+		return nil, err
+	}
+	result.Facets = *facets
+	return result, nil
+}
+
+func (r *Repository) productFacets(filter domain.ProductFilter) (*domain.ProductFacets, error) {
+	facets := &domain.ProductFacets{Attributes: make(map[string][]domain.FacetValue)}
+	var err error
+	if facets.Price, err = r.priceFacet(filter); err != nil {
+		return nil, err
+	}
+	q := newProductQuery(filter, facetAvailability)
+	query := `SELECT COUNT(*) FILTER (WHERE p.quantity > 0) AS in_stock, COUNT(*) FILTER (WHERE p.quantity = 0) AS out_of_stock
+	          FROM products p WHERE ` + q.whereSQL()
+	var availability struct {
+		InStock    int `db:"in_stock"`
+		OutOfStock int `db:"out_of_stock"`
+	}
+	if err := r.db.Get(&availability, query, q.args...); err != nil {
+		return nil, r.translateError(err)
+	}
+	facets.Availability = domain.AvailabilityFacet{InStock: availability.InStock, OutOfStock: availability.OutOfStock}
+
+	q = newProductQuery(filter, facetShop)
+	query = `SELECT p.shop_id::text AS value, COUNT(*) AS count FROM products p
+	         WHERE ` + q.whereSQL() + `
+	         GROUP BY p.shop_id ORDER BY count DESC, p.shop_id LIMIT ` + q.arg(maxShopFacetValues)
+	var shopRows []struct {
+		Value string `db:"value"`
+		Count int    `db:"count"`
+	}
+	if err := r.db.Select(&shopRows, query, q.args...); err != nil {
+		return nil, r.translateError(err)
+	}
+	facets.Shops = make([]domain.FacetValue, len(shopRows))
+	for i, row := range shopRows {
+		facets.Shops[i] = domain.FacetValue{Value: row.Value, Count: row.Count}
+	}
+
+	q = newProductQuery(filter, facetAttributes)
+	// В выбранной категории характеристики, которые схема объявляет нефильтруемыми, не показываются
+	hidden := ""
+	if filter.CategoryID > 0 {
+		hidden = ` AND kv.key NOT IN (SELECT key FROM (` + effectiveCategoryAttributes(q.arg(pq.Array([]int64{filter.CategoryID}))) + `) attrs
+		          WHERE NOT filterable)`
+	}
+	query = `SELECT kv.key, kv.value, COUNT(*) AS count
+	         FROM products p CROSS JOIN LATERAL jsonb_each_text(p.attributes) kv
+	         WHERE ` + q.whereSQL() + hidden + `
+	         GROUP BY kv.key, kv.value ORDER BY kv.key, count DESC, kv.value LIMIT ` + q.arg(maxAttributeFacetRows)
+	var attributeRows []struct {
+		Key   string `db:"key"`
+		Value string `db:"value"`
+		Count int    `db:"count"`
+	}
+	if err := r.db.Select(&attributeRows, query, q.args...); err != nil {
+		return nil, r.translateError(err)
+	}
+	for _, row := range attributeRows {
+		if len(facets.Attributes[row.Key]) < maxAttributeFacetValues {
+			facets.Attributes[row.Key] = append(facets.Attributes[row.Key], domain.FacetValue{Value: row.Value, Count: row.Count})
+		}
+	}
+	if facets.Categories, err = r.categoryFacet(filter); err != nil {
+		return nil, err
+	}
+	return facets, nil
+}
+
+// categoryFacet считает товары по подкатегориям выбранной категории (без нее - по корневым),
+// включая товары всех их потомков. Фильтр категории не применяется: поддеревья детей и так
+// лежат внутри выбранной категории.
+func (r *Repository) categoryFacet(filter domain.ProductFilter) ([]domain.FacetValue, error) {
+	var parentID *int64
+	if filter.CategoryID > 0 {
+		parentID = &filter.CategoryID
+	}
+	q := newProductQuery(filter, facetCategory)
+	query := `WITH RECURSIVE tree AS (
+	              SELECT c.id AS facet_id, c.id, c.name, c.position FROM categories c
+	              WHERE c.parent_id IS NOT DISTINCT FROM ` + q.arg(parentID) + `::bigint
+	              UNION ALL
+	              SELECT tree.facet_id, c.id, tree.name, tree.position FROM tree JOIN categories c ON c.parent_id = tree.id
+	          )
+	          SELECT tree.facet_id::text AS value, MIN(tree.name) AS label, COUNT(DISTINCT p.id) AS count
+	          FROM tree
+	          JOIN product_categories pc ON pc.category_id = tree.id
+	          JOIN products p ON p.id = pc.product_id
+	          WHERE ` + q.whereSQL() + `
+	          GROUP BY tree.facet_id ORDER BY MIN(tree.position), tree.facet_id`
+	var rows []struct {
+		Value string `db:"value"`
+		Label string `db:"label"`
+		Count int    `db:"count"`
+	}
+	if err := r.db.Select(&rows, query, q.args...); err != nil {
+		return nil, r.translateError(err)
+	}
+	values := make([]domain.FacetValue, len(rows))
+	for i, row := range rows {
+		values[i] = domain.FacetValue{Value: row.Value, Label: row.Label, Count: row.Count}
+	}
+	return values, nil
+}
+
+// priceFacet считает товары в валюте интервалов по интервалам [0, b1), [b1, b2), ..., [bn, ∞).
+func (r *Repository) priceFacet(filter domain.ProductFilter) ([]domain.PriceBucket, error) {
+	if len(filter.PriceBuckets) == 0 {
+		return []domain.PriceBucket{}, nil
+	}
+	currency := filter.PriceBuckets[0].Currency
+	bounds := make([]string, len(filter.PriceBuckets))
+	for i, bound := range filter.PriceBuckets {
+		bounds[i] = bound.Decimal()
+	}
+	q := newProductQuery(filter, facetPrice)
+	q.where("p.currency = " + q.arg(currency))
+	query := `SELECT width_bucket(p.price, ` + q.arg(pq.StringArray(bounds)) + `::numeric[]) AS bucket, COUNT(*) AS count
+	          FROM products p WHERE ` + q.whereSQL() + `
+	          GROUP BY bucket`
+	var rows []struct {
+		Bucket int `db:"bucket"`
+		Count  int `db:"count"`
+	}
+	if err := r.db.Select(&rows, query, q.args...); err != nil {
+		return nil, r.translateError(err)
+	}
+	buckets := make([]domain.PriceBucket, len(filter.PriceBuckets)+1)
+	for i := range buckets {
+		if i > 0 {
+			buckets[i].From = &filter.PriceBuckets[i-1]
+		}
+		if i < len(filter.PriceBuckets) {
+			buckets[i].To = &filter.PriceBuckets[i]
+		}
+	}
+	for _, row := range rows {
+		if row.Bucket >= 0 && row.Bucket < len(buckets) {
+			buckets[row.Bucket].Count = row.Count
+		}
+	}
+	return buckets, nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"errors"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"testing"
+)
+
+func TestDecreaseProductQuantityInsufficientStock(t *testing.T) {
+	r := testRepository(t)
+	_, productID := testProduct(t, r, 3)
+
+	if err := r.DecreaseProductQuantity(productID, 4); !errors.Is(err, errs.ErrInsufficientStock) {
+		t.Fatalf("DecreaseProductQuantity error = %v, want ErrInsufficientStock", err)
+	}
+	tx, err := r.BeginTx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if err := r.DecreaseProductQuantitiesWithTx(tx, map[int64]int{productID: 4}); !errors.Is(err, errs.ErrInsufficientStock) {
+		t.Fatalf("DecreaseProductQuantitiesWithTx error = %v, want ErrInsufficientStock", err)
+	}
+	if err := r.DecreaseProductQuantitiesWithTx(tx, map[int64]int{productID: 3}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Цены в валютах с тремя знаками minor units сохраняются без округления
+func TestProductPriceRoundTrip(t *testing.T) {
+	r := testRepository(t)
+	_, productID := testProduct(t, r, 1)
+	for _, price := range []domain.Money{domain.NewMoney(12345, "KWD"), domain.NewMoney(1999, "USD"), domain.NewMoney(1500, "JPY")} {
+		if _, err := r.db.Exec(`UPDATE products SET price = $1, currency = $2 WHERE id = $3`, price, price.Currency, productID); err != nil {
+			t.Fatal(err)
+		}
+		product, err := r.GetProductByID(productID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if product.Price != price {
+			t.Errorf("price %s read back as %s", price, product.Price)
+		}
+	}
+}
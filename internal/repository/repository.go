@@ -3,9 +3,11 @@ package repository
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"marketplace/internal/errs"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type Repository struct {
@@ -22,9 +24,40 @@ func (r *Repository) BeginTx() (*sqlx.Tx, error) {
 }
 func (r *Repository) translateError(err error) error {
 	switch {
+	case err == nil:
+		return nil
 	case errors.Is(err, sql.ErrNoRows):
 		return errs.ErrNotfound
+	case isTxConflict(err):
+		return fmt.Errorf("%w: %s", errs.ErrTxConflict, err.Error())
 	default:
 		return err
 	}
 }
+
+// isTxConflict распознает ошибки serialization_failure и deadlock_detected:
+// транзакцию с такой ошибкой можно безопасно повторить целиком.
+func isTxConflict(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
+
+// jsonParam передает JSON в драйвер строкой: lib/pq кодирует []byte как bytea,
+// что не подходит для колонок JSONB.
+func jsonParam(b []byte) interface{} {
+	if b == nil {
+		return nil
+	}
+	return string(b)
+}
+
+// nullString передает пустую строку как NULL.
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
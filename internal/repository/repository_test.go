@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+// testDSNEnv - строка подключения к Postgres с примененными миграциями. Без нее тесты и
+// бенчмарки, которым нужна БД, пропускаются.
+const testDSNEnv = "MARKETPLACE_TEST_DSN"
+
+func testRepository(tb testing.TB) *Repository {
+	tb.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		tb.Skipf("%s is not set", testDSNEnv)
+	}
+	conn, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		tb.Fatalf("connect: %v", err)
+	}
+	conn.SetMaxOpenConns(32)
+	tb.Cleanup(func() { _ = conn.Close() })
+	return NewRepository(conn)
+}
+
+// testProduct создает владельца, магазин и товар с остатком quantity; все удаляется после теста.
+func testProduct(tb testing.TB, r *Repository, quantity int) (userID, productID int64) {
+	tb.Helper()
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	err := r.db.QueryRow(`INSERT INTO users (full_name, username, email, password) VALUES ('Test', $1, $1 || '@test.local', 'x') RETURNING id`,
+		"test_"+suffix).Scan(&userID)
+	if err != nil {
+		tb.Fatalf("create user: %v", err)
+	}
+	tb.Cleanup(func() {
+		_, _ = r.db.Exec(`DELETE FROM flash_sales WHERE created_by = $1`, userID)
+		_, _ = r.db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	})
+	var shopID int64
+	if err := r.db.QueryRow(`INSERT INTO shops (name, slug, owner_id) VALUES ('Test shop', $1, $2) RETURNING id`,
+		"test-shop-"+suffix, userID).Scan(&shopID); err != nil {
+		tb.Fatalf("create shop: %v", err)
+	}
+	if err := r.db.QueryRow(`INSERT INTO products (name, slug, price, currency, quantity, shop_id) VALUES ('Test product', $1, 10, 'USD', $2, $3) RETURNING id`,
+		"test-product-"+suffix, quantity, shopID).Scan(&productID); err != nil {
+		tb.Fatalf("create product: %v", err)
+	}
+	return userID, productID
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"html"
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// Параметры ts_headline. Совпадения отмечаются символами из области частного использования
+// Unicode, а не сразу <mark>: название и описание - пользовательский текст, и перед выдачей
+// его нужно экранировать как HTML (см. highlightHTML).
+const (
+	highlightStart = "\uE000"
+	highlightStop  = "\uE001"
+
+	nameHeadlineOptions    = `StartSel="` + highlightStart + `", StopSel="` + highlightStop + `", HighlightAll=true`
+	snippetHeadlineOptions = `StartSel="` + highlightStart + `", StopSel="` + highlightStop + `", MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=" … "`
+)
+
+// highlightReplacer заменяет метки совпадений на <mark> после экранирования текста.
+var highlightReplacer = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// searchSort - сортировка результатов поиска в курсоре
+const searchSort = "relevance"
+
+type productSearchRow struct {
+	db.Product
+	Rank          float64 `db:"rank"`
+	NameHighlight string  `db:"name_highlight"`
+	Snippet       string  `db:"snippet"`
+}
+
+// SearchProducts ищет товары по всему каталогу. Совпадением считается попадание в полнотекстовый
+// индекс (русская и английская морфология), похожее по триграммам слово в названии (опечатки)
+// или префикс SKU. Релевантность - ts_rank_cd плюс триграммная похожесть названия; она
+// считается в float8, чтобы значение из курсора точно совпадало с пересчитанным. Подсветка
+// строится только для страницы результатов.
+func (r *Repository) SearchProducts(filter domain.ProductSearchFilter) (*domain.ProductSearchResult, error) {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "SearchProducts").Logger()
+	cursor, err := decodeCursor(filter.Cursor, searchSort)
+	if err != nil {
+		return nil, err
+	}
+	q := newProductQuery(filter.ProductFilter, facetNone, filter.Query, likeEscaper.Replace(filter.Query)+"%")
+	q.where("(p.search_vector @@ q.query OR $1 <% p.name OR p.sku ILIKE $2)")
+	const searchQuery = `q AS (
+	              SELECT websearch_to_tsquery('russian', $1) || websearch_to_tsquery('english', $1) || websearch_to_tsquery('simple', $1) AS query
+	          )`
+	result := &domain.ProductSearchResult{}
+	result.Total, err = r.countTotal(filter.WithTotal, `WITH `+searchQuery+` SELECT COUNT(*) FROM products p, q WHERE `+q.whereSQL(), q.args...)
+	if err != nil {
+		logger.Error().Err(err).Str("query", filter.Query).Msg("failed to count search results")
+		return nil, err
+	}
+	after := "true"
+	if cursor != nil {
+		after = "(rank, id) < (" + q.arg(cursor.Key) + "::float8, " + q.arg(cursor.ID) + ")"
+	}
+	language := q.arg(filter.Language)
+	query := `WITH ` + searchQuery + `, matches AS (
+	              SELECT p.id, (ts_rank_cd(p.search_vector, q.query, 32) + word_similarity($1, p.name))::float8 AS rank
+	              FROM products p, q
+	              WHERE ` + q.whereSQL() + `
+	          ), hits AS (
+	              SELECT id, rank FROM matches
+	              WHERE ` + after + `
+	              ORDER BY rank DESC, id DESC
+	              LIMIT ` + q.arg(filter.Limit+1) + `
+	          )
+	          SELECT ` + prefixColumns("p", productColumns) + `, hits.rank,
+	              ts_headline(` + language + `::regconfig, p.name, q.query, '` + nameHeadlineOptions + `') AS name_highlight,
+	              COALESCE(ts_headline(` + language + `::regconfig, p.description, q.query, '` + snippetHeadlineOptions + `'), '') AS snippet
+	          FROM hits JOIN products p ON p.id = hits.id, q
+	          ORDER BY hits.rank DESC, p.id DESC`
+	var rows []productSearchRow
+	if err := r.db.Select(&rows, query, q.args...); err != nil {
+		logger.Error().Err(err).Str("query", filter.Query).Msg("failed to search products")
+		return nil, r.translateCursorError(err, cursor)
+	}
+	rows, result.NextCursor = trimPage(rows, filter.Limit, func(row productSearchRow) pageCursor {
+		return pageCursor{Sort: searchSort, Key: strconv.FormatFloat(row.Rank, 'g', -1, 64), ID: row.ID}
+	})
+	result.Items = make([]domain.ProductSearchHit, len(rows))
+	for i, row := range rows {
+		product, err := row.Product.ToDomain()
+		if err != nil {
+			return nil, err
+		}
+		result.Items[i] = domain.ProductSearchHit{
+			Product:       product,
+			Rank:          row.Rank,
+			NameHighlight: highlightHTML(row.NameHighlight),
+			Snippet:       highlightHTML(row.Snippet),
+		}
+	}
+	return result, nil
+}
+
+// highlightHTML экранирует текст с метками ts_headline как HTML и превращает метки в <mark>.
+// Теги и сущности из названия и описания товара выдаются как текст, поэтому единственная
+// разметка в результате - <mark>. Метки, попавшие в сам текст товара, дают лишь лишний <mark>.
+func highlightHTML(headline string) string {
+	return highlightReplacer.Replace(html.EscapeString(headline))
+}
+
+// prefixColumns добавляет псевдоним таблицы к списку колонок "a, b, c".
+func prefixColumns(alias, columns string) string {
+	return alias + "." + strings.ReplaceAll(columns, ", ", ", "+alias+".")
+}
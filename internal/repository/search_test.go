@@ -0,0 +1,23 @@
+package repository
+
+import "testing"
+
+func TestHighlightHTML(t *testing.T) {
+	tests := []struct {
+		headline string
+		want     string
+	}{
+		{headline: "Смартфон " + highlightStart + "Galaxy" + highlightStop + " S24", want: "Смартфон <mark>Galaxy</mark> S24"},
+		{
+			headline: `<img src=x onerror="alert(1)"> ` + highlightStart + "phone" + highlightStop,
+			want:     `&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>phone</mark>`,
+		},
+		{headline: "Tom & Jerry's " + highlightStart + "<b>" + highlightStop, want: "Tom &amp; Jerry&#39;s <mark>&lt;b&gt;</mark>"},
+		{headline: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := highlightHTML(tt.headline); got != tt.want {
+			t.Errorf("highlightHTML(%q) = %q, want %q", tt.headline, got, tt.want)
+		}
+	}
+}
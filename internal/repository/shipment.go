@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+func (r *Repository) CreateShipmentWithTx(tx *sqlx.Tx, shipment *domain.Shipment) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateShipmentWithTx").Logger()
+	dbShipment := db.Shipment{}
+	dbShipment.FromDomain(shipment)
+	now := time.Now()
+	query := `INSERT INTO shipments (order_id, shop_id, carrier, tracking_number, status, shipped_at, delivered_at, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING id, created_at, updated_at`
+	err := tx.QueryRow(query, dbShipment.OrderID, dbShipment.ShopID, dbShipment.Carrier, dbShipment.TrackingNumber, dbShipment.Status,
+		dbShipment.ShippedAt, dbShipment.DeliveredAt, now, now).Scan(&dbShipment.ID, &dbShipment.CreatedAt, &dbShipment.UpdatedAt)
+	if err != nil {
+		logger.Error().Err(err).Int64("order_id", shipment.OrderID).Msg("failed to create shipment")
+		return r.translateError(err)
+	}
+	shipment.ID = dbShipment.ID
+	shipment.CreatedAt = dbShipment.CreatedAt
+	shipment.UpdatedAt = dbShipment.UpdatedAt
+	itemQuery := `INSERT INTO shipment_items (shipment_id, order_item_id, quantity) VALUES ($1, $2, $3) RETURNING id`
+	for i := range shipment.Items {
+		shipment.Items[i].ShipmentID = shipment.ID
+		if err := tx.QueryRow(itemQuery, shipment.ID, shipment.Items[i].OrderItemID, shipment.Items[i].Quantity).Scan(&shipment.Items[i].ID); err != nil {
+			logger.Error().Err(err).Int64("shipment_id", shipment.ID).Msg("failed to create shipment item")
+			return r.translateError(err)
+		}
+	}
+	logger.Info().Int64("shipment_id", shipment.ID).Msg("shipment created successfully")
+	return nil
+}
+
+func (r *Repository) GetShipmentByIDWithTx(tx *sqlx.Tx, id int64) (*domain.Shipment, error) {
+	var dbShipment db.Shipment
+	query := `SELECT id, order_id, shop_id, carrier, tracking_number, status, shipped_at, delivered_at, created_at, updated_at FROM shipments WHERE id = $1 FOR UPDATE`
+	if err := tx.Get(&dbShipment, query, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbShipment.ToDomain(), nil
+}
+
+func (r *Repository) UpdateShipmentStatusWithTx(tx *sqlx.Tx, shipment *domain.Shipment) error {
+	query := `UPDATE shipments SET status = $1, shipped_at = $2, delivered_at = $3, updated_at = $4 WHERE id = $5`
+	shipment.UpdatedAt = time.Now()
+	_, err := tx.Exec(query, shipment.Status, shipment.ShippedAt, shipment.DeliveredAt, shipment.UpdatedAt, shipment.ID)
+	if err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+func (r *Repository) CreateShipmentEventWithTx(tx *sqlx.Tx, event *domain.ShipmentEvent) error {
+	dbEvent := db.ShipmentEvent{}
+	dbEvent.FromDomain(event)
+	query := `INSERT INTO shipment_events (shipment_id, status, location, description, occurred_at) VALUES ($1,$2,$3,$4,$5) RETURNING id, created_at`
+	err := tx.QueryRow(query, dbEvent.ShipmentID, dbEvent.Status, dbEvent.Location, dbEvent.Description, dbEvent.OccurredAt).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+func (r *Repository) ListShipmentsByOrderIDWithTx(tx *sqlx.Tx, orderID int64) ([]domain.Shipment, error) {
+	return r.listShipmentsByOrderID(tx, orderID, false)
+}
+
+func (r *Repository) ListShipmentsByOrderID(orderID int64) ([]domain.Shipment, error) {
+	return r.listShipmentsByOrderID(r.db, orderID, true)
+}
+
+func (r *Repository) listShipmentsByOrderID(q sqlx.Queryer, orderID int64, withEvents bool) ([]domain.Shipment, error) {
+	var dbShipments []db.Shipment
+	query := `SELECT id, order_id, shop_id, carrier, tracking_number, status, shipped_at, delivered_at, created_at, updated_at FROM shipments WHERE order_id = $1 ORDER BY created_at, id`
+	if err := sqlx.Select(q, &dbShipments, query, orderID); err != nil {
+		return nil, r.translateError(err)
+	}
+	if len(dbShipments) == 0 {
+		return []domain.Shipment{}, nil
+	}
+	shipments := make([]domain.Shipment, len(dbShipments))
+	index := make(map[int64]int, len(dbShipments))
+	ids := make([]int64, len(dbShipments))
+	for i, s := range dbShipments {
+		shipments[i] = *s.ToDomain()
+		index[s.ID] = i
+		ids[i] = s.ID
+	}
+	var dbItems []db.ShipmentItem
+	itemsQuery := `SELECT id, shipment_id, order_item_id, quantity FROM shipment_items WHERE shipment_id = ANY($1) ORDER BY id`
+	if err := sqlx.Select(q, &dbItems, itemsQuery, pq.Array(ids)); err != nil {
+		return nil, r.translateError(err)
+	}
+	for _, item := range dbItems {
+		i := index[item.ShipmentID]
+		shipments[i].Items = append(shipments[i].Items, *item.ToDomain())
+	}
+	if !withEvents {
+		return shipments, nil
+	}
+	var dbEvents []db.ShipmentEvent
+	eventsQuery := `SELECT id, shipment_id, status, location, description, occurred_at, created_at FROM shipment_events WHERE shipment_id = ANY($1) ORDER BY occurred_at, id`
+	if err := sqlx.Select(q, &dbEvents, eventsQuery, pq.Array(ids)); err != nil {
+		return nil, r.translateError(err)
+	}
+	for _, event := range dbEvents {
+		i := index[event.ShipmentID]
+		shipments[i].Events = append(shipments[i].Events, *event.ToDomain())
+	}
+	return shipments, nil
+}
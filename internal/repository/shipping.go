@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+const shippingMethodColumns = `id, zone_id, shop_id, name, type, rate, per_kg_rate, free_threshold, max_weight_grams, currency, active, created_at, updated_at`
+
+func (r *Repository) CreateShippingZone(zone *domain.ShippingZone) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateShippingZone").Logger()
+	dbZone := db.ShippingZone{}
+	dbZone.FromDomain(zone)
+	now := time.Now()
+	query := `INSERT INTO shipping_zones (shop_id, name, countries, created_at, updated_at) VALUES ($1,$2,$3,$4,$5) RETURNING id, created_at, updated_at`
+	err := r.db.QueryRow(query, dbZone.ShopID, dbZone.Name, dbZone.Countries, now, now).Scan(&dbZone.ID, &dbZone.CreatedAt, &dbZone.UpdatedAt)
+	if err != nil {
+		logger.Error().Err(err).Int64("shop_id", dbZone.ShopID).Msg("failed to create shipping zone")
+		return r.translateError(err)
+	}
+	zone.ID = dbZone.ID
+	zone.CreatedAt = dbZone.CreatedAt
+	zone.UpdatedAt = dbZone.UpdatedAt
+	return nil
+}
+
+func (r *Repository) GetShippingZoneByID(id int64) (*domain.ShippingZone, error) {
+	var dbZone db.ShippingZone
+	query := `SELECT id, shop_id, name, countries, created_at, updated_at FROM shipping_zones WHERE id = $1`
+	if err := r.db.Get(&dbZone, query, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbZone.ToDomain(), nil
+}
+
+func (r *Repository) DeleteShippingZone(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM shipping_zones WHERE id = $1`, id)
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return r.translateError(err)
+	} else if rowsAffected == 0 {
+		return errs.ErrShippingZoneNotFound
+	}
+	return nil
+}
+
+// ListShippingZones возвращает зоны магазинов вместе со способами доставки.
+// Если activeOnly, неактивные способы не загружаются.
+func (r *Repository) ListShippingZones(shopIDs []int64, activeOnly bool) ([]domain.ShippingZone, error) {
+	var dbZones []db.ShippingZone
+	query := `SELECT id, shop_id, name, countries, created_at, updated_at FROM shipping_zones WHERE shop_id = ANY($1) ORDER BY shop_id, id`
+	if err := r.db.Select(&dbZones, query, pq.Array(shopIDs)); err != nil {
+		return nil, r.translateError(err)
+	}
+	return r.withShippingMethods(dbZones, activeOnly)
+}
+
+// ListShippingZonesPage возвращает страницу зон магазина, новые первыми (по убыванию id), вместе со всеми
+// способами доставки.
+func (r *Repository) ListShippingZonesPage(shopID int64, page domain.PageRequest) (*domain.Page[domain.ShippingZone], error) {
+	cursor, err := decodeCursor(page.Cursor, "")
+	if err != nil {
+		return nil, err
+	}
+	var dbZones []db.ShippingZone
+	query := `SELECT id, shop_id, name, countries, created_at, updated_at FROM shipping_zones WHERE shop_id = $1 AND ($2 = 0 OR id < $2) ORDER BY id DESC LIMIT $3`
+	if err := r.db.Select(&dbZones, query, shopID, cursorID(cursor), page.Limit+1); err != nil {
+		return nil, r.translateError(err)
+	}
+	dbZones, nextCursor := trimPage(dbZones, page.Limit, func(z db.ShippingZone) pageCursor { return pageCursor{ID: z.ID} })
+	zones, err := r.withShippingMethods(dbZones, false)
+	if err != nil {
+		return nil, err
+	}
+	result := &domain.Page[domain.ShippingZone]{Items: zones, NextCursor: nextCursor}
+	if result.Total, err = r.countTotal(page.WithTotal, `SELECT COUNT(*) FROM shipping_zones WHERE shop_id = $1`, shopID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// withShippingMethods переводит зоны в домен и загружает их способы доставки одним запросом.
+func (r *Repository) withShippingMethods(dbZones []db.ShippingZone, activeOnly bool) ([]domain.ShippingZone, error) {
+	zones := make([]domain.ShippingZone, len(dbZones))
+	index := make(map[int64]int, len(dbZones))
+	zoneIDs := make([]int64, len(dbZones))
+	for i, z := range dbZones {
+		zones[i] = *z.ToDomain()
+		index[z.ID] = i
+		zoneIDs[i] = z.ID
+	}
+	if len(zones) == 0 {
+		return zones, nil
+	}
+	var dbMethods []db.ShippingMethod
+	methodsQuery := `SELECT ` + shippingMethodColumns + ` FROM shipping_methods WHERE zone_id = ANY($1) AND (active OR NOT $2) ORDER BY zone_id, id`
+	if err := r.db.Select(&dbMethods, methodsQuery, pq.Array(zoneIDs), activeOnly); err != nil {
+		return nil, r.translateError(err)
+	}
+	for _, m := range dbMethods {
+		i := index[m.ZoneID]
+		method, err := m.ToDomain()
+		if err != nil {
+			return nil, err
+		}
+		zones[i].Methods = append(zones[i].Methods, *method)
+	}
+	return zones, nil
+}
+
+func (r *Repository) CreateShippingMethod(method *domain.ShippingMethod) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateShippingMethod").Logger()
+	dbMethod := db.ShippingMethod{}
+	dbMethod.FromDomain(method)
+	now := time.Now()
+	query := `INSERT INTO shipping_methods (zone_id, shop_id, name, type, rate, per_kg_rate, free_threshold, max_weight_grams, currency, active, created_at, updated_at)
+	          VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12) RETURNING id, created_at, updated_at`
+	err := r.db.QueryRow(query, dbMethod.ZoneID, dbMethod.ShopID, dbMethod.Name, dbMethod.Type, dbMethod.Rate, dbMethod.PerKgRate,
+		dbMethod.FreeThreshold, dbMethod.MaxWeightGrams, dbMethod.Currency, dbMethod.Active, now, now).
+		Scan(&dbMethod.ID, &dbMethod.CreatedAt, &dbMethod.UpdatedAt)
+	if err != nil {
+		logger.Error().Err(err).Int64("zone_id", dbMethod.ZoneID).Msg("failed to create shipping method")
+		return r.translateError(err)
+	}
+	method.ID = dbMethod.ID
+	method.CreatedAt = dbMethod.CreatedAt
+	method.UpdatedAt = dbMethod.UpdatedAt
+	return nil
+}
+
+func (r *Repository) GetShippingMethodByID(id int64) (*domain.ShippingMethod, error) {
+	var dbMethod db.ShippingMethod
+	query := `SELECT ` + shippingMethodColumns + ` FROM shipping_methods WHERE id = $1`
+	if err := r.db.Get(&dbMethod, query, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbMethod.ToDomain()
+}
+
+func (r *Repository) UpdateShippingMethod(method *domain.ShippingMethod) error {
+	dbMethod := db.ShippingMethod{}
+	dbMethod.FromDomain(method)
+	query := `UPDATE shipping_methods SET name = $1, type = $2, rate = $3, per_kg_rate = $4, free_threshold = $5, max_weight_grams = $6,
+	          currency = $7, active = $8, updated_at = $9 WHERE id = $10`
+	_, err := r.db.Exec(query, dbMethod.Name, dbMethod.Type, dbMethod.Rate, dbMethod.PerKgRate, dbMethod.FreeThreshold,
+		dbMethod.MaxWeightGrams, dbMethod.Currency, dbMethod.Active, time.Now(), dbMethod.ID)
+	if err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+func (r *Repository) DeleteShippingMethod(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM shipping_methods WHERE id = $1`, id)
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return r.translateError(err)
+	} else if rowsAffected == 0 {
+		return errs.ErrShippingMethodNotFound
+	}
+	return nil
+}
@@ -1,27 +1,32 @@
 package repository
 
 import (
+	"database/sql"
+	"errors"
 	"marketplace/internal/errs"
 	"marketplace/internal/models/db"
 	"marketplace/internal/models/domain"
 	"os"
 	"time"
 
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/rs/zerolog"
 )
 
-func (r *Repository) CreateShop(shop *domain.Shop) error {
-	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateShop").Logger()
+func (r *Repository) CreateShopWithTx(tx *sqlx.Tx, shop *domain.Shop) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateShopWithTx").Logger()
 	dbShop := db.Shop{}
 	dbShop.FromDomain(shop)
 	now := time.Now()
-	query := `INSERT INTO shops (name, slug, owner_id, description, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$6) RETURNING id, created_at, updated_at`
-	err := r.db.QueryRow(query, dbShop.Name, dbShop.Slug, dbShop.OwnerID, dbShop.Description, now, now).Scan(&dbShop.ID, &dbShop.CreatedAt, &dbShop.UpdatedAt)
+	query := `INSERT INTO shops (name, slug, owner_id, description, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$6) RETURNING id, version, created_at, updated_at`
+	err := tx.QueryRow(query, dbShop.Name, dbShop.Slug, dbShop.OwnerID, dbShop.Description, now, now).Scan(&dbShop.ID, &dbShop.Version, &dbShop.CreatedAt, &dbShop.UpdatedAt)
 	if err != nil {
 		logger.Error().Err(err).Msg("failed to create shop")
 		return errs.ErrSomethingWentWrong
 	}
 	shop.ID = dbShop.ID
+	shop.Version = dbShop.Version
 	shop.CreatedAt = dbShop.CreatedAt
 	shop.UpdatedAt = dbShop.UpdatedAt
 	logger.Info().Int64("shop_id", shop.ID).Msg("shop created successfully")
@@ -33,7 +38,7 @@ func (r *Repository) GetShopByID(id int64) (*domain.Shop, error) {
 		return nil, errs.ErrInvalidID
 	}
 	var dbShop db.Shop
-	query := `SELECT id, name, slug, owner_id, description, created_at, updated_at, deleted_at FROM shops WHERE id = $1 AND deleted_at IS NULL`
+	query := `SELECT id, name, slug, owner_id, description, version, created_at, updated_at, deleted_at FROM shops WHERE id = $1 AND deleted_at IS NULL`
 	err := r.db.Get(&dbShop, query, id)
 	if err != nil {
 		logger.Error().Err(err).Int64("id", id).Msg("failed to get shop by id")
@@ -41,6 +46,9 @@ func (r *Repository) GetShopByID(id int64) (*domain.Shop, error) {
 	}
 	return dbShop.ToDomain(), nil
 }
+
+// UpdateShop сохраняет магазин. shop.Version - прочитанная версия магазина, после
+// обновления в нее записывается новая.
 func (r *Repository) UpdateShop(shop *domain.Shop) error {
 	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "UpdateShop").Logger()
 	if shop == nil || shop.ID <= 0 {
@@ -49,51 +57,90 @@ func (r *Repository) UpdateShop(shop *domain.Shop) error {
 	dbShop := db.Shop{}
 	dbShop.FromDomain(shop)
 	dbShop.UpdatedAt = time.Now()
-	query := `UPDATE shops SET name = $1, slug = $2, description = $3, updated_at = $4 WHERE id = $5 AND deleted_at IS NULL`
-	_, err := r.db.Exec(query, dbShop.Name, dbShop.Slug, dbShop.Description, dbShop.UpdatedAt, dbShop.ID)
+	version, err := updateVersioned(r.db, "shops", dbShop.ID, dbShop.Version, `name = $1, slug = $2, description = $3, updated_at = $4`,
+		dbShop.Name, dbShop.Slug, dbShop.Description, dbShop.UpdatedAt)
 	if err != nil {
 		logger.Error().Err(err).Int64("id", dbShop.ID).Msg("failed to update shop")
-		return errs.ErrSomethingWentWrong
+		return r.translateShopError(err)
 	}
+	shop.Version = version
 	logger.Info().Int64("shop_id", dbShop.ID).Msg("shop updated successfully")
 	return nil
 }
-func (r *Repository) DeleteShop(id int64) error {
+
+// DeleteShop помечает магазин удаленным; expectedVersion - как в UpdateShop.
+func (r *Repository) DeleteShop(id, expectedVersion int64) error {
 	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "DeleteShop").Logger()
 	if id <= 0 {
 		return errs.ErrInvalidID
 	}
-	query := `UPDATE shops SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`
-	_, err := r.db.Exec(query, time.Now(), id)
-	if err != nil {
+	if _, err := updateVersioned(r.db, "shops", id, expectedVersion, `deleted_at = $1`, time.Now()); err != nil {
 		logger.Error().Err(err).Int64("id", id).Msg("failed to delete shop")
-		return errs.ErrSomethingWentWrong
+		return r.translateShopError(err)
 	}
 	logger.Info().Int64("shop_id", id).Msg("shop soft deleted successfully")
 	return nil
 }
-func (r *Repository) ListShops(ownerID int64, limit, offset int) ([]*domain.Shop, error) {
+
+func (r *Repository) translateShopError(err error) error {
+	var pqErr *pq.Error
+	switch {
+	case errors.As(err, &pqErr) && pqErr.Code == "23505":
+		return errs.ErrShopSlugAlreadyExists
+	case errors.Is(err, errs.ErrVersionMismatch):
+		return err
+	case errors.Is(err, sql.ErrNoRows):
+		return errs.ErrShopNotFound
+	}
+	return errs.ErrSomethingWentWrong
+}
+
+// ListShops возвращает магазины владельца, новые первыми (по убыванию id).
+func (r *Repository) ListShops(ownerID int64, page domain.PageRequest) (*domain.Page[*domain.Shop], error) {
 	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "ListShops").Logger()
 	if ownerID <= 0 {
 		return nil, errs.ErrInvalidID
 	}
-	if limit <= 0 {
-		limit = 20
-	}
-	if offset < 0 {
-		offset = 0
+	cursor, err := decodeCursor(page.Cursor, "")
+	if err != nil {
+		return nil, err
 	}
 	var dbShops []db.Shop
-	query := `SELECT id, name, slug, owner_id, description, created_at, updated_at, deleted_at FROM shops WHERE owner_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC LIMIT $2 OFFSET $3`
-	err := r.db.Select(&dbShops, query, ownerID, limit, offset)
-	if err != nil {
+	query := `SELECT id, name, slug, owner_id, description, version, created_at, updated_at, deleted_at FROM shops
+	          WHERE owner_id = $1 AND deleted_at IS NULL AND ($2 = 0 OR id < $2)
+	          ORDER BY id DESC LIMIT $3`
+	if err := r.db.Select(&dbShops, query, ownerID, cursorID(cursor), page.Limit+1); err != nil {
 		logger.Error().Err(err).Msg("failed to list shops")
 		return nil, r.translateError(err)
 	}
-	shops := make([]*domain.Shop, 0, len(dbShops))
+	dbShops, nextCursor := trimPage(dbShops, page.Limit, func(sh db.Shop) pageCursor { return pageCursor{ID: sh.ID} })
+	result := &domain.Page[*domain.Shop]{Items: make([]*domain.Shop, 0, len(dbShops)), NextCursor: nextCursor}
 	for _, sh := range dbShops {
-		shops = append(shops, sh.ToDomain())
+		result.Items = append(result.Items, sh.ToDomain())
+	}
+	result.Total, err = r.countTotal(page.WithTotal, `SELECT COUNT(*) FROM shops WHERE owner_id = $1 AND deleted_at IS NULL`, ownerID)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to count shops")
+		return nil, err
+	}
+	logger.Info().Int("count", len(result.Items)).Msg("shops listed successfully")
+	return result, nil
+}
+
+func (r *Repository) ListShopIDsByOwner(ownerID int64) ([]int64, error) {
+	var shopIDs []int64
+	if err := r.db.Select(&shopIDs, `SELECT id FROM shops WHERE owner_id = $1 AND deleted_at IS NULL ORDER BY id`, ownerID); err != nil {
+		return nil, r.translateError(err)
+	}
+	return shopIDs, nil
+}
+
+// ListShopOwnerIDs возвращает владельцев магазинов shopIDs без повторов.
+func (r *Repository) ListShopOwnerIDs(shopIDs []int64) ([]int64, error) {
+	var ownerIDs []int64
+	query := `SELECT DISTINCT owner_id FROM shops WHERE id = ANY($1) AND deleted_at IS NULL ORDER BY owner_id`
+	if err := r.db.Select(&ownerIDs, query, pq.Array(shopIDs)); err != nil {
+		return nil, r.translateError(err)
 	}
-	logger.Info().Int("count", len(shops)).Msg("shops listed successfully")
-	return shops, nil
+	return ownerIDs, nil
 }
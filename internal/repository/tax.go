@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+const taxRateColumns = `id, country, region, tax_class, name, rate, inclusive, active, created_at, updated_at`
+
+func (r *Repository) CreateTaxRate(rate *domain.TaxRate) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateTaxRate").Logger()
+	dbRate := db.TaxRate{}
+	dbRate.FromDomain(rate)
+	now := time.Now()
+	query := `INSERT INTO tax_rates (country, region, tax_class, name, rate, inclusive, active, created_at, updated_at)
+	          VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING id, created_at, updated_at`
+	err := r.db.QueryRow(query, dbRate.Country, dbRate.Region, dbRate.TaxClass, dbRate.Name, dbRate.Rate, dbRate.Inclusive, dbRate.Active, now, now).
+		Scan(&dbRate.ID, &dbRate.CreatedAt, &dbRate.UpdatedAt)
+	if err != nil {
+		logger.Error().Err(err).Str("country", dbRate.Country).Str("tax_class", dbRate.TaxClass).Msg("failed to create tax rate")
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return errs.ErrTaxRateAlreadyExists
+		}
+		return r.translateError(err)
+	}
+	rate.ID = dbRate.ID
+	rate.CreatedAt = dbRate.CreatedAt
+	rate.UpdatedAt = dbRate.UpdatedAt
+	return nil
+}
+
+func (r *Repository) GetTaxRateByID(id int64) (*domain.TaxRate, error) {
+	var dbRate db.TaxRate
+	query := `SELECT ` + taxRateColumns + ` FROM tax_rates WHERE id = $1`
+	if err := r.db.Get(&dbRate, query, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbRate.ToDomain(), nil
+}
+
+func (r *Repository) UpdateTaxRate(rate *domain.TaxRate) error {
+	dbRate := db.TaxRate{}
+	dbRate.FromDomain(rate)
+	query := `UPDATE tax_rates SET country = $1, region = $2, tax_class = $3, name = $4, rate = $5, inclusive = $6, active = $7, updated_at = $8
+	          WHERE id = $9 RETURNING updated_at`
+	err := r.db.QueryRow(query, dbRate.Country, dbRate.Region, dbRate.TaxClass, dbRate.Name, dbRate.Rate, dbRate.Inclusive, dbRate.Active,
+		time.Now(), dbRate.ID).Scan(&rate.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return errs.ErrTaxRateAlreadyExists
+		}
+		if err = r.translateError(err); err == errs.ErrNotfound {
+			return errs.ErrTaxRateNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) DeleteTaxRate(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM tax_rates WHERE id = $1`, id)
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return r.translateError(err)
+	} else if rowsAffected == 0 {
+		return errs.ErrTaxRateNotFound
+	}
+	return nil
+}
+
+// ListTaxRatesPage возвращает страницу ставок, новые первыми (по убыванию id); пустой country - ставки всех стран.
+func (r *Repository) ListTaxRatesPage(country string, page domain.PageRequest) (*domain.Page[domain.TaxRate], error) {
+	cursor, err := decodeCursor(page.Cursor, "")
+	if err != nil {
+		return nil, err
+	}
+	var dbRates []db.TaxRate
+	query := `SELECT ` + taxRateColumns + ` FROM tax_rates WHERE ($1 = '' OR country = $1) AND ($2 = 0 OR id < $2) ORDER BY id DESC LIMIT $3`
+	if err := r.db.Select(&dbRates, query, country, cursorID(cursor), page.Limit+1); err != nil {
+		return nil, r.translateError(err)
+	}
+	dbRates, nextCursor := trimPage(dbRates, page.Limit, func(rate db.TaxRate) pageCursor { return pageCursor{ID: rate.ID} })
+	result := &domain.Page[domain.TaxRate]{Items: make([]domain.TaxRate, 0, len(dbRates)), NextCursor: nextCursor}
+	for _, rate := range dbRates {
+		result.Items = append(result.Items, *rate.ToDomain())
+	}
+	if result.Total, err = r.countTotal(page.WithTotal, `SELECT COUNT(*) FROM tax_rates WHERE $1 = '' OR country = $1`, country); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListTaxRates возвращает все ставки для расчета налога заказа; пустой country - ставки всех стран.
+func (r *Repository) ListTaxRates(country string, activeOnly bool) ([]domain.TaxRate, error) {
+	var dbRates []db.TaxRate
+	query := `SELECT ` + taxRateColumns + ` FROM tax_rates
+	          WHERE ($1 = '' OR country = $1) AND (NOT $2 OR active)
+	          ORDER BY country, region, tax_class`
+	if err := r.db.Select(&dbRates, query, country, activeOnly); err != nil {
+		return nil, r.translateError(err)
+	}
+	rates := make([]domain.TaxRate, 0, len(dbRates))
+	for _, rate := range dbRates {
+		rates = append(rates, *rate.ToDomain())
+	}
+	return rates, nil
+}
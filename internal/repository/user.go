@@ -7,26 +7,28 @@ import (
 	"os"
 	"strings"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/rs/zerolog"
 )
 
-func (repository *Repository) CreateUser(user domain.User) (err error) {
-	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func_name", "repository.CreateUser").Logger()
-	_, err = repository.db.Exec(`INSERT INTO users (full_name, username, email, password, phone) VALUES ($1, $2, $3, $4, $5)`, user.FullName, user.Username, user.Email, user.Password, user.Phone)
+// CreateUserWithTx создает пользователя в транзакции вызывающего и возвращает его id.
+func (repository *Repository) CreateUserWithTx(tx *sqlx.Tx, user domain.User) (id int, err error) {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func_name", "repository.CreateUserWithTx").Logger()
+	err = tx.QueryRow(`INSERT INTO users (full_name, username, email, password, phone) VALUES ($1, $2, $3, $4, $5) RETURNING id`, user.FullName, user.Username, user.Email, user.Password, user.Phone).Scan(&id)
 	if err != nil {
 		logger.Err(err).Msg("error inserting user")
 		if strings.Contains(err.Error(), "unique constraint") {
 			if strings.Contains(err.Error(), "username") {
-				return errs.ErrUsernameAlreadyExists
+				return 0, errs.ErrUsernameAlreadyExists
 			} else if strings.Contains(err.Error(), "email") {
-				return errs.ErrEmailAlreadyExists
+				return 0, errs.ErrEmailAlreadyExists
 			} else if strings.Contains(err.Error(), "phone") {
-				return errs.ErrPhoneAlreadyExists
+				return 0, errs.ErrPhoneAlreadyExists
 			}
 		}
-		return repository.translateError(err)
+		return 0, repository.translateError(err)
 	}
-	return nil
+	return id, nil
 }
 func (repository *Repository) GetUserByID(id int) (domain.User, error) {
 	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func_name", "repository.GetUserByID").Logger()
@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"errors"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+// Цена варианта хранится без валюты, поэтому она берется из товара
+const variantColumns = `v.id, v.product_id, v.sku, v.options, v.price, p.currency, v.quantity, v.active, v.position, v.created_at, v.updated_at, v.deleted_at`
+
+const categoryAttributeColumns = `id, category_id, key, name, type, "values", required, filterable, position`
+
+// ListProductOptions возвращает опции товаров в порядке position.
+func (r *Repository) ListProductOptions(productIDs []int64) (map[int64][]domain.ProductOption, error) {
+	var dbOptions []db.ProductOption
+	query := `SELECT id, product_id, name, position, "values" FROM product_options
+	          WHERE product_id = ANY($1) ORDER BY product_id, position, id`
+	if err := r.db.Select(&dbOptions, query, pq.Array(productIDs)); err != nil {
+		return nil, r.translateError(err)
+	}
+	options := make(map[int64][]domain.ProductOption)
+	for _, o := range dbOptions {
+		options[o.ProductID] = append(options[o.ProductID], o.ToDomain())
+	}
+	return options, nil
+}
+
+// SetProductOptionsWithTx заменяет опции товара; position - порядок в списке.
+func (r *Repository) SetProductOptionsWithTx(tx *sqlx.Tx, productID int64, options []domain.ProductOption) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "SetProductOptionsWithTx").Logger()
+	if _, err := tx.Exec(`DELETE FROM product_options WHERE product_id = $1`, productID); err != nil {
+		logger.Error().Err(err).Int64("product_id", productID).Msg("failed to delete product options")
+		return r.translateError(err)
+	}
+	query := `INSERT INTO product_options (product_id, name, position, "values") VALUES ($1, $2, $3, $4) RETURNING id`
+	for i := range options {
+		options[i].ProductID = productID
+		options[i].Position = i
+		if err := tx.QueryRow(query, productID, options[i].Name, i, pq.Array(options[i].Values)).Scan(&options[i].ID); err != nil {
+			logger.Error().Err(err).Int64("product_id", productID).Msg("failed to insert product option")
+			return r.translateError(err)
+		}
+	}
+	return nil
+}
+
+// ListProductVariants возвращает неудаленные варианты товаров в порядке position.
+func (r *Repository) ListProductVariants(productIDs []int64) (map[int64][]domain.ProductVariant, error) {
+	var dbVariants []db.ProductVariant
+	query := `SELECT ` + variantColumns + ` FROM product_variants v JOIN products p ON p.id = v.product_id
+	          WHERE v.product_id = ANY($1) AND v.deleted_at IS NULL ORDER BY v.product_id, v.position, v.id`
+	if err := r.db.Select(&dbVariants, query, pq.Array(productIDs)); err != nil {
+		return nil, r.translateError(err)
+	}
+	variants := make(map[int64][]domain.ProductVariant)
+	for _, v := range dbVariants {
+		variant, err := v.ToDomain()
+		if err != nil {
+			return nil, err
+		}
+		variants[v.ProductID] = append(variants[v.ProductID], *variant)
+	}
+	return variants, nil
+}
+
+// ListProductVariantsWithTx возвращает неудаленные варианты товара внутри транзакции.
+func (r *Repository) ListProductVariantsWithTx(tx *sqlx.Tx, productID int64) ([]*domain.ProductVariant, error) {
+	var dbVariants []db.ProductVariant
+	query := `SELECT ` + variantColumns + ` FROM product_variants v JOIN products p ON p.id = v.product_id
+	          WHERE v.product_id = $1 AND v.deleted_at IS NULL ORDER BY v.position, v.id`
+	if err := tx.Select(&dbVariants, query, productID); err != nil {
+		return nil, r.translateError(err)
+	}
+	variants := make([]*domain.ProductVariant, len(dbVariants))
+	for i, v := range dbVariants {
+		variant, err := v.ToDomain()
+		if err != nil {
+			return nil, err
+		}
+		variants[i] = variant
+	}
+	return variants, nil
+}
+
+func (r *Repository) GetProductVariantByID(id int64) (*domain.ProductVariant, error) {
+	var dbVariant db.ProductVariant
+	query := `SELECT ` + variantColumns + ` FROM product_variants v JOIN products p ON p.id = v.product_id
+	          WHERE v.id = $1 AND v.deleted_at IS NULL AND p.deleted_at IS NULL`
+	if err := r.db.Get(&dbVariant, query, id); err != nil {
+		return nil, r.translateVariantError(err)
+	}
+	return dbVariant.ToDomain()
+}
+
+// LockProductVariantsWithTx блокирует варианты в порядке возрастания id, как LockProductsWithTx.
+// Удаленные варианты в результат не попадают.
+func (r *Repository) LockProductVariantsWithTx(tx *sqlx.Tx, ids []int64) ([]*domain.ProductVariant, error) {
+	var dbVariants []db.ProductVariant
+	query := `SELECT ` + variantColumns + ` FROM product_variants v JOIN products p ON p.id = v.product_id
+	          WHERE v.id = ANY($1) AND v.deleted_at IS NULL ORDER BY v.id FOR UPDATE OF v`
+	if err := tx.Select(&dbVariants, query, pq.Array(ids)); err != nil {
+		return nil, r.translateError(err)
+	}
+	variants := make([]*domain.ProductVariant, len(dbVariants))
+	for i, v := range dbVariants {
+		variant, err := v.ToDomain()
+		if err != nil {
+			return nil, err
+		}
+		variants[i] = variant
+	}
+	return variants, nil
+}
+
+// ListProductIDsWithVariantsWithTx возвращает id товаров из списка, у которых есть неудаленные варианты.
+func (r *Repository) ListProductIDsWithVariantsWithTx(tx *sqlx.Tx, productIDs []int64) ([]int64, error) {
+	var ids []int64
+	query := `SELECT DISTINCT product_id FROM product_variants WHERE product_id = ANY($1) AND deleted_at IS NULL`
+	if err := tx.Select(&ids, query, pq.Array(productIDs)); err != nil {
+		return nil, r.translateError(err)
+	}
+	return ids, nil
+}
+
+func (r *Repository) CreateProductVariantWithTx(tx *sqlx.Tx, variant *domain.ProductVariant) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateProductVariantWithTx").Logger()
+	dbVariant := db.ProductVariant{}
+	dbVariant.FromDomain(variant)
+	query := `INSERT INTO product_variants (product_id, sku, options, price, quantity, active, position, created_at, updated_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8) RETURNING id, created_at, updated_at`
+	err := tx.QueryRow(query, dbVariant.ProductID, dbVariant.SKU, jsonParam(dbVariant.Options), dbVariant.Price, dbVariant.Quantity,
+		dbVariant.Active, dbVariant.Position, time.Now()).Scan(&variant.ID, &variant.CreatedAt, &variant.UpdatedAt)
+	if err != nil {
+		logger.Error().Err(err).Int64("product_id", variant.ProductID).Msg("failed to create product variant")
+		return r.translateVariantError(err)
+	}
+	return nil
+}
+
+func (r *Repository) UpdateProductVariantWithTx(tx *sqlx.Tx, variant *domain.ProductVariant) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "UpdateProductVariantWithTx").Logger()
+	dbVariant := db.ProductVariant{}
+	dbVariant.FromDomain(variant)
+	query := `UPDATE product_variants SET sku = $1, options = $2, price = $3, quantity = $4, active = $5, position = $6, updated_at = $7
+	          WHERE id = $8 AND deleted_at IS NULL RETURNING updated_at`
+	err := tx.QueryRow(query, dbVariant.SKU, jsonParam(dbVariant.Options), dbVariant.Price, dbVariant.Quantity, dbVariant.Active,
+		dbVariant.Position, time.Now(), dbVariant.ID).Scan(&variant.UpdatedAt)
+	if err != nil {
+		logger.Error().Err(err).Int64("variant_id", variant.ID).Msg("failed to update product variant")
+		return r.translateVariantError(err)
+	}
+	return nil
+}
+
+// DeleteProductVariantWithTx мягко удаляет вариант: на него ссылаются позиции заказов.
+func (r *Repository) DeleteProductVariantWithTx(tx *sqlx.Tx, id int64) error {
+	result, err := tx.Exec(`UPDATE product_variants SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`, time.Now(), id)
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return r.translateError(err)
+	} else if rowsAffected == 0 {
+		return errs.ErrVariantNotFound
+	}
+	return nil
+}
+
+// IncreaseVariantQuantitiesWithTx возвращает остатки нескольких вариантов одним UPDATE.
+func (r *Repository) IncreaseVariantQuantitiesWithTx(tx *sqlx.Tx, quantities map[int64]int) error {
+	ids, amounts := quantityArrays(quantities)
+	query := `UPDATE product_variants v SET quantity = v.quantity + s.quantity
+	          FROM unnest($1::bigint[], $2::int[]) AS s(id, quantity)
+	          WHERE v.id = s.id`
+	if _, err := tx.Exec(query, pq.Array(ids), pq.Array(amounts)); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+// DecreaseVariantQuantitiesWithTx списывает остатки нескольких вариантов одним UPDATE.
+// Если хотя бы одного варианта не хватает, возвращается ошибка и транзакцию нужно откатить.
+func (r *Repository) DecreaseVariantQuantitiesWithTx(tx *sqlx.Tx, quantities map[int64]int) error {
+	ids, amounts := quantityArrays(quantities)
+	query := `UPDATE product_variants v SET quantity = v.quantity - s.quantity
+	          FROM unnest($1::bigint[], $2::int[]) AS s(id, quantity)
+	          WHERE v.id = s.id AND v.quantity >= s.quantity`
+	result, err := tx.Exec(query, pq.Array(ids), pq.Array(amounts))
+	if err != nil {
+		return r.translateError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected != int64(len(quantities)) {
+		return errs.ErrInsufficientStock
+	}
+	return nil
+}
+
+// RecomputeProductQuantityWithTx выставляет остаток товара суммой остатков его активных вариантов.
+func (r *Repository) RecomputeProductQuantityWithTx(tx *sqlx.Tx, productID int64) (int, error) {
+	var quantity int
+	query := `UPDATE products SET quantity = (
+	              SELECT COALESCE(SUM(quantity), 0) FROM product_variants
+	              WHERE product_id = $1 AND deleted_at IS NULL AND active
+	          ), updated_at = $2
+	          WHERE id = $1 RETURNING quantity`
+	if err := tx.QueryRow(query, productID, time.Now()).Scan(&quantity); err != nil {
+		return 0, r.translateError(err)
+	}
+	return quantity, nil
+}
+
+func quantityArrays(quantities map[int64]int) ([]int64, []int64) {
+	ids := make([]int64, 0, len(quantities))
+	amounts := make([]int64, 0, len(quantities))
+	for id, quantity := range quantities {
+		ids = append(ids, id)
+		amounts = append(amounts, int64(quantity))
+	}
+	return ids, amounts
+}
+
+// effectiveCategoryAttributes - подзапрос схемы характеристик категорий из массива $n вместе
+// с предками. Если ключ объявлен на нескольких уровнях, действует ближайшее к категории
+// объявление; depth - расстояние до категории, у которой оно найдено.
+func effectiveCategoryAttributes(idsParam string) string {
+	return `WITH RECURSIVE ancestors AS (
+	            SELECT id, parent_id, 0 AS depth FROM categories WHERE id = ANY(` + idsParam + `)
+	            UNION ALL
+	            SELECT c.id, c.parent_id, a.depth + 1 FROM categories c JOIN ancestors a ON c.id = a.parent_id
+	        )
+	        SELECT DISTINCT ON (ca.key) ` + prefixColumns("ca", categoryAttributeColumns) + `, a.depth
+	        FROM category_attributes ca JOIN ancestors a ON a.id = ca.category_id
+	        ORDER BY ca.key, a.depth`
+}
+
+// ListCategoryAttributes возвращает действующую схему категорий: собственные характеристики
+// и унаследованные от предков, начиная с корня.
+func (r *Repository) ListCategoryAttributes(categoryIDs []int64) ([]domain.CategoryAttribute, error) {
+	var dbAttributes []db.CategoryAttribute
+	query := `SELECT ` + categoryAttributeColumns + ` FROM (` + effectiveCategoryAttributes("$1") + `) attrs
+	          ORDER BY depth DESC, position, key`
+	if err := r.db.Select(&dbAttributes, query, pq.Array(categoryIDs)); err != nil {
+		return nil, r.translateError(err)
+	}
+	attributes := make([]domain.CategoryAttribute, len(dbAttributes))
+	for i, a := range dbAttributes {
+		attributes[i] = a.ToDomain()
+	}
+	return attributes, nil
+}
+
+// SetCategoryAttributesWithTx заменяет собственную схему категории; position - порядок в списке.
+func (r *Repository) SetCategoryAttributesWithTx(tx *sqlx.Tx, categoryID int64, attributes []domain.CategoryAttribute) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "SetCategoryAttributesWithTx").Logger()
+	if _, err := tx.Exec(`DELETE FROM category_attributes WHERE category_id = $1`, categoryID); err != nil {
+		logger.Error().Err(err).Int64("category_id", categoryID).Msg("failed to delete category attributes")
+		return r.translateCategoryError(err)
+	}
+	query := `INSERT INTO category_attributes (category_id, key, name, type, "values", required, filterable, position)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+	for i := range attributes {
+		a := &attributes[i]
+		a.CategoryID = categoryID
+		a.Position = i
+		err := tx.QueryRow(query, categoryID, a.Key, a.Name, a.Type, pq.Array(a.Values), a.Required, a.Filterable, i).Scan(&a.ID)
+		if err != nil {
+			logger.Error().Err(err).Int64("category_id", categoryID).Msg("failed to insert category attribute")
+			return r.translateCategoryError(err)
+		}
+	}
+	return nil
+}
+
+// translateVariantError переводит нарушения уникальности SKU и набора опций в ошибки домена.
+func (r *Repository) translateVariantError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return errs.ErrVariantAlreadyExists
+	}
+	if err = r.translateError(err); errors.Is(err, errs.ErrNotfound) {
+		return errs.ErrVariantNotFound
+	}
+	return err
+}
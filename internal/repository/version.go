@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"marketplace/internal/errs"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// updateVersioned выполняет UPDATE неудаленной строки table по id с оптимистичной блокировкой:
+// строка меняется, только если ее version все еще равна expectedVersion - версии, прочитанной
+// вызывающим, - и version увеличивается на 1. set - присваивания с плейсхолдерами $1..$n для
+// args. Возвращает новую версию; если строка есть, но версия другая - errs.ErrVersionMismatch,
+// если строки нет - sql.ErrNoRows.
+func updateVersioned(q sqlx.Queryer, table string, id, expectedVersion int64, set string, args ...interface{}) (int64, error) {
+	idParam, versionParam := len(args)+1, len(args)+2
+	query := fmt.Sprintf(`UPDATE %s SET %s, version = version + 1
+	          WHERE id = $%d AND deleted_at IS NULL AND version = $%d
+	          RETURNING version`, table, set, idParam, versionParam)
+	var version int64
+	err := q.QueryRowx(query, append(args, id, expectedVersion)...).Scan(&version)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return version, err
+	}
+	var current int64
+	err = q.QueryRowx(`SELECT version FROM `+table+` WHERE id = $1 AND deleted_at IS NULL`, id).Scan(&current)
+	if err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("%w: current version is %d", errs.ErrVersionMismatch, current)
+}
+
+// touchVersion увеличивает версию строки, когда меняются связанные с ней данные, которые
+// входят в ее представление (варианты и изображения товара, отправления заказа).
+func touchVersion(q sqlx.Execer, table string, id int64) error {
+	_, err := q.Exec(`UPDATE `+table+` SET version = version + 1 WHERE id = $1 AND deleted_at IS NULL`, id)
+	return err
+}
+
+// TouchProductWithTx увеличивает версию товара после изменения его вариантов, изображений или категорий.
+func (r *Repository) TouchProductWithTx(tx *sqlx.Tx, productID int64) error {
+	return r.translateError(touchVersion(tx, "products", productID))
+}
+
+// TouchOrderWithTx увеличивает версию заказа после изменения его отправлений.
+func (r *Repository) TouchOrderWithTx(tx *sqlx.Tx, orderID int64) error {
+	return r.translateError(touchVersion(tx, "orders", orderID))
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/db"
+	"marketplace/internal/models/domain"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+const webhookEndpointColumns = `id, shop_id, url, secret, event_types, description, active, consecutive_failures, disabled_at, disabled_reason, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, endpoint_id, event_id, event_type, payload, status, attempts, last_response_status, last_error, created_at, updated_at, delivered_at`
+
+func (r *Repository) CreateWebhookEndpoint(endpoint *domain.WebhookEndpoint) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateWebhookEndpoint").Logger()
+	dbEndpoint := db.WebhookEndpoint{}
+	dbEndpoint.FromDomain(endpoint)
+	query := `INSERT INTO webhook_endpoints (shop_id, url, secret, event_types, description, active)
+	          VALUES ($1, $2, $3, $4, $5, $6) RETURNING ` + webhookEndpointColumns
+	var created db.WebhookEndpoint
+	err := r.db.Get(&created, query, dbEndpoint.ShopID, dbEndpoint.URL, dbEndpoint.Secret, dbEndpoint.EventTypes, dbEndpoint.Description, dbEndpoint.Active)
+	if err != nil {
+		logger.Error().Err(err).Int64("shop_id", dbEndpoint.ShopID).Msg("failed to create webhook endpoint")
+		return r.translateError(err)
+	}
+	*endpoint = *created.ToDomain()
+	return nil
+}
+
+func (r *Repository) GetWebhookEndpointByID(id int64) (*domain.WebhookEndpoint, error) {
+	var dbEndpoint db.WebhookEndpoint
+	if err := r.db.Get(&dbEndpoint, `SELECT `+webhookEndpointColumns+` FROM webhook_endpoints WHERE id = $1`, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbEndpoint.ToDomain(), nil
+}
+
+func (r *Repository) ListWebhookEndpoints(shopID int64) ([]domain.WebhookEndpoint, error) {
+	var dbEndpoints []db.WebhookEndpoint
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints WHERE shop_id = $1 ORDER BY id`
+	if err := r.db.Select(&dbEndpoints, query, shopID); err != nil {
+		return nil, r.translateError(err)
+	}
+	endpoints := make([]domain.WebhookEndpoint, len(dbEndpoints))
+	for i, e := range dbEndpoints {
+		endpoints[i] = *e.ToDomain()
+	}
+	return endpoints, nil
+}
+
+// ListActiveWebhookEndpointsForEvent возвращает активные вебхуки магазинов shopIDs, подписанные на eventType.
+func (r *Repository) ListActiveWebhookEndpointsForEvent(shopIDs []int64, eventType string) ([]domain.WebhookEndpoint, error) {
+	var dbEndpoints []db.WebhookEndpoint
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints
+	          WHERE active AND shop_id = ANY($1) AND $2 = ANY(event_types) ORDER BY id`
+	if err := r.db.Select(&dbEndpoints, query, pq.Array(shopIDs), eventType); err != nil {
+		return nil, r.translateError(err)
+	}
+	endpoints := make([]domain.WebhookEndpoint, len(dbEndpoints))
+	for i, e := range dbEndpoints {
+		endpoints[i] = *e.ToDomain()
+	}
+	return endpoints, nil
+}
+
+func (r *Repository) UpdateWebhookEndpoint(endpoint *domain.WebhookEndpoint) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "UpdateWebhookEndpoint").Logger()
+	dbEndpoint := db.WebhookEndpoint{}
+	dbEndpoint.FromDomain(endpoint)
+	query := `UPDATE webhook_endpoints SET url = $1, event_types = $2, description = $3, active = $4,
+	              consecutive_failures = $5, disabled_at = $6, disabled_reason = $7, updated_at = NOW()
+	          WHERE id = $8 RETURNING ` + webhookEndpointColumns
+	var updated db.WebhookEndpoint
+	err := r.db.Get(&updated, query, dbEndpoint.URL, dbEndpoint.EventTypes, dbEndpoint.Description, dbEndpoint.Active,
+		dbEndpoint.ConsecutiveFailures, dbEndpoint.DisabledAt, dbEndpoint.DisabledReason, dbEndpoint.ID)
+	if err != nil {
+		logger.Error().Err(err).Int64("id", dbEndpoint.ID).Msg("failed to update webhook endpoint")
+		return r.translateError(err)
+	}
+	*endpoint = *updated.ToDomain()
+	return nil
+}
+
+func (r *Repository) DeleteWebhookEndpoint(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM webhook_endpoints WHERE id = $1`, id)
+	if err != nil {
+		return r.translateError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return r.translateError(err)
+	}
+	if rowsAffected == 0 {
+		return errs.ErrNotfound
+	}
+	return nil
+}
+
+// IncrementWebhookFailuresWithTx увеличивает счетчик неудач подряд и возвращает новое значение.
+func (r *Repository) IncrementWebhookFailuresWithTx(tx *sqlx.Tx, id int64) (int, error) {
+	var failures int
+	query := `UPDATE webhook_endpoints SET consecutive_failures = consecutive_failures + 1 WHERE id = $1 RETURNING consecutive_failures`
+	if err := tx.Get(&failures, query, id); err != nil {
+		return 0, r.translateError(err)
+	}
+	return failures, nil
+}
+
+func (r *Repository) ResetWebhookFailuresWithTx(tx *sqlx.Tx, id int64) error {
+	if _, err := tx.Exec(`UPDATE webhook_endpoints SET consecutive_failures = 0 WHERE id = $1 AND consecutive_failures > 0`, id); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+func (r *Repository) DisableWebhookEndpointWithTx(tx *sqlx.Tx, id int64, reason string) error {
+	query := `UPDATE webhook_endpoints SET active = false, disabled_at = NOW(), disabled_reason = $1, updated_at = NOW() WHERE id = $2 AND active`
+	if _, err := tx.Exec(query, reason, id); err != nil {
+		return r.translateError(err)
+	}
+	return nil
+}
+
+// CreateWebhookDeliveryWithTx создает доставку события на вебхук. Повторная доставка того же
+// события (повтор публикации из outbox) не создает новую запись: возвращается false.
+func (r *Repository) CreateWebhookDeliveryWithTx(tx *sqlx.Tx, delivery *domain.WebhookDelivery) (bool, error) {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "CreateWebhookDeliveryWithTx").Logger()
+	query := `INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload)
+	          VALUES ($1, $2, $3, $4) ON CONFLICT (endpoint_id, event_id) DO NOTHING RETURNING ` + webhookDeliveryColumns
+	var dbDelivery db.WebhookDelivery
+	err := tx.Get(&dbDelivery, query, delivery.EndpointID, delivery.EventID, delivery.EventType, jsonParam(delivery.Payload))
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		logger.Error().Err(err).Int64("endpoint_id", delivery.EndpointID).Str("event_id", delivery.EventID).Msg("failed to create webhook delivery")
+		return false, r.translateError(err)
+	}
+	*delivery = *dbDelivery.ToDomain()
+	return true, nil
+}
+
+func (r *Repository) GetWebhookDeliveryByID(id int64) (*domain.WebhookDelivery, error) {
+	var dbDelivery db.WebhookDelivery
+	if err := r.db.Get(&dbDelivery, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbDelivery.ToDomain(), nil
+}
+
+func (r *Repository) ListWebhookDeliveries(filter domain.WebhookDeliveryFilter) (*domain.Page[domain.WebhookDelivery], error) {
+	cursor, err := decodeCursor(filter.Cursor, "")
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries
+	          WHERE endpoint_id = $1 AND ($2 = '' OR status = $2) AND ($3 = 0 OR id < $3)
+	          ORDER BY id DESC LIMIT $4`
+	var dbDeliveries []db.WebhookDelivery
+	if err := r.db.Select(&dbDeliveries, query, filter.EndpointID, filter.Status, cursorID(cursor), filter.Limit+1); err != nil {
+		return nil, r.translateError(err)
+	}
+	dbDeliveries, nextCursor := trimPage(dbDeliveries, filter.Limit, func(d db.WebhookDelivery) pageCursor { return pageCursor{ID: d.ID} })
+	page := &domain.Page[domain.WebhookDelivery]{Items: make([]domain.WebhookDelivery, len(dbDeliveries)), NextCursor: nextCursor}
+	for i, d := range dbDeliveries {
+		page.Items[i] = *d.ToDomain()
+	}
+	page.Total, err = r.countTotal(filter.WithTotal, `SELECT COUNT(*) FROM webhook_deliveries WHERE endpoint_id = $1 AND ($2 = '' OR status = $2)`,
+		filter.EndpointID, filter.Status)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (r *Repository) ListWebhookDeliveryAttempts(deliveryID int64) ([]domain.WebhookDeliveryAttempt, error) {
+	query := `SELECT id, delivery_id, attempt, response_status, response_body, error, duration_ms, created_at
+	          FROM webhook_delivery_attempts WHERE delivery_id = $1 ORDER BY id`
+	var dbAttempts []db.WebhookDeliveryAttempt
+	if err := r.db.Select(&dbAttempts, query, deliveryID); err != nil {
+		return nil, r.translateError(err)
+	}
+	attempts := make([]domain.WebhookDeliveryAttempt, len(dbAttempts))
+	for i, a := range dbAttempts {
+		attempts[i] = *a.ToDomain()
+	}
+	return attempts, nil
+}
+
+// RecordWebhookAttemptWithTx сохраняет попытку в журнал и обновляет статус доставки.
+func (r *Repository) RecordWebhookAttemptWithTx(tx *sqlx.Tx, attempt *domain.WebhookDeliveryAttempt, status string) error {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("func", "RecordWebhookAttemptWithTx").Logger()
+	var responseBody, attemptError *string
+	if attempt.ResponseBody != "" {
+		responseBody = &attempt.ResponseBody
+	}
+	if attempt.Error != "" {
+		attemptError = &attempt.Error
+	}
+	query := `INSERT INTO webhook_delivery_attempts (delivery_id, attempt, response_status, response_body, error, duration_ms)
+	          VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`
+	err := tx.QueryRow(query, attempt.DeliveryID, attempt.Attempt, attempt.ResponseStatus, responseBody, attemptError, attempt.DurationMs).
+		Scan(&attempt.ID, &attempt.CreatedAt)
+	if err != nil {
+		logger.Error().Err(err).Int64("delivery_id", attempt.DeliveryID).Msg("failed to record webhook attempt")
+		return r.translateError(err)
+	}
+	query = `UPDATE webhook_deliveries SET status = $1, attempts = $2, last_response_status = $3, last_error = $4, updated_at = NOW(),
+	             delivered_at = CASE WHEN $1 = 'succeeded' THEN NOW() ELSE delivered_at END
+	         WHERE id = $5`
+	if _, err := tx.Exec(query, status, attempt.Attempt, attempt.ResponseStatus, attemptError, attempt.DeliveryID); err != nil {
+		logger.Error().Err(err).Int64("delivery_id", attempt.DeliveryID).Msg("failed to update webhook delivery")
+		return r.translateError(err)
+	}
+	return nil
+}
+
+// ResetWebhookDeliveryWithTx возвращает доставку в pending для повторной отправки; журнал попыток сохраняется.
+func (r *Repository) ResetWebhookDeliveryWithTx(tx *sqlx.Tx, id int64) (*domain.WebhookDelivery, error) {
+	query := `UPDATE webhook_deliveries SET status = 'pending', attempts = 0, updated_at = NOW()
+	          WHERE id = $1 RETURNING ` + webhookDeliveryColumns
+	var dbDelivery db.WebhookDelivery
+	if err := tx.Get(&dbDelivery, query, id); err != nil {
+		return nil, r.translateError(err)
+	}
+	return dbDelivery.ToDomain(), nil
+}
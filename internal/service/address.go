@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"strings"
+	"time"
+)
+
+func (s *Service) CreateAddress(userID int, address *domain.Address) error {
+	if address == nil {
+		return errs.ErrInvalidRequestBody
+	}
+	address.UserID = int64(userID)
+	if err := normalizeAddress(address); err != nil {
+		return err
+	}
+	existing, err := s.repository.ListAddresses(address.UserID)
+	if err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		address.IsDefault = true
+	}
+	address.CreatedAt = time.Now()
+	address.UpdatedAt = time.Now()
+	if err := s.repository.CreateAddress(address); err != nil {
+		s.logger.Error().Err(err).Int("user_id", userID).Msg("failed to create address")
+		return err
+	}
+	return nil
+}
+
+func (s *Service) GetAddress(userID int, addressID int64) (*domain.Address, error) {
+	if addressID <= 0 {
+		return nil, errs.ErrInvalidID
+	}
+	address, err := s.repository.GetAddressByID(addressID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotfound) {
+			return nil, errs.ErrAddressNotFound
+		}
+		return nil, err
+	}
+	if address.UserID != int64(userID) {
+		return nil, errs.ErrAddressNotFound
+	}
+	return address, nil
+}
+
+func (s *Service) ListAddresses(userID int) ([]*domain.Address, error) {
+	return s.repository.ListAddresses(int64(userID))
+}
+
+func (s *Service) UpdateAddress(userID int, address *domain.Address) error {
+	existing, err := s.GetAddress(userID, address.ID)
+	if err != nil {
+		return err
+	}
+	address.UserID = existing.UserID
+	if err := normalizeAddress(address); err != nil {
+		return err
+	}
+	// Снять отметку "по умолчанию" можно только назначив другой адрес.
+	if existing.IsDefault {
+		address.IsDefault = true
+	}
+	if err := s.repository.UpdateAddress(address); err != nil {
+		s.logger.Error().Err(err).Int64("address_id", address.ID).Msg("failed to update address")
+		return err
+	}
+	address.CreatedAt = existing.CreatedAt
+	address.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *Service) SetDefaultAddress(userID int, addressID int64) error {
+	if _, err := s.GetAddress(userID, addressID); err != nil {
+		return err
+	}
+	return s.repository.SetDefaultAddress(int64(userID), addressID)
+}
+
+func (s *Service) DeleteAddress(userID int, addressID int64) error {
+	if _, err := s.GetAddress(userID, addressID); err != nil {
+		return err
+	}
+	if err := s.repository.DeleteAddress(int64(userID), addressID); err != nil {
+		if errors.Is(err, errs.ErrNotfound) {
+			return errs.ErrAddressNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// resolveShippingAddress возвращает снимок выбранного адреса или адреса по умолчанию.
+func (s *Service) resolveShippingAddress(userID int, addressID *int64) (*domain.AddressSnapshot, error) {
+	if addressID != nil {
+		address, err := s.GetAddress(userID, *addressID)
+		if err != nil {
+			return nil, err
+		}
+		return address.Snapshot(), nil
+	}
+	address, err := s.repository.GetDefaultAddress(int64(userID))
+	if err != nil {
+		if errors.Is(err, errs.ErrNotfound) {
+			return nil, errs.ErrShippingAddressRequired
+		}
+		return nil, err
+	}
+	return address.Snapshot(), nil
+}
+
+func normalizeAddress(address *domain.Address) error {
+	address.Label = strings.TrimSpace(address.Label)
+	address.RecipientName = strings.TrimSpace(address.RecipientName)
+	address.Phone = strings.TrimSpace(address.Phone)
+	address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+	address.Region = strings.TrimSpace(address.Region)
+	address.City = strings.TrimSpace(address.City)
+	address.PostalCode = strings.TrimSpace(address.PostalCode)
+	address.Line1 = strings.TrimSpace(address.Line1)
+	address.Line2 = strings.TrimSpace(address.Line2)
+	if address.RecipientName == "" || address.City == "" || address.Line1 == "" {
+		return fmt.Errorf("%w: recipient_name, city and line1 are required", errs.ErrInvalidFieldValue)
+	}
+	if len(address.Country) != 2 {
+		return fmt.Errorf("%w: country must be an ISO 3166-1 alpha-2 code", errs.ErrInvalidFieldValue)
+	}
+	return nil
+}
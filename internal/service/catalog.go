@@ -0,0 +1,74 @@
+package service
+
+import (
+	"fmt"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"slices"
+	"strings"
+)
+
+const (
+	maxAttributeFilters   = 20
+	maxAttributeKeyLength = 100
+	maxPriceBuckets       = 20
+)
+
+// defaultPriceBuckets - верхние границы интервалов фасета цен по умолчанию
+var defaultPriceBuckets = []string{"10", "25", "50", "100", "250", "500", "1000"}
+
+// normalizeProductFilter проверяет фильтры каталога и приводит их к каноническому виду.
+// Диапазон цен и границы интервалов задаются в валюте currency, поэтому без нее не принимаются.
+func normalizeProductFilter(filter *domain.ProductFilter) error {
+	filter.Currency = strings.ToUpper(strings.TrimSpace(filter.Currency))
+	if filter.Currency != "" && !domain.IsValidCurrency(filter.Currency) {
+		return errs.ErrInvalidCurrency
+	}
+	if (filter.MinPrice != nil || filter.MaxPrice != nil) && filter.Currency == "" {
+		return errs.ErrInvalidCurrency
+	}
+	for _, price := range []*domain.Money{filter.MinPrice, filter.MaxPrice} {
+		if price != nil && (price.IsNegative() || price.Currency != filter.Currency) {
+			return fmt.Errorf("%w: invalid price range", errs.ErrInvalidFieldValue)
+		}
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && filter.MinPrice.Amount > filter.MaxPrice.Amount {
+		return fmt.Errorf("%w: min_price is greater than max_price", errs.ErrInvalidFieldValue)
+	}
+	if filter.Sort == "" {
+		filter.Sort = domain.ProductSortNewest
+	}
+	if !slices.Contains(domain.ProductSorts, filter.Sort) {
+		return fmt.Errorf("%w: unknown sort %q", errs.ErrInvalidFieldValue, filter.Sort)
+	}
+	if len(filter.Attributes) > maxAttributeFilters {
+		return fmt.Errorf("%w: too many attribute filters", errs.ErrInvalidFieldValue)
+	}
+	attributes := make(map[string][]string, len(filter.Attributes))
+	for key, values := range filter.Attributes {
+		key = strings.TrimSpace(key)
+		if key == "" || len(key) > maxAttributeKeyLength {
+			return fmt.Errorf("%w: invalid attribute name", errs.ErrInvalidFieldValue)
+		}
+		for _, value := range values {
+			if value = strings.TrimSpace(value); value != "" && !slices.Contains(attributes[key], value) {
+				attributes[key] = append(attributes[key], value)
+			}
+		}
+	}
+	filter.Attributes = attributes
+	if len(filter.PriceBuckets) > maxPriceBuckets {
+		return fmt.Errorf("%w: too many price buckets", errs.ErrInvalidFieldValue)
+	}
+	if len(filter.PriceBuckets) > 0 && filter.PriceBuckets[0].Currency == "" {
+		return errs.ErrInvalidCurrency
+	}
+	for i, bound := range filter.PriceBuckets {
+		if bound.IsNegative() || bound.Currency != filter.PriceBuckets[0].Currency ||
+			(i > 0 && bound.Amount <= filter.PriceBuckets[i-1].Amount) {
+			return fmt.Errorf("%w: price buckets must be increasing amounts in one currency", errs.ErrInvalidFieldValue)
+		}
+	}
+	normalizePage(&filter.PageRequest)
+	return nil
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"fmt"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"marketplace/utils"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	maxCategoryNameLength = 255
+	maxProductCategories  = 20
+)
+
+// ListCategoryTree возвращает дерево категорий: корни и вложенные children по position.
+func (s *Service) ListCategoryTree() ([]*domain.Category, error) {
+	categories, err := s.repository.ListCategories()
+	if err != nil {
+		return nil, err
+	}
+	return buildCategoryTree(categories, nil), nil
+}
+
+// GetCategory возвращает категорию с поддеревом.
+func (s *Service) GetCategory(id int64) (*domain.Category, error) {
+	if id <= 0 {
+		return nil, errs.ErrCategoryNotFound
+	}
+	categories, err := s.repository.ListCategories()
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		if category.ID == id {
+			category.Children = buildCategoryTree(categories, &id)
+			return category, nil
+		}
+	}
+	return nil, errs.ErrCategoryNotFound
+}
+
+// buildCategoryTree собирает детей parentID из плоского списка, упорядоченного по position.
+func buildCategoryTree(categories []*domain.Category, parentID *int64) []*domain.Category {
+	children := make(map[int64][]*domain.Category)
+	roots := []*domain.Category{}
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+		} else {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		}
+	}
+	for _, category := range categories {
+		category.Children = children[category.ID]
+	}
+	if parentID == nil {
+		return roots
+	}
+	return children[*parentID]
+}
+
+func (s *Service) CreateCategory(input domain.CreateCategoryInput) (*domain.Category, error) {
+	category := &domain.Category{ParentID: input.ParentID}
+	if err := normalizeCategoryNames(category, input.Name, input.Slug); err != nil {
+		return nil, err
+	}
+	if input.Position != nil && *input.Position < 0 {
+		return nil, fmt.Errorf("%w: position must not be negative", errs.ErrInvalidFieldValue)
+	}
+	err := s.withCategoryTree(func(tx *sqlx.Tx) error {
+		if category.ParentID != nil {
+			if _, err := s.repository.GetCategoryByIDWithTx(tx, *category.ParentID); err != nil {
+				return err
+			}
+		}
+		if err := s.repository.CreateCategoryWithTx(tx, category); err != nil {
+			return err
+		}
+		return s.placeCategoryWithTx(tx, category, input.Position)
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Str("slug", category.Slug).Msg("failed to create category")
+		return nil, err
+	}
+	s.logger.Info().Int64("category_id", category.ID).Msg("category created")
+	return category, nil
+}
+
+func (s *Service) UpdateCategory(id int64, input domain.UpdateCategoryInput) (*domain.Category, error) {
+	category, err := s.repository.GetCategoryByID(id)
+	if err != nil {
+		return nil, err
+	}
+	name, slug := category.Name, input.Slug
+	if input.Name != "" {
+		name = input.Name
+	}
+	if slug == "" {
+		slug = category.Slug
+	}
+	if err := normalizeCategoryNames(category, name, slug); err != nil {
+		return nil, err
+	}
+	if err := s.repository.UpdateCategory(category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// MoveCategory переносит категорию к новому родителю (nil - в корень) на позицию среди его
+// детей. Перенос внутрь собственного поддерева создал бы цикл и отклоняется; дерево на время
+// проверки заблокировано, поэтому два встречных переноса не обойдут проверку.
+func (s *Service) MoveCategory(id int64, input domain.MoveCategoryInput) (*domain.Category, error) {
+	if input.Position < 0 {
+		return nil, fmt.Errorf("%w: position must not be negative", errs.ErrInvalidFieldValue)
+	}
+	var category *domain.Category
+	err := s.withCategoryTree(func(tx *sqlx.Tx) error {
+		var err error
+		if category, err = s.repository.GetCategoryByIDWithTx(tx, id); err != nil {
+			return err
+		}
+		if input.ParentID != nil {
+			if _, err := s.repository.GetCategoryByIDWithTx(tx, *input.ParentID); err != nil {
+				return err
+			}
+			inSubtree, err := s.repository.IsCategoryInSubtreeWithTx(tx, id, *input.ParentID)
+			if err != nil {
+				return err
+			}
+			if inSubtree {
+				return errs.ErrCategoryCycle
+			}
+		}
+		if err := s.repository.MoveCategoryWithTx(tx, id, input.ParentID); err != nil {
+			return err
+		}
+		category.ParentID = input.ParentID
+		return s.placeCategoryWithTx(tx, category, &input.Position)
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Int64("category_id", id).Msg("failed to move category")
+		return nil, err
+	}
+	return category, nil
+}
+
+func (s *Service) DeleteCategory(id int64) error {
+	if id <= 0 {
+		return errs.ErrCategoryNotFound
+	}
+	return s.repository.DeleteCategory(id)
+}
+
+// withCategoryTree выполняет изменение дерева в транзакции с блокировкой дерева.
+func (s *Service) withCategoryTree(fn func(tx *sqlx.Tx) error) error {
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	if err := s.repository.LockCategoryTreeWithTx(tx); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// placeCategoryWithTx ставит категорию на позицию среди соседей (nil или больше числа
+// соседей - в конец) и перенумеровывает соседей подряд.
+func (s *Service) placeCategoryWithTx(tx *sqlx.Tx, category *domain.Category, position *int) error {
+	siblings, err := s.repository.ListCategoryChildIDsWithTx(tx, category.ParentID)
+	if err != nil {
+		return err
+	}
+	ids := make([]int64, 0, len(siblings))
+	for _, id := range siblings {
+		if id != category.ID {
+			ids = append(ids, id)
+		}
+	}
+	category.Position = len(ids)
+	if position != nil {
+		category.Position = min(*position, len(ids))
+	}
+	ids = slices.Insert(ids, category.Position, category.ID)
+	return s.repository.SetCategoryPositionsWithTx(tx, ids)
+}
+
+func normalizeCategoryNames(category *domain.Category, name, slug string) error {
+	category.Name = strings.TrimSpace(name)
+	if category.Name == "" || len(category.Name) > maxCategoryNameLength {
+		return fmt.Errorf("%w: category name must be 1-%d characters long", errs.ErrInvalidFieldValue, maxCategoryNameLength)
+	}
+	if slug == "" {
+		slug = category.Name
+	}
+	category.Slug = utils.GenerateSlug(slug)
+	if category.Slug == "" {
+		return fmt.Errorf("%w: slug must contain latin letters or digits", errs.ErrInvalidFieldValue)
+	}
+	return nil
+}
+
+// SetProductCategories заменяет категории товара (только владелец магазина или админ).
+func (s *Service) SetProductCategories(productID int64, categoryIDs []int64, userID int, userRole string) ([]domain.ProductCategory, error) {
+	product, err := s.GetProductByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.ensureShopOwner(product.ShopID, userID, userRole); err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(categoryIDs))
+	for _, id := range categoryIDs {
+		if id <= 0 {
+			return nil, errs.ErrCategoryNotFound
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > maxProductCategories {
+		return nil, fmt.Errorf("%w: a product can belong to at most %d categories", errs.ErrInvalidFieldValue, maxProductCategories)
+	}
+	if err := s.validateProductAttributes(ids, product.Attributes); err != nil {
+		return nil, err
+	}
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return nil, err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	if err := s.repository.SetProductCategoriesWithTx(tx, productID, ids); err != nil {
+		return nil, err
+	}
+	if err := s.repository.TouchProductWithTx(tx, productID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return nil, err
+	}
+	committed = true
+	if err := s.AttachProductCategories(product); err != nil {
+		return nil, err
+	}
+	return product.Categories, nil
+}
+
+// AttachProductCategories заполняет категории товаров с хлебными крошками для ответа API.
+func (s *Service) AttachProductCategories(products ...*domain.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	categories, err := s.repository.ListProductCategories(ids)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to load product categories")
+		return err
+	}
+	for _, product := range products {
+		product.Categories = categories[product.ID]
+	}
+	return nil
+}
+
+// ensureCategoryExists проверяет категорию фильтра каталога.
+func (s *Service) ensureCategoryExists(id int64) error {
+	if id == 0 {
+		return nil
+	}
+	if id < 0 {
+		return errs.ErrCategoryNotFound
+	}
+	_, err := s.repository.GetCategoryByID(id)
+	return err
+}
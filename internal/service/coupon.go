@@ -0,0 +1,376 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (s *Service) CreateCoupon(coupon *domain.Coupon, userID int, userRole string) error {
+	if coupon == nil {
+		return errs.ErrInvalidRequestBody
+	}
+	coupon.Code = strings.ToUpper(strings.TrimSpace(coupon.Code))
+	coupon.Currency = strings.ToUpper(strings.TrimSpace(coupon.Currency))
+	if coupon.Code == "" || len(coupon.Code) > 50 {
+		return fmt.Errorf("%w: code must be 1-50 characters", errs.ErrInvalidFieldValue)
+	}
+	switch coupon.Type {
+	case domain.CouponTypePercentage:
+		if coupon.Value <= 0 || coupon.Value > 100*domain.BasisPointsPerPercent {
+			return fmt.Errorf("%w: percentage must be in (0, 100]", errs.ErrInvalidFieldValue)
+		}
+		coupon.Amount = nil
+	case domain.CouponTypeFixedAmount:
+		if coupon.Amount == nil || coupon.Amount.Amount <= 0 {
+			return fmt.Errorf("%w: fixed amount coupon requires positive amount", errs.ErrInvalidFieldValue)
+		}
+		coupon.Value = 0
+	case domain.CouponTypeFreeShipping:
+		coupon.Value = 0
+		coupon.Amount = nil
+	default:
+		return fmt.Errorf("%w: unknown coupon type %q", errs.ErrInvalidFieldValue, coupon.Type)
+	}
+	if coupon.Scope == "" {
+		coupon.Scope = domain.CouponScopeMarketplace
+	}
+	switch coupon.Scope {
+	case domain.CouponScopeMarketplace:
+		if userRole != domain.AdminRole {
+			return fmt.Errorf("%w: only admins can create marketplace coupons", errs.ErrForbidden)
+		}
+		coupon.ShopID = nil
+		coupon.ProductIDs = nil
+	case domain.CouponScopeShop, domain.CouponScopeProducts:
+		if coupon.ShopID == nil {
+			return fmt.Errorf("%w: shop_id is required for %s coupons", errs.ErrInvalidFieldValue, coupon.Scope)
+		}
+		if _, err := s.ensureShopOwner(*coupon.ShopID, userID, userRole); err != nil {
+			return err
+		}
+		if coupon.Scope == domain.CouponScopeShop {
+			coupon.ProductIDs = nil
+			break
+		}
+		if len(coupon.ProductIDs) == 0 {
+			return fmt.Errorf("%w: product_ids are required for products coupons", errs.ErrInvalidFieldValue)
+		}
+		for _, productID := range coupon.ProductIDs {
+			product, err := s.GetProductByID(productID)
+			if err != nil {
+				return err
+			}
+			if product.ShopID != *coupon.ShopID {
+				return fmt.Errorf("%w: product %d does not belong to shop %d", errs.ErrInvalidFieldValue, productID, *coupon.ShopID)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown coupon scope %q", errs.ErrInvalidFieldValue, coupon.Scope)
+	}
+	if err := couponCurrency(coupon); err != nil {
+		return err
+	}
+	if coupon.StartsAt != nil && coupon.EndsAt != nil && !coupon.EndsAt.After(*coupon.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", errs.ErrInvalidFieldValue)
+	}
+	if (coupon.UsageLimit != nil && *coupon.UsageLimit <= 0) || (coupon.UsageLimitPerUser != nil && *coupon.UsageLimitPerUser <= 0) {
+		return fmt.Errorf("%w: usage limits must be positive", errs.ErrInvalidFieldValue)
+	}
+	coupon.TimesUsed = 0
+	coupon.Active = true
+	coupon.CreatedBy = int64(userID)
+	if err := s.repository.CreateCoupon(coupon); err != nil {
+		s.logger.Error().Err(err).Str("code", coupon.Code).Msg("failed to create coupon")
+		return err
+	}
+	return nil
+}
+
+func (s *Service) ListCoupons(shopID *int64, page domain.PageRequest, userID int, userRole string) (*domain.Page[*domain.Coupon], error) {
+	if shopID == nil {
+		if userRole != domain.AdminRole {
+			return nil, fmt.Errorf("%w: only admins can list marketplace coupons", errs.ErrForbidden)
+		}
+	} else if _, err := s.ensureShopOwner(*shopID, userID, userRole); err != nil {
+		return nil, err
+	}
+	normalizePage(&page)
+	return s.repository.ListCoupons(shopID, page)
+}
+
+func (s *Service) DeactivateCoupon(couponID int64, userID int, userRole string) error {
+	if couponID <= 0 {
+		return errs.ErrInvalidID
+	}
+	coupon, err := s.repository.GetCouponByID(couponID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotfound) {
+			return errs.ErrCouponNotFound
+		}
+		return err
+	}
+	if coupon.ShopID == nil {
+		if userRole != domain.AdminRole {
+			return fmt.Errorf("%w: only admins can manage marketplace coupons", errs.ErrForbidden)
+		}
+	} else if _, err := s.ensureShopOwner(*coupon.ShopID, userID, userRole); err != nil {
+		return err
+	}
+	return s.repository.DeactivateCoupon(couponID)
+}
+
+// applyCouponsWithTx блокирует купоны заказа, проверяет лимиты использования и считает скидки.
+// Сами погашения фиксируются в redeemCouponsWithTx после создания заказа в той же транзакции.
+func (s *Service) applyCouponsWithTx(tx *sqlx.Tx, userID int, codes []string, cart couponCart) ([]domain.OrderDiscount, error) {
+	if len(codes) == 0 {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(codes))
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		normalized = append(normalized, code)
+	}
+	found, err := s.repository.GetCouponsByCodesWithTx(tx, normalized)
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]*domain.Coupon, len(found))
+	for _, coupon := range found {
+		byCode[coupon.Code] = coupon
+	}
+	coupons := make([]*domain.Coupon, 0, len(normalized))
+	for _, code := range normalized {
+		coupon, ok := byCode[code]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", errs.ErrCouponNotFound, code)
+		}
+		if coupon.UsageLimit != nil && coupon.TimesUsed >= *coupon.UsageLimit {
+			return nil, fmt.Errorf("%w: %s", errs.ErrCouponUsageLimitReached, code)
+		}
+		if coupon.UsageLimitPerUser != nil {
+			used, err := s.repository.CountCouponRedemptionsWithTx(tx, coupon.ID, int64(userID))
+			if err != nil {
+				return nil, err
+			}
+			if used >= *coupon.UsageLimitPerUser {
+				return nil, fmt.Errorf("%w: %s", errs.ErrCouponUsageLimitReached, code)
+			}
+		}
+		coupons = append(coupons, coupon)
+	}
+	return computeDiscounts(coupons, cart, time.Now())
+}
+
+func (s *Service) redeemCouponsWithTx(tx *sqlx.Tx, userID int, orderID int64, discounts []domain.OrderDiscount) error {
+	for _, discount := range discounts {
+		if discount.CouponID == nil {
+			continue
+		}
+		if err := s.repository.RedeemCouponWithTx(tx, *discount.CouponID, int64(userID), orderID, discount.Amount); err != nil {
+			s.logger.Error().Err(err).Int64("coupon_id", *discount.CouponID).Msg("failed to redeem coupon")
+			return fmt.Errorf("%w: %s", err, discount.Code)
+		}
+	}
+	return nil
+}
+
+// couponCart - данные заказа, необходимые для расчета скидок.
+type couponCart struct {
+	currency      string
+	items         []couponCartItem
+	shippingLines []domain.OrderShippingLine
+}
+
+type couponCartItem struct {
+	productID int64
+	shopID    int64
+	total     domain.Money
+}
+
+// computeDiscounts применяет купоны по порядку. Скидки на товары в сумме не превышают
+// стоимость товаров, скидки на доставку - стоимость доставки.
+func computeDiscounts(coupons []*domain.Coupon, cart couponCart, now time.Time) ([]domain.OrderDiscount, error) {
+	if len(coupons) > 1 {
+		for _, coupon := range coupons {
+			if !coupon.Stackable {
+				return nil, fmt.Errorf("%w: %s", errs.ErrCouponsNotStackable, coupon.Code)
+			}
+		}
+	}
+	itemsLeft := domain.NewMoney(0, cart.currency)
+	shippingLeft := domain.NewMoney(0, cart.currency)
+	var err error
+	for _, item := range cart.items {
+		if itemsLeft, err = itemsLeft.Add(item.total); err != nil {
+			return nil, err
+		}
+	}
+	for _, line := range cart.shippingLines {
+		if shippingLeft, err = shippingLeft.Add(line.Amount); err != nil {
+			return nil, err
+		}
+	}
+
+	discounts := make([]domain.OrderDiscount, 0, len(coupons))
+	for _, coupon := range coupons {
+		if err := couponApplicable(coupon, cart.currency, now); err != nil {
+			return nil, err
+		}
+		eligible, eligibleShops, err := eligibleItems(coupon, cart.currency, cart.items)
+		if err != nil {
+			return nil, err
+		}
+		if eligible.Amount <= 0 {
+			return nil, fmt.Errorf("%w: %s has no eligible items", errs.ErrCouponNotApplicable, coupon.Code)
+		}
+		if coupon.MinOrderValue != nil {
+			if cmp, err := eligible.Cmp(*coupon.MinOrderValue); err != nil {
+				return nil, err
+			} else if cmp < 0 {
+				return nil, fmt.Errorf("%w: %s requires eligible items worth at least %s", errs.ErrCouponNotApplicable, coupon.Code, coupon.MinOrderValue)
+			}
+		}
+		amount, err := couponDiscount(coupon, eligible, eligibleShops, cart.shippingLines, &itemsLeft, &shippingLeft)
+		if err != nil {
+			return nil, err
+		}
+		couponID := coupon.ID
+		discounts = append(discounts, domain.OrderDiscount{
+			CouponID: &couponID,
+			Code:     coupon.Code,
+			Type:     coupon.Type,
+			Amount:   amount,
+		})
+	}
+	return discounts, nil
+}
+
+func couponApplicable(coupon *domain.Coupon, currency string, now time.Time) error {
+	switch {
+	case !coupon.Active:
+		return fmt.Errorf("%w: %s is not active", errs.ErrCouponNotApplicable, coupon.Code)
+	case coupon.StartsAt != nil && now.Before(*coupon.StartsAt):
+		return fmt.Errorf("%w: %s is not valid yet", errs.ErrCouponNotApplicable, coupon.Code)
+	case coupon.EndsAt != nil && !now.Before(*coupon.EndsAt):
+		return fmt.Errorf("%w: %s has expired", errs.ErrCouponNotApplicable, coupon.Code)
+	case coupon.Currency != "" && coupon.Currency != currency:
+		return fmt.Errorf("%w: %s is only valid for %s orders", errs.ErrCouponNotApplicable, coupon.Code, coupon.Currency)
+	}
+	return nil
+}
+
+// eligibleItems возвращает стоимость позиций, на которые действует купон, и магазины этих позиций.
+func eligibleItems(coupon *domain.Coupon, currency string, items []couponCartItem) (domain.Money, map[int64]bool, error) {
+	products := make(map[int64]bool, len(coupon.ProductIDs))
+	for _, id := range coupon.ProductIDs {
+		products[id] = true
+	}
+	total := domain.NewMoney(0, currency)
+	shops := make(map[int64]bool)
+	for _, item := range items {
+		switch coupon.Scope {
+		case domain.CouponScopeShop:
+			if coupon.ShopID == nil || item.shopID != *coupon.ShopID {
+				continue
+			}
+		case domain.CouponScopeProducts:
+			if !products[item.productID] {
+				continue
+			}
+		}
+		var err error
+		if total, err = total.Add(item.total); err != nil {
+			return domain.Money{}, nil, err
+		}
+		shops[item.shopID] = true
+	}
+	return total, shops, nil
+}
+
+// couponDiscount считает скидку купона и уменьшает остаток стоимости товаров или доставки,
+// на который еще можно дать скидку.
+func couponDiscount(coupon *domain.Coupon, eligible domain.Money, eligibleShops map[int64]bool,
+	shippingLines []domain.OrderShippingLine, itemsLeft, shippingLeft *domain.Money) (domain.Money, error) {
+	var amount domain.Money
+	var err error
+	switch coupon.Type {
+	case domain.CouponTypePercentage:
+		if amount, err = eligible.Percent(int64(coupon.Value)); err != nil {
+			return domain.Money{}, err
+		}
+		return takeDiscount(amount, itemsLeft)
+	case domain.CouponTypeFixedAmount:
+		if amount, err = coupon.Amount.Min(eligible); err != nil {
+			return domain.Money{}, err
+		}
+		return takeDiscount(amount, itemsLeft)
+	case domain.CouponTypeFreeShipping:
+		amount = domain.NewMoney(0, eligible.Currency)
+		for _, line := range shippingLines {
+			if eligibleShops[line.ShopID] {
+				if amount, err = amount.Add(line.Amount); err != nil {
+					return domain.Money{}, err
+				}
+			}
+		}
+		return takeDiscount(amount, shippingLeft)
+	}
+	return domain.NewMoney(0, eligible.Currency), nil
+}
+
+// takeDiscount ограничивает скидку остатком left и вычитает ее из остатка.
+func takeDiscount(amount domain.Money, left *domain.Money) (domain.Money, error) {
+	amount, err := amount.Min(*left)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	if *left, err = left.Sub(amount); err != nil {
+		return domain.Money{}, err
+	}
+	return amount, nil
+}
+
+// couponCurrency выводит валюту купона из фиксированной суммы и минимальной суммы заказа.
+// Процентный купон без минимальной суммы валюты не имеет и применим к заказу в любой валюте.
+func couponCurrency(coupon *domain.Coupon) error {
+	if coupon.Currency == "" && coupon.Amount != nil {
+		coupon.Currency = coupon.Amount.Currency
+	}
+	if coupon.Currency == "" && coupon.MinOrderValue != nil {
+		coupon.Currency = coupon.MinOrderValue.Currency
+	}
+	if coupon.Currency == "" {
+		return nil
+	}
+	if !domain.IsValidCurrency(coupon.Currency) {
+		return fmt.Errorf("%w: %q", errs.ErrInvalidCurrency, coupon.Currency)
+	}
+	if coupon.Amount != nil {
+		if err := matchCurrency(coupon.Amount, coupon.Currency, "amount"); err != nil {
+			return err
+		}
+	}
+	if coupon.MinOrderValue != nil {
+		if err := matchCurrency(coupon.MinOrderValue, coupon.Currency, "min_order_value"); err != nil {
+			return err
+		}
+		if coupon.MinOrderValue.IsNegative() {
+			return fmt.Errorf("%w: min_order_value must not be negative", errs.ErrInvalidFieldValue)
+		}
+		if coupon.MinOrderValue.IsZero() {
+			coupon.MinOrderValue = nil
+		}
+	}
+	return nil
+}
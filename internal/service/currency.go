@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"marketplace/internal/configs"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+func (s *Service) ListExchangeRates(page domain.PageRequest) (*domain.Page[domain.ExchangeRate], error) {
+	normalizePage(&page)
+	return s.repository.ListExchangeRatesPage(page)
+}
+
+// SetExchangeRate сохраняет курс, введенный администратором вручную.
+func (s *Service) SetExchangeRate(rate *domain.ExchangeRate) error {
+	if rate == nil {
+		return errs.ErrInvalidRequestBody
+	}
+	rate.BaseCurrency = strings.ToUpper(strings.TrimSpace(rate.BaseCurrency))
+	rate.QuoteCurrency = strings.ToUpper(strings.TrimSpace(rate.QuoteCurrency))
+	if !domain.IsValidCurrency(rate.BaseCurrency) || !domain.IsValidCurrency(rate.QuoteCurrency) {
+		return errs.ErrInvalidCurrency
+	}
+	if rate.BaseCurrency == rate.QuoteCurrency {
+		return fmt.Errorf("%w: base and quote currencies must differ", errs.ErrInvalidFieldValue)
+	}
+	parsed, err := domain.ParseRate(rate.Rate)
+	if err != nil {
+		return fmt.Errorf("%w: rate must be a positive decimal", errs.ErrInvalidFieldValue)
+	}
+	rate.Rate = domain.FormatRate(parsed)
+	rate.Source = domain.ExchangeRateSourceManual
+	if err := s.repository.UpsertExchangeRate(rate); err != nil {
+		s.logger.Error().Err(err).Str("base", rate.BaseCurrency).Str("quote", rate.QuoteCurrency).Msg("failed to set exchange rate")
+		return err
+	}
+	return nil
+}
+
+func (s *Service) DeleteExchangeRate(id int64) error {
+	if id <= 0 {
+		return errs.ErrInvalidID
+	}
+	return s.repository.DeleteExchangeRate(id)
+}
+
+// RefreshExchangeRates загружает курсы из настроенного источника и сохраняет их.
+func (s *Service) RefreshExchangeRates(ctx context.Context) ([]domain.ExchangeRate, error) {
+	if s.rateSource == nil {
+		return nil, errs.ErrRateSourceNotConfigured
+	}
+	rates, err := s.rateSource.FetchRates(ctx)
+	if err != nil {
+		s.logger.Error().Err(err).Str("source", s.rateSource.Name()).Msg("failed to fetch exchange rates")
+		return nil, err
+	}
+	for i := range rates {
+		if err := s.repository.UpsertExchangeRate(&rates[i]); err != nil {
+			s.logger.Error().Err(err).Str("quote", rates[i].QuoteCurrency).Msg("failed to store exchange rate")
+			return nil, err
+		}
+	}
+	s.logger.Info().Str("source", s.rateSource.Name()).Int("count", len(rates)).Msg("exchange rates refreshed")
+	return rates, nil
+}
+
+func (s *Service) GetUserPreferences(userID int) (*domain.UserPreferences, error) {
+	currency, err := s.repository.GetUserDisplayCurrency(userID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotfound) {
+			return nil, errs.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &domain.UserPreferences{DisplayCurrency: currency}, nil
+}
+
+// UpdateUserPreferences сохраняет валюту отображения; пустая строка сбрасывает выбор.
+func (s *Service) UpdateUserPreferences(userID int, prefs *domain.UserPreferences) error {
+	if prefs == nil {
+		return errs.ErrInvalidRequestBody
+	}
+	prefs.DisplayCurrency = strings.ToUpper(strings.TrimSpace(prefs.DisplayCurrency))
+	if prefs.DisplayCurrency != "" && !domain.IsValidCurrency(prefs.DisplayCurrency) {
+		return errs.ErrInvalidCurrency
+	}
+	return s.repository.SetUserDisplayCurrency(userID, prefs.DisplayCurrency)
+}
+
+// ApplyDisplayCurrency заполняет DisplayPrice товаров и их вариантов в валюте покупателя.
+// Если валюта не выбрана или курса нет, цены остаются только в валюте товара.
+func (s *Service) ApplyDisplayCurrency(userID int, products ...*domain.Product) {
+	currency, err := s.repository.GetUserDisplayCurrency(userID)
+	if err != nil || currency == "" {
+		return
+	}
+	converter := s.newConverter(currency)
+	for _, product := range products {
+		if product == nil || product.Price.Currency == currency {
+			continue
+		}
+		price, err := converter.convert(product.Price)
+		if err != nil {
+			continue
+		}
+		product.DisplayPrice = &price
+		for i := range product.Variants {
+			if price, err := converter.convert(product.Variants[i].Price); err == nil {
+				product.Variants[i].DisplayPrice = &price
+			}
+		}
+	}
+}
+
+// resolveOrderCurrency выбирает валюту заказа: явно запрошенная, затем валюта отображения
+// покупателя. Пустой результат означает валюту первой позиции корзины.
+func (s *Service) resolveOrderCurrency(userID int, requested string) (string, error) {
+	requested = strings.ToUpper(strings.TrimSpace(requested))
+	if requested != "" {
+		if !domain.IsValidCurrency(requested) {
+			return "", errs.ErrInvalidCurrency
+		}
+		return requested, nil
+	}
+	preferred, err := s.repository.GetUserDisplayCurrency(userID)
+	if err != nil && !errors.Is(err, errs.ErrNotfound) {
+		return "", err
+	}
+	return preferred, nil
+}
+
+func baseCurrency() string {
+	if currency := configs.AppSettings.CurrencyParams.BaseCurrency; currency != "" {
+		return currency
+	}
+	return "USD"
+}
+
+// currencyConverter пересчитывает суммы в одну целевую валюту. Курсы загружаются
+// при первой конвертации; кросс-курсы считаются через базовую валюту платформы.
+// Примененные курсы запоминаются для снимка на заказе.
+type currencyConverter struct {
+	target string
+	base   string
+	load   func() ([]domain.ExchangeRate, error)
+	rates  map[[2]string]*big.Rat
+	used   map[string]*big.Rat
+}
+
+func (s *Service) newConverter(target string) *currencyConverter {
+	return &currencyConverter{
+		target: target,
+		base:   baseCurrency(),
+		load:   s.repository.ListExchangeRates,
+		used:   make(map[string]*big.Rat),
+	}
+}
+
+func (c *currencyConverter) convert(m domain.Money) (domain.Money, error) {
+	if m.Currency == c.target {
+		return m, nil
+	}
+	rate, err := c.rate(m.Currency)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	return m.Convert(rate, c.target)
+}
+
+// rate возвращает курс from -> target, округленный до точности хранения, чтобы
+// пересчет по снимку курса на заказе давал те же суммы.
+func (c *currencyConverter) rate(from string) (*big.Rat, error) {
+	if rate, ok := c.used[from]; ok {
+		return rate, nil
+	}
+	if c.rates == nil {
+		list, err := c.load()
+		if err != nil {
+			return nil, err
+		}
+		c.rates = make(map[[2]string]*big.Rat, len(list))
+		for _, r := range list {
+			if parsed, err := domain.ParseRate(r.Rate); err == nil {
+				c.rates[[2]string{r.BaseCurrency, r.QuoteCurrency}] = parsed
+			}
+		}
+	}
+	rate, ok := c.pair(from, c.target)
+	if !ok {
+		toBase, okFrom := c.pair(from, c.base)
+		fromBase, okTo := c.pair(c.base, c.target)
+		if !okFrom || !okTo {
+			return nil, fmt.Errorf("%w: %s/%s", errs.ErrExchangeRateUnavailable, from, c.target)
+		}
+		rate = new(big.Rat).Mul(toBase, fromBase)
+	}
+	rate, _ = domain.ParseRate(domain.FormatRate(rate))
+	if rate == nil {
+		return nil, fmt.Errorf("%w: %s/%s", errs.ErrExchangeRateUnavailable, from, c.target)
+	}
+	c.used[from] = rate
+	return rate, nil
+}
+
+// pair ищет прямой или обратный курс между двумя валютами.
+func (c *currencyConverter) pair(from, to string) (*big.Rat, bool) {
+	if from == to {
+		return big.NewRat(1, 1), true
+	}
+	if rate, ok := c.rates[[2]string{from, to}]; ok {
+		return rate, true
+	}
+	if rate, ok := c.rates[[2]string{to, from}]; ok {
+		return new(big.Rat).Inv(rate), true
+	}
+	return nil, false
+}
+
+// snapshot возвращает курсы, примененные к заказу.
+func (c *currencyConverter) snapshot() []domain.OrderExchangeRate {
+	result := make([]domain.OrderExchangeRate, 0, len(c.used))
+	for from, rate := range c.used {
+		result = append(result, domain.OrderExchangeRate{
+			FromCurrency: from,
+			ToCurrency:   c.target,
+			Rate:         domain.FormatRate(rate),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].FromCurrency < result[j].FromCurrency })
+	return result
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"maps"
+	"marketplace/internal/configs"
+	"marketplace/internal/models/domain"
+	"marketplace/utils"
+	"slices"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	defaultStockLowThreshold = 5
+	defaultEventRetention    = 7 * 24 * time.Hour
+	maxEventErrorLength      = 2000
+)
+
+// recordEventWithTx записывает доменное событие в outbox в транзакции изменения.
+func (s *Service) recordEventWithTx(tx *sqlx.Tx, eventType, aggregateType string, aggregateID int64, payload interface{}) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event payload: %w", eventType, err)
+	}
+	event := &domain.Event{
+		EventID:       utils.NewUUID(),
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       raw,
+	}
+	if err := s.repository.AddOutboxEventWithTx(tx, event); err != nil {
+		s.logger.Error().Err(err).Str("type", eventType).Int64("aggregate_id", aggregateID).Msg("failed to record event")
+		return err
+	}
+	return nil
+}
+
+// recordStockChangeWithTx записывает stock.changed с новым остатком и stock.low, если остаток
+// пересек порог сверху вниз.
+func (s *Service) recordStockChangeWithTx(tx *sqlx.Tx, product *domain.Product, before, after int) error {
+	if before == after {
+		return nil
+	}
+	err := s.recordEventWithTx(tx, domain.EventStockChanged, domain.AggregateProduct, product.ID, domain.StockChangedPayload{
+		ProductID: product.ID,
+		ShopID:    product.ShopID,
+		Quantity:  after,
+	})
+	if err != nil {
+		return err
+	}
+	threshold := stockLowThreshold()
+	if before <= threshold || after > threshold {
+		return nil
+	}
+	return s.recordEventWithTx(tx, domain.EventStockLow, domain.AggregateProduct, product.ID, domain.StockLowPayload{
+		ProductID: product.ID,
+		ShopID:    product.ShopID,
+		Name:      product.Name,
+		Quantity:  after,
+		Threshold: threshold,
+	})
+}
+
+// recordOrderStatusChangedWithTx записывает order.status_changed с магазинами, товары которых есть в заказе.
+func (s *Service) recordOrderStatusChangedWithTx(tx *sqlx.Tx, order *domain.Order, change *domain.OrderStatusChange) error {
+	itemShops, err := s.repository.GetOrderItemShopIDsWithTx(tx, order.ID)
+	if err != nil {
+		s.logger.Error().Err(err).Int64("order_id", order.ID).Msg("failed to get order shops")
+		return err
+	}
+	return s.recordEventWithTx(tx, domain.EventOrderStatusChanged, domain.AggregateOrder, order.ID, domain.OrderStatusChangedPayload{
+		OrderID:    order.ID,
+		UserID:     order.UserID,
+		ShopIDs:    uniqueShopIDs(maps.Values(itemShops)),
+		FromStatus: change.FromStatus,
+		ToStatus:   change.ToStatus,
+		Reason:     change.Reason,
+	})
+}
+
+// uniqueShopIDs возвращает отсортированные id магазинов без повторов.
+func uniqueShopIDs(shopIDs iter.Seq[int64]) []int64 {
+	return slices.Compact(slices.Sorted(shopIDs))
+}
+
+// RelayOutboxEvents публикует до limit неопубликованных событий через publish. События блокируются
+// на время публикации (SKIP LOCKED), поэтому экземпляры приложения не публикуют одно событие одновременно.
+// Доставка - не менее одного раза: событие, опубликованное до сбоя фиксации, будет отправлено повторно,
+// подписчики отбрасывают дубли по event_id. Ошибка publish откладывает событие с экспоненциальной задержкой.
+func (s *Service) RelayOutboxEvents(ctx context.Context, limit int, publish func(ctx context.Context, event domain.Event) error) (int, error) {
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return 0, err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	events, err := s.repository.LockPendingOutboxEventsWithTx(tx, limit)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to lock outbox events")
+		return 0, err
+	}
+	var published int
+	for _, event := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		if pubErr := publish(ctx, event); pubErr != nil {
+			message := pubErr.Error()
+			if len(message) > maxEventErrorLength {
+				message = message[:maxEventErrorLength]
+			}
+			// Та же политика задержек, что у очереди задач
+			nextAttemptAt := time.Now().Add(jobBackoff(event.Attempts + 1))
+			s.logger.Warn().Err(pubErr).Str("event_id", event.EventID).Str("type", event.Type).Int("attempt", event.Attempts+1).Msg("failed to publish event")
+			if err := s.repository.MarkOutboxEventFailedWithTx(tx, event.ID, message, nextAttemptAt); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if err := s.repository.MarkOutboxEventPublishedWithTx(tx, event.ID); err != nil {
+			return 0, err
+		}
+		published++
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return 0, err
+	}
+	committed = true
+	return published, nil
+}
+
+func (s *Service) IsEventProcessed(eventID, subscriber string) (bool, error) {
+	return s.repository.IsEventProcessed(eventID, subscriber)
+}
+
+func (s *Service) MarkEventProcessed(eventID, subscriber string) error {
+	return s.repository.MarkEventProcessed(eventID, subscriber)
+}
+
+// PurgeOutboxEvents удаляет опубликованные события старше retention_hours.
+func (s *Service) PurgeOutboxEvents() (int64, error) {
+	retention := defaultEventRetention
+	if hours := configs.AppSettings.EventParams.RetentionHours; hours > 0 {
+		retention = time.Duration(hours) * time.Hour
+	}
+	deleted, err := s.repository.PurgeOutboxEvents(time.Now().Add(-retention))
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to purge outbox events")
+		return 0, err
+	}
+	return deleted, nil
+}
+
+func stockLowThreshold() int {
+	if n := configs.AppSettings.EventParams.StockLowThreshold; n > 0 {
+		return n
+	}
+	return defaultStockLowThreshold
+}
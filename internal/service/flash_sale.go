@@ -0,0 +1,320 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"marketplace/internal/configs"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	defaultFlashSlotCount     = 16
+	defaultFlashQueueCapacity = 32
+	defaultFlashQueueWait     = 2 * time.Second
+)
+
+func (s *Service) CreateFlashSale(productID int64, input domain.CreateFlashSaleInput, userID int, userRole string) (*domain.FlashSale, error) {
+	if input.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be positive", errs.ErrInvalidFieldValue)
+	}
+	if input.PerUserLimit <= 0 {
+		return nil, fmt.Errorf("%w: per_user_limit must be positive", errs.ErrInvalidFieldValue)
+	}
+	if input.SlotCount < 0 {
+		return nil, fmt.Errorf("%w: slot_count must be positive", errs.ErrInvalidFieldValue)
+	}
+	if input.SlotCount == 0 {
+		input.SlotCount = flashSlotCount()
+	}
+	// Пустой слот не снижает конкуренцию, поэтому слотов не больше, чем единиц товара
+	input.SlotCount = min(input.SlotCount, input.Quantity)
+	if input.StartsAt.IsZero() {
+		input.StartsAt = time.Now()
+	}
+	if !input.EndsAt.After(input.StartsAt) {
+		return nil, fmt.Errorf("%w: ends_at must be after starts_at", errs.ErrInvalidFieldValue)
+	}
+	product, err := s.repository.GetProductByID(productID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotfound) {
+			return nil, errs.ErrProductNotfound
+		}
+		return nil, err
+	}
+	if _, err := s.ensureShopOwner(product.ShopID, userID, userRole); err != nil {
+		return nil, err
+	}
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return nil, err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	locked, err := s.repository.LockProductsWithTx(tx, []int64{productID})
+	if err != nil {
+		return nil, err
+	}
+	if len(locked) == 0 {
+		return nil, errs.ErrProductNotfound
+	}
+	// Остаток распродажи выделяется из товара целиком, поэтому товары с вариантами не участвуют
+	withVariants, err := s.repository.ListProductIDsWithVariantsWithTx(tx, []int64{productID})
+	if err != nil {
+		return nil, err
+	}
+	if len(withVariants) > 0 {
+		return nil, fmt.Errorf("%w: flash sales are not available for products with variants", errs.ErrInvalidFieldValue)
+	}
+	if locked[0].Quantity < input.Quantity {
+		return nil, fmt.Errorf("%w for flash sale (available: %d, requested: %d)",
+			errs.ErrInsufficientStock, locked[0].Quantity, input.Quantity)
+	}
+	// Остаток распродажи выделяется из products.quantity и возвращается при завершении
+	if err := s.repository.DecreaseProductQuantitiesWithTx(tx, map[int64]int{productID: input.Quantity}); err != nil {
+		return nil, err
+	}
+	sale := &domain.FlashSale{
+		ProductID:         productID,
+		AllocatedQuantity: input.Quantity,
+		SlotCount:         input.SlotCount,
+		PerUserLimit:      input.PerUserLimit,
+		Status:            domain.FlashSaleStatusActive,
+		StartsAt:          input.StartsAt,
+		EndsAt:            input.EndsAt,
+		CreatedBy:         int64(userID),
+	}
+	if err := s.repository.CreateFlashSaleWithTx(tx, sale); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return nil, err
+	}
+	committed = true
+	s.logger.Info().Int64("flash_sale_id", sale.ID).Int64("product_id", productID).Msg("flash sale created")
+	return sale, nil
+}
+
+func (s *Service) GetFlashSaleReport(saleID int64, userID int, userRole string) (*domain.FlashSaleReport, error) {
+	if _, err := s.flashSaleForOwner(saleID, userID, userRole); err != nil {
+		return nil, err
+	}
+	return s.repository.GetFlashSaleReport(saleID)
+}
+
+// FinishFlashSale завершает распродажу и возвращает нераспроданный остаток товару.
+func (s *Service) FinishFlashSale(saleID int64, userID int, userRole string) (*domain.FlashSaleReport, error) {
+	if _, err := s.flashSaleForOwner(saleID, userID, userRole); err != nil {
+		return nil, err
+	}
+	if err := s.finishFlashSale(saleID); err != nil {
+		return nil, err
+	}
+	return s.repository.GetFlashSaleReport(saleID)
+}
+
+// FinishEndedFlashSales завершает распродажи с истекшим ends_at (фоновая задача).
+func (s *Service) FinishEndedFlashSales() (int, error) {
+	saleIDs, err := s.repository.ListEndedFlashSaleIDs()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to list ended flash sales")
+		return 0, err
+	}
+	var finished int
+	for _, saleID := range saleIDs {
+		err := s.finishFlashSale(saleID)
+		// Распродажу мог успеть завершить владелец или другой экземпляр
+		if errors.Is(err, errs.ErrInvalidFieldValue) {
+			continue
+		}
+		if err != nil {
+			return finished, err
+		}
+		finished++
+	}
+	return finished, nil
+}
+
+func (s *Service) finishFlashSale(saleID int64) error {
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	sale, err := s.repository.GetFlashSaleByIDForUpdateWithTx(tx, saleID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotfound) {
+			return errs.ErrFlashSaleNotFound
+		}
+		return err
+	}
+	if sale.Status != domain.FlashSaleStatusActive {
+		return fmt.Errorf("%w: flash sale is already finished", errs.ErrInvalidFieldValue)
+	}
+	if err := s.repository.FinishFlashSaleWithTx(tx, sale); err != nil {
+		s.logger.Error().Err(err).Int64("flash_sale_id", saleID).Msg("failed to finish flash sale")
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return err
+	}
+	committed = true
+	s.logger.Info().Int64("flash_sale_id", saleID).Int("returned", sale.ReturnedQuantity).Msg("flash sale finished")
+	return nil
+}
+
+func (s *Service) flashSaleForOwner(saleID int64, userID int, userRole string) (*domain.FlashSale, error) {
+	sale, err := s.repository.GetFlashSaleByID(saleID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotfound) {
+			return nil, errs.ErrFlashSaleNotFound
+		}
+		return nil, err
+	}
+	product, err := s.repository.GetProductByID(sale.ProductID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotfound) {
+			return nil, errs.ErrProductNotfound
+		}
+		return nil, err
+	}
+	if _, err := s.ensureShopOwner(product.ShopID, userID, userRole); err != nil {
+		return nil, err
+	}
+	return sale, nil
+}
+
+// activeFlashSales возвращает идущие распродажи товаров заказа по product_id.
+func (s *Service) activeFlashSales(items []domain.CreateOrderItemInput) (map[int64]*domain.FlashSale, error) {
+	productIDs := make([]int64, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	sales, err := s.repository.ListActiveFlashSales(productIDs)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to list active flash sales")
+		return nil, err
+	}
+	now := time.Now()
+	active := make(map[int64]*domain.FlashSale, len(sales))
+	for _, sale := range sales {
+		if sale.Active(now) {
+			active[sale.ProductID] = sale
+		}
+	}
+	return active, nil
+}
+
+// reserveFlashSaleWithTx учитывает лимит покупателя и списывает количество из слотов распродажи.
+func (s *Service) reserveFlashSaleWithTx(tx *sqlx.Tx, sale *domain.FlashSale, userID int, quantity int) error {
+	if quantity > sale.PerUserLimit {
+		return errs.ErrFlashSaleLimitExceeded
+	}
+	ok, err := s.repository.AddFlashSaleUserQuantityWithTx(tx, sale.ID, int64(userID), quantity, sale.PerUserLimit)
+	if err != nil {
+		s.logger.Error().Err(err).Int64("flash_sale_id", sale.ID).Msg("failed to add flash sale user quantity")
+		return err
+	}
+	if !ok {
+		return errs.ErrFlashSaleLimitExceeded
+	}
+	ok, err = s.repository.ReserveFlashSaleStockWithTx(tx, sale.ID, quantity)
+	if err != nil {
+		s.logger.Error().Err(err).Int64("flash_sale_id", sale.ID).Msg("failed to reserve flash sale stock")
+		return err
+	}
+	if !ok {
+		return errs.ErrFlashSaleSoldOut
+	}
+	return nil
+}
+
+// admissionQueue ограничивает число одновременных покупок по каждой распродаже:
+// остальные покупатели ждут своей очереди, а не держат соединения с БД на блокировках.
+type admissionQueue struct {
+	mu     sync.Mutex
+	queues map[int64]chan struct{}
+}
+
+func newAdmissionQueue() *admissionQueue {
+	return &admissionQueue{queues: make(map[int64]chan struct{})}
+}
+
+// acquire занимает место в очередях распродаж (в порядке id) и возвращает функцию освобождения.
+// Если место не освободилось за queue_wait_ms, возвращается ErrFlashSaleBusy.
+func (q *admissionQueue) acquire(saleIDs []int64) (func(), error) {
+	sort.Slice(saleIDs, func(i, j int) bool { return saleIDs[i] < saleIDs[j] })
+	timer := time.NewTimer(flashQueueWait())
+	defer timer.Stop()
+	acquired := make([]chan struct{}, 0, len(saleIDs))
+	release := func() {
+		for _, queue := range acquired {
+			<-queue
+		}
+	}
+	for _, id := range saleIDs {
+		queue := q.queue(id)
+		select {
+		case queue <- struct{}{}:
+			acquired = append(acquired, queue)
+		case <-timer.C:
+			release()
+			return nil, errs.ErrFlashSaleBusy
+		}
+	}
+	return release, nil
+}
+
+func (q *admissionQueue) queue(saleID int64) chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	queue, ok := q.queues[saleID]
+	if !ok {
+		queue = make(chan struct{}, flashQueueCapacity())
+		q.queues[saleID] = queue
+	}
+	return queue
+}
+
+func flashSlotCount() int {
+	if n := configs.AppSettings.FlashSaleParams.DefaultSlotCount; n > 0 {
+		return n
+	}
+	return defaultFlashSlotCount
+}
+
+func flashQueueCapacity() int {
+	if n := configs.AppSettings.FlashSaleParams.QueueCapacity; n > 0 {
+		return n
+	}
+	return defaultFlashQueueCapacity
+}
+
+func flashQueueWait() time.Duration {
+	if ms := configs.AppSettings.FlashSaleParams.QueueWaitMs; ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return defaultFlashQueueWait
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"errors"
+	"marketplace/internal/configs"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+	defaultIdempotencyTTL   = 24 * time.Hour
+)
+
+// BeginIdempotentRequest резервирует ключ за запросом. Если ключ уже использован тем же запросом
+// и ответ сохранен, возвращается сохраненная запись и started = false - ответ нужно воспроизвести.
+func (s *Service) BeginIdempotentRequest(userID int, key, method, path, fingerprint string) (*domain.IdempotencyRecord, bool, error) {
+	if !validIdempotencyKey(key) {
+		return nil, false, errs.ErrInvalidIdempotencyKey
+	}
+	record := &domain.IdempotencyRecord{
+		UserID:      int64(userID),
+		Key:         key,
+		Method:      method,
+		Path:        path,
+		Fingerprint: fingerprint,
+		Status:      domain.IdempotencyStatusProcessing,
+		ExpiresAt:   time.Now().Add(idempotencyTTL()),
+	}
+	// Вторая попытка нужна, если найденный ключ истек или был удален между INSERT и SELECT
+	for attempt := 0; attempt < 2; attempt++ {
+		created, err := s.repository.CreateIdempotencyKey(record)
+		if err != nil {
+			s.logger.Error().Err(err).Int("user_id", userID).Msg("failed to create idempotency key")
+			return nil, false, err
+		}
+		if created {
+			return record, true, nil
+		}
+		existing, err := s.repository.GetIdempotencyKey(record.UserID, key)
+		if errors.Is(err, errs.ErrNotfound) {
+			continue
+		}
+		if err != nil {
+			s.logger.Error().Err(err).Int("user_id", userID).Msg("failed to get idempotency key")
+			return nil, false, err
+		}
+		if existing.Method != method || existing.Path != path || existing.Fingerprint != fingerprint {
+			return nil, false, errs.ErrIdempotencyKeyReused
+		}
+		if existing.Status != domain.IdempotencyStatusCompleted {
+			return nil, false, errs.ErrIdempotencyKeyInProgress
+		}
+		return existing, false, nil
+	}
+	return nil, false, errs.ErrIdempotencyKeyInProgress
+}
+
+// CompleteIdempotentRequest сохраняет итоговый ответ для последующих повторов.
+func (s *Service) CompleteIdempotentRequest(record *domain.IdempotencyRecord, status int, body []byte, contentType string) error {
+	if err := s.repository.CompleteIdempotencyKey(record.ID, status, body, contentType); err != nil {
+		s.logger.Error().Err(err).Int64("idempotency_key_id", record.ID).Msg("failed to complete idempotency key")
+		return err
+	}
+	return nil
+}
+
+// AbortIdempotentRequest освобождает ключ, чтобы клиент мог повторить запрос после сбоя сервера.
+func (s *Service) AbortIdempotentRequest(record *domain.IdempotencyRecord) error {
+	if err := s.repository.DeleteIdempotencyKey(record.ID); err != nil {
+		s.logger.Error().Err(err).Int64("idempotency_key_id", record.ID).Msg("failed to delete idempotency key")
+		return err
+	}
+	return nil
+}
+
+// PurgeExpiredIdempotencyKeys удаляет истекшие ключи (фоновая задача).
+func (s *Service) PurgeExpiredIdempotencyKeys() (int64, error) {
+	deleted, err := s.repository.PurgeExpiredIdempotencyKeys()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to purge expired idempotency keys")
+		return 0, err
+	}
+	return deleted, nil
+}
+
+func idempotencyTTL() time.Duration {
+	if hours := configs.AppSettings.IdempotencyParams.KeyTtlHours; hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultIdempotencyTTL
+}
+
+func validIdempotencyKey(key string) bool {
+	if key == "" || len(key) > maxIdempotencyKeyLength || strings.TrimSpace(key) != key {
+		return false
+	}
+	for _, r := range key {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,536 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"marketplace/internal/blob"
+	"marketplace/internal/configs"
+	"marketplace/internal/contracts"
+	"marketplace/internal/errs"
+	"marketplace/internal/imaging"
+	"marketplace/internal/models/domain"
+	"marketplace/utils"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	defaultImageMaxUploadMB    = 10
+	defaultMaxImagesPerProduct = 20
+	defaultImageMaxDimension   = 8000
+	maxImageAltLength          = 255
+	blobPurgeBatchSize         = 500
+	maxBlobDeletionAttempts    = 10
+)
+
+var defaultThumbnailSizes = []int{160, 480, 1024}
+
+// UploadProductImage проверяет и сохраняет изображение товара (только владелец магазина или
+// админ). Формат определяется по содержимому, а не по имени файла и заголовкам. Миниатюры
+// создает фоновая задача; до ее завершения изображение в статусе processing.
+func (s *Service) UploadProductImage(productID int64, input domain.UploadImageInput, userID int, userRole string) (*domain.ProductImage, error) {
+	if s.blobStore == nil {
+		return nil, errs.ErrBlobStoreNotConfigured
+	}
+	product, err := s.GetProductByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.ensureShopOwner(product.ShopID, userID, userRole); err != nil {
+		return nil, err
+	}
+	image, ext, err := inspectImage(input.Data)
+	if err != nil {
+		return nil, err
+	}
+	image.ProductID = productID
+	if image.Alt, err = normalizeImageAlt(input.Alt); err != nil {
+		return nil, err
+	}
+	if input.VariantID != nil && *input.VariantID != 0 {
+		if err := s.ensureProductVariant(productID, *input.VariantID); err != nil {
+			return nil, err
+		}
+		image.VariantID = input.VariantID
+	}
+	image.Key = fmt.Sprintf("products/%d/%s%s", productID, utils.NewUUID(), ext)
+	ctx := context.Background()
+	if err := s.blobStore.Put(ctx, image.Key, input.Data, image.ContentType); err != nil {
+		s.logger.Error().Err(err).Int64("product_id", productID).Msg("failed to store product image")
+		return nil, err
+	}
+	err = s.withProductImages(productID, func(tx *sqlx.Tx, images []*domain.ProductImage) error {
+		if len(images) >= maxImagesPerProduct() {
+			return fmt.Errorf("%w: at most %d images per product", errs.ErrImageLimitReached, maxImagesPerProduct())
+		}
+		image.Position = len(images)
+		image.Primary = len(images) == 0
+		if err := s.repository.CreateProductImageWithTx(tx, image); err != nil {
+			return err
+		}
+		if input.Primary && !image.Primary {
+			if err := s.repository.SetPrimaryProductImageWithTx(tx, productID, image.ID); err != nil {
+				return err
+			}
+			image.Primary = true
+		}
+		job, err := newJob(domain.JobTypeGenerateThumbnails, domain.ImageJob{ImageID: image.ID}, time.Time{})
+		if err != nil {
+			return err
+		}
+		return s.repository.CreateJobWithTx(tx, job)
+	})
+	if err != nil {
+		s.discardBlobs(ctx, image.Key)
+		return nil, err
+	}
+	s.setImageURLs(image)
+	s.logger.Info().Int64("image_id", image.ID).Int64("product_id", productID).Msg("product image uploaded")
+	return image, nil
+}
+
+// inspectImage проверяет размер, формат и габариты файла и заполняет поля изображения.
+// Вместе с изображением возвращается расширение файла для ключа.
+func inspectImage(data []byte) (*domain.ProductImage, string, error) {
+	if len(data) == 0 {
+		return nil, "", errs.ErrInvalidImage
+	}
+	if int64(len(data)) > imageMaxUploadBytes() {
+		return nil, "", fmt.Errorf("%w: at most %d MB", errs.ErrImageTooLarge, imageMaxUploadBytes()>>20)
+	}
+	config, format, err := imaging.DecodeConfig(data)
+	if err != nil {
+		return nil, "", errs.ErrInvalidImage
+	}
+	contentType, ok := imaging.ContentTypes[format]
+	if !ok || http.DetectContentType(data) != contentType {
+		return nil, "", errs.ErrInvalidImage
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, "", errs.ErrInvalidImage
+	}
+	if config.Width > imageMaxDimension() || config.Height > imageMaxDimension() {
+		return nil, "", fmt.Errorf("%w: width and height must not exceed %d pixels", errs.ErrInvalidImage, imageMaxDimension())
+	}
+	return &domain.ProductImage{
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+		Width:       config.Width,
+		Height:      config.Height,
+		Thumbnails:  []domain.ImageThumbnail{},
+		Status:      domain.ImageStatusProcessing,
+	}, imaging.Extensions[format], nil
+}
+
+// ListProductImages возвращает изображения товара в порядке показа.
+func (s *Service) ListProductImages(productID int64) ([]domain.ProductImage, error) {
+	product, err := s.GetProductByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.AttachProductImages(product); err != nil {
+		return nil, err
+	}
+	return product.Images, nil
+}
+
+// AttachProductImages заполняет изображения товаров и их вариантов для ответа API.
+func (s *Service) AttachProductImages(products ...*domain.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	images, err := s.repository.ListProductImages(ids)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to load product images")
+		return err
+	}
+	for _, product := range products {
+		product.Images = images[product.ID]
+		if product.Images == nil {
+			product.Images = []domain.ProductImage{}
+		}
+		for i := range product.Images {
+			s.setImageURLs(&product.Images[i])
+		}
+		for i := range product.Variants {
+			variant := &product.Variants[i]
+			for _, image := range product.Images {
+				if image.VariantID != nil && *image.VariantID == variant.ID {
+					variant.Images = append(variant.Images, image)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// UpdateProductImage меняет подпись, привязку к варианту или делает изображение главным.
+func (s *Service) UpdateProductImage(imageID int64, input domain.UpdateProductImageInput, userID int, userRole string) (*domain.ProductImage, error) {
+	image, err := s.getOwnedProductImage(imageID, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	if input.Primary != nil && !*input.Primary && image.Primary {
+		return nil, fmt.Errorf("%w: make another image primary instead", errs.ErrInvalidFieldValue)
+	}
+	if input.VariantID != nil && *input.VariantID != 0 {
+		if err := s.ensureProductVariant(image.ProductID, *input.VariantID); err != nil {
+			return nil, err
+		}
+	}
+	var alt string
+	if input.Alt != nil {
+		if alt, err = normalizeImageAlt(*input.Alt); err != nil {
+			return nil, err
+		}
+	}
+	err = s.withProductImages(image.ProductID, func(tx *sqlx.Tx, images []*domain.ProductImage) error {
+		index := slices.IndexFunc(images, func(i *domain.ProductImage) bool { return i.ID == imageID })
+		if index < 0 {
+			return errs.ErrImageNotFound
+		}
+		image = images[index]
+		if input.Alt != nil {
+			image.Alt = alt
+		}
+		if input.VariantID != nil {
+			image.VariantID = input.VariantID
+			if *input.VariantID == 0 {
+				image.VariantID = nil
+			}
+		}
+		if err := s.repository.UpdateProductImageWithTx(tx, image); err != nil {
+			return err
+		}
+		if input.Primary != nil && *input.Primary && !image.Primary {
+			if err := s.repository.SetPrimaryProductImageWithTx(tx, image.ProductID, image.ID); err != nil {
+				return err
+			}
+			image.Primary = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.setImageURLs(image)
+	return image, nil
+}
+
+// ReorderProductImages задает порядок показа; передаются все изображения товара.
+func (s *Service) ReorderProductImages(productID int64, input domain.ReorderProductImagesInput, userID int, userRole string) ([]domain.ProductImage, error) {
+	product, err := s.GetProductByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.ensureShopOwner(product.ShopID, userID, userRole); err != nil {
+		return nil, err
+	}
+	err = s.withProductImages(productID, func(tx *sqlx.Tx, images []*domain.ProductImage) error {
+		if len(input.ImageIDs) != len(images) {
+			return fmt.Errorf("%w: image_ids must list all %d images of the product", errs.ErrInvalidFieldValue, len(images))
+		}
+		for _, image := range images {
+			if !slices.Contains(input.ImageIDs, image.ID) {
+				return fmt.Errorf("%w: image_ids must list all %d images of the product", errs.ErrInvalidFieldValue, len(images))
+			}
+		}
+		return s.repository.SetProductImagePositionsWithTx(tx, input.ImageIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.ListProductImages(productID)
+}
+
+// DeleteProductImage удаляет изображение; его файлы удаляются из хранилища фоновой задачей.
+// Если удалено главное изображение, главным становится первое из оставшихся.
+func (s *Service) DeleteProductImage(imageID int64, userID int, userRole string) error {
+	image, err := s.getOwnedProductImage(imageID, userID, userRole)
+	if err != nil {
+		return err
+	}
+	return s.withProductImages(image.ProductID, func(tx *sqlx.Tx, images []*domain.ProductImage) error {
+		index := slices.IndexFunc(images, func(i *domain.ProductImage) bool { return i.ID == imageID })
+		if index < 0 {
+			return errs.ErrImageNotFound
+		}
+		wasPrimary := images[index].Primary
+		if err := s.repository.DeleteProductImageWithTx(tx, imageID); err != nil {
+			return err
+		}
+		remaining := slices.Delete(images, index, index+1)
+		ids := make([]int64, len(remaining))
+		for i, image := range remaining {
+			ids[i] = image.ID
+		}
+		if err := s.repository.SetProductImagePositionsWithTx(tx, ids); err != nil {
+			return err
+		}
+		if wasPrimary && len(ids) > 0 {
+			if err := s.repository.SetPrimaryProductImageWithTx(tx, image.ProductID, ids[0]); err != nil {
+				return err
+			}
+		}
+		return s.enqueueBlobPurgeWithTx(tx)
+	})
+}
+
+// GenerateImageThumbnails создает миниатюры изображения. Миниатюры не больше оригинала не
+// создаются. Поврежденный файл переводит изображение в статус failed без повтора задачи.
+func (s *Service) GenerateImageThumbnails(ctx context.Context, imageID int64) error {
+	if s.blobStore == nil {
+		return errs.ErrBlobStoreNotConfigured
+	}
+	image, err := s.repository.GetProductImageByID(imageID)
+	if errors.Is(err, errs.ErrImageNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if image.Status != domain.ImageStatusProcessing {
+		return nil
+	}
+	original, err := s.blobStore.Get(ctx, image.Key)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(original.Body)
+	original.Body.Close()
+	if err != nil {
+		return err
+	}
+	src, format, err := imaging.Decode(data)
+	if err != nil {
+		s.logger.Warn().Err(err).Int64("image_id", imageID).Msg("failed to decode product image")
+		return s.repository.SetProductImageThumbnails(imageID, nil, domain.ImageStatusFailed)
+	}
+	base := strings.TrimSuffix(image.Key, path.Ext(image.Key))
+	thumbnails := []domain.ImageThumbnail{}
+	var keys []string
+	for _, size := range imageThumbnailSizes() {
+		if size >= max(image.Width, image.Height) {
+			continue
+		}
+		thumbnail := imaging.Thumbnail(src, size)
+		encoded, contentType, ext, err := imaging.Encode(thumbnail, format)
+		if err != nil {
+			return err
+		}
+		key := base + "_" + strconv.Itoa(size) + ext
+		if err := s.blobStore.Put(ctx, key, encoded, contentType); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		bounds := thumbnail.Bounds()
+		thumbnails = append(thumbnails, domain.ImageThumbnail{Size: size, Key: key, Width: bounds.Dx(), Height: bounds.Dy()})
+	}
+	err = s.repository.SetProductImageThumbnails(imageID, thumbnails, domain.ImageStatusReady)
+	if errors.Is(err, errs.ErrImageNotFound) {
+		// Изображение удалили во время обработки: миниатюры больше ни на что не ссылаются.
+		if len(keys) > 0 {
+			return s.repository.QueueBlobDeletion(keys)
+		}
+		return nil
+	}
+	return err
+}
+
+// PurgeOrphanBlobs удаляет из хранилища файлы из очереди удаления. Файлы, которые не удалось
+// удалить, остаются в очереди до maxBlobDeletionAttempts попыток.
+func (s *Service) PurgeOrphanBlobs(ctx context.Context) (int, error) {
+	if s.blobStore == nil {
+		return 0, nil
+	}
+	deletions, err := s.repository.ListBlobDeletions(blobPurgeBatchSize, maxBlobDeletionAttempts)
+	if err != nil {
+		return 0, err
+	}
+	var deleted, failed []int64
+	for _, deletion := range deletions {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := s.blobStore.Delete(ctx, deletion.Key); err != nil {
+			s.logger.Warn().Err(err).Str("key", deletion.Key).Int("attempts", deletion.Attempts+1).Msg("failed to delete blob")
+			failed = append(failed, deletion.ID)
+			continue
+		}
+		deleted = append(deleted, deletion.ID)
+	}
+	if len(failed) > 0 {
+		if err := s.repository.IncrementBlobDeletionAttempts(failed); err != nil {
+			return 0, err
+		}
+	}
+	if len(deleted) > 0 {
+		if err := s.repository.DeleteBlobDeletions(deleted); err != nil {
+			return 0, err
+		}
+	}
+	return len(deleted), nil
+}
+
+// OpenBlob открывает файл хранилища для раздачи по /media.
+func (s *Service) OpenBlob(ctx context.Context, key string) (*contracts.Blob, error) {
+	if s.blobStore == nil {
+		return nil, errs.ErrBlobStoreNotConfigured
+	}
+	if !blob.ValidKey(key) {
+		return nil, errs.ErrBlobNotFound
+	}
+	return s.blobStore.Get(ctx, key)
+}
+
+// getOwnedProductImage возвращает изображение, если пользователь владеет магазином товара.
+func (s *Service) getOwnedProductImage(imageID int64, userID int, userRole string) (*domain.ProductImage, error) {
+	if imageID <= 0 {
+		return nil, errs.ErrImageNotFound
+	}
+	image, err := s.repository.GetProductImageByID(imageID)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.GetProductByID(image.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.ensureShopOwner(product.ShopID, userID, userRole); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// ensureProductVariant проверяет, что вариант принадлежит товару.
+func (s *Service) ensureProductVariant(productID, variantID int64) error {
+	variant, err := s.getProductVariant(variantID)
+	if err != nil {
+		return err
+	}
+	if variant.ProductID != productID {
+		return errs.ErrVariantNotFound
+	}
+	return nil
+}
+
+// withProductImages выполняет изменение изображений товара в транзакции под блокировкой
+// товара и его изображений и увеличивает версию товара. Права проверяет вызывающий.
+func (s *Service) withProductImages(productID int64, fn func(tx *sqlx.Tx, images []*domain.ProductImage) error) error {
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	if _, err := s.repository.GetProductByIDWithTx(tx, productID); err != nil {
+		if errors.Is(err, errs.ErrNotfound) {
+			return errs.ErrProductNotfound
+		}
+		return err
+	}
+	images, err := s.repository.LockProductImagesWithTx(tx, productID)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx, images); err != nil {
+		return err
+	}
+	if err := s.repository.TouchProductWithTx(tx, productID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// enqueueBlobPurgeWithTx ставит задачу удаления файлов сразу, не дожидаясь расписания.
+func (s *Service) enqueueBlobPurgeWithTx(tx *sqlx.Tx) error {
+	job, err := newJob(domain.JobTypePurgeBlobs, nil, time.Time{})
+	if err != nil {
+		return err
+	}
+	return s.repository.CreateJobWithTx(tx, job)
+}
+
+// discardBlobs удаляет файлы, не попавшие в базу; при ошибке они ставятся в очередь удаления.
+func (s *Service) discardBlobs(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := s.blobStore.Delete(ctx, key); err != nil {
+			s.logger.Warn().Err(err).Str("key", key).Msg("failed to delete blob, queueing")
+			if err := s.repository.QueueBlobDeletion([]string{key}); err != nil {
+				s.logger.Error().Err(err).Str("key", key).Msg("failed to queue blob deletion")
+			}
+		}
+	}
+}
+
+func (s *Service) setImageURLs(image *domain.ProductImage) {
+	if s.blobStore == nil {
+		return
+	}
+	image.URL = s.blobStore.URL(image.Key)
+	for i := range image.Thumbnails {
+		image.Thumbnails[i].URL = s.blobStore.URL(image.Thumbnails[i].Key)
+	}
+}
+
+func normalizeImageAlt(alt string) (string, error) {
+	alt = strings.TrimSpace(alt)
+	if len(alt) > maxImageAltLength {
+		return "", fmt.Errorf("%w: alt must be at most %d characters long", errs.ErrInvalidFieldValue, maxImageAltLength)
+	}
+	return alt, nil
+}
+
+func imageMaxUploadBytes() int64 {
+	if mb := configs.AppSettings.ImageParams.MaxUploadMB; mb > 0 {
+		return int64(mb) << 20
+	}
+	return defaultImageMaxUploadMB << 20
+}
+
+func maxImagesPerProduct() int {
+	if n := configs.AppSettings.ImageParams.MaxImagesPerProduct; n > 0 {
+		return n
+	}
+	return defaultMaxImagesPerProduct
+}
+
+func imageMaxDimension() int {
+	if n := configs.AppSettings.ImageParams.MaxDimension; n > 0 {
+		return n
+	}
+	return defaultImageMaxDimension
+}
+
+// imageThumbnailSizes возвращает положительные размеры миниатюр по возрастанию.
+func imageThumbnailSizes() []int {
+	sizes := slices.DeleteFunc(slices.Clone(configs.AppSettings.ImageParams.ThumbnailSizes), func(size int) bool { return size <= 0 })
+	if len(sizes) == 0 {
+		return defaultThumbnailSizes
+	}
+	slices.Sort(sizes)
+	return slices.Compact(sizes)
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"marketplace/internal/blob"
+	"marketplace/internal/configs"
+	"marketplace/internal/contracts"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"testing"
+)
+
+func testImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func encodeTestImage(t *testing.T, format string, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func withImageParams(t *testing.T, params configs.ImageParams) {
+	t.Helper()
+	saved := configs.AppSettings.ImageParams
+	configs.AppSettings.ImageParams = params
+	t.Cleanup(func() { configs.AppSettings.ImageParams = saved })
+}
+
+func TestInspectImage(t *testing.T) {
+	withImageParams(t, configs.ImageParams{MaxUploadMB: 1, MaxDimension: 400})
+	pngData := encodeTestImage(t, "png", testImage(300, 200))
+	bmpHeader := append([]byte("BM"), make([]byte, 64)...)
+
+	tests := []struct {
+		name            string
+		data            []byte
+		wantContentType string
+		wantExt         string
+		wantErr         error
+	}{
+		{name: "png", data: pngData, wantContentType: "image/png", wantExt: ".png"},
+		{name: "jpeg", data: encodeTestImage(t, "jpeg", testImage(40, 30)), wantContentType: "image/jpeg", wantExt: ".jpg"},
+		{name: "gif", data: encodeTestImage(t, "gif", testImage(40, 30)), wantContentType: "image/gif", wantExt: ".gif"},
+		{name: "empty", data: nil, wantErr: errs.ErrInvalidImage},
+		{name: "text", data: []byte("<svg xmlns='http://www.w3.org/2000/svg'></svg>"), wantErr: errs.ErrInvalidImage},
+		{name: "unsupported format", data: bmpHeader, wantErr: errs.ErrInvalidImage},
+		{name: "truncated png", data: pngData[:20], wantErr: errs.ErrInvalidImage},
+		{name: "too large", data: append(bytes.Clone(pngData), make([]byte, 1<<20)...), wantErr: errs.ErrImageTooLarge},
+		{name: "too wide", data: encodeTestImage(t, "png", testImage(401, 10)), wantErr: errs.ErrInvalidImage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			image, ext, err := inspectImage(tt.data)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if image.ContentType != tt.wantContentType || ext != tt.wantExt || image.SizeBytes != int64(len(tt.data)) {
+				t.Errorf("got %s %s %d bytes, want %s %s", image.ContentType, ext, image.SizeBytes, tt.wantContentType, tt.wantExt)
+			}
+		})
+	}
+}
+
+// imageRepository - репозиторий в памяти для одного изображения товара.
+type imageRepository struct {
+	contracts.RepositoryI
+	image  domain.ProductImage
+	status string
+	queued []string
+}
+
+func (r *imageRepository) GetProductImageByID(int64) (*domain.ProductImage, error) {
+	image := r.image
+	return &image, nil
+}
+
+func (r *imageRepository) SetProductImageThumbnails(_ int64, thumbnails []domain.ImageThumbnail, status string) error {
+	r.image.Thumbnails, r.status = thumbnails, status
+	return nil
+}
+
+func (r *imageRepository) QueueBlobDeletion(keys []string) error {
+	r.queued = append(r.queued, keys...)
+	return nil
+}
+
+func TestGenerateImageThumbnails(t *testing.T) {
+	withImageParams(t, configs.ImageParams{ThumbnailSizes: []int{480, 100, 0, 1000, 100}})
+	store := blob.NewLocalStore(t.TempDir(), "")
+	ctx := context.Background()
+
+	tests := []struct {
+		name            string
+		format          string
+		width, height   int
+		wantSizes       [][2]int
+		wantContentType string
+	}{
+		// 1000 не меньше оригинала и пропускается, размеры идут по возрастанию
+		{name: "landscape jpeg", format: "jpeg", width: 640, height: 480, wantSizes: [][2]int{{100, 75}, {480, 360}}, wantContentType: "image/jpeg"},
+		{name: "portrait png keeps transparency format", format: "png", width: 120, height: 600, wantSizes: [][2]int{{20, 100}, {96, 480}}, wantContentType: "image/png"},
+		{name: "small image has no thumbnails", format: "gif", width: 90, height: 60, wantSizes: nil},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := "products/1/image" + string(rune('a'+i)) + ".img"
+			if err := store.Put(ctx, key, encodeTestImage(t, tt.format, testImage(tt.width, tt.height)), "application/octet-stream"); err != nil {
+				t.Fatal(err)
+			}
+			repository := &imageRepository{image: domain.ProductImage{
+				ID: 1, Key: key, Width: tt.width, Height: tt.height, Status: domain.ImageStatusProcessing,
+			}}
+			s := NewService(repository, WithBlobStore(store))
+			if err := s.GenerateImageThumbnails(ctx, 1); err != nil {
+				t.Fatal(err)
+			}
+			if repository.status != domain.ImageStatusReady || len(repository.image.Thumbnails) != len(tt.wantSizes) {
+				t.Fatalf("status %s, thumbnails %+v", repository.status, repository.image.Thumbnails)
+			}
+			for j, thumbnail := range repository.image.Thumbnails {
+				if thumbnail.Width != tt.wantSizes[j][0] || thumbnail.Height != tt.wantSizes[j][1] {
+					t.Errorf("thumbnail %d is %dx%d, want %v", j, thumbnail.Width, thumbnail.Height, tt.wantSizes[j])
+				}
+				stored, err := store.Get(ctx, thumbnail.Key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				data, err := io.ReadAll(stored.Body)
+				stored.Body.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				config, _, err := image.DecodeConfig(bytes.NewReader(data))
+				if err != nil || config.Width != thumbnail.Width || config.Height != thumbnail.Height {
+					t.Errorf("stored thumbnail %s: %dx%d, %v", thumbnail.Key, config.Width, config.Height, err)
+				}
+				if stored.ContentType != tt.wantContentType {
+					t.Errorf("stored thumbnail %s has type %s, want %s", thumbnail.Key, stored.ContentType, tt.wantContentType)
+				}
+			}
+		})
+	}
+
+	// Поврежденный оригинал переводит изображение в failed без ошибки, чтобы задача не повторялась
+	if err := store.Put(ctx, "products/1/broken.png", []byte("not an image"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	repository := &imageRepository{image: domain.ProductImage{ID: 2, Key: "products/1/broken.png", Width: 800, Height: 800, Status: domain.ImageStatusProcessing}}
+	if err := NewService(repository, WithBlobStore(store)).GenerateImageThumbnails(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if repository.status != domain.ImageStatusFailed {
+		t.Errorf("broken image status %s, want failed", repository.status)
+	}
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"marketplace/internal/configs"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"marketplace/utils"
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultJobMaxAttempts = 5
+	defaultJobBackoffBase = 10 * time.Second
+	defaultJobBackoffMax  = time.Hour
+	defaultJobLockTimeout = 10 * time.Minute
+	maxJobErrorLength     = 2000
+)
+
+// EnqueueJob ставит задачу в очередь; payload сериализуется в JSON. Нулевой runAt - выполнить сразу.
+func (s *Service) EnqueueJob(jobType string, payload interface{}, runAt time.Time) (*domain.Job, error) {
+	job, err := newJob(jobType, payload, runAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repository.CreateJob(job); err != nil {
+		s.logger.Error().Err(err).Str("type", jobType).Msg("failed to enqueue job")
+		return nil, err
+	}
+	return job, nil
+}
+
+func newJob(jobType string, payload interface{}, runAt time.Time) (*domain.Job, error) {
+	if jobType == "" {
+		return nil, fmt.Errorf("%w: job type is required", errs.ErrInvalidFieldValue)
+	}
+	raw := json.RawMessage("{}")
+	if payload != nil {
+		var err error
+		if raw, err = json.Marshal(payload); err != nil {
+			return nil, fmt.Errorf("failed to encode job payload: %w", err)
+		}
+	}
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+	return &domain.Job{
+		Type:        jobType,
+		Payload:     raw,
+		Status:      domain.JobStatusPending,
+		MaxAttempts: jobMaxAttempts(),
+		RunAt:       runAt,
+	}, nil
+}
+
+// ClaimJobs забирает до limit готовых к выполнению задач воркеру workerID.
+func (s *Service) ClaimJobs(workerID string, limit int) ([]domain.Job, error) {
+	jobs, err := s.repository.ClaimJobs(workerID, limit, jobLockTimeout())
+	if err != nil {
+		s.logger.Error().Err(err).Str("worker_id", workerID).Msg("failed to claim jobs")
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (s *Service) CompleteJob(job *domain.Job, workerID string) error {
+	if err := s.repository.CompleteJob(job.ID, workerID); err != nil {
+		s.logger.Error().Err(err).Int64("job_id", job.ID).Msg("failed to complete job")
+		return err
+	}
+	return nil
+}
+
+// FailJob сохраняет ошибку задачи и планирует повтор с экспоненциальной задержкой;
+// после max_attempts попыток задача переходит в dead.
+func (s *Service) FailJob(job *domain.Job, workerID string, jobErr error) error {
+	message := jobErr.Error()
+	if len(message) > maxJobErrorLength {
+		message = message[:maxJobErrorLength]
+	}
+	var retryAt *time.Time
+	if job.Attempts < job.MaxAttempts {
+		at := time.Now().Add(jobBackoff(job.Attempts))
+		retryAt = &at
+	}
+	if err := s.repository.FailJob(job.ID, workerID, message, retryAt); err != nil {
+		s.logger.Error().Err(err).Int64("job_id", job.ID).Msg("failed to record job failure")
+		return err
+	}
+	if retryAt == nil {
+		s.logger.Error().Int64("job_id", job.ID).Str("type", job.Type).Int("attempts", job.Attempts).Str("error", message).Msg("job moved to dead state")
+	}
+	return nil
+}
+
+func (s *Service) GetJob(jobID int64) (*domain.Job, error) {
+	job, err := s.repository.GetJobByID(jobID)
+	if errors.Is(err, errs.ErrNotfound) {
+		return nil, errs.ErrJobNotFound
+	}
+	return job, err
+}
+
+func (s *Service) ListJobs(filter domain.JobFilter) (*domain.Page[domain.Job], error) {
+	switch filter.Status {
+	case "", domain.JobStatusPending, domain.JobStatusRunning, domain.JobStatusCompleted, domain.JobStatusDead, domain.JobStatusCancelled:
+	default:
+		return nil, errs.ErrInvalidJobStatus
+	}
+	normalizePage(&filter.PageRequest)
+	return s.repository.ListJobs(filter)
+}
+
+// RetryJob возвращает в очередь мертвую или отмененную задачу.
+func (s *Service) RetryJob(jobID int64) (*domain.Job, error) {
+	job, err := s.repository.RetryJob(jobID)
+	if errors.Is(err, errs.ErrNotfound) {
+		return nil, s.jobStateError(jobID)
+	}
+	if err != nil {
+		s.logger.Error().Err(err).Int64("job_id", jobID).Msg("failed to retry job")
+		return nil, err
+	}
+	s.logger.Info().Int64("job_id", jobID).Msg("job requeued")
+	return job, nil
+}
+
+// CancelJob отменяет задачу, ожидающую выполнения.
+func (s *Service) CancelJob(jobID int64) (*domain.Job, error) {
+	job, err := s.repository.CancelJob(jobID)
+	if errors.Is(err, errs.ErrNotfound) {
+		return nil, s.jobStateError(jobID)
+	}
+	if err != nil {
+		s.logger.Error().Err(err).Int64("job_id", jobID).Msg("failed to cancel job")
+		return nil, err
+	}
+	s.logger.Info().Int64("job_id", jobID).Msg("job cancelled")
+	return job, nil
+}
+
+// jobStateError различает отсутствующую задачу и задачу в неподходящем статусе.
+func (s *Service) jobStateError(jobID int64) error {
+	if _, err := s.GetJob(jobID); err != nil {
+		return err
+	}
+	return errs.ErrJobStateConflict
+}
+
+func (s *Service) ListJobSchedules() ([]domain.JobSchedule, error) {
+	return s.repository.ListJobSchedules()
+}
+
+// EnqueueDueSchedules ставит в очередь задачи расписаний, время которых наступило, и сдвигает
+// расписания на следующий запуск. Пропущенные запуски (приложение было остановлено) не догоняются.
+func (s *Service) EnqueueDueSchedules() (int, error) {
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return 0, err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	schedules, err := s.repository.ListDueJobSchedulesWithTx(tx)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to list due job schedules")
+		return 0, err
+	}
+	now := time.Now()
+	for _, schedule := range schedules {
+		var nextRunAt *time.Time
+		cron, err := utils.ParseCron(schedule.Cron)
+		if err != nil {
+			s.logger.Error().Err(err).Str("schedule", schedule.Name).Msg("invalid cron expression, schedule disabled")
+		} else if next := cron.Next(now); !next.IsZero() {
+			nextRunAt = &next
+		}
+		if err == nil {
+			job := &domain.Job{
+				Type:        schedule.JobType,
+				Payload:     schedule.Payload,
+				Status:      domain.JobStatusPending,
+				MaxAttempts: jobMaxAttempts(),
+				RunAt:       now,
+				ScheduleID:  &schedule.ID,
+			}
+			if err := s.repository.CreateJobWithTx(tx, job); err != nil {
+				s.logger.Error().Err(err).Str("schedule", schedule.Name).Msg("failed to enqueue scheduled job")
+				return 0, err
+			}
+		}
+		if err := s.repository.UpdateJobScheduleRunWithTx(tx, schedule.ID, now, nextRunAt); err != nil {
+			s.logger.Error().Err(err).Str("schedule", schedule.Name).Msg("failed to update job schedule")
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return 0, err
+	}
+	committed = true
+	return len(schedules), nil
+}
+
+// jobBackoff: base * 2^(attempt-1) со случайным разбросом до 20%, не больше backoff_max_seconds.
+func jobBackoff(attempt int) time.Duration {
+	base := defaultJobBackoffBase
+	if seconds := configs.AppSettings.JobParams.BackoffBaseSeconds; seconds > 0 {
+		base = time.Duration(seconds) * time.Second
+	}
+	maxDelay := defaultJobBackoffMax
+	if seconds := configs.AppSettings.JobParams.BackoffMaxSeconds; seconds > 0 {
+		maxDelay = time.Duration(seconds) * time.Second
+	}
+	delay := base
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxDelay)
+	return delay + time.Duration(rand.Int63n(int64(delay)/5+1))
+}
+
+func jobMaxAttempts() int {
+	if n := configs.AppSettings.JobParams.MaxAttempts; n > 0 {
+		return n
+	}
+	return defaultJobMaxAttempts
+}
+
+func jobLockTimeout() time.Duration {
+	if seconds := configs.AppSettings.JobParams.LockTimeoutSeconds; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultJobLockTimeout
+}
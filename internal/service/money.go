@@ -0,0 +1,31 @@
+package service
+
+import (
+	"fmt"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+)
+
+// matchCurrency приводит сумму к валюте сущности: нулевой сумме без валюты
+// проставляется currency, сумма в другой валюте считается ошибкой.
+func matchCurrency(m *domain.Money, currency string, field string) error {
+	if m.Currency == "" && m.IsZero() {
+		m.Currency = currency
+		return nil
+	}
+	if m.Currency != currency {
+		return fmt.Errorf("%w: %s must be in %s", errs.ErrInvalidFieldValue, field, currency)
+	}
+	return nil
+}
+
+// priceCurrency проверяет код валюты цены по ISO 4217 и согласует его с полем currency.
+func priceCurrency(price domain.Money, currency string) (string, error) {
+	if !domain.IsValidCurrency(price.Currency) {
+		return "", fmt.Errorf("%w: %q", errs.ErrInvalidCurrency, price.Currency)
+	}
+	if currency != "" && currency != price.Currency {
+		return "", fmt.Errorf("%w: currency %s does not match price currency %s", errs.ErrInvalidFieldValue, currency, price.Currency)
+	}
+	return price.Currency, nil
+}
@@ -0,0 +1,483 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"marketplace/internal/configs"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	defaultNotificationLocale          = "ru"
+	defaultNotificationDigestThreshold = 10
+	defaultNotificationDigestWindow    = time.Hour
+	maxNotificationDigestItems         = 50
+)
+
+var localePattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+// CreateNotifications создает уведомления о событии для всех получателей: по шаблону события на
+// языке получателя в каждом включенном у него канале. Входящие (in_app) сразу доступны в ленте,
+// email и SMS отправляются очередью задач. Если получатель за digest_window_minutes уже получил
+// в канале digest_threshold уведомлений, новые копятся до сводки. Повтор события ничего не дублирует.
+func (s *Service) CreateNotifications(event domain.Event) (int, error) {
+	if !slices.Contains(domain.NotificationEventTypes, event.Type) {
+		return 0, nil
+	}
+	recipients, err := s.notificationRecipients(event)
+	if err != nil || len(recipients) == 0 {
+		return 0, err
+	}
+	templates, err := s.repository.ListNotificationTemplates(event.Type)
+	if err != nil || len(templates) == 0 {
+		return 0, err
+	}
+	data, err := decodeNotificationData(event.Payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode %s event payload: %w", event.Type, err)
+	}
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return 0, err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	var created int
+	for _, userID := range recipients {
+		prefs, err := s.notificationPreferences(userID)
+		if err != nil {
+			return 0, err
+		}
+		for _, channel := range domain.NotificationChannels {
+			if !prefs.Enabled(channel) {
+				continue
+			}
+			tmpl := pickNotificationTemplate(templates, channel, prefs.Locale)
+			if tmpl == nil {
+				continue
+			}
+			subject, body, err := renderNotification(tmpl, data)
+			if err != nil {
+				s.logger.Error().Err(err).Int64("template_id", tmpl.ID).Str("event_type", event.Type).Msg("failed to render notification template")
+				continue
+			}
+			notification := &domain.Notification{
+				UserID:    userID,
+				EventID:   event.EventID,
+				EventType: event.Type,
+				Channel:   channel,
+				Subject:   subject,
+				Body:      body,
+			}
+			ok, err := s.createNotificationWithTx(tx, notification)
+			if err != nil {
+				return 0, err
+			}
+			if ok {
+				created++
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return 0, err
+	}
+	committed = true
+	return created, nil
+}
+
+// createNotificationWithTx сохраняет уведомление в статусе, зависящем от канала и частоты
+// уведомлений получателя, и ставит в очередь его отправку.
+func (s *Service) createNotificationWithTx(tx *sqlx.Tx, notification *domain.Notification) (bool, error) {
+	if notification.Channel == domain.NotificationChannelInApp {
+		now := time.Now()
+		notification.Status = domain.NotificationStatusSent
+		notification.SentAt = &now
+	} else {
+		recent, err := s.repository.CountRecentNotificationsWithTx(tx, notification.UserID, notification.Channel, time.Now().Add(-notificationDigestWindow()))
+		if err != nil {
+			return false, err
+		}
+		notification.Status = domain.NotificationStatusPending
+		if recent >= notificationDigestThreshold() {
+			notification.Status = domain.NotificationStatusDigest
+		}
+	}
+	created, err := s.repository.CreateNotificationWithTx(tx, notification)
+	if err != nil || !created || notification.Status != domain.NotificationStatusPending {
+		return created, err
+	}
+	job, err := newJob(domain.JobTypeSendNotification, domain.NotificationJob{NotificationID: notification.ID}, time.Time{})
+	if err != nil {
+		return false, err
+	}
+	if err := s.repository.CreateJobWithTx(tx, job); err != nil {
+		s.logger.Error().Err(err).Int64("notification_id", notification.ID).Msg("failed to enqueue notification")
+		return false, err
+	}
+	return true, nil
+}
+
+// SendNotification отправляет email или SMS уведомление отправителем канала. Ошибка отправителя
+// возвращается, чтобы очередь задач повторила отправку с экспоненциальной задержкой.
+func (s *Service) SendNotification(ctx context.Context, notificationID int64) error {
+	notification, err := s.repository.GetNotificationByID(notificationID)
+	if errors.Is(err, errs.ErrNotfound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if notification.Status != domain.NotificationStatusPending {
+		return nil
+	}
+	sender, ok := s.senders[notification.Channel]
+	if !ok {
+		return s.repository.UpdateNotificationStatus(notificationID, domain.NotificationStatusFailed, "no sender configured for channel "+notification.Channel)
+	}
+	user, err := s.repository.GetUserByID(int(notification.UserID))
+	if err != nil {
+		return err
+	}
+	to := user.Email
+	if notification.Channel == domain.NotificationChannelSMS {
+		to = user.Phone
+	}
+	if to == "" {
+		return s.repository.UpdateNotificationStatus(notificationID, domain.NotificationStatusFailed, "user has no "+notification.Channel+" contact")
+	}
+	sendErr := sender.Send(ctx, domain.NotificationMessage{
+		NotificationID: notification.ID,
+		Channel:        notification.Channel,
+		To:             to,
+		Subject:        notification.Subject,
+		Body:           notification.Body,
+	})
+	if sendErr != nil {
+		if err := s.repository.UpdateNotificationStatus(notificationID, domain.NotificationStatusPending, sendErr.Error()); err != nil {
+			s.logger.Error().Err(err).Int64("notification_id", notificationID).Msg("failed to record notification error")
+		}
+		return sendErr
+	}
+	return s.repository.UpdateNotificationStatus(notificationID, domain.NotificationStatusSent, "")
+}
+
+// SendNotificationDigests собирает накопленные уведомления каждого получателя и канала в одну
+// сводку и ставит ее отправку в очередь.
+func (s *Service) SendNotificationDigests(ctx context.Context) (int, error) {
+	groups, err := s.repository.ListNotificationDigestGroups()
+	if err != nil || len(groups) == 0 {
+		return 0, err
+	}
+	templates, err := s.repository.ListNotificationTemplates(domain.EventNotificationDigest)
+	if err != nil {
+		return 0, err
+	}
+	var sent int
+	for _, group := range groups {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		ok, err := s.createNotificationDigest(group, templates)
+		if err != nil {
+			return sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+func (s *Service) createNotificationDigest(group domain.NotificationDigestGroup, templates []domain.NotificationTemplate) (bool, error) {
+	prefs, err := s.notificationPreferences(group.UserID)
+	if err != nil {
+		return false, err
+	}
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return false, err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	items, err := s.repository.LockDigestNotificationsWithTx(tx, group.UserID, group.Channel)
+	if err != nil || len(items) == 0 {
+		return false, err
+	}
+	digest := &domain.Notification{
+		UserID:    group.UserID,
+		EventType: domain.EventNotificationDigest,
+		Channel:   group.Channel,
+		Status:    domain.NotificationStatusPending,
+	}
+	digest.Subject, digest.Body, err = renderNotificationDigest(pickNotificationTemplate(templates, group.Channel, prefs.Locale), items)
+	if err != nil {
+		s.logger.Error().Err(err).Int64("user_id", group.UserID).Msg("failed to render notification digest")
+		return false, err
+	}
+	if _, err := s.repository.CreateNotificationWithTx(tx, digest); err != nil {
+		return false, err
+	}
+	ids := make([]int64, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	if err := s.repository.MarkNotificationsDigestedWithTx(tx, ids, digest.ID); err != nil {
+		return false, err
+	}
+	job, err := newJob(domain.JobTypeSendNotification, domain.NotificationJob{NotificationID: digest.ID}, time.Time{})
+	if err != nil {
+		return false, err
+	}
+	if err := s.repository.CreateJobWithTx(tx, job); err != nil {
+		s.logger.Error().Err(err).Int64("notification_id", digest.ID).Msg("failed to enqueue notification digest")
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return false, err
+	}
+	committed = true
+	s.logger.Info().Int64("user_id", group.UserID).Str("channel", group.Channel).Int("count", len(items)).Msg("notification digest created")
+	return true, nil
+}
+
+// ListNotifications возвращает ленту входящих уведомлений пользователя и число непрочитанных.
+func (s *Service) ListNotifications(userID int, unreadOnly bool, page domain.PageRequest) (*domain.NotificationInbox, error) {
+	normalizePage(&page)
+	items, err := s.repository.ListUserNotifications(domain.NotificationFilter{
+		UserID:      int64(userID),
+		UnreadOnly:  unreadOnly,
+		PageRequest: page,
+	})
+	if err != nil {
+		return nil, err
+	}
+	unread, err := s.repository.CountUnreadNotifications(int64(userID))
+	if err != nil {
+		return nil, err
+	}
+	return &domain.NotificationInbox{Unread: unread, Items: items.Items, NextCursor: items.NextCursor, Total: items.Total}, nil
+}
+
+func (s *Service) MarkNotificationRead(notificationID int64, userID int) (*domain.Notification, error) {
+	notification, err := s.repository.MarkNotificationRead(notificationID, int64(userID))
+	if errors.Is(err, errs.ErrNotfound) {
+		return nil, errs.ErrNotificationNotFound
+	}
+	return notification, err
+}
+
+func (s *Service) MarkAllNotificationsRead(userID int) (int64, error) {
+	return s.repository.MarkAllNotificationsRead(int64(userID))
+}
+
+// GetNotificationPreferences возвращает настройки уведомлений; пока пользователь их не менял,
+// действуют значения по умолчанию: email и входящие включены, SMS выключены.
+func (s *Service) GetNotificationPreferences(userID int) (*domain.NotificationPreferences, error) {
+	return s.notificationPreferences(int64(userID))
+}
+
+func (s *Service) UpdateNotificationPreferences(userID int, prefs *domain.NotificationPreferences) error {
+	if prefs == nil {
+		return errs.ErrInvalidRequestBody
+	}
+	prefs.Locale = strings.ToLower(strings.TrimSpace(prefs.Locale))
+	if prefs.Locale == "" {
+		prefs.Locale = notificationDefaultLocale()
+	}
+	if !localePattern.MatchString(prefs.Locale) {
+		return errs.ErrInvalidLocale
+	}
+	return s.repository.SaveNotificationPreferences(int64(userID), prefs)
+}
+
+func (s *Service) ListNotificationTemplates(eventType string) ([]domain.NotificationTemplate, error) {
+	return s.repository.ListNotificationTemplates(strings.TrimSpace(eventType))
+}
+
+// SaveNotificationTemplate создает или заменяет шаблон; шаблон с синтаксической ошибкой не сохраняется.
+func (s *Service) SaveNotificationTemplate(tmpl *domain.NotificationTemplate) error {
+	if tmpl == nil {
+		return errs.ErrInvalidRequestBody
+	}
+	tmpl.EventType = strings.TrimSpace(tmpl.EventType)
+	tmpl.Locale = strings.ToLower(strings.TrimSpace(tmpl.Locale))
+	if tmpl.EventType != domain.EventNotificationDigest && !slices.Contains(domain.NotificationEventTypes, tmpl.EventType) {
+		return fmt.Errorf("%w: unknown event type %q", errs.ErrInvalidFieldValue, tmpl.EventType)
+	}
+	if !slices.Contains(domain.NotificationChannels, tmpl.Channel) {
+		return fmt.Errorf("%w: unknown channel %q", errs.ErrInvalidFieldValue, tmpl.Channel)
+	}
+	if !localePattern.MatchString(tmpl.Locale) {
+		return errs.ErrInvalidLocale
+	}
+	if strings.TrimSpace(tmpl.Body) == "" {
+		return fmt.Errorf("%w: body is required", errs.ErrInvalidNotificationTemplate)
+	}
+	for _, text := range []string{tmpl.Subject, tmpl.Body} {
+		if _, err := template.New("notification").Parse(text); err != nil {
+			return fmt.Errorf("%w: %s", errs.ErrInvalidNotificationTemplate, err.Error())
+		}
+	}
+	if err := s.repository.UpsertNotificationTemplate(tmpl); err != nil {
+		s.logger.Error().Err(err).Str("event_type", tmpl.EventType).Msg("failed to save notification template")
+		return err
+	}
+	return nil
+}
+
+// notificationRecipients определяет получателей события: владельцев магазинов для нового заказа
+// и заканчивающегося товара, покупателя для смены статуса заказа, самого пользователя для регистрации.
+func (s *Service) notificationRecipients(event domain.Event) ([]int64, error) {
+	switch event.Type {
+	case domain.EventOrderCreated, domain.EventStockLow:
+		shopIDs, err := eventShopIDs(event)
+		if err != nil || len(shopIDs) == 0 {
+			return nil, err
+		}
+		return s.repository.ListShopOwnerIDs(shopIDs)
+	case domain.EventOrderStatusChanged, domain.EventUserRegistered:
+		var payload struct {
+			UserID int64 `json:"user_id"`
+		}
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return nil, fmt.Errorf("failed to decode %s event payload: %w", event.Type, err)
+		}
+		if payload.UserID > 0 {
+			return []int64{payload.UserID}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Service) notificationPreferences(userID int64) (*domain.NotificationPreferences, error) {
+	prefs, err := s.repository.GetNotificationPreferences(userID)
+	if errors.Is(err, errs.ErrNotfound) {
+		return &domain.NotificationPreferences{Locale: notificationDefaultLocale(), Email: true, InApp: true}, nil
+	}
+	return prefs, err
+}
+
+// pickNotificationTemplate выбирает шаблон канала на языке получателя, иначе на языке по умолчанию.
+func pickNotificationTemplate(templates []domain.NotificationTemplate, channel, locale string) *domain.NotificationTemplate {
+	var fallback *domain.NotificationTemplate
+	for i := range templates {
+		if templates[i].Channel != channel {
+			continue
+		}
+		switch templates[i].Locale {
+		case locale:
+			return &templates[i]
+		case notificationDefaultLocale():
+			fallback = &templates[i]
+		}
+	}
+	return fallback
+}
+
+func renderNotification(tmpl *domain.NotificationTemplate, data interface{}) (string, string, error) {
+	subject, err := renderNotificationText(tmpl.Subject, data)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := renderNotificationText(tmpl.Body, data)
+	if err != nil {
+		return "", "", err
+	}
+	return subject, body, nil
+}
+
+func renderNotificationText(text string, data interface{}) (string, error) {
+	t, err := template.New("notification").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// renderNotificationDigest формирует сводку по шаблону notification.digest с данными
+// {count, items: [{event_type, subject, body, created_at}]}; без шаблона сводка - список тем.
+func renderNotificationDigest(tmpl *domain.NotificationTemplate, items []domain.Notification) (string, string, error) {
+	listed := items[:min(len(items), maxNotificationDigestItems)]
+	if tmpl == nil {
+		subjects := make([]string, len(listed))
+		for i, item := range listed {
+			subjects[i] = "- " + item.Subject
+		}
+		return fmt.Sprintf("%d notifications", len(items)), strings.Join(subjects, "\n"), nil
+	}
+	data := map[string]interface{}{"count": len(items)}
+	entries := make([]map[string]interface{}, len(listed))
+	for i, item := range listed {
+		entries[i] = map[string]interface{}{
+			"event_type": item.EventType,
+			"subject":    item.Subject,
+			"body":       item.Body,
+			"created_at": item.CreatedAt,
+		}
+	}
+	data["items"] = entries
+	return renderNotification(tmpl, data)
+}
+
+// decodeNotificationData разбирает payload события для шаблона; числа остаются в исходной записи.
+func decodeNotificationData(payload []byte) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func notificationDefaultLocale() string {
+	if locale := configs.AppSettings.NotificationParams.DefaultLocale; locale != "" {
+		return locale
+	}
+	return defaultNotificationLocale
+}
+
+func notificationDigestThreshold() int {
+	if n := configs.AppSettings.NotificationParams.DigestThreshold; n > 0 {
+		return n
+	}
+	return defaultNotificationDigestThreshold
+}
+
+func notificationDigestWindow() time.Duration {
+	if minutes := configs.AppSettings.NotificationParams.DigestWindowMinutes; minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultNotificationDigestWindow
+}
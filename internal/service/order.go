@@ -5,12 +5,95 @@ import (
 	"fmt"
 	"marketplace/internal/errs"
 	"marketplace/internal/models/domain"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// maxOrderAttempts - сколько раз повторяется транзакция заказа при конфликте блокировок
+	maxOrderAttempts = 3
+	orderRetryDelay  = 20 * time.Millisecond
+
+	maxPaymentReferenceLength = 255
 )
 
 func (s *Service) CreateOrder(userID int, input domain.CreateOrderInput) (int64, error) {
 	if len(input.Items) == 0 {
 		return 0, errors.New("order must contain at least one item")
 	}
+	items, err := mergeOrderItems(input.Items)
+	if err != nil {
+		return 0, err
+	}
+	shippingAddress, err := s.resolveShippingAddress(userID, input.AddressID)
+	if err != nil {
+		return 0, err
+	}
+	currency, err := s.resolveOrderCurrency(userID, input.Currency)
+	if err != nil {
+		return 0, err
+	}
+	flashSales, err := s.activeFlashSales(items)
+	if err != nil {
+		return 0, err
+	}
+	if len(flashSales) > 0 {
+		saleIDs := make([]int64, 0, len(flashSales))
+		for _, sale := range flashSales {
+			saleIDs = append(saleIDs, sale.ID)
+		}
+		release, err := s.flashQueue.acquire(saleIDs)
+		if err != nil {
+			return 0, err
+		}
+		defer release()
+	}
+	for attempt := 1; ; attempt++ {
+		orderID, err := s.createOrder(userID, input, items, flashSales, shippingAddress, currency)
+		if err == nil || !errors.Is(err, errs.ErrTxConflict) || attempt == maxOrderAttempts {
+			return orderID, err
+		}
+		s.logger.Warn().Err(err).Int("attempt", attempt).Msg("order transaction conflict, retrying")
+		time.Sleep(time.Duration(attempt) * orderRetryDelay)
+	}
+}
+
+// orderItemKey - товар и вариант позиции; позиции с одинаковым ключом объединяются
+type orderItemKey struct {
+	productID int64
+	variantID int64
+}
+
+// mergeOrderItems объединяет позиции с одинаковым товаром и вариантом, сохраняя порядок первого появления.
+func mergeOrderItems(input []domain.CreateOrderItemInput) ([]domain.CreateOrderItemInput, error) {
+	items := make([]domain.CreateOrderItemInput, 0, len(input))
+	positions := make(map[orderItemKey]int, len(input))
+	for _, item := range input {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantity must be positive for product %d", errs.ErrInvalidFieldValue, item.ProductID)
+		}
+		key := orderItemKey{productID: item.ProductID}
+		if item.VariantID != nil {
+			key.variantID = *item.VariantID
+		}
+		if i, ok := positions[key]; ok {
+			items[i].Quantity += item.Quantity
+			continue
+		}
+		positions[key] = len(items)
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// createOrder выполняет транзакцию заказа. Товары на флеш-распродаже не блокируются:
+// их остаток списывается из слотов распродажи. Для товара с вариантами остаток списывается
+// и с варианта, и с суммарного остатка товара; варианты блокируются после товаров.
+func (s *Service) createOrder(userID int, input domain.CreateOrderInput, items []domain.CreateOrderItemInput,
+	flashSales map[int64]*domain.FlashSale, shippingAddress *domain.AddressSnapshot, currency string) (int64, error) {
 	tx, err := s.repository.BeginTx()
 	if err != nil {
 		s.logger.Error().Err(err).Msg("failed to begin transaction")
@@ -24,56 +107,284 @@ func (s *Service) CreateOrder(userID int, input domain.CreateOrderInput) (int64,
 			}
 		}
 	}()
-	var total float64
-	var currency string
-	orderItems := make([]domain.OrderItem, 0, len(input.Items))
-	productMap := make(map[int64]*domain.Product)
-	for _, item := range input.Items {
-		product, err := s.repository.GetProductByIDWithTx(tx, item.ProductID)
+	var lockIDs, flashIDs, variantIDs []int64
+	for _, item := range items {
+		if _, ok := flashSales[item.ProductID]; ok {
+			flashIDs = append(flashIDs, item.ProductID)
+		} else if !slices.Contains(lockIDs, item.ProductID) {
+			lockIDs = append(lockIDs, item.ProductID)
+		}
+		if item.VariantID != nil {
+			variantIDs = append(variantIDs, *item.VariantID)
+		}
+	}
+	productMap := make(map[int64]*domain.Product, len(items))
+	if len(lockIDs) > 0 {
+		products, err := s.repository.LockProductsWithTx(tx, lockIDs)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("failed to lock products with tx")
+			return 0, err
+		}
+		for _, product := range products {
+			productMap[product.ID] = product
+		}
+	}
+	if len(flashIDs) > 0 {
+		products, err := s.repository.GetProductsByIDsWithTx(tx, flashIDs)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("failed to get flash sale products with tx")
+			return 0, err
+		}
+		for _, product := range products {
+			productMap[product.ID] = product
+		}
+	}
+	variantMap := make(map[int64]*domain.ProductVariant, len(variantIDs))
+	hasVariants := make(map[int64]bool)
+	var productOptions map[int64][]domain.ProductOption
+	if len(lockIDs) > 0 {
+		ids, err := s.repository.ListProductIDsWithVariantsWithTx(tx, lockIDs)
 		if err != nil {
-			if errors.Is(err, errs.ErrNotfound) {
-				return 0, errs.ErrProductNotfound
-			}
-			s.logger.Error().Err(err).Int64("product_id", item.ProductID).Msg("failed to get product with tx")
 			return 0, err
 		}
-		if product.Quantity < item.Quantity {
-			return 0, fmt.Errorf("not enough stock for product: %s (available: %d, requested: %d)",
-				product.Name, product.Quantity, item.Quantity)
+		for _, id := range ids {
+			hasVariants[id] = true
+		}
+	}
+	if len(variantIDs) > 0 {
+		variants, err := s.repository.LockProductVariantsWithTx(tx, variantIDs)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("failed to lock product variants with tx")
+			return 0, err
+		}
+		productIDs := make([]int64, 0, len(variants))
+		for _, variant := range variants {
+			variantMap[variant.ID] = variant
+			productIDs = append(productIDs, variant.ProductID)
+		}
+		if productOptions, err = s.repository.ListProductOptions(productIDs); err != nil {
+			return 0, err
+		}
+	}
+	var total domain.Money
+	var converter *currencyConverter
+	orderItems := make([]domain.OrderItem, 0, len(items))
+	quantities := make(map[int64]int, len(items))
+	variantQuantities := make(map[int64]int, len(variantIDs))
+	for _, item := range items {
+		product, ok := productMap[item.ProductID]
+		if !ok {
+			return 0, errs.ErrProductNotfound
+		}
+		var variant *domain.ProductVariant
+		if item.VariantID != nil {
+			variant, ok = variantMap[*item.VariantID]
+			if !ok || variant.ProductID != product.ID || !variant.Active {
+				return 0, fmt.Errorf("%w: %d", errs.ErrVariantNotFound, *item.VariantID)
+			}
+		} else if hasVariants[product.ID] {
+			return 0, fmt.Errorf("%w: product %d", errs.ErrVariantRequired, product.ID)
+		}
+		sale, onSale := flashSales[product.ID]
+		if onSale {
+			if variant != nil {
+				return 0, fmt.Errorf("%w: flash sale products have no variants", errs.ErrInvalidFieldValue)
+			}
+			if err := s.reserveFlashSaleWithTx(tx, sale, userID, item.Quantity); err != nil {
+				return 0, err
+			}
+		} else {
+			// Остаток проверяется и у варианта, и у товара: списываются оба
+			if variant != nil && variant.Quantity < variantQuantities[variant.ID]+item.Quantity {
+				return 0, fmt.Errorf("%w for product: %s (available: %d, requested: %d)", errs.ErrInsufficientStock,
+					variantName(product.Name, productOptions[product.ID], variant.Options), variant.Quantity, variantQuantities[variant.ID]+item.Quantity)
+			}
+			if product.Quantity < quantities[product.ID]+item.Quantity {
+				return 0, fmt.Errorf("%w for product: %s (available: %d, requested: %d)", errs.ErrInsufficientStock,
+					product.Name, product.Quantity, quantities[product.ID]+item.Quantity)
+			}
 		}
 		if currency == "" {
-			currency = product.Currency
-		} else if currency != product.Currency {
-			return 0, errors.New("all items in order must have the same currency")
+			currency = product.Price.Currency
+		}
+		if converter == nil {
+			converter = s.newConverter(currency)
+		}
+		price, name, sku := product.Price, product.Name, product.SKU
+		if variant != nil {
+			price, name = variant.Price, variantName(product.Name, productOptions[product.ID], variant.Options)
+			if variant.SKU != nil {
+				sku = variant.SKU
+			}
+		}
+		unitPrice, err := converter.convert(price)
+		if err != nil {
+			return 0, err
+		}
+		totalPrice, err := unitPrice.Mul(item.Quantity)
+		if err != nil {
+			return 0, err
 		}
-		itemTotal := product.Price * float64(item.Quantity)
-		total += itemTotal
-		orderItems = append(orderItems, domain.OrderItem{
+		orderItem := domain.OrderItem{
 			ProductID:  product.ID,
-			Name:       product.Name,
-			UnitPrice:  product.Price,
+			VariantID:  item.VariantID,
+			Name:       name,
+			UnitPrice:  unitPrice,
 			Quantity:   item.Quantity,
-			TotalPrice: itemTotal,
+			TotalPrice: totalPrice,
+			TaxAmount:  domain.NewMoney(0, currency),
+		}
+		if sku != nil {
+			orderItem.SKU = *sku
+		}
+		if price.Currency != currency {
+			original := price
+			orderItem.OriginalUnitPrice = &original
+		}
+		if onSale {
+			orderItem.FlashSaleID = &sale.ID
+		} else {
+			quantities[product.ID] += item.Quantity
+			if variant != nil {
+				variantQuantities[variant.ID] += item.Quantity
+			}
+		}
+		if total, err = total.Add(orderItem.TotalPrice); err != nil {
+			return 0, err
+		}
+		orderItems = append(orderItems, orderItem)
+	}
+	taxRates, err := s.repository.ListTaxRates(shippingAddress.Country, true)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to list tax rates")
+		return 0, err
+	}
+	taxableLines := make([]taxableLine, len(orderItems))
+	for i, item := range orderItems {
+		taxableLines[i] = taxableLine{taxClass: productMap[item.ProductID].TaxClass, total: item.TotalPrice}
+	}
+	lineTaxes, taxLines, err := computeTaxes(taxRates, shippingAddress.Country, shippingAddress.Region, taxableLines)
+	if err != nil {
+		return 0, err
+	}
+	taxTotal := domain.NewMoney(0, currency)
+	exclusiveTax := domain.NewMoney(0, currency)
+	for i, tax := range lineTaxes {
+		if tax.rate == nil {
+			continue
+		}
+		orderItems[i].TaxAmount = tax.amount
+		orderItems[i].TaxRate = tax.rate.Rate
+		orderItems[i].TaxInclusive = tax.rate.Inclusive
+		if taxTotal, err = taxTotal.Add(tax.amount); err != nil {
+			return 0, err
+		}
+		if !tax.rate.Inclusive {
+			if exclusiveTax, err = exclusiveTax.Add(tax.amount); err != nil {
+				return 0, err
+			}
+		}
+	}
+	carts := make(shopCarts)
+	for _, item := range orderItems {
+		if err := carts.add(productMap[item.ProductID], item.Quantity, item.TotalPrice); err != nil {
+			return 0, err
+		}
+	}
+	shippingLines, err := s.selectShipping(shippingAddress.Country, carts, input.ShippingMethodIDs, converter)
+	if err != nil {
+		return 0, err
+	}
+	shippingTotal := domain.NewMoney(0, currency)
+	for _, line := range shippingLines {
+		if shippingTotal, err = shippingTotal.Add(line.Amount); err != nil {
+			return 0, err
+		}
+	}
+	cart := couponCart{currency: currency, shippingLines: shippingLines}
+	for _, item := range orderItems {
+		cart.items = append(cart.items, couponCartItem{
+			productID: item.ProductID,
+			shopID:    productMap[item.ProductID].ShopID,
+			total:     item.TotalPrice,
 		})
-		productMap[product.ID] = product
+	}
+	discounts, err := s.applyCouponsWithTx(tx, userID, input.CouponCodes, cart)
+	if err != nil {
+		return 0, err
+	}
+	discountTotal := domain.NewMoney(0, currency)
+	for _, discount := range discounts {
+		if discountTotal, err = discountTotal.Add(discount.Amount); err != nil {
+			return 0, err
+		}
+	}
+	orderTotal, err := domain.Sum(total, shippingTotal, exclusiveTax)
+	if err != nil {
+		return 0, err
+	}
+	if orderTotal, err = orderTotal.Sub(discountTotal); err != nil {
+		return 0, err
 	}
 	newOrder := &domain.Order{
-		UserID:   int64(userID),
-		Total:    total,
-		Currency: currency,
-		Status:   "pending",
-		Note:     input.Note,
+		UserID:          int64(userID),
+		Subtotal:        total,
+		ShippingTotal:   shippingTotal,
+		DiscountTotal:   discountTotal,
+		TaxTotal:        taxTotal,
+		Total:           orderTotal,
+		Currency:        currency,
+		Status:          domain.OrderStatusPending,
+		Note:            input.Note,
+		ShippingAddress: shippingAddress,
+		ShippingLines:   shippingLines,
+		Discounts:       discounts,
+		ExchangeRates:   converter.snapshot(),
+		Taxes:           taxLines,
 	}
 	orderID, err := s.repository.CreateOrderWithTx(tx, newOrder, orderItems)
 	if err != nil {
 		s.logger.Error().Err(err).Msg("failed to create order with tx")
 		return 0, err
 	}
+	if err := s.redeemCouponsWithTx(tx, userID, orderID, discounts); err != nil {
+		return 0, err
+	}
+	if len(quantities) > 0 {
+		if err := s.repository.DecreaseProductQuantitiesWithTx(tx, quantities); err != nil {
+			s.logger.Error().Err(err).Int64("order_id", orderID).Msg("failed to decrease product quantities with tx")
+			return 0, fmt.Errorf("failed to update stock: %w", err)
+		}
+	}
+	if len(variantQuantities) > 0 {
+		if err := s.repository.DecreaseVariantQuantitiesWithTx(tx, variantQuantities); err != nil {
+			s.logger.Error().Err(err).Int64("order_id", orderID).Msg("failed to decrease variant quantities with tx")
+			return 0, fmt.Errorf("failed to update stock: %w", err)
+		}
+	}
+	shopIDs := make([]int64, len(orderItems))
+	for i, item := range orderItems {
+		shopIDs[i] = productMap[item.ProductID].ShopID
+	}
+	err = s.recordEventWithTx(tx, domain.EventOrderCreated, domain.AggregateOrder, orderID, domain.OrderCreatedPayload{
+		OrderID:   orderID,
+		UserID:    int64(userID),
+		ShopIDs:   uniqueShopIDs(slices.Values(shopIDs)),
+		Total:     newOrder.Total,
+		Currency:  currency,
+		ItemCount: len(orderItems),
+	})
+	if err != nil {
+		return 0, err
+	}
+	recorded := make(map[int64]bool, len(quantities))
 	for _, item := range orderItems {
-		err := s.repository.DecreaseProductQuantityWithTx(tx, item.ProductID, item.Quantity)
-		if err != nil {
-			s.logger.Error().Err(err).Int64("product_id", item.ProductID).Msg("failed to decrease product quantity with tx")
-			return 0, fmt.Errorf("failed to update stock for product %d: %w", item.ProductID, err)
+		if quantity, ok := quantities[item.ProductID]; ok && !recorded[item.ProductID] {
+			recorded[item.ProductID] = true
+			product := productMap[item.ProductID]
+			if err := s.recordStockChangeWithTx(tx, product, product.Quantity, product.Quantity-quantity); err != nil {
+				return 0, err
+			}
 		}
 	}
 	if err := tx.Commit(); err != nil {
@@ -84,6 +395,61 @@ func (s *Service) CreateOrder(userID int, input domain.CreateOrderInput) (int64,
 	s.logger.Info().Int64("order_id", orderID).Msg("order created successfully")
 	return orderID, nil
 }
+
+// ConfirmOrder подтверждает оплату заказа: pending переходит в confirmed, после чего заказ можно
+// отгружать и он больше не отменяется по сроку. Переход пишется в историю статусов с номером
+// платежа и автором и публикуется событием order.status_changed в той же транзакции. Строка
+// заказа блокируется, поэтому подтверждение и отмена по сроку не проходят одновременно.
+func (s *Service) ConfirmOrder(orderID int64, userID int, input domain.ConfirmOrderInput) (*domain.Order, error) {
+	if orderID <= 0 {
+		return nil, errs.ErrInvalidID
+	}
+	reference := strings.TrimSpace(input.PaymentReference)
+	if reference == "" || len(reference) > maxPaymentReferenceLength {
+		return nil, fmt.Errorf("%w: payment_reference must be 1-%d characters long", errs.ErrInvalidFieldValue, maxPaymentReferenceLength)
+	}
+	var order *domain.Order
+	err := s.withTx(func(tx *sqlx.Tx) error {
+		var err error
+		order, _, err = s.repository.GetOrderByIDWithTx(tx, orderID)
+		if errors.Is(err, errs.ErrNotfound) {
+			return errs.ErrOrderNotFound
+		} else if err != nil {
+			return err
+		}
+		if order.Status != domain.OrderStatusPending {
+			return fmt.Errorf("%w: order is %s", errs.ErrOrderNotPending, order.Status)
+		}
+		if err := s.repository.UpdateOrderStatusWithTx(tx, orderID, domain.OrderStatusConfirmed); err != nil {
+			s.logger.Error().Err(err).Int64("order_id", orderID).Msg("failed to confirm order")
+			return err
+		}
+		changedBy := int64(userID)
+		change := &domain.OrderStatusChange{
+			OrderID:    orderID,
+			FromStatus: order.Status,
+			ToStatus:   domain.OrderStatusConfirmed,
+			Reason:     domain.OrderConfirmReasonPayment + ": " + reference,
+			ChangedBy:  &changedBy,
+		}
+		if err := s.repository.AddOrderStatusChangeWithTx(tx, change); err != nil {
+			s.logger.Error().Err(err).Int64("order_id", orderID).Msg("failed to record order status change")
+			return err
+		}
+		if err := s.recordOrderStatusChangedWithTx(tx, order, change); err != nil {
+			return err
+		}
+		order.Status = domain.OrderStatusConfirmed
+		order.Version++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info().Int64("order_id", orderID).Int("user_id", userID).Str("payment_reference", reference).Msg("order confirmed")
+	return order, nil
+}
+
 func (s *Service) GetOrderByID(orderID int64) (*domain.Order, []domain.OrderItem, error) {
 	return s.repository.GetOrderByID(orderID)
 }
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"marketplace/internal/configs"
+	"marketplace/internal/models/domain"
+	"time"
+)
+
+const defaultExpiryBatchSize = 100
+
+// ExpireAbandonedOrders отменяет заказы, ожидающие оплаты (pending), старше pending_ttl_minutes и
+// возвращает их остатки и погашения купонов. Заказ с подтвержденной оплатой (ConfirmOrder) уже не
+// pending и по сроку не отменяется; подтверждение и отмена блокируют строку заказа и не пересекаются.
+// Заказы обрабатываются пачками; каждая пачка - отдельная транзакция с блокировкой SKIP LOCKED,
+// поэтому несколько экземпляров приложения не отменяют один заказ дважды.
+func (s *Service) ExpireAbandonedOrders() (int, error) {
+	ttl := time.Duration(configs.AppSettings.OrderExpiryParams.PendingTtlMinutes) * time.Minute
+	if ttl <= 0 {
+		return 0, nil
+	}
+	batchSize := configs.AppSettings.OrderExpiryParams.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultExpiryBatchSize
+	}
+	var expired int
+	for {
+		n, err := s.expireOrderBatch(time.Now().Add(-ttl), batchSize)
+		expired += n
+		if err != nil || n < batchSize {
+			return expired, err
+		}
+	}
+}
+
+// flashSaleReturn - позиция флеш-распродажи отмененного заказа, которую нужно вернуть в распродажу.
+type flashSaleReturn struct {
+	orderID, saleID, userID int64
+	quantity                int
+}
+
+func (s *Service) expireOrderBatch(before time.Time, limit int) (int, error) {
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return 0, err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	orderIDs, err := s.repository.ListExpiredPendingOrderIDsWithTx(tx, before, limit)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to list expired pending orders")
+		return 0, err
+	}
+	if len(orderIDs) == 0 {
+		return 0, nil
+	}
+	quantities := make(map[int64]int)
+	variantQuantities := make(map[int64]int)
+	var flashReturns []flashSaleReturn
+	lockIDs := make(map[int64]bool)
+	for _, orderID := range orderIDs {
+		order, items, err := s.repository.GetOrderByIDWithTx(tx, orderID)
+		if err != nil {
+			s.logger.Error().Err(err).Int64("order_id", orderID).Msg("failed to get expired order")
+			return 0, err
+		}
+		if err := s.repository.CancelOrderWithTx(tx, orderID, domain.OrderCancelReasonExpired); err != nil {
+			s.logger.Error().Err(err).Int64("order_id", orderID).Msg("failed to cancel expired order")
+			return 0, err
+		}
+		change := &domain.OrderStatusChange{
+			OrderID:    orderID,
+			FromStatus: order.Status,
+			ToStatus:   domain.OrderStatusCancelled,
+			Reason:     domain.OrderCancelReasonExpired,
+		}
+		if err := s.repository.AddOrderStatusChangeWithTx(tx, change); err != nil {
+			s.logger.Error().Err(err).Int64("order_id", orderID).Msg("failed to record order status change")
+			return 0, err
+		}
+		if err := s.recordOrderStatusChangedWithTx(tx, order, change); err != nil {
+			return 0, err
+		}
+		for _, item := range items {
+			lockIDs[item.ProductID] = true
+			if item.FlashSaleID == nil {
+				quantities[item.ProductID] += item.Quantity
+				if item.VariantID != nil {
+					variantQuantities[*item.VariantID] += item.Quantity
+				}
+				continue
+			}
+			flashReturns = append(flashReturns, flashSaleReturn{orderID: orderID, saleID: *item.FlashSaleID, userID: order.UserID, quantity: item.Quantity})
+		}
+	}
+	// Товары блокируются в порядке id до любых изменений остатков, как при создании заказа, чтобы
+	// не взаимоблокироваться с ним; возврат в завершенную флеш-распродажу тоже меняет строку товара
+	var products []*domain.Product
+	if len(lockIDs) > 0 {
+		productIDs := make([]int64, 0, len(lockIDs))
+		for id := range lockIDs {
+			productIDs = append(productIDs, id)
+		}
+		if products, err = s.repository.LockProductsWithTx(tx, productIDs); err != nil {
+			return 0, err
+		}
+	}
+	for _, ret := range flashReturns {
+		if err := s.repository.ReturnFlashSaleStockWithTx(tx, ret.saleID, ret.userID, ret.quantity); err != nil {
+			s.logger.Error().Err(err).Int64("order_id", ret.orderID).Msg("failed to return flash sale stock")
+			return 0, err
+		}
+	}
+	if len(quantities) > 0 {
+		if err := s.repository.IncreaseProductQuantitiesWithTx(tx, quantities); err != nil {
+			s.logger.Error().Err(err).Msg("failed to return stock of expired orders")
+			return 0, err
+		}
+		if len(variantQuantities) > 0 {
+			variantIDs := make([]int64, 0, len(variantQuantities))
+			for id := range variantQuantities {
+				variantIDs = append(variantIDs, id)
+			}
+			if _, err := s.repository.LockProductVariantsWithTx(tx, variantIDs); err != nil {
+				return 0, err
+			}
+			if err := s.repository.IncreaseVariantQuantitiesWithTx(tx, variantQuantities); err != nil {
+				s.logger.Error().Err(err).Msg("failed to return variant stock of expired orders")
+				return 0, err
+			}
+		}
+		for _, product := range products {
+			returned, ok := quantities[product.ID]
+			if !ok {
+				continue
+			}
+			if err := s.recordStockChangeWithTx(tx, product, product.Quantity, product.Quantity+returned); err != nil {
+				return 0, err
+			}
+		}
+	}
+	// Купоны блокируются после товаров, как при создании заказа
+	released, err := s.repository.ReleaseCouponRedemptionsWithTx(tx, orderIDs)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to release coupon redemptions of expired orders")
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return 0, err
+	}
+	committed = true
+	if released > 0 {
+		s.logger.Info().Int("redemptions", released).Msg("coupon redemptions of expired orders released")
+	}
+	for _, orderID := range orderIDs {
+		s.logger.Info().Int64("order_id", orderID).Str("reason", domain.OrderCancelReasonExpired).Msg("order expired and cancelled")
+	}
+	return len(orderIDs), nil
+}
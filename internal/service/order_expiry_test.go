@@ -0,0 +1,129 @@
+package service
+
+import (
+	"fmt"
+	"marketplace/internal/configs"
+	"marketplace/internal/contracts"
+	"marketplace/internal/models/domain"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// По сроку отменяются только заказы, ожидающие оплаты: подтвержденный заказ того же возраста остается.
+func TestExpireAbandonedOrdersSkipsConfirmed(t *testing.T) {
+	s, conn := testDBService(t)
+	saved := configs.AppSettings.OrderExpiryParams
+	configs.AppSettings.OrderExpiryParams = configs.OrderExpiryParams{PendingTtlMinutes: 60}
+	t.Cleanup(func() { configs.AppSettings.OrderExpiryParams = saved })
+
+	adminID, productID := testShopProduct(t, conn, 5)
+	buyerID := testUser(t, conn)
+	input := domain.CreateOrderInput{Items: []domain.CreateOrderItemInput{{ProductID: productID, Quantity: 1}}}
+	pendingID, err := s.CreateOrder(buyerID, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	confirmedID, err := s.CreateOrder(buyerID, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ConfirmOrder(confirmedID, adminID, domain.ConfirmOrderInput{PaymentReference: "pi_test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(`UPDATE orders SET created_at = NOW() - INTERVAL '2 hours' WHERE id = ANY(ARRAY[$1, $2]::bigint[])`, pendingID, confirmedID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.ExpireAbandonedOrders(); err != nil {
+		t.Fatal(err)
+	}
+	for orderID, want := range map[int64]string{pendingID: domain.OrderStatusCancelled, confirmedID: domain.OrderStatusConfirmed} {
+		order, _, err := s.GetOrderByID(orderID)
+		if err != nil || order.Status != want {
+			t.Errorf("order %d: %+v, %v; want status %s", orderID, order, err, want)
+		}
+	}
+	var quantity int
+	if err := conn.Get(&quantity, `SELECT quantity FROM products WHERE id = $1`, productID); err != nil || quantity != 4 {
+		t.Errorf("product quantity %d, %v; want 4", quantity, err)
+	}
+}
+
+// expiryRepository отдает один просроченный заказ с обычной позицией и позицией флеш-распродажи
+// и запоминает порядок изменений в транзакции.
+type expiryRepository struct {
+	contracts.RepositoryI
+	db    *sqlx.DB
+	calls []string
+}
+
+func (r *expiryRepository) BeginTx() (*sqlx.Tx, error) { return r.db.Beginx() }
+
+func (r *expiryRepository) ListExpiredPendingOrderIDsWithTx(*sqlx.Tx, time.Time, int) ([]int64, error) {
+	return []int64{1}, nil
+}
+
+func (r *expiryRepository) GetOrderByIDWithTx(_ *sqlx.Tx, id int64) (*domain.Order, []domain.OrderItem, error) {
+	saleID := int64(7)
+	return &domain.Order{ID: id, UserID: 2, Status: domain.OrderStatusPending}, []domain.OrderItem{
+		{ProductID: 20, Quantity: 1, FlashSaleID: &saleID},
+		{ProductID: 10, Quantity: 2},
+	}, nil
+}
+
+func (r *expiryRepository) CancelOrderWithTx(*sqlx.Tx, int64, string) error { return nil }
+
+func (r *expiryRepository) AddOrderStatusChangeWithTx(*sqlx.Tx, *domain.OrderStatusChange) error {
+	return nil
+}
+
+func (r *expiryRepository) GetOrderItemShopIDsWithTx(*sqlx.Tx, int64) (map[int64]int64, error) {
+	return nil, nil
+}
+
+func (r *expiryRepository) AddOutboxEventWithTx(*sqlx.Tx, *domain.Event) error { return nil }
+
+func (r *expiryRepository) LockProductsWithTx(_ *sqlx.Tx, ids []int64) ([]*domain.Product, error) {
+	slices.Sort(ids)
+	r.calls = append(r.calls, fmt.Sprintf("lock products %v", ids))
+	products := make([]*domain.Product, 0, len(ids))
+	for _, id := range ids {
+		products = append(products, &domain.Product{ID: id, Quantity: 5})
+	}
+	return products, nil
+}
+
+func (r *expiryRepository) ReturnFlashSaleStockWithTx(_ *sqlx.Tx, saleID, userID int64, quantity int) error {
+	r.calls = append(r.calls, fmt.Sprintf("return flash sale %d", saleID))
+	return nil
+}
+
+func (r *expiryRepository) IncreaseProductQuantitiesWithTx(_ *sqlx.Tx, quantities map[int64]int) error {
+	r.calls = append(r.calls, fmt.Sprintf("increase %v", quantities))
+	return nil
+}
+
+func (r *expiryRepository) ReleaseCouponRedemptionsWithTx(*sqlx.Tx, []int64) (int, error) {
+	r.calls = append(r.calls, "release coupons")
+	return 0, nil
+}
+
+// Строки товаров блокируются до возврата в флеш-распродажу, которая тоже может изменить товар
+func TestExpireOrderBatchLockOrder(t *testing.T) {
+	db, err := sqlx.Open("noop", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repository := &expiryRepository{db: db}
+	if n, err := NewService(repository).expireOrderBatch(time.Now(), 10); err != nil || n != 1 {
+		t.Fatalf("expired %d, %v", n, err)
+	}
+	want := []string{"lock products [10 20]", "return flash sale 7", "increase map[10:2]", "release coupons"}
+	if !slices.Equal(repository.calls, want) {
+		t.Errorf("calls %q, want %q", repository.calls, want)
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"sync"
+	"testing"
+)
+
+// Параллельные заказы не продают больше остатка: товар и вариант блокируются в транзакции
+// заказа, и проигравшие получают ErrInsufficientStock, а не отрицательный остаток.
+func TestCreateOrderConcurrentOversell(t *testing.T) {
+	const buyers, stock = 10, 3
+	s, conn := testDBService(t)
+	_, productID := testShopProduct(t, conn, stock)
+	var variantID int64
+	if err := conn.QueryRow(`INSERT INTO product_variants (product_id, sku, options, price, quantity) VALUES ($1, NULL, '{"size": "M"}', 10, $2) RETURNING id`,
+		productID, stock).Scan(&variantID); err != nil {
+		t.Fatalf("create variant: %v", err)
+	}
+	userIDs := make([]int, buyers)
+	for i := range userIDs {
+		userIDs[i] = testUser(t, conn)
+	}
+
+	results := make([]error, buyers)
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i, userID := range userIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, results[i] = s.CreateOrder(userID, domain.CreateOrderInput{
+				Items: []domain.CreateOrderItemInput{{ProductID: productID, VariantID: &variantID, Quantity: 1}},
+			})
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	var succeeded int
+	for i, err := range results {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, errs.ErrInsufficientStock):
+			t.Errorf("buyer %d: error = %v, want ErrInsufficientStock", i, err)
+		}
+	}
+	if succeeded != stock {
+		t.Errorf("%d orders succeeded, want %d", succeeded, stock)
+	}
+	var productQuantity, variantQuantity int
+	if err := conn.QueryRow(`SELECT p.quantity, v.quantity FROM products p JOIN product_variants v ON v.product_id = p.id WHERE v.id = $1`,
+		variantID).Scan(&productQuantity, &variantQuantity); err != nil {
+		t.Fatal(err)
+	}
+	if productQuantity != 0 || variantQuantity != 0 {
+		t.Errorf("remaining stock: product %d, variant %d; want 0 and 0", productQuantity, variantQuantity)
+	}
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"marketplace/internal/configs"
+	"marketplace/internal/models/domain"
+)
+
+const (
+	defaultPageSize    = 20
+	defaultMaxPageSize = 100
+)
+
+// normalizePage подставляет размер страницы по умолчанию и ограничивает его сверху
+// pagination_params.max_page_size: один запрос не может выгрузить произвольно большую страницу.
+func normalizePage(page *domain.PageRequest) {
+	if page.Limit <= 0 {
+		page.Limit = defaultPageSize
+		if n := configs.AppSettings.PaginationParams.DefaultPageSize; n > 0 {
+			page.Limit = n
+		}
+	}
+	page.Limit = min(page.Limit, maxPageSize())
+}
+
+func maxPageSize() int {
+	if n := configs.AppSettings.PaginationParams.MaxPageSize; n > 0 {
+		return n
+	}
+	return defaultMaxPageSize
+}
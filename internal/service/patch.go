@@ -0,0 +1,42 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"marketplace/internal/errs"
+	"marketplace/utils"
+	"slices"
+	"sort"
+)
+
+// applyMergePatch применяет JSON Merge Patch к JSON-представлению current и разбирает
+// результат в target. Патч может менять только ключи из mutable; возвращает ключи патча.
+func applyMergePatch(current, target any, patch []byte, mutable []string) ([]string, error) {
+	keys, err := utils.MergePatchKeys(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errs.ErrInvalidRequestBody, err.Error())
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !slices.Contains(mutable, key) {
+			return nil, fmt.Errorf("%w: %s", errs.ErrImmutableField, key)
+		}
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := utils.MergePatch(doc, patch)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(merged, target); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("%w: %s", errs.ErrInvalidFieldValue, typeErr.Field)
+		}
+		return nil, fmt.Errorf("%w: %s", errs.ErrInvalidFieldValue, err.Error())
+	}
+	return keys, nil
+}
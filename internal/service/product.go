@@ -1,31 +1,55 @@
 package service
 
 import (
-	"errors"
+	"fmt"
 	"marketplace/internal/errs"
 	"marketplace/internal/models/domain"
 	"marketplace/utils"
+	"slices"
 	"time"
+
+	"github.com/jmoiron/sqlx"
 )
 
 func (s *Service) CreateProduct(product *domain.Product) error {
 	s.logger.Info().Str("func", "CreateProduct").Msg("creating product")
-	if product.Price <= 0 {
+	if err := prepareNewProduct(product); err != nil {
+		return err
+	}
+	if err := s.repository.CreateProduct(product); err != nil {
+		s.logger.Error().Err(err).Msg("failed to create product")
+		return err
+	}
+	s.logger.Info().Int64("id", product.ID).Msg("product created successfully")
+	return nil
+}
+
+// prepareNewProduct проверяет новый товар и заполняет вычисляемые поля. Те же правила
+// применяются к строкам импорта.
+func prepareNewProduct(product *domain.Product) error {
+	if product.Price.Amount <= 0 {
 		return errs.ErrInvalidFieldValue
 	}
+	currency, err := priceCurrency(product.Price, product.Currency)
+	if err != nil {
+		return err
+	}
+	product.Currency = currency
+	if product.TaxClass, err = normalizeTaxClass(product.TaxClass); err != nil {
+		return err
+	}
 	if product.ShopID == 0 {
 		return errs.ErrInvalidFieldValue
 	}
+	if product.WeightGrams < 0 || product.LengthMM < 0 || product.WidthMM < 0 || product.HeightMM < 0 {
+		return errs.ErrInvalidFieldValue
+	}
 	product.Slug = utils.GenerateSlug(product.Name)
 	product.CreatedAt = time.Now()
 	product.UpdatedAt = time.Now()
-	if err := s.repository.CreateProduct(product); err != nil {
-		s.logger.Error().Err(err).Msg("failed to create product")
-		return err
-	}
-	s.logger.Info().Int64("id", product.ID).Msg("product created successfully")
 	return nil
 }
+
 func (s *Service) GetProductByID(id int64) (*domain.Product, error) {
 	s.logger.Info().Int64("id", id).Msg("fetching product by id")
 	if id <= 0 {
@@ -42,76 +66,255 @@ func (s *Service) GetProductByID(id int64) (*domain.Product, error) {
 	return product, nil
 }
 
-func (s *Service) UpdateProduct(product *domain.Product, userID int, userRole string) error {
-	s.logger.Info().Int64("id", product.ID).Msg("updating product")
+// UpdateProduct заменяет товар целиком (PUT): пропущенные поля обнуляются, поэтому товар
+// проверяется по правилам создания. shop_id и slug не меняются клиентом, slug пересчитывается
+// из названия при переименовании. expectedVersion - версия из If-Match, 0 - заголовка нет.
+func (s *Service) UpdateProduct(product *domain.Product, expectedVersion int64, userID int, userRole string) error {
 	if product == nil || product.ID <= 0 {
 		return errs.ErrInvalidProductID
 	}
+	s.logger.Info().Int64("id", product.ID).Msg("updating product")
+	replacement := *product
+	return s.retryOnVersionMismatch(expectedVersion, func() error {
+		existingProduct, err := s.productForUpdate(product.ID, expectedVersion, userID, userRole)
+		if err != nil {
+			return err
+		}
+		if replacement.ShopID != 0 && replacement.ShopID != existingProduct.ShopID {
+			return fmt.Errorf("%w: shop_id", errs.ErrImmutableField)
+		}
+		if replacement.Slug != "" && replacement.Slug != existingProduct.Slug {
+			return fmt.Errorf("%w: slug is derived from name", errs.ErrImmutableField)
+		}
+		*product = replacement
+		return s.applyProductUpdate(existingProduct, product)
+	})
+}
+
+// PatchProduct применяет к товару JSON Merge Patch (RFC 7386): меняются только переданные
+// поля, null очищает необязательные (sku, description, attributes), а attributes сливаются
+// по ключам. Результат проверяется так же, как при UpdateProduct. Если товар изменился между
+// чтением и записью, без If-Match патч применяется заново к свежей версии.
+func (s *Service) PatchProduct(id int64, patch []byte, expectedVersion int64, userID int, userRole string) (*domain.Product, error) {
+	if id <= 0 {
+		return nil, errs.ErrInvalidProductID
+	}
+	s.logger.Info().Int64("id", id).Msg("patching product")
+	var product *domain.Product
+	err := s.retryOnVersionMismatch(expectedVersion, func() error {
+		existingProduct, err := s.productForUpdate(id, expectedVersion, userID, userRole)
+		if err != nil {
+			return err
+		}
+		product = &domain.Product{}
+		keys, err := applyMergePatch(existingProduct, product, patch, domain.ProductPatchFields)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(keys, "attributes") {
+			// nil не перезаписывает характеристики и не запускает их проверку по схеме категорий
+			product.Attributes = nil
+		} else if product.Attributes == nil {
+			product.Attributes = map[string]string{}
+		}
+		if slices.Contains(keys, "price") && !slices.Contains(keys, "currency") {
+			product.Currency = ""
+		}
+		return s.applyProductUpdate(existingProduct, product)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
 
-	existingProduct, err := s.repository.GetProductByID(product.ID)
+// productForUpdate загружает товар и проверяет, что пользователь - владелец его магазина или
+// админ, а версия товара совпадает с expectedVersion.
+func (s *Service) productForUpdate(id, expectedVersion int64, userID int, userRole string) (*domain.Product, error) {
+	existingProduct, err := s.repository.GetProductByID(id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	shop, err := s.repository.GetShopByID(existingProduct.ShopID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if userRole != domain.AdminRole && shop.OwnerID != int64(userID) {
-		return errors.New("permission denied: you are not the owner of this product's shop")
+		return nil, fmt.Errorf("%w: you are not the owner of this product's shop", errs.ErrForbidden)
+	}
+	if err := checkVersion(expectedVersion, existingProduct.Version); err != nil {
+		return nil, err
 	}
-	if product.Name != "" && len(product.Name) < 4 {
+	return existingProduct, nil
+}
+
+// applyProductUpdate проверяет новое состояние товара и сохраняет его. Неизменяемые поля
+// берутся из existingProduct; запись проходит, только если товар (в том числе его остаток)
+// не менялся после чтения existingProduct, иначе - ErrVersionMismatch.
+func (s *Service) applyProductUpdate(existingProduct, product *domain.Product) error {
+	product.ID = existingProduct.ID
+	product.Version = existingProduct.Version
+	product.ShopID = existingProduct.ShopID
+	product.CreatedAt = existingProduct.CreatedAt
+	if len(product.Name) < 4 {
 		return errs.ErrInvalidProductName
 	}
-	if product.Price < 0 {
+	if product.Price.Amount <= 0 {
 		return errs.ErrInvalidFieldValue
 	}
+	currency, err := priceCurrency(product.Price, product.Currency)
+	if err != nil {
+		return err
+	}
+	product.Currency = currency
+	if product.WeightGrams < 0 || product.LengthMM < 0 || product.WidthMM < 0 || product.HeightMM < 0 || product.Quantity < 0 {
+		return errs.ErrInvalidFieldValue
+	}
+	if product.TaxClass, err = normalizeTaxClass(product.TaxClass); err != nil {
+		return err
+	}
+	if product.Slug = productSlug(existingProduct, product.Name); product.Slug == "" {
+		return errs.ErrInvalidProductName
+	}
+	if product.Attributes != nil {
+		categoryIDs, err := s.productCategoryIDs(product.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.validateProductAttributes(categoryIDs, product.Attributes); err != nil {
+			return err
+		}
+	}
 	product.UpdatedAt = time.Now()
-	if err := s.repository.UpdateProduct(product); err != nil {
-		s.logger.Error().Err(err).Msg("failed to update product")
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	if err := s.saveProductWithTx(tx, existingProduct, product); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
 		return err
 	}
+	committed = true
+	if product.Attributes == nil {
+		product.Attributes = existingProduct.Attributes
+	}
 	s.logger.Info().Int64("id", product.ID).Msg("product updated successfully")
 	return nil
 }
-func (s *Service) DeleteProduct(id int64, userID int, userRole string) error {
-	s.logger.Info().Int64("id", id).Msg("deleting product")
-	if id <= 0 {
-		return errs.ErrInvalidProductID
+
+// productSlug возвращает slug товара после переименования: прежний, если название не менялось.
+func productSlug(existingProduct *domain.Product, name string) string {
+	if existingProduct != nil && existingProduct.Name == name {
+		return existingProduct.Slug
 	}
-	existingProduct, err := s.repository.GetProductByID(id)
+	return utils.GenerateSlug(name)
+}
+
+// saveProductWithTx сохраняет измененный товар и записывает события изменения товара и остатка.
+// Остаток товара с вариантами - сумма остатков вариантов, напрямую он не меняется.
+func (s *Service) saveProductWithTx(tx *sqlx.Tx, existingProduct, product *domain.Product) error {
+	withVariants, err := s.repository.ListProductIDsWithVariantsWithTx(tx, []int64{product.ID})
 	if err != nil {
 		return err
 	}
-	shop, err := s.repository.GetShopByID(existingProduct.ShopID)
+	if len(withVariants) > 0 {
+		locked, err := s.repository.GetProductByIDWithTx(tx, product.ID)
+		if err != nil {
+			return err
+		}
+		existingProduct.Quantity = locked.Quantity
+		product.Quantity = locked.Quantity
+	}
+	if err := s.repository.UpdateProductWithTx(tx, product); err != nil {
+		s.logger.Error().Err(err).Msg("failed to update product")
+		return err
+	}
+	err = s.recordEventWithTx(tx, domain.EventProductUpdated, domain.AggregateProduct, product.ID, domain.ProductUpdatedPayload{
+		ProductID: product.ID,
+		ShopID:    product.ShopID,
+		Name:      product.Name,
+		Price:     product.Price,
+		Quantity:  product.Quantity,
+		Active:    product.Active,
+	})
 	if err != nil {
 		return err
 	}
-	if userRole != domain.AdminRole && shop.OwnerID != int64(userID) {
-		return errors.New("permission denied: you are not the owner of this product's shop")
+	return s.recordStockChangeWithTx(tx, product, existingProduct.Quantity, product.Quantity)
+}
+
+// DeleteProduct удаляет товар (soft delete); expectedVersion - версия из If-Match, 0 - заголовка нет.
+func (s *Service) DeleteProduct(id, expectedVersion int64, userID int, userRole string) error {
+	s.logger.Info().Int64("id", id).Msg("deleting product")
+	if id <= 0 {
+		return errs.ErrInvalidProductID
 	}
-	if err := s.repository.DeleteProduct(id); err != nil {
-		s.logger.Error().Err(err).Msg("failed to delete product")
+	err := s.retryOnVersionMismatch(expectedVersion, func() error {
+		existingProduct, err := s.productForUpdate(id, expectedVersion, userID, userRole)
+		if err != nil {
+			return err
+		}
+		return s.withTx(func(tx *sqlx.Tx) error {
+			if err := s.repository.DeleteProductWithTx(tx, id, existingProduct.Version); err != nil {
+				s.logger.Error().Err(err).Msg("failed to delete product")
+				return err
+			}
+			// Изображения удаленного товара больше не показываются: их файлы уходят в очередь удаления.
+			queued, err := s.repository.DeleteProductImagesWithTx(tx, id)
+			if err != nil {
+				return err
+			}
+			if queued > 0 {
+				return s.enqueueBlobPurgeWithTx(tx)
+			}
+			return nil
+		})
+	})
+	if err != nil {
 		return err
 	}
 	s.logger.Info().Int64("id", id).Msg("product soft deleted successfully")
 	return nil
 }
-func (s *Service) ListProducts(shopID int64, limit, offset int) ([]*domain.Product, error) {
-	s.logger.Info().Int64("shop_id", shopID).Int("limit", limit).Int("offset", offset).Msg("listing products")
-	if shopID <= 0 {
-		return nil, errs.ErrInvalidFieldValue
+
+// ListProducts возвращает страницу каталога по фильтрам и сортировке с фасетами. Фасет цен
+// считается по интервалам в валюте фильтра currency, без нее - в базовой валюте.
+func (s *Service) ListProducts(filter domain.ProductFilter) (*domain.ProductListResult, error) {
+	if err := normalizeProductFilter(&filter); err != nil {
+		return nil, err
 	}
-	if limit <= 0 {
-		limit = 20
+	if err := s.ensureCategoryExists(filter.CategoryID); err != nil {
+		return nil, err
 	}
-	if offset < 0 {
-		offset = 0
+	if len(filter.PriceBuckets) == 0 {
+		currency := filter.Currency
+		if currency == "" {
+			currency = baseCurrency()
+		}
+		for _, bound := range defaultPriceBuckets {
+			price, err := domain.ParseMoney(bound, currency)
+			if err != nil {
+				return nil, err
+			}
+			filter.PriceBuckets = append(filter.PriceBuckets, price)
+		}
 	}
-	products, err := s.repository.ListProducts(shopID, limit, offset)
+	result, err := s.repository.ListProducts(filter)
 	if err != nil {
 		s.logger.Error().Err(err).Msg("failed to list products")
 		return nil, err
 	}
-	s.logger.Info().Int("count", len(products)).Msg("products listed successfully")
-	return products, nil
+	return result, nil
 }
@@ -0,0 +1,605 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"marketplace/internal/configs"
+	"marketplace/internal/contracts"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"marketplace/utils"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	defaultImportMaxFileMB     = 50
+	defaultImportMaxRows       = 100000
+	defaultImportRetentionDays = 7
+	importProgressInterval     = 100
+	exportBatchSize            = 500
+	maxSKULength               = 100
+)
+
+// CreateProductImport сохраняет файл импорта и ставит задачу его обработки (владелец магазина
+// или админ). Формат берется из input.Format или из расширения файла (.csv, .jsonl, .ndjson);
+// заголовок CSV проверяется сразу, строки - в фоне.
+func (s *Service) CreateProductImport(shopID int64, input domain.CreateProductImportInput, userID int, userRole string) (*domain.ProductImport, error) {
+	if s.blobStore == nil {
+		return nil, errs.ErrBlobStoreNotConfigured
+	}
+	if _, err := s.ensureShopOwner(shopID, userID, userRole); err != nil {
+		return nil, err
+	}
+	format, err := importFormat(input.Format, input.Filename)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(input.Data)) > importMaxFileBytes() {
+		return nil, fmt.Errorf("%w: at most %d MB", errs.ErrImportFileTooLarge, importMaxFileBytes()>>20)
+	}
+	if len(bytes.TrimSpace(input.Data)) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", errs.ErrInvalidImportFile)
+	}
+	if _, err := newImportReader(format, bytes.NewReader(input.Data)); err != nil {
+		return nil, err
+	}
+	if lines := bytes.Count(input.Data, []byte{'\n'}); lines > importMaxRows()+1 {
+		return nil, fmt.Errorf("%w: at most %d rows", errs.ErrInvalidImportFile, importMaxRows())
+	}
+	productImport := &domain.ProductImport{
+		ShopID:   shopID,
+		UserID:   int64(userID),
+		Format:   format,
+		DryRun:   input.DryRun,
+		InputKey: fmt.Sprintf("imports/%d/%s.%s", shopID, utils.NewUUID(), format),
+	}
+	ctx := context.Background()
+	if err := s.blobStore.Put(ctx, productImport.InputKey, input.Data, "text/plain; charset=utf-8"); err != nil {
+		s.logger.Error().Err(err).Int64("shop_id", shopID).Msg("failed to store import file")
+		return nil, err
+	}
+	inputKey := productImport.InputKey
+	err = s.withTx(func(tx *sqlx.Tx) error {
+		if err := s.repository.CreateProductImportWithTx(tx, productImport); err != nil {
+			return err
+		}
+		job, err := newJob(domain.JobTypeImportProducts, domain.ProductImportJob{ImportID: productImport.ID}, time.Time{})
+		if err != nil {
+			return err
+		}
+		return s.repository.CreateJobWithTx(tx, job)
+	})
+	if err != nil {
+		s.discardBlobs(ctx, inputKey)
+		return nil, err
+	}
+	s.logger.Info().Int64("import_id", productImport.ID).Int64("shop_id", shopID).Bool("dry_run", input.DryRun).Msg("product import created")
+	return productImport, nil
+}
+
+// GetProductImport возвращает импорт с прогрессом (владелец магазина или админ).
+func (s *Service) GetProductImport(id int64, userID int, userRole string) (*domain.ProductImport, error) {
+	if id <= 0 {
+		return nil, errs.ErrImportNotFound
+	}
+	productImport, err := s.repository.GetProductImportByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.ensureShopOwner(productImport.ShopID, userID, userRole); err != nil {
+		return nil, err
+	}
+	setImportErrorsURL(productImport)
+	return productImport, nil
+}
+
+// OpenProductImportErrors открывает CSV-отчет об ошибках строк импорта.
+func (s *Service) OpenProductImportErrors(id int64, userID int, userRole string) (*contracts.Blob, error) {
+	productImport, err := s.GetProductImport(id, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	if productImport.ErrorsKey == "" {
+		return nil, fmt.Errorf("%w: import has no error report", errs.ErrImportNotFound)
+	}
+	if s.blobStore == nil {
+		return nil, errs.ErrBlobStoreNotConfigured
+	}
+	return s.blobStore.Get(context.Background(), productImport.ErrorsKey)
+}
+
+// RunProductImport обрабатывает файл импорта: каждая строка проверяется по правилам
+// CreateProduct и создает товар или обновляет товар магазина с тем же SKU в отдельной
+// транзакции. Ошибки строк попадают в CSV-отчет. С dry_run строки только проверяются.
+func (s *Service) RunProductImport(ctx context.Context, importID int64) error {
+	productImport, err := s.repository.GetProductImportByID(importID)
+	if errors.Is(err, errs.ErrImportNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if s.blobStore == nil {
+		return errs.ErrBlobStoreNotConfigured
+	}
+	started, err := s.repository.StartProductImport(importID)
+	if err != nil || !started {
+		return err
+	}
+	input, err := s.blobStore.Get(ctx, productImport.InputKey)
+	if errors.Is(err, errs.ErrBlobNotFound) {
+		return s.failProductImport(productImport, "import file is missing")
+	} else if err != nil {
+		return err
+	}
+	defer input.Body.Close()
+	reader, err := newImportReader(productImport.Format, input.Body)
+	if err != nil {
+		return s.failProductImport(productImport, err.Error())
+	}
+	var report bytes.Buffer
+	reportWriter := csv.NewWriter(&report)
+	_ = reportWriter.Write([]string{"line", "sku", "error"})
+	productImport.TotalRows, productImport.Created, productImport.Updated, productImport.Failed = 0, 0, 0, 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		row, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+		productImport.TotalRows++
+		rowErr := row.err
+		var created bool
+		if rowErr == nil {
+			created, rowErr = s.importProductRow(productImport, row.fields)
+		}
+		switch {
+		case rowErr != nil:
+			productImport.Failed++
+			_ = reportWriter.Write([]string{strconv.Itoa(row.line), strings.TrimSpace(row.fields["sku"]), rowErr.Error()})
+		case created:
+			productImport.Created++
+		default:
+			productImport.Updated++
+		}
+		if productImport.TotalRows%importProgressInterval == 0 {
+			if err := s.repository.UpdateProductImportProgress(productImport); err != nil {
+				s.logger.Warn().Err(err).Int64("import_id", importID).Msg("failed to save import progress")
+			}
+		}
+	}
+	if productImport.Failed > 0 {
+		reportWriter.Flush()
+		productImport.ErrorsKey = strings.TrimSuffix(productImport.InputKey, path.Ext(productImport.InputKey)) + "_errors.csv"
+		if err := s.blobStore.Put(ctx, productImport.ErrorsKey, report.Bytes(), "text/csv; charset=utf-8"); err != nil {
+			return err
+		}
+	}
+	productImport.Status = domain.ImportStatusCompleted
+	if err := s.repository.FinishProductImport(productImport); err != nil {
+		return err
+	}
+	s.logger.Info().Int64("import_id", importID).Int("rows", productImport.TotalRows).Int("created", productImport.Created).
+		Int("updated", productImport.Updated).Int("failed", productImport.Failed).Msg("product import finished")
+	return nil
+}
+
+// importProductRow применяет строку импорта; created - товар с таким SKU создан (или был бы
+// создан в dry_run).
+func (s *Service) importProductRow(productImport *domain.ProductImport, fields map[string]string) (bool, error) {
+	sku := strings.TrimSpace(fields["sku"])
+	if sku == "" || len(sku) > maxSKULength {
+		return false, fmt.Errorf("%w: sku must be 1-%d characters long", errs.ErrInvalidFieldValue, maxSKULength)
+	}
+	if productImport.DryRun {
+		existing, err := s.repository.FindShopProductBySKU(productImport.ShopID, sku)
+		if err != nil && !errors.Is(err, errs.ErrNotfound) {
+			return false, err
+		}
+		_, err = s.buildImportedProduct(productImport.ShopID, sku, existing, fields)
+		return existing == nil, err
+	}
+	var created bool
+	err := s.withTx(func(tx *sqlx.Tx) error {
+		existing, err := s.repository.LockShopProductBySKUWithTx(tx, productImport.ShopID, sku)
+		if err != nil && !errors.Is(err, errs.ErrNotfound) {
+			return err
+		}
+		product, err := s.buildImportedProduct(productImport.ShopID, sku, existing, fields)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			created = true
+			return s.repository.CreateProductWithTx(tx, product)
+		}
+		return s.saveProductWithTx(tx, existing, product)
+	})
+	return created, err
+}
+
+// buildImportedProduct собирает товар из строки импорта: новый - из колонок строки,
+// существующий - меняя только переданные колонки. Проверки - как у CreateProduct;
+// slug существующего товара меняется только при переименовании. Характеристики проверяются
+// по схеме категорий товара и для новых строк: у нового товара категорий еще нет, поэтому
+// схема применится при их назначении (SetProductCategories).
+func (s *Service) buildImportedProduct(shopID int64, sku string, existing *domain.Product, fields map[string]string) (*domain.Product, error) {
+	product := &domain.Product{ShopID: shopID}
+	if existing != nil {
+		copied := *existing
+		product = &copied
+	}
+	product.SKU = &sku
+	if err := applyImportFields(product, fields); err != nil {
+		return nil, err
+	}
+	if err := prepareNewProduct(product); err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		product.Slug, product.CreatedAt = productSlug(existing, product.Name), existing.CreatedAt
+	}
+	if _, ok := fields["attributes"]; ok {
+		var categoryIDs []int64
+		if existing != nil {
+			var err error
+			if categoryIDs, err = s.productCategoryIDs(product.ID); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.validateProductAttributes(categoryIDs, product.Attributes); err != nil {
+			return nil, err
+		}
+	}
+	return product, nil
+}
+
+// applyImportFields переносит в товар переданные колонки строки импорта.
+func applyImportFields(product *domain.Product, fields map[string]string) error {
+	intField := func(column string, target *int) error {
+		value, ok := fields[column]
+		if !ok {
+			return nil
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return fmt.Errorf("%w: %s must be a non-negative integer", errs.ErrInvalidFieldValue, column)
+		}
+		*target = n
+		return nil
+	}
+	if value, ok := fields["name"]; ok {
+		product.Name = strings.TrimSpace(value)
+	}
+	if value, ok := fields["description"]; ok {
+		product.Description = nil
+		if value = strings.TrimSpace(value); value != "" {
+			product.Description = &value
+		}
+	}
+	currency := product.Currency
+	if value, ok := fields["currency"]; ok {
+		currency = strings.ToUpper(strings.TrimSpace(value))
+	}
+	if value, ok := fields["price"]; ok {
+		if currency == "" {
+			return fmt.Errorf("%w: currency is required", errs.ErrInvalidFieldValue)
+		}
+		if !domain.IsValidCurrency(currency) {
+			return fmt.Errorf("%w: %q", errs.ErrInvalidCurrency, currency)
+		}
+		price, err := domain.ParseMoney(value, currency)
+		if err != nil {
+			return fmt.Errorf("%w: price: %s", errs.ErrInvalidFieldValue, err.Error())
+		}
+		product.Price = price
+	} else if currency != product.Currency {
+		return fmt.Errorf("%w: changing currency requires price", errs.ErrInvalidFieldValue)
+	}
+	product.Currency = currency
+	if err := intField("quantity", &product.Quantity); err != nil {
+		return err
+	}
+	if value, ok := fields["tax_class"]; ok {
+		product.TaxClass = value
+	}
+	if value, ok := fields["active"]; ok {
+		active, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%w: active must be true or false", errs.ErrInvalidFieldValue)
+		}
+		product.Active = active
+	}
+	for column, target := range map[string]*int{
+		"weight_grams": &product.WeightGrams,
+		"length_mm":    &product.LengthMM,
+		"width_mm":     &product.WidthMM,
+		"height_mm":    &product.HeightMM,
+	} {
+		if err := intField(column, target); err != nil {
+			return err
+		}
+	}
+	if value, ok := fields["attributes"]; ok {
+		product.Attributes = map[string]string{}
+		if value = strings.TrimSpace(value); value != "" {
+			if err := json.Unmarshal([]byte(value), &product.Attributes); err != nil {
+				return fmt.Errorf("%w: attributes must be a JSON object with string values", errs.ErrInvalidFieldValue)
+			}
+		}
+	}
+	return nil
+}
+
+// PurgeProductImports удаляет импорты, завершенные раньше срока хранения, вместе с файлами.
+func (s *Service) PurgeProductImports() (int64, error) {
+	deleted, err := s.repository.PurgeProductImports(time.Now().AddDate(0, 0, -importRetentionDays()))
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to purge product imports")
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// ExportShopProducts пишет в w все неудаленные товары магазина (включая неактивные) в формате
+// импорта, пачками по exportBatchSize. Файл экспорта можно загрузить обратно как импорт.
+func (s *Service) ExportShopProducts(shopID int64, format string, userID int, userRole string, w io.Writer) error {
+	if _, err := s.ensureShopOwner(shopID, userID, userRole); err != nil {
+		return err
+	}
+	if !slices.Contains(domain.ImportFormats, format) {
+		return fmt.Errorf("%w: format must be one of %s", errs.ErrInvalidFieldValue, strings.Join(domain.ImportFormats, ", "))
+	}
+	csvWriter := csv.NewWriter(w)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	if format == domain.ImportFormatCSV {
+		if err := csvWriter.Write(domain.ProductImportColumns); err != nil {
+			return err
+		}
+	}
+	var afterID int64
+	for {
+		products, err := s.repository.ListShopProductsAfter(shopID, afterID, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, product := range products {
+			row := productExportRow(product)
+			if format == domain.ImportFormatJSONL {
+				err = encoder.Encode(row)
+			} else {
+				err = csvWriter.Write(productExportRecord(row))
+			}
+			if err != nil {
+				return err
+			}
+			afterID = product.ID
+		}
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return err
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if len(products) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+func productExportRow(product *domain.Product) domain.ProductExportRow {
+	row := domain.ProductExportRow{
+		Name:        product.Name,
+		Price:       product.Price.Decimal(),
+		Currency:    product.Currency,
+		Quantity:    product.Quantity,
+		TaxClass:    product.TaxClass,
+		Active:      product.Active,
+		WeightGrams: product.WeightGrams,
+		LengthMM:    product.LengthMM,
+		WidthMM:     product.WidthMM,
+		HeightMM:    product.HeightMM,
+		Attributes:  product.Attributes,
+	}
+	if product.SKU != nil {
+		row.SKU = *product.SKU
+	}
+	if product.Description != nil {
+		row.Description = *product.Description
+	}
+	if row.Attributes == nil {
+		row.Attributes = map[string]string{}
+	}
+	return row
+}
+
+// productExportRecord раскладывает строку экспорта по колонкам ProductImportColumns.
+func productExportRecord(row domain.ProductExportRow) []string {
+	attributes := ""
+	if len(row.Attributes) > 0 {
+		data, _ := json.Marshal(row.Attributes)
+		attributes = string(data)
+	}
+	return []string{
+		row.SKU, row.Name, row.Description, row.Price, row.Currency, strconv.Itoa(row.Quantity), row.TaxClass,
+		strconv.FormatBool(row.Active), strconv.Itoa(row.WeightGrams), strconv.Itoa(row.LengthMM),
+		strconv.Itoa(row.WidthMM), strconv.Itoa(row.HeightMM), attributes,
+	}
+}
+
+func (s *Service) failProductImport(productImport *domain.ProductImport, message string) error {
+	productImport.Status = domain.ImportStatusFailed
+	productImport.Error = message
+	s.logger.Warn().Int64("import_id", productImport.ID).Str("error", message).Msg("product import failed")
+	return s.repository.FinishProductImport(productImport)
+}
+
+func setImportErrorsURL(productImport *domain.ProductImport) {
+	if productImport.ErrorsKey != "" {
+		productImport.ErrorsURL = fmt.Sprintf("/api/v1/imports/%d/errors", productImport.ID)
+	}
+}
+
+// importFormat определяет формат файла импорта по явному значению или расширению файла.
+func importFormat(format, filename string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		switch strings.ToLower(path.Ext(filename)) {
+		case ".csv":
+			format = domain.ImportFormatCSV
+		case ".jsonl", ".ndjson":
+			format = domain.ImportFormatJSONL
+		}
+	}
+	if !slices.Contains(domain.ImportFormats, format) {
+		return "", fmt.Errorf("%w: format must be one of %s", errs.ErrInvalidImportFile, strings.Join(domain.ImportFormats, ", "))
+	}
+	return format, nil
+}
+
+// importRow - строка файла импорта; err - ошибка разбора строки, файл при этом читается дальше.
+type importRow struct {
+	line   int
+	fields map[string]string
+	err    error
+}
+
+// importReader читает строки файла импорта; конец файла - io.EOF, другие ошибки чтения прерывают импорт.
+type importReader interface {
+	next() (importRow, error)
+}
+
+func newImportReader(format string, r io.Reader) (importReader, error) {
+	if format == domain.ImportFormatJSONL {
+		return &jsonlImportReader{reader: bufio.NewReader(r)}, nil
+	}
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot read csv header", errs.ErrInvalidImportFile)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\uFEFF")
+	}
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+		if !slices.Contains(domain.ProductImportColumns, header[i]) {
+			return nil, fmt.Errorf("%w: unknown column %q", errs.ErrInvalidImportFile, column)
+		}
+		if slices.Contains(header[:i], header[i]) {
+			return nil, fmt.Errorf("%w: duplicate column %q", errs.ErrInvalidImportFile, column)
+		}
+	}
+	if !slices.Contains(header, "sku") {
+		return nil, fmt.Errorf("%w: sku column is required", errs.ErrInvalidImportFile)
+	}
+	return &csvImportReader{reader: reader, header: header}, nil
+}
+
+type csvImportReader struct {
+	reader *csv.Reader
+	header []string
+}
+
+func (r *csvImportReader) next() (importRow, error) {
+	record, err := r.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return importRow{line: parseErr.StartLine, err: fmt.Errorf("%w: %s", errs.ErrInvalidImportFile, parseErr.Err.Error())}, nil
+	} else if err != nil {
+		return importRow{}, err
+	}
+	line, _ := r.reader.FieldPos(0)
+	fields := make(map[string]string, len(r.header))
+	for i, column := range r.header {
+		fields[column] = record[i]
+	}
+	return importRow{line: line, fields: fields}, nil
+}
+
+type jsonlImportReader struct {
+	reader *bufio.Reader
+	line   int
+}
+
+func (r *jsonlImportReader) next() (importRow, error) {
+	for {
+		data, err := r.reader.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return importRow{}, err
+		}
+		r.line++
+		if data = bytes.TrimSpace(data); len(data) == 0 {
+			continue
+		}
+		fields, parseErr := parseJSONLRow(data)
+		return importRow{line: r.line, fields: fields, err: parseErr}, nil
+	}
+}
+
+// parseJSONLRow разбирает объект строки JSONL в значения колонок: строки без кавычек,
+// числа, true/false и объекты (attributes) - как в JSON, null - пустое значение.
+func parseJSONLRow(data []byte) (map[string]string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return map[string]string{}, fmt.Errorf("%w: line is not a JSON object", errs.ErrInvalidImportFile)
+	}
+	fields := make(map[string]string, len(raw))
+	var rowErr error
+	for key, value := range raw {
+		if !slices.Contains(domain.ProductImportColumns, key) {
+			rowErr = fmt.Errorf("%w: unknown key %q", errs.ErrInvalidImportFile, key)
+			continue
+		}
+		text := strings.TrimSpace(string(value))
+		switch {
+		case text == "null":
+			text = ""
+		case strings.HasPrefix(text, `"`):
+			if err := json.Unmarshal(value, &text); err != nil {
+				rowErr = fmt.Errorf("%w: %s: invalid string", errs.ErrInvalidImportFile, key)
+			}
+		}
+		fields[key] = text
+	}
+	return fields, rowErr
+}
+
+func importMaxFileBytes() int64 {
+	if mb := configs.AppSettings.ImportParams.MaxFileMB; mb > 0 {
+		return int64(mb) << 20
+	}
+	return defaultImportMaxFileMB << 20
+}
+
+func importMaxRows() int {
+	if n := configs.AppSettings.ImportParams.MaxRows; n > 0 {
+		return n
+	}
+	return defaultImportMaxRows
+}
+
+func importRetentionDays() int {
+	if n := configs.AppSettings.ImportParams.RetentionDays; n > 0 {
+		return n
+	}
+	return defaultImportRetentionDays
+}
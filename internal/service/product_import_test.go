@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"marketplace/internal/contracts"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"testing"
+)
+
+// importRepository отдает схему характеристик: товар 7 лежит в категории 2 с обязательным цветом.
+type importRepository struct {
+	contracts.RepositoryI
+}
+
+func (r *importRepository) ListProductCategories(productIDs []int64) (map[int64][]domain.ProductCategory, error) {
+	result := map[int64][]domain.ProductCategory{}
+	for _, id := range productIDs {
+		if id == 7 {
+			result[id] = []domain.ProductCategory{{CategoryRef: domain.CategoryRef{ID: 2}}}
+		}
+	}
+	return result, nil
+}
+
+func (r *importRepository) ListCategoryAttributes([]int64) ([]domain.CategoryAttribute, error) {
+	return []domain.CategoryAttribute{{CategoryID: 2, Key: "color", Type: domain.AttributeTypeEnum, Values: []string{"red", "blue"}, Required: true}}, nil
+}
+
+func TestBuildImportedProductAttributes(t *testing.T) {
+	s := NewService(&importRepository{})
+	existing := &domain.Product{ID: 7, ShopID: 1, Name: "Phone", Slug: "phone", Price: domain.NewMoney(1000, "USD"), Currency: "USD"}
+	row := func(attributes string) map[string]string {
+		return map[string]string{"name": "Phone", "price": "10", "currency": "USD", "attributes": attributes}
+	}
+
+	tests := []struct {
+		name     string
+		existing *domain.Product
+		fields   map[string]string
+		wantErr  error
+	}{
+		{name: "existing valid", existing: existing, fields: row(`{"color": "red"}`)},
+		{name: "existing invalid value", existing: existing, fields: row(`{"color": "green"}`), wantErr: errs.ErrInvalidAttribute},
+		{name: "existing missing required", existing: existing, fields: row(`{}`), wantErr: errs.ErrInvalidAttribute},
+		// У нового товара категорий нет, схема проверится при их назначении
+		{name: "new row", fields: row(`{"color": "green"}`)},
+		{name: "new row malformed", fields: row(`["green"]`), wantErr: errs.ErrInvalidFieldValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product, err := s.buildImportedProduct(1, "SKU-1", tt.existing, tt.fields)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(product.Attributes) != 1 {
+				t.Errorf("attributes %v", product.Attributes)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxSearchQueryLength  = 200
+	searchLanguageRussian = "russian"
+	searchLanguageEnglish = "english"
+)
+
+// SearchProducts ищет товары по всему каталогу. Без явного фильтра active ищутся только активные
+// товары. Диапазон цен задается в валюте currency, поэтому без нее не принимается.
+func (s *Service) SearchProducts(filter domain.ProductSearchFilter) (*domain.ProductSearchResult, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.Query == "" || utf8.RuneCountInString(filter.Query) > maxSearchQueryLength {
+		return nil, errs.ErrInvalidSearchQuery
+	}
+	if err := normalizeProductFilter(&filter.ProductFilter); err != nil {
+		return nil, err
+	}
+	if err := s.ensureCategoryExists(filter.CategoryID); err != nil {
+		return nil, err
+	}
+	if filter.Active == nil {
+		active := true
+		filter.Active = &active
+	}
+	filter.Language = searchLanguage(filter.Query)
+	result, err := s.repository.SearchProducts(filter)
+	if err != nil {
+		s.logger.Error().Err(err).Str("query", filter.Query).Msg("failed to search products")
+		return nil, err
+	}
+	return result, nil
+}
+
+// searchLanguage выбирает морфологию для подсветки: русскую, если в запросе есть кириллица.
+func searchLanguage(query string) string {
+	for _, r := range query {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return searchLanguageRussian
+		}
+	}
+	return searchLanguageEnglish
+}
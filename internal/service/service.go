@@ -2,20 +2,94 @@ package service
 
 import (
 	"marketplace/internal/contracts"
+	"marketplace/internal/realtime"
+	"net/http"
 	"os"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/rs/zerolog"
 )
 
 type Service struct {
-	repository contracts.RepositoryI
-	logger     zerolog.Logger
+	repository    contracts.RepositoryI
+	rateSource    contracts.RateSource
+	flashQueue    *admissionQueue
+	webhookClient *http.Client
+	streamHub     *realtime.Hub
+	senders       map[string]contracts.NotificationSender
+	blobStore     contracts.BlobStore
+	logger        zerolog.Logger
 }
 
-func NewService(repository contracts.RepositoryI) *Service {
+// Option настраивает необязательные зависимости сервиса.
+type Option func(*Service)
+
+// WithRateSource подключает внешний источник курсов валют.
+func WithRateSource(source contracts.RateSource) Option {
+	return func(s *Service) {
+		s.rateSource = source
+	}
+}
+
+// WithWebhookClient заменяет HTTP-клиент доставки вебхуков.
+func WithWebhookClient(client *http.Client) Option {
+	return func(s *Service) {
+		s.webhookClient = client
+	}
+}
+
+// WithNotificationSender назначает отправителя канала уведомлений (email, sms).
+func WithNotificationSender(channel string, sender contracts.NotificationSender) Option {
+	return func(s *Service) {
+		s.senders[channel] = sender
+	}
+}
+
+// WithBlobStore подключает хранилище файлов (изображения товаров).
+func WithBlobStore(store contracts.BlobStore) Option {
+	return func(s *Service) {
+		s.blobStore = store
+	}
+}
+
+func NewService(repository contracts.RepositoryI, opts ...Option) *Service {
 	logger := zerolog.New(os.Stdout).With().Timestamp().Str("entity", "service").Logger()
-	return &Service{
-		repository: repository,
-		logger:     logger,
+	s := &Service{
+		repository:    repository,
+		flashQueue:    newAdmissionQueue(),
+		webhookClient: newWebhookClient(),
+		streamHub:     realtime.NewHub(),
+		senders:       make(map[string]contracts.NotificationSender),
+		logger:        logger,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// withTx выполняет fn в транзакции: ошибка fn откатывает транзакцию, иначе она фиксируется.
+func (s *Service) withTx(fn func(tx *sqlx.Tx) error) error {
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return err
 	}
+	committed = true
+	return nil
 }
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (s *Service) CreateShipment(orderID int64, userID int, userRole string, input domain.CreateShipmentInput) (*domain.Shipment, error) {
+	if orderID <= 0 {
+		return nil, errs.ErrInvalidID
+	}
+	input.Carrier = strings.TrimSpace(input.Carrier)
+	input.TrackingNumber = strings.TrimSpace(input.TrackingNumber)
+	if input.Carrier == "" || input.TrackingNumber == "" {
+		return nil, fmt.Errorf("%w: carrier and tracking_number are required", errs.ErrInvalidFieldValue)
+	}
+	if len(input.Items) == 0 {
+		return nil, fmt.Errorf("%w: shipment must contain at least one item", errs.ErrInvalidFieldValue)
+	}
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return nil, err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	order, orderItems, err := s.repository.GetOrderByIDWithTx(tx, orderID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotfound) {
+			return nil, errs.ErrOrderNotFound
+		}
+		return nil, err
+	}
+	// Отгружать можно только подтвержденный заказ; неоплаченный pending может еще отмениться по сроку
+	switch order.Status {
+	case domain.OrderStatusConfirmed, domain.OrderStatusProcessing, domain.OrderStatusShipped:
+	default:
+		return nil, fmt.Errorf("%w: %s", errs.ErrOrderNotShippable, order.Status)
+	}
+	itemShops, err := s.repository.GetOrderItemShopIDsWithTx(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.repository.ListShipmentsByOrderIDWithTx(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	ordered := make(map[int64]int, len(orderItems))
+	for _, item := range orderItems {
+		ordered[item.ID] = item.Quantity
+	}
+	shipped := shippedQuantities(existing)
+
+	var shopID int64
+	requested := make(map[int64]int, len(input.Items))
+	for _, item := range input.Items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantity must be positive", errs.ErrInvalidFieldValue)
+		}
+		itemShopID, ok := itemShops[item.OrderItemID]
+		if !ok {
+			return nil, fmt.Errorf("%w: order item %d does not belong to order %d", errs.ErrInvalidFieldValue, item.OrderItemID, orderID)
+		}
+		if shopID == 0 {
+			shopID = itemShopID
+		} else if shopID != itemShopID {
+			return nil, fmt.Errorf("%w: all shipment items must belong to the same shop", errs.ErrInvalidFieldValue)
+		}
+		requested[item.OrderItemID] += item.Quantity
+		if shipped[item.OrderItemID]+requested[item.OrderItemID] > ordered[item.OrderItemID] {
+			return nil, fmt.Errorf("%w: order item %d would be shipped more than ordered (ordered: %d, already shipped: %d)",
+				errs.ErrInvalidFieldValue, item.OrderItemID, ordered[item.OrderItemID], shipped[item.OrderItemID])
+		}
+	}
+	shop, err := s.repository.GetShopByID(shopID)
+	if err != nil {
+		return nil, err
+	}
+	if userRole != domain.AdminRole && shop.OwnerID != int64(userID) {
+		return nil, fmt.Errorf("%w: you are not the owner of this shop", errs.ErrForbidden)
+	}
+
+	shipment := &domain.Shipment{
+		OrderID:        orderID,
+		ShopID:         shopID,
+		Carrier:        input.Carrier,
+		TrackingNumber: input.TrackingNumber,
+		Status:         domain.ShipmentStatusLabelCreated,
+	}
+	for orderItemID, quantity := range requested {
+		shipment.Items = append(shipment.Items, domain.ShipmentItem{OrderItemID: orderItemID, Quantity: quantity})
+	}
+	if err := s.repository.CreateShipmentWithTx(tx, shipment); err != nil {
+		s.logger.Error().Err(err).Int64("order_id", orderID).Msg("failed to create shipment")
+		return nil, err
+	}
+	event := domain.ShipmentEvent{
+		ShipmentID:  shipment.ID,
+		Status:      shipment.Status,
+		Description: "Shipping label created",
+		OccurredAt:  shipment.CreatedAt,
+	}
+	if err := s.repository.CreateShipmentEventWithTx(tx, &event); err != nil {
+		return nil, err
+	}
+	shipment.Events = []domain.ShipmentEvent{event}
+	if err := s.syncOrderStatusWithTx(tx, order, orderItems, append(existing, *shipment)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return nil, err
+	}
+	committed = true
+	s.logger.Info().Int64("shipment_id", shipment.ID).Int64("order_id", orderID).Msg("shipment created successfully")
+	return shipment, nil
+}
+
+func (s *Service) AddShipmentEvent(shipmentID int64, userID int, userRole string, input domain.CreateShipmentEventInput) (*domain.ShipmentEvent, error) {
+	if shipmentID <= 0 {
+		return nil, errs.ErrInvalidID
+	}
+	if !domain.IsValidShipmentStatus(input.Status) {
+		return nil, errs.ErrInvalidShipmentStatus
+	}
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return nil, err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	shipment, err := s.repository.GetShipmentByIDWithTx(tx, shipmentID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotfound) {
+			return nil, errs.ErrShipmentNotFound
+		}
+		return nil, err
+	}
+	shop, err := s.repository.GetShopByID(shipment.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	if userRole != domain.AdminRole && shop.OwnerID != int64(userID) {
+		return nil, fmt.Errorf("%w: you are not the owner of this shop", errs.ErrForbidden)
+	}
+	if shipment.Status == domain.ShipmentStatusDelivered || shipment.Status == domain.ShipmentStatusReturned {
+		return nil, fmt.Errorf("%w: shipment is already %s", errs.ErrInvalidShipmentStatus, shipment.Status)
+	}
+	order, orderItems, err := s.repository.GetOrderByIDWithTx(tx, shipment.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	occurredAt := time.Now()
+	if input.OccurredAt != nil {
+		occurredAt = *input.OccurredAt
+	}
+	event := &domain.ShipmentEvent{
+		ShipmentID:  shipment.ID,
+		Status:      input.Status,
+		Location:    strings.TrimSpace(input.Location),
+		Description: strings.TrimSpace(input.Description),
+		OccurredAt:  occurredAt,
+	}
+	if err := s.repository.CreateShipmentEventWithTx(tx, event); err != nil {
+		return nil, err
+	}
+	shipment.Status = input.Status
+	if shipment.ShippedAt == nil && input.Status != domain.ShipmentStatusLabelCreated {
+		shipment.ShippedAt = &occurredAt
+	}
+	if input.Status == domain.ShipmentStatusDelivered {
+		shipment.DeliveredAt = &occurredAt
+	}
+	if err := s.repository.UpdateShipmentStatusWithTx(tx, shipment); err != nil {
+		return nil, err
+	}
+	shipments, err := s.repository.ListShipmentsByOrderIDWithTx(tx, shipment.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.syncOrderStatusWithTx(tx, order, orderItems, shipments); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return nil, err
+	}
+	committed = true
+	s.logger.Info().Int64("shipment_id", shipment.ID).Str("status", input.Status).Msg("shipment event added")
+	return event, nil
+}
+
+func (s *Service) GetOrderShipments(orderID int64) ([]domain.Shipment, error) {
+	return s.repository.ListShipmentsByOrderID(orderID)
+}
+
+// syncOrderStatusWithTx пересчитывает статус заказа по состоянию его отправлений. Отправления
+// входят в представление заказа, поэтому версия заказа увеличивается и без смены статуса.
+func (s *Service) syncOrderStatusWithTx(tx *sqlx.Tx, order *domain.Order, items []domain.OrderItem, shipments []domain.Shipment) error {
+	status := deriveOrderStatus(order.Status, items, shipments)
+	if status == order.Status {
+		return s.repository.TouchOrderWithTx(tx, order.ID)
+	}
+	if err := s.repository.UpdateOrderStatusWithTx(tx, order.ID, status); err != nil {
+		s.logger.Error().Err(err).Int64("order_id", order.ID).Msg("failed to update order status")
+		return err
+	}
+	change := &domain.OrderStatusChange{OrderID: order.ID, FromStatus: order.Status, ToStatus: status, Reason: "derived from shipments"}
+	if err := s.repository.AddOrderStatusChangeWithTx(tx, change); err != nil {
+		s.logger.Error().Err(err).Int64("order_id", order.ID).Msg("failed to record order status change")
+		return err
+	}
+	if err := s.recordOrderStatusChangedWithTx(tx, order, change); err != nil {
+		return err
+	}
+	s.logger.Info().Int64("order_id", order.ID).Str("from", order.Status).Str("to", status).Msg("order status derived from shipments")
+	order.Status = status
+	return nil
+}
+
+// deriveOrderStatus: заказ "delivered", когда все позиции отправлены и доставлены,
+// "shipped", когда все позиции отправлены и хотя бы одно отправление в пути,
+// "processing" при частичной отправке. Отменённый заказ не меняется.
+func deriveOrderStatus(current string, items []domain.OrderItem, shipments []domain.Shipment) string {
+	if current == domain.OrderStatusCancelled || len(shipments) == 0 {
+		return current
+	}
+	shipped := shippedQuantities(shipments)
+	allShipped := true
+	for _, item := range items {
+		if shipped[item.ID] < item.Quantity {
+			allShipped = false
+			break
+		}
+	}
+	allDelivered := true
+	anyMoving := false
+	for _, shipment := range shipments {
+		switch shipment.Status {
+		case domain.ShipmentStatusDelivered:
+			anyMoving = true
+		case domain.ShipmentStatusInTransit, domain.ShipmentStatusOutForDelivery:
+			anyMoving = true
+			allDelivered = false
+		default:
+			allDelivered = false
+		}
+	}
+	switch {
+	case allShipped && allDelivered:
+		return domain.OrderStatusDelivered
+	case allShipped && anyMoving:
+		return domain.OrderStatusShipped
+	default:
+		return domain.OrderStatusProcessing
+	}
+}
+
+func shippedQuantities(shipments []domain.Shipment) map[int64]int {
+	shipped := make(map[int64]int)
+	for _, shipment := range shipments {
+		if shipment.Status == domain.ShipmentStatusReturned {
+			continue
+		}
+		for _, item := range shipment.Items {
+			shipped[item.OrderItemID] += item.Quantity
+		}
+	}
+	return shipped
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"errors"
+	"marketplace/internal/contracts"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// shipmentRepository отдает заказ в заданном статусе; дальше проверки статуса CreateShipment не идет.
+type shipmentRepository struct {
+	contracts.RepositoryI
+	db     *sqlx.DB
+	status string
+}
+
+func (r *shipmentRepository) BeginTx() (*sqlx.Tx, error) { return r.db.Beginx() }
+
+func (r *shipmentRepository) GetOrderByIDWithTx(_ *sqlx.Tx, id int64) (*domain.Order, []domain.OrderItem, error) {
+	return &domain.Order{ID: id, Status: r.status}, nil, nil
+}
+
+func (r *shipmentRepository) GetOrderItemShopIDsWithTx(*sqlx.Tx, int64) (map[int64]int64, error) {
+	return nil, errors.New("status check passed")
+}
+
+func TestCreateShipmentOrderStatus(t *testing.T) {
+	db, err := sqlx.Open("noop", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	input := domain.CreateShipmentInput{Carrier: "DHL", TrackingNumber: "JD0001", Items: []domain.CreateShipmentItemInput{{OrderItemID: 1, Quantity: 1}}}
+	for status, shippable := range map[string]bool{
+		domain.OrderStatusPending:    false,
+		domain.OrderStatusConfirmed:  true,
+		domain.OrderStatusProcessing: true,
+		domain.OrderStatusShipped:    true,
+		domain.OrderStatusDelivered:  false,
+		domain.OrderStatusCancelled:  false,
+	} {
+		s := NewService(&shipmentRepository{db: db, status: status})
+		_, err := s.CreateShipment(1, 1, "admin", input)
+		if got := !errors.Is(err, errs.ErrOrderNotShippable); got != shippable {
+			t.Errorf("%s: error = %v, shippable = %v", status, err, shippable)
+		}
+	}
+}
+
+func TestCreateShipmentAfterPaymentConfirmation(t *testing.T) {
+	s, conn := testDBService(t)
+	ownerID, productID := testShopProduct(t, conn, 5)
+	buyerID := testUser(t, conn)
+
+	orderID, err := s.CreateOrder(buyerID, domain.CreateOrderInput{Items: []domain.CreateOrderItemInput{{ProductID: productID, Quantity: 2}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, items, err := s.GetOrderByID(orderID)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("order items %+v, %v", items, err)
+	}
+	input := domain.CreateShipmentInput{Carrier: "DHL", TrackingNumber: "JD0001", Items: []domain.CreateShipmentItemInput{{OrderItemID: items[0].ID, Quantity: 2}}}
+
+	// Неоплаченный заказ отгрузить нельзя
+	if _, err := s.CreateShipment(orderID, ownerID, domain.ShopkeperRole, input); !errors.Is(err, errs.ErrOrderNotShippable) {
+		t.Fatalf("shipment of pending order: error = %v, want ErrOrderNotShippable", err)
+	}
+	order, err := s.ConfirmOrder(orderID, ownerID, domain.ConfirmOrderInput{PaymentReference: " pi_test "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != domain.OrderStatusConfirmed {
+		t.Fatalf("confirmed order status %s", order.Status)
+	}
+	if _, err := s.ConfirmOrder(orderID, ownerID, domain.ConfirmOrderInput{PaymentReference: "pi_test"}); !errors.Is(err, errs.ErrOrderNotPending) {
+		t.Fatalf("second confirmation: error = %v, want ErrOrderNotPending", err)
+	}
+	var reason string
+	var changedBy int
+	if err := conn.QueryRow(`SELECT reason, changed_by FROM order_status_history WHERE order_id = $1 AND to_status = $2`,
+		orderID, domain.OrderStatusConfirmed).Scan(&reason, &changedBy); err != nil {
+		t.Fatalf("confirmation history: %v", err)
+	}
+	if reason != domain.OrderConfirmReasonPayment+": pi_test" || changedBy != ownerID {
+		t.Errorf("history reason %q by %d", reason, changedBy)
+	}
+	var events int
+	if err := conn.Get(&events, `SELECT COUNT(*) FROM outbox_events WHERE type = 'order.status_changed' AND aggregate_id = $1`, orderID); err != nil || events != 1 {
+		t.Errorf("status change events %d, %v", events, err)
+	}
+
+	if _, err := s.CreateShipment(orderID, ownerID, domain.ShopkeperRole, input); err != nil {
+		t.Fatalf("shipment of confirmed order: %v", err)
+	}
+	if order, _, err = s.GetOrderByID(orderID); err != nil || order.Status != domain.OrderStatusProcessing {
+		t.Errorf("order after shipment: %+v, %v", order, err)
+	}
+}
@@ -0,0 +1,421 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"sort"
+	"strings"
+)
+
+func (s *Service) CreateShippingZone(zone *domain.ShippingZone, userID int, userRole string) error {
+	if zone == nil {
+		return errs.ErrInvalidRequestBody
+	}
+	if _, err := s.ensureShopOwner(zone.ShopID, userID, userRole); err != nil {
+		return err
+	}
+	zone.Name = strings.TrimSpace(zone.Name)
+	if zone.Name == "" {
+		return fmt.Errorf("%w: zone name is required", errs.ErrInvalidFieldValue)
+	}
+	countries := make([]string, 0, len(zone.Countries))
+	for _, country := range zone.Countries {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if len(country) != 2 {
+			return fmt.Errorf("%w: country must be an ISO 3166-1 alpha-2 code", errs.ErrInvalidFieldValue)
+		}
+		countries = append(countries, country)
+	}
+	zone.Countries = countries
+	if err := s.repository.CreateShippingZone(zone); err != nil {
+		s.logger.Error().Err(err).Int64("shop_id", zone.ShopID).Msg("failed to create shipping zone")
+		return err
+	}
+	return nil
+}
+
+func (s *Service) ListShippingZones(shopID int64, page domain.PageRequest) (*domain.Page[domain.ShippingZone], error) {
+	if shopID <= 0 {
+		return nil, errs.ErrInvalidShopID
+	}
+	normalizePage(&page)
+	return s.repository.ListShippingZonesPage(shopID, page)
+}
+
+func (s *Service) DeleteShippingZone(zoneID int64, userID int, userRole string) error {
+	zone, err := s.getShippingZone(zoneID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.ensureShopOwner(zone.ShopID, userID, userRole); err != nil {
+		return err
+	}
+	return s.repository.DeleteShippingZone(zoneID)
+}
+
+func (s *Service) CreateShippingMethod(method *domain.ShippingMethod, userID int, userRole string) error {
+	if method == nil {
+		return errs.ErrInvalidRequestBody
+	}
+	zone, err := s.getShippingZone(method.ZoneID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.ensureShopOwner(zone.ShopID, userID, userRole); err != nil {
+		return err
+	}
+	method.ShopID = zone.ShopID
+	if err := validateShippingMethod(method); err != nil {
+		return err
+	}
+	if err := s.repository.CreateShippingMethod(method); err != nil {
+		s.logger.Error().Err(err).Int64("zone_id", method.ZoneID).Msg("failed to create shipping method")
+		return err
+	}
+	return nil
+}
+
+func (s *Service) UpdateShippingMethod(method *domain.ShippingMethod, userID int, userRole string) error {
+	existing, err := s.getShippingMethod(method.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.ensureShopOwner(existing.ShopID, userID, userRole); err != nil {
+		return err
+	}
+	method.ZoneID = existing.ZoneID
+	method.ShopID = existing.ShopID
+	method.CreatedAt = existing.CreatedAt
+	if err := validateShippingMethod(method); err != nil {
+		return err
+	}
+	return s.repository.UpdateShippingMethod(method)
+}
+
+func (s *Service) DeleteShippingMethod(methodID int64, userID int, userRole string) error {
+	method, err := s.getShippingMethod(methodID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.ensureShopOwner(method.ShopID, userID, userRole); err != nil {
+		return err
+	}
+	return s.repository.DeleteShippingMethod(methodID)
+}
+
+// QuoteShipping возвращает доступные способы доставки с ценой для каждого магазина корзины.
+func (s *Service) QuoteShipping(userID int, input domain.ShippingQuoteInput) ([]domain.ShippingQuote, error) {
+	if len(input.Items) == 0 {
+		return nil, errors.New("cart must contain at least one item")
+	}
+	address, err := s.resolveShippingAddress(userID, input.AddressID)
+	if err != nil {
+		return nil, err
+	}
+	currency, err := s.resolveOrderCurrency(userID, input.Currency)
+	if err != nil {
+		return nil, err
+	}
+	var converter *currencyConverter
+	carts := make(shopCarts)
+	for _, item := range input.Items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantity must be positive", errs.ErrInvalidFieldValue)
+		}
+		product, err := s.GetProductByID(item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if converter == nil {
+			if currency == "" {
+				currency = product.Price.Currency
+			}
+			converter = s.newConverter(currency)
+		}
+		unitPrice, err := converter.convert(product.Price)
+		if err != nil {
+			return nil, err
+		}
+		lineTotal, err := unitPrice.Mul(item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		if err := carts.add(product, item.Quantity, lineTotal); err != nil {
+			return nil, err
+		}
+	}
+	options, err := s.shippingOptions(address.Country, carts, converter)
+	if err != nil {
+		return nil, err
+	}
+	quotes := make([]domain.ShippingQuote, 0, len(carts))
+	for _, shopID := range carts.shopIDs() {
+		quotes = append(quotes, domain.ShippingQuote{ShopID: shopID, Options: options[shopID]})
+	}
+	return quotes, nil
+}
+
+// selectShipping проверяет выбранные покупателем способы доставки и формирует строки заказа.
+func (s *Service) selectShipping(country string, carts shopCarts, methodIDs []int64, converter *currencyConverter) ([]domain.OrderShippingLine, error) {
+	options, err := s.shippingOptions(country, carts, converter)
+	if err != nil {
+		return nil, err
+	}
+	selected := make(map[int64]domain.OrderShippingLine, len(methodIDs))
+	for _, methodID := range methodIDs {
+		found := false
+		for shopID, shopOptions := range options {
+			for _, option := range shopOptions {
+				if option.MethodID != methodID {
+					continue
+				}
+				if _, dup := selected[shopID]; dup {
+					return nil, fmt.Errorf("%w: only one shipping method per shop", errs.ErrInvalidFieldValue)
+				}
+				id := option.MethodID
+				selected[shopID] = domain.OrderShippingLine{
+					ShopID:           shopID,
+					ShippingMethodID: &id,
+					Name:             option.Name,
+					Type:             option.Type,
+					Amount:           option.Cost,
+				}
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: method %d", errs.ErrShippingMethodUnavailable, methodID)
+		}
+	}
+	lines := make([]domain.OrderShippingLine, 0, len(selected))
+	for _, shopID := range carts.shopIDs() {
+		line, ok := selected[shopID]
+		if !ok {
+			if len(options[shopID]) > 0 {
+				return nil, fmt.Errorf("%w: shop %d", errs.ErrShippingMethodRequired, shopID)
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+func (s *Service) shippingOptions(country string, carts shopCarts, converter *currencyConverter) (map[int64][]domain.ShippingOption, error) {
+	zones, err := s.repository.ListShippingZones(carts.shopIDs(), true)
+	if err != nil {
+		return nil, err
+	}
+	options := make(map[int64][]domain.ShippingOption, len(carts))
+	for shopID, cart := range carts {
+		options[shopID] = []domain.ShippingOption{}
+		for _, method := range methodsForCountry(zones, shopID, country) {
+			// Способы без курса к валюте корзины недоступны
+			method, err := convertMethod(method, converter)
+			if err != nil {
+				continue
+			}
+			cost, ok, err := shippingCost(method, cart.subtotal, cart.weightGrams)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			options[shopID] = append(options[shopID], domain.ShippingOption{
+				MethodID: method.ID,
+				Name:     method.Name,
+				Type:     method.Type,
+				Cost:     cost,
+			})
+		}
+	}
+	return options, nil
+}
+
+// methodsForCountry выбирает способы доставки из зон, явно содержащих страну;
+// если таких нет - из зон "остальной мир" (без списка стран).
+func methodsForCountry(zones []domain.ShippingZone, shopID int64, country string) []domain.ShippingMethod {
+	var matched, fallback []domain.ShippingMethod
+	for _, zone := range zones {
+		if zone.ShopID != shopID {
+			continue
+		}
+		if len(zone.Countries) == 0 {
+			fallback = append(fallback, zone.Methods...)
+			continue
+		}
+		for _, c := range zone.Countries {
+			if c == country {
+				matched = append(matched, zone.Methods...)
+				break
+			}
+		}
+	}
+	if len(matched) > 0 {
+		return matched
+	}
+	return fallback
+}
+
+// shippingCost считает стоимость доставки; false - способ недоступен для корзины.
+func shippingCost(method domain.ShippingMethod, subtotal domain.Money, weightGrams int) (domain.Money, bool, error) {
+	free := domain.NewMoney(0, method.Currency)
+	if method.MaxWeightGrams != nil && weightGrams > *method.MaxWeightGrams {
+		return free, false, nil
+	}
+	switch method.Type {
+	case domain.ShippingMethodFlatRate:
+		return method.Rate, true, nil
+	case domain.ShippingMethodWeightBased:
+		// Вес округляется вверх до целого килограмма
+		kg := (weightGrams + 999) / 1000
+		weightCost, err := method.PerKgRate.Mul(kg)
+		if err != nil {
+			return free, false, err
+		}
+		cost, err := method.Rate.Add(weightCost)
+		return cost, err == nil, err
+	case domain.ShippingMethodFreeOverThreshold:
+		if method.FreeThreshold != nil {
+			cmp, err := subtotal.Cmp(*method.FreeThreshold)
+			if err != nil {
+				return free, false, err
+			}
+			if cmp >= 0 {
+				return free, true, nil
+			}
+		}
+		return method.Rate, true, nil
+	case domain.ShippingMethodLocalPickup:
+		return free, true, nil
+	}
+	return free, false, nil
+}
+
+// convertMethod пересчитывает тарифы способа доставки в валюту корзины.
+func convertMethod(method domain.ShippingMethod, converter *currencyConverter) (domain.ShippingMethod, error) {
+	if method.Currency == converter.target {
+		return method, nil
+	}
+	var err error
+	if method.Rate, err = converter.convert(method.Rate); err != nil {
+		return method, err
+	}
+	if method.PerKgRate, err = converter.convert(method.PerKgRate); err != nil {
+		return method, err
+	}
+	if method.FreeThreshold != nil {
+		threshold, err := converter.convert(*method.FreeThreshold)
+		if err != nil {
+			return method, err
+		}
+		method.FreeThreshold = &threshold
+	}
+	method.Currency = converter.target
+	return method, nil
+}
+
+func validateShippingMethod(method *domain.ShippingMethod) error {
+	method.Name = strings.TrimSpace(method.Name)
+	method.Currency = strings.ToUpper(strings.TrimSpace(method.Currency))
+	if method.Name == "" {
+		return fmt.Errorf("%w: method name is required", errs.ErrInvalidFieldValue)
+	}
+	if !domain.IsValidShippingMethodType(method.Type) {
+		return fmt.Errorf("%w: unknown shipping method type %q", errs.ErrInvalidFieldValue, method.Type)
+	}
+	if method.Currency == "" {
+		method.Currency = method.Rate.Currency
+	}
+	if method.Currency == "" {
+		method.Currency = "USD"
+	}
+	if !domain.IsValidCurrency(method.Currency) {
+		return fmt.Errorf("%w: %q", errs.ErrInvalidCurrency, method.Currency)
+	}
+	if err := matchCurrency(&method.Rate, method.Currency, "rate"); err != nil {
+		return err
+	}
+	if err := matchCurrency(&method.PerKgRate, method.Currency, "per_kg_rate"); err != nil {
+		return err
+	}
+	if method.Rate.IsNegative() || method.PerKgRate.IsNegative() {
+		return fmt.Errorf("%w: rates must not be negative", errs.ErrInvalidFieldValue)
+	}
+	if method.FreeThreshold != nil {
+		if err := matchCurrency(method.FreeThreshold, method.Currency, "free_threshold"); err != nil {
+			return err
+		}
+	}
+	if method.Type == domain.ShippingMethodFreeOverThreshold && (method.FreeThreshold == nil || method.FreeThreshold.IsNegative()) {
+		return fmt.Errorf("%w: free_threshold is required for free_over_threshold", errs.ErrInvalidFieldValue)
+	}
+	if method.MaxWeightGrams != nil && *method.MaxWeightGrams <= 0 {
+		return fmt.Errorf("%w: max_weight_grams must be positive", errs.ErrInvalidFieldValue)
+	}
+	return nil
+}
+
+func (s *Service) getShippingZone(id int64) (*domain.ShippingZone, error) {
+	if id <= 0 {
+		return nil, errs.ErrInvalidID
+	}
+	zone, err := s.repository.GetShippingZoneByID(id)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotfound) {
+			return nil, errs.ErrShippingZoneNotFound
+		}
+		return nil, err
+	}
+	return zone, nil
+}
+
+func (s *Service) getShippingMethod(id int64) (*domain.ShippingMethod, error) {
+	if id <= 0 {
+		return nil, errs.ErrInvalidID
+	}
+	method, err := s.repository.GetShippingMethodByID(id)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotfound) {
+			return nil, errs.ErrShippingMethodNotFound
+		}
+		return nil, err
+	}
+	return method, nil
+}
+
+// shopCart - часть корзины одного магазина, по которой считается доставка.
+type shopCart struct {
+	subtotal    domain.Money
+	weightGrams int
+}
+
+type shopCarts map[int64]*shopCart
+
+// add добавляет позицию; lineTotal уже пересчитан в валюту заказа.
+func (c shopCarts) add(product *domain.Product, quantity int, lineTotal domain.Money) error {
+	cart, ok := c[product.ShopID]
+	if !ok {
+		cart = &shopCart{subtotal: domain.NewMoney(0, lineTotal.Currency)}
+		c[product.ShopID] = cart
+	}
+	subtotal, err := cart.subtotal.Add(lineTotal)
+	if err != nil {
+		return err
+	}
+	cart.subtotal = subtotal
+	cart.weightGrams += product.WeightGrams * quantity
+	return nil
+}
+
+func (c shopCarts) shopIDs() []int64 {
+	ids := make([]int64, 0, len(c))
+	for id := range c {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -1,7 +1,7 @@
 package service
 
 import (
-	"errors"
+	"fmt"
 	"marketplace/internal/errs"
 	"marketplace/internal/models/domain"
 	"marketplace/utils"
@@ -18,9 +18,36 @@ func (s *Service) CreateShop(shop *domain.Shop) error {
 	shop.Slug = utils.GenerateSlug(shop.Name)
 	shop.CreatedAt = time.Now()
 	shop.UpdatedAt = time.Now()
-	if err := s.repository.CreateShop(shop); err != nil {
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	if err := s.repository.CreateShopWithTx(tx, shop); err != nil {
+		return err
+	}
+	err = s.recordEventWithTx(tx, domain.EventShopCreated, domain.AggregateShop, shop.ID, domain.ShopCreatedPayload{
+		ShopID:  shop.ID,
+		OwnerID: shop.OwnerID,
+		Name:    shop.Name,
+		Slug:    shop.Slug,
+	})
+	if err != nil {
 		return err
 	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return err
+	}
+	committed = true
 	return nil
 }
 func (s *Service) GetShopByID(id int64) (*domain.Shop, error) {
@@ -36,57 +63,126 @@ func (s *Service) GetShopByID(id int64) (*domain.Shop, error) {
 	}
 	return shop, nil
 }
-func (s *Service) UpdateShop(shop *domain.Shop, userID int, userRole string) error {
+
+// UpdateShop заменяет название и описание магазина (PUT). owner_id и slug не меняются
+// клиентом, slug пересчитывается из названия при переименовании. expectedVersion - версия
+// из If-Match, 0 - заголовка нет.
+func (s *Service) UpdateShop(shop *domain.Shop, expectedVersion int64, userID int, userRole string) error {
 	if shop.ID <= 0 {
 		return errs.ErrInvalidShopID
 	}
+	replacement := *shop
+	return s.retryOnVersionMismatch(expectedVersion, func() error {
+		existingShop, err := s.shopForUpdate(shop.ID, expectedVersion, userID, userRole)
+		if err != nil {
+			return err
+		}
+		if replacement.OwnerID != 0 && replacement.OwnerID != existingShop.OwnerID {
+			return fmt.Errorf("%w: owner_id", errs.ErrImmutableField)
+		}
+		if replacement.Slug != "" && replacement.Slug != existingShop.Slug {
+			return fmt.Errorf("%w: slug is derived from name", errs.ErrImmutableField)
+		}
+		*shop = replacement
+		return s.applyShopUpdate(existingShop, shop)
+	})
+}
 
-	existingShop, err := s.repository.GetShopByID(shop.ID)
+// PatchShop применяет к магазину JSON Merge Patch (RFC 7386): меняются только переданные
+// name и description. Если магазин изменился между чтением и записью, без If-Match патч
+// применяется заново к свежей версии.
+func (s *Service) PatchShop(id int64, patch []byte, expectedVersion int64, userID int, userRole string) (*domain.Shop, error) {
+	if id <= 0 {
+		return nil, errs.ErrInvalidShopID
+	}
+	var shop *domain.Shop
+	err := s.retryOnVersionMismatch(expectedVersion, func() error {
+		existingShop, err := s.shopForUpdate(id, expectedVersion, userID, userRole)
+		if err != nil {
+			return err
+		}
+		shop = &domain.Shop{}
+		if _, err := applyMergePatch(existingShop, shop, patch, domain.ShopPatchFields); err != nil {
+			return err
+		}
+		return s.applyShopUpdate(existingShop, shop)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return shop, nil
+}
 
+// shopForUpdate загружает магазин и проверяет, что пользователь - его владелец или админ,
+// а версия магазина совпадает с expectedVersion.
+func (s *Service) shopForUpdate(id, expectedVersion int64, userID int, userRole string) (*domain.Shop, error) {
+	existingShop, err := s.repository.GetShopByID(id)
+	if err != nil {
+		return nil, err
+	}
 	if userRole != domain.AdminRole && existingShop.OwnerID != int64(userID) {
-		return errors.New("permission denied: you are not the owner of this shop")
+		return nil, fmt.Errorf("%w: you are not the owner of this shop", errs.ErrForbidden)
 	}
+	if err := checkVersion(expectedVersion, existingShop.Version); err != nil {
+		return nil, err
+	}
+	return existingShop, nil
+}
 
+// applyShopUpdate проверяет новое состояние магазина и сохраняет его, если магазин не менялся
+// после чтения existingShop, иначе - ErrVersionMismatch.
+func (s *Service) applyShopUpdate(existingShop, shop *domain.Shop) error {
+	shop.ID = existingShop.ID
+	shop.Version = existingShop.Version
+	shop.OwnerID = existingShop.OwnerID
+	shop.CreatedAt = existingShop.CreatedAt
+	shop.Slug = existingShop.Slug
+	if shop.Name != existingShop.Name {
+		shop.Slug = utils.GenerateSlug(shop.Name)
+	}
+	if len(shop.Name) < 3 || shop.Slug == "" {
+		return errs.ErrInvalidShopName
+	}
 	shop.UpdatedAt = time.Now()
 	if err := s.repository.UpdateShop(shop); err != nil {
 		return err
 	}
 	return nil
 }
-func (s *Service) DeleteShop(id int64, userID int, userRole string) error {
+
+// DeleteShop удаляет магазин (soft delete); expectedVersion - версия из If-Match, 0 - заголовка нет.
+func (s *Service) DeleteShop(id, expectedVersion int64, userID int, userRole string) error {
 	if id <= 0 {
 		return errs.ErrInvalidShopID
 	}
-	existingShop, err := s.repository.GetShopByID(id)
-	if err != nil {
-		return err
-	}
-
-	if userRole != domain.AdminRole && existingShop.OwnerID != int64(userID) {
-		return errors.New("permission denied: you are not the owner of this shop")
-	}
-
-	if err := s.repository.DeleteShop(id); err != nil {
-		return err
-	}
-	return nil
+	return s.retryOnVersionMismatch(expectedVersion, func() error {
+		existingShop, err := s.shopForUpdate(id, expectedVersion, userID, userRole)
+		if err != nil {
+			return err
+		}
+		return s.repository.DeleteShop(id, existingShop.Version)
+	})
 }
-func (s *Service) ListShops(ownerID int64, limit, offset int) ([]*domain.Shop, error) {
+func (s *Service) ListShops(ownerID int64, page domain.PageRequest) (*domain.Page[*domain.Shop], error) {
 	if ownerID <= 0 {
 		return nil, errs.ErrInvalidFieldValue
 	}
-	if limit <= 0 {
-		limit = 20
-	}
-	if offset < 0 {
-		offset = 0
-	}
-	shops, err := s.repository.ListShops(ownerID, limit, offset)
+	normalizePage(&page)
+	shops, err := s.repository.ListShops(ownerID, page)
 	if err != nil {
 		return nil, err
 	}
 	return shops, nil
 }
+
+// ensureShopOwner проверяет, что пользователь - владелец магазина или админ.
+func (s *Service) ensureShopOwner(shopID int64, userID int, userRole string) (*domain.Shop, error) {
+	shop, err := s.GetShopByID(shopID)
+	if err != nil {
+		return nil, err
+	}
+	if userRole != domain.AdminRole && shop.OwnerID != int64(userID) {
+		return nil, fmt.Errorf("%w: you are not the owner of this shop", errs.ErrForbidden)
+	}
+	return shop, nil
+}
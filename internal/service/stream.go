@@ -0,0 +1,99 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"marketplace/internal/realtime"
+)
+
+const (
+	maxStreamProducts = 100
+	// maxNotifyPayload - предел размера NOTIFY в Postgres (8000 байт) с запасом
+	maxNotifyPayload = 7900
+)
+
+// OpenStream подписывает соединение пользователя на обновления его заказов, заказов и остатков
+// его магазинов и остатков товаров productIDs.
+func (s *Service) OpenStream(userID int, productIDs []int64) (*realtime.Subscription, error) {
+	if len(productIDs) > maxStreamProducts {
+		return nil, fmt.Errorf("%w: at most %d products can be watched", errs.ErrInvalidFieldValue, maxStreamProducts)
+	}
+	for _, id := range productIDs {
+		if id <= 0 {
+			return nil, errs.ErrInvalidProductID
+		}
+	}
+	shopIDs, err := s.repository.ListShopIDsByOwner(int64(userID))
+	if err != nil {
+		s.logger.Error().Err(err).Int("user_id", userID).Msg("failed to list owned shops")
+		return nil, err
+	}
+	return s.streamHub.Subscribe(int64(userID), shopIDs, productIDs), nil
+}
+
+// CloseStreams отключает все открытые потоки обновлений этого экземпляра перед остановкой сервера.
+func (s *Service) CloseStreams() {
+	s.streamHub.CloseAll()
+}
+
+// PublishStreamEvent определяет получателей события и рассылает его всем экземплярам через NOTIFY.
+// Каждый экземпляр получает его в StreamListener и раздает своим соединениям.
+func (s *Service) PublishStreamEvent(event domain.Event) error {
+	streamEvent := domain.StreamEvent{EventID: event.EventID, Type: event.Type, Data: event.Payload}
+	switch event.Type {
+	case domain.EventOrderCreated:
+		var payload domain.OrderCreatedPayload
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return err
+		}
+		streamEvent.UserIDs = []int64{payload.UserID}
+		streamEvent.ShopIDs = payload.ShopIDs
+	case domain.EventOrderStatusChanged:
+		var payload domain.OrderStatusChangedPayload
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return err
+		}
+		streamEvent.UserIDs = []int64{payload.UserID}
+		streamEvent.ShopIDs = payload.ShopIDs
+	case domain.EventStockChanged:
+		var payload domain.StockChangedPayload
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return err
+		}
+		streamEvent.ProductIDs = []int64{payload.ProductID}
+	case domain.EventStockLow:
+		var payload domain.StockLowPayload
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return err
+		}
+		streamEvent.ShopIDs = []int64{payload.ShopID}
+		streamEvent.ProductIDs = []int64{payload.ProductID}
+	default:
+		return nil
+	}
+	body, err := json.Marshal(streamEvent)
+	if err != nil {
+		return err
+	}
+	if len(body) > maxNotifyPayload {
+		s.logger.Warn().Str("event_id", event.EventID).Int("size", len(body)).Msg("stream event too large for NOTIFY, skipped")
+		return nil
+	}
+	if err := s.repository.NotifyStreamEvent(string(body)); err != nil {
+		s.logger.Error().Err(err).Str("event_id", event.EventID).Msg("failed to notify stream event")
+		return err
+	}
+	return nil
+}
+
+// BroadcastStreamEvent раздает полученное через LISTEN событие соединениям этого экземпляра.
+func (s *Service) BroadcastStreamEvent(payload string) error {
+	var event domain.StreamEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		return fmt.Errorf("invalid stream event: %w", err)
+	}
+	s.streamHub.Broadcast(event)
+	return nil
+}
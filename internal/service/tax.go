@@ -0,0 +1,188 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"math"
+	"regexp"
+	"strings"
+)
+
+var taxClassPattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+func (s *Service) CreateTaxRate(rate *domain.TaxRate) error {
+	if rate == nil {
+		return errs.ErrInvalidRequestBody
+	}
+	if err := normalizeTaxRate(rate); err != nil {
+		return err
+	}
+	rate.Active = true
+	if err := s.repository.CreateTaxRate(rate); err != nil {
+		s.logger.Error().Err(err).Str("country", rate.Country).Msg("failed to create tax rate")
+		return err
+	}
+	return nil
+}
+
+func (s *Service) ListTaxRates(country string, page domain.PageRequest) (*domain.Page[domain.TaxRate], error) {
+	normalizePage(&page)
+	return s.repository.ListTaxRatesPage(strings.ToUpper(strings.TrimSpace(country)), page)
+}
+
+func (s *Service) UpdateTaxRate(rate *domain.TaxRate) error {
+	if rate == nil || rate.ID <= 0 {
+		return errs.ErrInvalidID
+	}
+	existing, err := s.repository.GetTaxRateByID(rate.ID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotfound) {
+			return errs.ErrTaxRateNotFound
+		}
+		return err
+	}
+	if err := normalizeTaxRate(rate); err != nil {
+		return err
+	}
+	rate.CreatedAt = existing.CreatedAt
+	return s.repository.UpdateTaxRate(rate)
+}
+
+func (s *Service) DeleteTaxRate(id int64) error {
+	if id <= 0 {
+		return errs.ErrInvalidID
+	}
+	return s.repository.DeleteTaxRate(id)
+}
+
+func normalizeTaxRate(rate *domain.TaxRate) error {
+	rate.Country = strings.ToUpper(strings.TrimSpace(rate.Country))
+	rate.Region = strings.TrimSpace(rate.Region)
+	rate.Name = strings.TrimSpace(rate.Name)
+	if len(rate.Country) != 2 {
+		return fmt.Errorf("%w: country must be an ISO 3166-1 alpha-2 code", errs.ErrInvalidFieldValue)
+	}
+	if rate.Name == "" {
+		return fmt.Errorf("%w: tax name is required", errs.ErrInvalidFieldValue)
+	}
+	class, err := normalizeTaxClass(rate.TaxClass)
+	if err != nil {
+		return err
+	}
+	rate.TaxClass = class
+	if rate.Rate < 0 || rate.Rate > 100 {
+		return fmt.Errorf("%w: tax rate must be in [0, 100]", errs.ErrInvalidFieldValue)
+	}
+	rate.Rate = math.Round(rate.Rate*10000) / 10000
+	return nil
+}
+
+// normalizeTaxClass приводит налоговый класс к нижнему регистру; пустой класс - standard.
+func normalizeTaxClass(class string) (string, error) {
+	class = strings.ToLower(strings.TrimSpace(class))
+	if class == "" {
+		return domain.TaxClassStandard, nil
+	}
+	if !taxClassPattern.MatchString(class) {
+		return "", fmt.Errorf("%w: tax_class may contain only a-z, 0-9, '_' and '-'", errs.ErrInvalidFieldValue)
+	}
+	return class, nil
+}
+
+// taxableLine - позиция заказа, облагаемая налогом.
+type taxableLine struct {
+	taxClass string
+	total    domain.Money
+}
+
+// lineTax - налог одной позиции; rate == nil, если ставка для позиции не найдена.
+type lineTax struct {
+	rate   *domain.TaxRate
+	amount domain.Money
+}
+
+// computeTaxes считает налог по каждой позиции и сводку по ставкам. Функция не обращается
+// к БД: ставки передаются списком. Налог округляется по позициям, поэтому сводка
+// всегда равна сумме налогов позиций.
+func computeTaxes(rates []domain.TaxRate, country, region string, lines []taxableLine) ([]lineTax, []domain.OrderTaxLine, error) {
+	taxes := make([]lineTax, len(lines))
+	var summary []domain.OrderTaxLine
+	index := make(map[int64]int)
+	for i, line := range lines {
+		rate := matchTaxRate(rates, country, region, line.taxClass)
+		if rate == nil {
+			taxes[i] = lineTax{amount: domain.NewMoney(0, line.total.Currency)}
+			continue
+		}
+		amount, err := taxAmount(line.total, rate)
+		if err != nil {
+			return nil, nil, err
+		}
+		taxes[i] = lineTax{rate: rate, amount: amount}
+		taxable := line.total
+		if rate.Inclusive {
+			if taxable, err = taxable.Sub(amount); err != nil {
+				return nil, nil, err
+			}
+		}
+		pos, ok := index[rate.ID]
+		if !ok {
+			rateID := rate.ID
+			summary = append(summary, domain.OrderTaxLine{
+				TaxRateID:     &rateID,
+				Name:          rate.Name,
+				Country:       rate.Country,
+				Region:        rate.Region,
+				TaxClass:      rate.TaxClass,
+				Rate:          rate.Rate,
+				Inclusive:     rate.Inclusive,
+				TaxableAmount: domain.NewMoney(0, line.total.Currency),
+				Amount:        domain.NewMoney(0, line.total.Currency),
+			})
+			pos = len(summary) - 1
+			index[rate.ID] = pos
+		}
+		if summary[pos].TaxableAmount, err = summary[pos].TaxableAmount.Add(taxable); err != nil {
+			return nil, nil, err
+		}
+		if summary[pos].Amount, err = summary[pos].Amount.Add(amount); err != nil {
+			return nil, nil, err
+		}
+	}
+	return taxes, summary, nil
+}
+
+// matchTaxRate выбирает активную ставку класса: сначала для региона, затем для всей страны.
+func matchTaxRate(rates []domain.TaxRate, country, region, taxClass string) *domain.TaxRate {
+	if taxClass == "" {
+		taxClass = domain.TaxClassStandard
+	}
+	var countryRate *domain.TaxRate
+	for i := range rates {
+		rate := &rates[i]
+		if !rate.Active || rate.Country != country || rate.TaxClass != taxClass {
+			continue
+		}
+		if rate.Region == "" {
+			countryRate = rate
+			continue
+		}
+		if region != "" && strings.EqualFold(rate.Region, region) {
+			return rate
+		}
+	}
+	return countryRate
+}
+
+// taxAmount - налог с суммы: для включенного налога выделяется из цены
+// (total * r / (100 + r)), для невключенного начисляется сверху (total * r / 100).
+func taxAmount(total domain.Money, rate *domain.TaxRate) (domain.Money, error) {
+	// ставка в десятитысячных долях процента
+	units := int64(math.Round(rate.Rate * 10000))
+	if rate.Inclusive {
+		return total.MulRat(units, 100*10000+units)
+	}
+	return total.MulRat(units, 100*10000)
+}
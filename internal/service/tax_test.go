@@ -0,0 +1,154 @@
+package service
+
+import (
+	"marketplace/internal/models/domain"
+	"testing"
+)
+
+var testTaxRates = []domain.TaxRate{
+	{ID: 1, Country: "DE", TaxClass: "standard", Name: "VAT", Rate: 19, Inclusive: true, Active: true},
+	{ID: 2, Country: "DE", TaxClass: "reduced", Name: "VAT reduced", Rate: 7, Inclusive: true, Active: true},
+	{ID: 3, Country: "US", TaxClass: "standard", Name: "Sales tax", Rate: 5, Active: true},
+	{ID: 4, Country: "US", Region: "CA", TaxClass: "standard", Name: "CA sales tax", Rate: 7.25, Active: true},
+	{ID: 5, Country: "US", Region: "NY", TaxClass: "standard", Name: "NY sales tax", Rate: 8, Active: false},
+	{ID: 6, Country: "JP", TaxClass: "standard", Name: "Consumption tax", Rate: 10, Active: true},
+}
+
+func usd(amount int64) domain.Money { return domain.NewMoney(amount, "USD") }
+func eur(amount int64) domain.Money { return domain.NewMoney(amount, "EUR") }
+
+func TestComputeTaxes(t *testing.T) {
+	tests := []struct {
+		name      string
+		country   string
+		region    string
+		lines     []taxableLine
+		wantRates []int64 // 0 - позиция без налога
+		wantTaxes []domain.Money
+	}{
+		{
+			name:      "inclusive tax is extracted from price",
+			country:   "DE",
+			lines:     []taxableLine{{taxClass: "standard", total: eur(11900)}},
+			wantRates: []int64{1},
+			wantTaxes: []domain.Money{eur(1900)},
+		},
+		{
+			name:      "exclusive tax is added on top",
+			country:   "US",
+			lines:     []taxableLine{{taxClass: "standard", total: usd(10000)}},
+			wantRates: []int64{3},
+			wantTaxes: []domain.Money{usd(500)},
+		},
+		{
+			name:      "region rate wins over country rate",
+			country:   "US",
+			region:    "ca",
+			lines:     []taxableLine{{total: usd(10000)}},
+			wantRates: []int64{4},
+			wantTaxes: []domain.Money{usd(725)},
+		},
+		{
+			name:      "unknown region falls back to country rate",
+			country:   "US",
+			region:    "TX",
+			lines:     []taxableLine{{total: usd(10000)}},
+			wantRates: []int64{3},
+			wantTaxes: []domain.Money{usd(500)},
+		},
+		{
+			name:      "inactive region rate falls back to country rate",
+			country:   "US",
+			region:    "NY",
+			lines:     []taxableLine{{total: usd(10000)}},
+			wantRates: []int64{3},
+			wantTaxes: []domain.Money{usd(500)},
+		},
+		{
+			name:      "line without matching rate is not taxed",
+			country:   "FR",
+			lines:     []taxableLine{{total: eur(10000)}, {taxClass: "reduced", total: eur(500)}},
+			wantRates: []int64{0, 0},
+			wantTaxes: []domain.Money{eur(0), eur(0)},
+		},
+		{
+			name:      "exclusive tax rounds half away from zero",
+			country:   "US",
+			region:    "CA",
+			lines:     []taxableLine{{total: usd(200)}}, // 2.00 * 7.25% = 0.145
+			wantRates: []int64{4},
+			wantTaxes: []domain.Money{usd(15)},
+		},
+		{
+			name:      "inclusive tax rounds to the nearest minor unit",
+			country:   "DE",
+			lines:     []taxableLine{{total: eur(10)}, {taxClass: "reduced", total: eur(1999)}}, // 0.0159..., 1.3077...
+			wantRates: []int64{1, 2},
+			wantTaxes: []domain.Money{eur(2), eur(131)},
+		},
+		{
+			name:      "currency without minor units",
+			country:   "JP",
+			lines:     []taxableLine{{total: domain.NewMoney(15, "JPY")}},
+			wantRates: []int64{6},
+			wantTaxes: []domain.Money{domain.NewMoney(2, "JPY")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taxes, _, err := computeTaxes(testTaxRates, tt.country, tt.region, tt.lines)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, tax := range taxes {
+				var rateID int64
+				if tax.rate != nil {
+					rateID = tax.rate.ID
+				}
+				if rateID != tt.wantRates[i] {
+					t.Errorf("line %d: rate %d, want %d", i, rateID, tt.wantRates[i])
+				}
+				if tax.amount != tt.wantTaxes[i] {
+					t.Errorf("line %d: tax %s, want %s", i, tax.amount, tt.wantTaxes[i])
+				}
+			}
+		})
+	}
+}
+
+// Налог округляется по позициям, поэтому сводка по ставке равна сумме налогов позиций,
+// а не налогу с суммы позиций.
+func TestComputeTaxesSummary(t *testing.T) {
+	lines := []taxableLine{
+		{total: usd(3)}, {total: usd(3)}, {total: usd(3)}, // 0.03 * 5% = 0.0015 -> 0.00
+		{total: usd(30)}, {total: usd(30)}, // 0.30 * 5% = 0.015 -> 0.02
+	}
+	taxes, summary, err := computeTaxes(testTaxRates, "US", "", lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary) != 1 {
+		t.Fatalf("summary has %d lines, want 1", len(summary))
+	}
+	var sum domain.Money
+	for _, tax := range taxes {
+		if sum, err = sum.Add(tax.amount); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if summary[0].Amount != sum || sum != usd(4) {
+		t.Errorf("summary amount %s, sum of lines %s, want 0.04 USD", summary[0].Amount, sum)
+	}
+	if summary[0].TaxableAmount != usd(69) {
+		t.Errorf("taxable amount %s, want 0.69 USD", summary[0].TaxableAmount)
+	}
+
+	// Для включенного налога облагаемая база - цена без налога
+	_, summary, err = computeTaxes(testTaxRates, "DE", "", []taxableLine{{total: eur(11900)}, {total: eur(119)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary[0].Amount != eur(1919) || summary[0].TaxableAmount != eur(10100) {
+		t.Errorf("inclusive summary %s / %s, want 19.19 / 101.00 EUR", summary[0].Amount, summary[0].TaxableAmount)
+	}
+}
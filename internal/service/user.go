@@ -2,6 +2,7 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"marketplace/internal/errs"
 	"marketplace/internal/models/domain"
 	"marketplace/utils"
@@ -36,9 +37,35 @@ func (s *Service) CreateUser(user domain.User) (err error) {
 		return err
 	}
 
-	if err = s.repository.CreateUser(user); err != nil {
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	if user.ID, err = s.repository.CreateUserWithTx(tx, user); err != nil {
+		return err
+	}
+	err = s.recordEventWithTx(tx, domain.EventUserRegistered, domain.AggregateUser, int64(user.ID), domain.UserRegisteredPayload{
+		UserID:   user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+	})
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
 		return err
 	}
+	committed = true
 	return nil
 }
 
@@ -75,7 +102,7 @@ func (s *Service) Authenticate(user domain.User) (int, string, error) {
 }
 func (s *Service) SetUserRole(actorUserID int, actorRole string, targetUserID int, newRole string) error {
 	if actorRole != domain.AdminRole {
-		return errors.New("permission denied: only admins can change user roles")
+		return fmt.Errorf("%w: only admins can change user roles", errs.ErrForbidden)
 	}
 	validRoles := map[string]bool{
 		domain.UserRole:      true,
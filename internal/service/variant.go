@@ -0,0 +1,433 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	maxProductOptions      = 5
+	maxOptionValues        = 100
+	maxOptionLength        = 100
+	maxCategoryAttributes  = 100
+	maxAttributeNameLength = 255
+)
+
+var attributeKeyPattern = regexp.MustCompile(`^[a-z0-9_]{1,100}$`)
+
+// GetProductVariants возвращает опции и варианты товара.
+func (s *Service) GetProductVariants(productID int64) (*domain.Product, error) {
+	product, err := s.GetProductByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.AttachProductVariants(product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// AttachProductVariants заполняет опции и неудаленные варианты товаров для ответа API.
+func (s *Service) AttachProductVariants(products ...*domain.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	options, err := s.repository.ListProductOptions(ids)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to load product options")
+		return err
+	}
+	variants, err := s.repository.ListProductVariants(ids)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to load product variants")
+		return err
+	}
+	for _, product := range products {
+		product.Options = options[product.ID]
+		product.Variants = variants[product.ID]
+	}
+	return nil
+}
+
+// SetProductOptions заменяет опции товара. Существующие варианты должны остаться допустимыми:
+// опцию со значениями, которые используют варианты, нельзя убрать или сузить.
+func (s *Service) SetProductOptions(productID int64, input domain.SetProductOptionsInput, userID int, userRole string) ([]domain.ProductOption, error) {
+	options, err := normalizeProductOptions(input.Options)
+	if err != nil {
+		return nil, err
+	}
+	err = s.withProductVariants(productID, userID, userRole, false, func(tx *sqlx.Tx, _ *domain.Product) error {
+		variants, err := s.repository.ListProductVariantsWithTx(tx, productID)
+		if err != nil {
+			return err
+		}
+		for _, variant := range variants {
+			if err := validateVariantOptions(options, variant.Options); err != nil {
+				return fmt.Errorf("%w: variant %d", err, variant.ID)
+			}
+		}
+		return s.repository.SetProductOptionsWithTx(tx, productID, options)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// CreateProductVariant добавляет вариант товара. Цена по умолчанию - цена товара; остаток
+// товара становится суммой остатков активных вариантов.
+func (s *Service) CreateProductVariant(productID int64, input domain.ProductVariantInput, userID int, userRole string) (*domain.ProductVariant, error) {
+	sales, err := s.repository.ListActiveFlashSales([]int64{productID})
+	if err != nil {
+		return nil, err
+	}
+	if len(sales) > 0 {
+		return nil, fmt.Errorf("%w: product has an unfinished flash sale", errs.ErrInvalidFieldValue)
+	}
+	variant := &domain.ProductVariant{ProductID: productID, Active: true}
+	err = s.withProductVariants(productID, userID, userRole, true, func(tx *sqlx.Tx, product *domain.Product) error {
+		variant.Price = product.Price
+		if err := s.applyVariantInput(product, variant, input); err != nil {
+			return err
+		}
+		return s.repository.CreateProductVariantWithTx(tx, variant)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info().Int64("variant_id", variant.ID).Int64("product_id", productID).Msg("product variant created")
+	return variant, nil
+}
+
+// UpdateProductVariant меняет переданные поля варианта. Вариант перечитывается под блокировкой,
+// чтобы не затереть остаток, списанный параллельным заказом.
+func (s *Service) UpdateProductVariant(variantID int64, input domain.ProductVariantInput, userID int, userRole string) (*domain.ProductVariant, error) {
+	variant, err := s.getProductVariant(variantID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.withProductVariants(variant.ProductID, userID, userRole, true, func(tx *sqlx.Tx, product *domain.Product) error {
+		locked, err := s.repository.LockProductVariantsWithTx(tx, []int64{variantID})
+		if err != nil {
+			return err
+		}
+		if len(locked) == 0 {
+			return errs.ErrVariantNotFound
+		}
+		variant = locked[0]
+		if err := s.applyVariantInput(product, variant, input); err != nil {
+			return err
+		}
+		return s.repository.UpdateProductVariantWithTx(tx, variant)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+// DeleteProductVariant мягко удаляет вариант; его остаток уходит из остатка товара.
+func (s *Service) DeleteProductVariant(variantID int64, userID int, userRole string) error {
+	variant, err := s.getProductVariant(variantID)
+	if err != nil {
+		return err
+	}
+	return s.withProductVariants(variant.ProductID, userID, userRole, true, func(tx *sqlx.Tx, _ *domain.Product) error {
+		return s.repository.DeleteProductVariantWithTx(tx, variantID)
+	})
+}
+
+func (s *Service) getProductVariant(id int64) (*domain.ProductVariant, error) {
+	if id <= 0 {
+		return nil, errs.ErrVariantNotFound
+	}
+	return s.repository.GetProductVariantByID(id)
+}
+
+// withProductVariants выполняет изменение опций или вариантов товара в транзакции под
+// блокировкой товара. С recompute остаток товара пересчитывается из вариантов и изменение
+// остатка попадает в события.
+func (s *Service) withProductVariants(productID int64, userID int, userRole string, recompute bool,
+	fn func(tx *sqlx.Tx, product *domain.Product) error) error {
+	product, err := s.GetProductByID(productID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.ensureShopOwner(product.ShopID, userID, userRole); err != nil {
+		return err
+	}
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	if product, err = s.repository.GetProductByIDWithTx(tx, productID); err != nil {
+		if errors.Is(err, errs.ErrNotfound) {
+			return errs.ErrProductNotfound
+		}
+		return err
+	}
+	if err := fn(tx, product); err != nil {
+		return err
+	}
+	if err := s.repository.TouchProductWithTx(tx, productID); err != nil {
+		return err
+	}
+	if recompute {
+		quantity, err := s.repository.RecomputeProductQuantityWithTx(tx, productID)
+		if err != nil {
+			s.logger.Error().Err(err).Int64("product_id", productID).Msg("failed to recompute product quantity")
+			return err
+		}
+		if err := s.recordStockChangeWithTx(tx, product, product.Quantity, quantity); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// applyVariantInput переносит переданные поля в вариант и проверяет их.
+func (s *Service) applyVariantInput(product *domain.Product, variant *domain.ProductVariant, input domain.ProductVariantInput) error {
+	if input.SKU != nil {
+		sku := strings.TrimSpace(*input.SKU)
+		variant.SKU = &sku
+		if sku == "" {
+			variant.SKU = nil
+		}
+	}
+	if input.Options != nil {
+		variant.Options = make(map[string]string, len(input.Options))
+		for name, value := range input.Options {
+			variant.Options[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	if input.Price != nil {
+		if input.Price.IsNegative() {
+			return fmt.Errorf("%w: price must not be negative", errs.ErrInvalidFieldValue)
+		}
+		if _, err := priceCurrency(*input.Price, product.Currency); err != nil {
+			return err
+		}
+		variant.Price = *input.Price
+	}
+	if input.Quantity != nil {
+		if *input.Quantity < 0 {
+			return fmt.Errorf("%w: quantity must not be negative", errs.ErrInvalidFieldValue)
+		}
+		variant.Quantity = *input.Quantity
+	}
+	if input.Active != nil {
+		variant.Active = *input.Active
+	}
+	if input.Position != nil {
+		if *input.Position < 0 {
+			return fmt.Errorf("%w: position must not be negative", errs.ErrInvalidFieldValue)
+		}
+		variant.Position = *input.Position
+	}
+	options, err := s.repository.ListProductOptions([]int64{product.ID})
+	if err != nil {
+		return err
+	}
+	if len(options[product.ID]) == 0 {
+		return fmt.Errorf("%w: set product options before adding variants", errs.ErrInvalidVariantOptions)
+	}
+	return validateVariantOptions(options[product.ID], variant.Options)
+}
+
+// normalizeProductOptions проверяет опции: уникальные непустые названия и значения.
+func normalizeProductOptions(input []domain.ProductOptionInput) ([]domain.ProductOption, error) {
+	if len(input) > maxProductOptions {
+		return nil, fmt.Errorf("%w: a product can have at most %d options", errs.ErrInvalidFieldValue, maxProductOptions)
+	}
+	options := make([]domain.ProductOption, 0, len(input))
+	names := make(map[string]bool, len(input))
+	for _, option := range input {
+		name := strings.TrimSpace(option.Name)
+		if name == "" || len(name) > maxOptionLength || names[name] {
+			return nil, fmt.Errorf("%w: option names must be unique and 1-%d characters long", errs.ErrInvalidFieldValue, maxOptionLength)
+		}
+		names[name] = true
+		if len(option.Values) == 0 || len(option.Values) > maxOptionValues {
+			return nil, fmt.Errorf("%w: option %q must have 1-%d values", errs.ErrInvalidFieldValue, name, maxOptionValues)
+		}
+		values := make([]string, 0, len(option.Values))
+		for _, value := range option.Values {
+			value = strings.TrimSpace(value)
+			if value == "" || len(value) > maxOptionLength || slices.Contains(values, value) {
+				return nil, fmt.Errorf("%w: values of option %q must be unique and 1-%d characters long", errs.ErrInvalidFieldValue, name, maxOptionLength)
+			}
+			values = append(values, value)
+		}
+		options = append(options, domain.ProductOption{Name: name, Values: values})
+	}
+	return options, nil
+}
+
+// validateVariantOptions проверяет, что вариант задает допустимое значение каждой опции товара
+// и не содержит лишних опций.
+func validateVariantOptions(options []domain.ProductOption, values map[string]string) error {
+	if len(values) != len(options) {
+		return fmt.Errorf("%w: expected a value for each of %d options", errs.ErrInvalidVariantOptions, len(options))
+	}
+	for _, option := range options {
+		value, ok := values[option.Name]
+		if !ok || !slices.Contains(option.Values, value) {
+			return fmt.Errorf("%w: invalid value of option %q", errs.ErrInvalidVariantOptions, option.Name)
+		}
+	}
+	return nil
+}
+
+// variantName - название позиции заказа: товар и значения опций в порядке опций.
+func variantName(productName string, options []domain.ProductOption, values map[string]string) string {
+	parts := make([]string, 0, len(options))
+	for _, option := range options {
+		if value, ok := values[option.Name]; ok {
+			parts = append(parts, value)
+		}
+	}
+	if len(parts) == 0 {
+		return productName
+	}
+	return productName + " (" + strings.Join(parts, ", ") + ")"
+}
+
+// GetCategoryAttributes возвращает действующую схему характеристик категории с унаследованными.
+func (s *Service) GetCategoryAttributes(categoryID int64) ([]domain.CategoryAttribute, error) {
+	if categoryID <= 0 {
+		return nil, errs.ErrCategoryNotFound
+	}
+	if _, err := s.repository.GetCategoryByID(categoryID); err != nil {
+		return nil, err
+	}
+	return s.repository.ListCategoryAttributes([]int64{categoryID})
+}
+
+// SetCategoryAttributes заменяет собственную схему характеристик категории. Уже сохраненные
+// характеристики товаров не перепроверяются: схема применяется при следующем изменении товара.
+func (s *Service) SetCategoryAttributes(categoryID int64, input domain.SetCategoryAttributesInput) ([]domain.CategoryAttribute, error) {
+	if len(input.Attributes) > maxCategoryAttributes {
+		return nil, fmt.Errorf("%w: a category can have at most %d attributes", errs.ErrInvalidFieldValue, maxCategoryAttributes)
+	}
+	attributes := make([]domain.CategoryAttribute, 0, len(input.Attributes))
+	keys := make(map[string]bool, len(input.Attributes))
+	for _, in := range input.Attributes {
+		attribute := domain.CategoryAttribute{
+			Key:        strings.TrimSpace(in.Key),
+			Name:       strings.TrimSpace(in.Name),
+			Type:       in.Type,
+			Required:   in.Required,
+			Filterable: in.Filterable == nil || *in.Filterable,
+		}
+		if !attributeKeyPattern.MatchString(attribute.Key) || keys[attribute.Key] {
+			return nil, fmt.Errorf("%w: attribute keys must be unique and match %s", errs.ErrInvalidFieldValue, attributeKeyPattern)
+		}
+		keys[attribute.Key] = true
+		if attribute.Name == "" || len(attribute.Name) > maxAttributeNameLength {
+			return nil, fmt.Errorf("%w: attribute name must be 1-%d characters long", errs.ErrInvalidFieldValue, maxAttributeNameLength)
+		}
+		if !slices.Contains(domain.AttributeTypes, attribute.Type) {
+			return nil, fmt.Errorf("%w: attribute type must be one of %s", errs.ErrInvalidFieldValue, strings.Join(domain.AttributeTypes, ", "))
+		}
+		if attribute.Type == domain.AttributeTypeEnum {
+			for _, value := range in.Values {
+				value = strings.TrimSpace(value)
+				if value == "" || slices.Contains(attribute.Values, value) {
+					return nil, fmt.Errorf("%w: values of attribute %q must be unique and not empty", errs.ErrInvalidFieldValue, attribute.Key)
+				}
+				attribute.Values = append(attribute.Values, value)
+			}
+			if len(attribute.Values) == 0 {
+				return nil, fmt.Errorf("%w: enum attribute %q must have values", errs.ErrInvalidFieldValue, attribute.Key)
+			}
+		}
+		attributes = append(attributes, attribute)
+	}
+	err := s.withCategoryTree(func(tx *sqlx.Tx) error {
+		if _, err := s.repository.GetCategoryByIDWithTx(tx, categoryID); err != nil {
+			return err
+		}
+		return s.repository.SetCategoryAttributesWithTx(tx, categoryID, attributes)
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Int64("category_id", categoryID).Msg("failed to set category attributes")
+		return nil, err
+	}
+	return attributes, nil
+}
+
+// validateProductAttributes проверяет характеристики товара по схеме его категорий:
+// обязательные заданы, значения соответствуют типу. Ключи вне схемы допускаются.
+func (s *Service) validateProductAttributes(categoryIDs []int64, attributes map[string]string) error {
+	if len(categoryIDs) == 0 {
+		return nil
+	}
+	schema, err := s.repository.ListCategoryAttributes(categoryIDs)
+	if err != nil {
+		return err
+	}
+	for _, attribute := range schema {
+		value, ok := attributes[attribute.Key]
+		if !ok {
+			if attribute.Required {
+				return fmt.Errorf("%w: attribute %q is required", errs.ErrInvalidAttribute, attribute.Key)
+			}
+			continue
+		}
+		var valid bool
+		switch attribute.Type {
+		case domain.AttributeTypeNumber:
+			_, err := strconv.ParseFloat(value, 64)
+			valid = err == nil
+		case domain.AttributeTypeBoolean:
+			valid = value == "true" || value == "false"
+		case domain.AttributeTypeEnum:
+			valid = slices.Contains(attribute.Values, value)
+		default:
+			valid = value != ""
+		}
+		if !valid {
+			return fmt.Errorf("%w: invalid %s value of attribute %q", errs.ErrInvalidAttribute, attribute.Type, attribute.Key)
+		}
+	}
+	return nil
+}
+
+// productCategoryIDs возвращает id категорий, к которым привязан товар.
+func (s *Service) productCategoryIDs(productID int64) ([]int64, error) {
+	categories, err := s.repository.ListProductCategories([]int64{productID})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, len(categories[productID]))
+	for i, category := range categories[productID] {
+		ids[i] = category.ID
+	}
+	return ids, nil
+}
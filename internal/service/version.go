@@ -0,0 +1,36 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"marketplace/internal/errs"
+)
+
+// maxVersionAttempts - сколько раз повторяется чтение-изменение-запись без If-Match, если
+// ресурс изменился между чтением и записью
+const maxVersionAttempts = 3
+
+// checkVersion сверяет версию ресурса с ожидаемой из If-Match; 0 - без проверки. Проверка до
+// транзакции отсекает заведомо устаревшие запросы, атомарно версию проверяет репозиторий.
+func checkVersion(expectedVersion, currentVersion int64) error {
+	if expectedVersion != 0 && expectedVersion != currentVersion {
+		return fmt.Errorf("%w: current version is %d", errs.ErrVersionMismatch, currentVersion)
+	}
+	return nil
+}
+
+// retryOnVersionMismatch выполняет fn, которая читает ресурс и сохраняет его с прочитанной
+// версией. С If-Match расхождение версий возвращается клиенту (412), без него fn повторяется
+// на свежих данных, а после maxVersionAttempts возвращается ErrTxConflict (409).
+func (s *Service) retryOnVersionMismatch(expectedVersion int64, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if expectedVersion != 0 || !errors.Is(err, errs.ErrVersionMismatch) {
+			return err
+		}
+		if attempt == maxVersionAttempts {
+			return errs.ErrTxConflict
+		}
+		s.logger.Warn().Err(err).Int("attempt", attempt).Msg("resource modified concurrently, retrying")
+	}
+}
@@ -0,0 +1,505 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"marketplace/internal/configs"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"marketplace/utils"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultWebhookTimeout        = 10 * time.Second
+	defaultWebhookMaxAttempts    = 8
+	defaultWebhookDisableAfter   = 50
+	maxWebhookResponseBodyLength = 1024
+)
+
+func (s *Service) CreateWebhook(shopID int64, input domain.WebhookEndpointInput, userID int, userRole string) (*domain.WebhookEndpoint, error) {
+	if _, err := s.ensureShopOwner(shopID, userID, userRole); err != nil {
+		return nil, err
+	}
+	endpoint := &domain.WebhookEndpoint{
+		ShopID:      shopID,
+		Secret:      utils.NewWebhookSecret(),
+		Description: strings.TrimSpace(input.Description),
+		Active:      true,
+	}
+	if err := applyWebhookInput(endpoint, input); err != nil {
+		return nil, err
+	}
+	if err := s.repository.CreateWebhookEndpoint(endpoint); err != nil {
+		s.logger.Error().Err(err).Int64("shop_id", shopID).Msg("failed to create webhook")
+		return nil, err
+	}
+	s.logger.Info().Int64("webhook_id", endpoint.ID).Int64("shop_id", shopID).Msg("webhook created")
+	return endpoint, nil
+}
+
+func (s *Service) ListWebhooks(shopID int64, userID int, userRole string) ([]domain.WebhookEndpoint, error) {
+	if _, err := s.ensureShopOwner(shopID, userID, userRole); err != nil {
+		return nil, err
+	}
+	endpoints, err := s.repository.ListWebhookEndpoints(shopID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range endpoints {
+		endpoints[i].Secret = ""
+	}
+	return endpoints, nil
+}
+
+// UpdateWebhook заменяет адрес, события и описание вебхука. active=true включает отключенный
+// вебхук и сбрасывает счетчик неудач.
+func (s *Service) UpdateWebhook(webhookID int64, input domain.WebhookEndpointInput, userID int, userRole string) (*domain.WebhookEndpoint, error) {
+	endpoint, err := s.webhookForOwner(webhookID, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyWebhookInput(endpoint, input); err != nil {
+		return nil, err
+	}
+	endpoint.Description = strings.TrimSpace(input.Description)
+	if input.Active != nil {
+		if *input.Active && !endpoint.Active {
+			endpoint.ConsecutiveFailures = 0
+			endpoint.DisabledAt = nil
+			endpoint.DisabledReason = ""
+		}
+		endpoint.Active = *input.Active
+	}
+	if err := s.repository.UpdateWebhookEndpoint(endpoint); err != nil {
+		s.logger.Error().Err(err).Int64("webhook_id", webhookID).Msg("failed to update webhook")
+		return nil, err
+	}
+	endpoint.Secret = ""
+	return endpoint, nil
+}
+
+func (s *Service) DeleteWebhook(webhookID int64, userID int, userRole string) error {
+	if _, err := s.webhookForOwner(webhookID, userID, userRole); err != nil {
+		return err
+	}
+	return s.repository.DeleteWebhookEndpoint(webhookID)
+}
+
+func (s *Service) ListWebhookDeliveries(filter domain.WebhookDeliveryFilter, userID int, userRole string) (*domain.Page[domain.WebhookDelivery], error) {
+	if _, err := s.webhookForOwner(filter.EndpointID, userID, userRole); err != nil {
+		return nil, err
+	}
+	switch filter.Status {
+	case "", domain.WebhookDeliveryPending, domain.WebhookDeliverySucceeded, domain.WebhookDeliveryFailed:
+	default:
+		return nil, fmt.Errorf("%w: unknown delivery status", errs.ErrInvalidFieldValue)
+	}
+	normalizePage(&filter.PageRequest)
+	return s.repository.ListWebhookDeliveries(filter)
+}
+
+// GetWebhookDelivery возвращает доставку с журналом попыток.
+func (s *Service) GetWebhookDelivery(deliveryID int64, userID int, userRole string) (*domain.WebhookDelivery, error) {
+	delivery, err := s.getWebhookDelivery(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.webhookForOwner(delivery.EndpointID, userID, userRole); err != nil {
+		return nil, err
+	}
+	if delivery.Log, err = s.repository.ListWebhookDeliveryAttempts(deliveryID); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// RedeliverWebhook повторно ставит доставку в очередь с новым счетчиком попыток.
+func (s *Service) RedeliverWebhook(deliveryID int64, userID int, userRole string) (*domain.WebhookDelivery, error) {
+	delivery, err := s.getWebhookDelivery(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := s.webhookForOwner(delivery.EndpointID, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	if !endpoint.Active {
+		return nil, errs.ErrWebhookDisabled
+	}
+	if delivery.Status == domain.WebhookDeliveryPending {
+		return nil, errs.ErrWebhookDeliveryPending
+	}
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return nil, err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	if delivery, err = s.repository.ResetWebhookDeliveryWithTx(tx, deliveryID); err != nil {
+		return nil, err
+	}
+	job, err := newWebhookJob(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repository.CreateJobWithTx(tx, job); err != nil {
+		s.logger.Error().Err(err).Int64("delivery_id", deliveryID).Msg("failed to enqueue webhook delivery")
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return nil, err
+	}
+	committed = true
+	s.logger.Info().Int64("delivery_id", deliveryID).Msg("webhook redelivery requested")
+	return delivery, nil
+}
+
+// EnqueueWebhookDeliveries создает доставки события на подписанные вебхуки магазинов, к которым
+// относится событие, и ставит задачи доставки в очередь. Повтор того же события ничего не дублирует.
+func (s *Service) EnqueueWebhookDeliveries(event domain.Event) (int, error) {
+	if !slices.Contains(domain.WebhookEventTypes, event.Type) {
+		return 0, nil
+	}
+	shopIDs, err := eventShopIDs(event)
+	if err != nil || len(shopIDs) == 0 {
+		return 0, err
+	}
+	endpoints, err := s.repository.ListActiveWebhookEndpointsForEvent(shopIDs, event.Type)
+	if err != nil || len(endpoints) == 0 {
+		return 0, err
+	}
+	body, err := json.Marshal(domain.WebhookMessage{
+		EventID:   event.EventID,
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode webhook message: %w", err)
+	}
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return 0, err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	var enqueued int
+	for _, endpoint := range endpoints {
+		delivery := &domain.WebhookDelivery{
+			EndpointID: endpoint.ID,
+			EventID:    event.EventID,
+			EventType:  event.Type,
+			Payload:    body,
+		}
+		created, err := s.repository.CreateWebhookDeliveryWithTx(tx, delivery)
+		if err != nil {
+			return 0, err
+		}
+		if !created {
+			continue
+		}
+		job, err := newWebhookJob(delivery.ID)
+		if err != nil {
+			return 0, err
+		}
+		if err := s.repository.CreateJobWithTx(tx, job); err != nil {
+			s.logger.Error().Err(err).Int64("delivery_id", delivery.ID).Msg("failed to enqueue webhook delivery")
+			return 0, err
+		}
+		enqueued++
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return 0, err
+	}
+	committed = true
+	return enqueued, nil
+}
+
+// DeliverWebhook отправляет доставку на вебхук с подписью и сохраняет попытку в журнал.
+// Ошибка возвращается, чтобы очередь задач повторила доставку с экспоненциальной задержкой;
+// после webhook_params.max_attempts попыток доставка переходит в failed. Вебхук, не отвечающий
+// успешно disable_after_failures попыток подряд, отключается.
+func (s *Service) DeliverWebhook(ctx context.Context, deliveryID int64) error {
+	delivery, err := s.getWebhookDelivery(deliveryID)
+	if err != nil {
+		return err
+	}
+	if delivery.Status != domain.WebhookDeliveryPending {
+		return nil
+	}
+	endpoint, err := s.repository.GetWebhookEndpointByID(delivery.EndpointID)
+	if err != nil {
+		return err
+	}
+	attempt := &domain.WebhookDeliveryAttempt{DeliveryID: delivery.ID, Attempt: delivery.Attempts + 1}
+	if !endpoint.Active {
+		attempt.Error = "webhook is disabled"
+		return s.recordWebhookAttempt(endpoint, attempt, domain.WebhookDeliveryFailed, false)
+	}
+	started := time.Now()
+	sendErr := s.sendWebhook(ctx, endpoint, delivery, attempt)
+	attempt.DurationMs = int(time.Since(started).Milliseconds())
+	if sendErr == nil {
+		return s.recordWebhookAttempt(endpoint, attempt, domain.WebhookDeliverySucceeded, true)
+	}
+	attempt.Error = sendErr.Error()
+	status := domain.WebhookDeliveryPending
+	if attempt.Attempt >= webhookMaxAttempts() {
+		status = domain.WebhookDeliveryFailed
+	}
+	if err := s.recordWebhookAttempt(endpoint, attempt, status, false); err != nil {
+		return err
+	}
+	if status == domain.WebhookDeliveryFailed {
+		s.logger.Warn().Int64("delivery_id", delivery.ID).Int64("webhook_id", endpoint.ID).Int("attempts", attempt.Attempt).Msg("webhook delivery failed permanently")
+		return nil
+	}
+	return sendErr
+}
+
+func (s *Service) sendWebhook(ctx context.Context, endpoint *domain.WebhookEndpoint, delivery *domain.WebhookDelivery, attempt *domain.WebhookDeliveryAttempt) error {
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "marketplace-webhooks/1.0")
+	req.Header.Set(utils.WebhookEventHeader, delivery.EventType)
+	req.Header.Set(utils.WebhookIDHeader, delivery.EventID)
+	req.Header.Set(utils.WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(utils.WebhookSignatureHeader, utils.SignWebhook(endpoint.Secret, timestamp, delivery.Payload))
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBodyLength))
+	status := resp.StatusCode
+	attempt.ResponseStatus = &status
+	attempt.ResponseBody = string(body)
+	if status < 200 || status >= 300 {
+		return fmt.Errorf("webhook responded with status %d", status)
+	}
+	return nil
+}
+
+// recordWebhookAttempt сохраняет попытку и обновляет счетчик неудач вебхука в одной транзакции.
+func (s *Service) recordWebhookAttempt(endpoint *domain.WebhookEndpoint, attempt *domain.WebhookDeliveryAttempt, status string, success bool) error {
+	tx, err := s.repository.BeginTx()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to begin transaction")
+		return err
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.Error().Err(rbErr).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+	if err := s.repository.RecordWebhookAttemptWithTx(tx, attempt, status); err != nil {
+		return err
+	}
+	var disabled bool
+	if success {
+		if err := s.repository.ResetWebhookFailuresWithTx(tx, endpoint.ID); err != nil {
+			return err
+		}
+	} else if endpoint.Active {
+		failures, err := s.repository.IncrementWebhookFailuresWithTx(tx, endpoint.ID)
+		if err != nil {
+			return err
+		}
+		if failures >= webhookDisableAfter() {
+			reason := fmt.Sprintf("disabled after %d consecutive failed deliveries", failures)
+			if err := s.repository.DisableWebhookEndpointWithTx(tx, endpoint.ID, reason); err != nil {
+				return err
+			}
+			disabled = true
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to commit transaction")
+		return err
+	}
+	committed = true
+	if disabled {
+		s.logger.Warn().Int64("webhook_id", endpoint.ID).Int64("shop_id", endpoint.ShopID).Msg("webhook disabled after sustained failures")
+	}
+	return nil
+}
+
+func (s *Service) webhookForOwner(webhookID int64, userID int, userRole string) (*domain.WebhookEndpoint, error) {
+	if webhookID <= 0 {
+		return nil, errs.ErrInvalidID
+	}
+	endpoint, err := s.repository.GetWebhookEndpointByID(webhookID)
+	if errors.Is(err, errs.ErrNotfound) {
+		return nil, errs.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.ensureShopOwner(endpoint.ShopID, userID, userRole); err != nil {
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+func (s *Service) getWebhookDelivery(deliveryID int64) (*domain.WebhookDelivery, error) {
+	if deliveryID <= 0 {
+		return nil, errs.ErrInvalidID
+	}
+	delivery, err := s.repository.GetWebhookDeliveryByID(deliveryID)
+	if errors.Is(err, errs.ErrNotfound) {
+		return nil, errs.ErrWebhookDeliveryNotFound
+	}
+	return delivery, err
+}
+
+func applyWebhookInput(endpoint *domain.WebhookEndpoint, input domain.WebhookEndpointInput) error {
+	target, err := url.Parse(strings.TrimSpace(input.URL))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return errs.ErrInvalidWebhookURL
+	}
+	// Адрес, заданный IP или localhost, отклоняется сразу; имена проверяются при каждом соединении
+	if !configs.AppSettings.WebhookParams.AllowPrivateTargets {
+		host := target.Hostname()
+		if ip, err := netip.ParseAddr(host); (err == nil && !isPublicWebhookAddr(ip)) || strings.EqualFold(host, "localhost") {
+			return fmt.Errorf("%w: %s", errs.ErrWebhookTargetForbidden, host)
+		}
+	}
+	if len(input.EventTypes) == 0 {
+		return fmt.Errorf("%w: at least one event type is required", errs.ErrInvalidWebhookEvent)
+	}
+	eventTypes := make([]string, 0, len(input.EventTypes))
+	for _, eventType := range input.EventTypes {
+		if !slices.Contains(domain.WebhookEventTypes, eventType) {
+			return fmt.Errorf("%w: %s", errs.ErrInvalidWebhookEvent, eventType)
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	endpoint.URL = target.String()
+	endpoint.EventTypes = eventTypes
+	return nil
+}
+
+// eventShopIDs извлекает магазины, к которым относится событие: shop_ids у событий заказа, shop_id у событий товара.
+func eventShopIDs(event domain.Event) ([]int64, error) {
+	var payload struct {
+		ShopID  int64   `json:"shop_id"`
+		ShopIDs []int64 `json:"shop_ids"`
+	}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("failed to decode %s event payload: %w", event.Type, err)
+	}
+	if payload.ShopID > 0 {
+		return append(payload.ShopIDs, payload.ShopID), nil
+	}
+	return payload.ShopIDs, nil
+}
+
+func newWebhookJob(deliveryID int64) (*domain.Job, error) {
+	job, err := newJob(domain.JobTypeDeliverWebhook, domain.WebhookDeliveryJob{DeliveryID: deliveryID}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	// Повторы задачи и есть повторы доставки
+	job.MaxAttempts = webhookMaxAttempts()
+	return job, nil
+}
+
+// newWebhookClient не следует редиректам: ответ 3xx считается неудачной доставкой. Адрес
+// проверяется после разрешения имени при каждом соединении, поэтому вебхук не попадет во
+// внутреннюю сеть ни через DNS-имя, ни через смену DNS-записи после проверки. Прокси из
+// окружения не используется: иначе проверялся бы адрес прокси, а не получателя.
+func newWebhookClient() *http.Client {
+	timeout := defaultWebhookTimeout
+	if seconds := configs.AppSettings.WebhookParams.TimeoutSeconds; seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+	}
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	if !configs.AppSettings.WebhookParams.AllowPrivateTargets {
+		dialer.Control = webhookDialControl
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// webhookDialControl запрещает соединения с loopback, частными, link-local, multicast и
+// неуказанными адресами; вызывается для уже разрешенного адреса перед connect.
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublicWebhookAddr(ip) {
+		return fmt.Errorf("%w: %s", errs.ErrWebhookTargetForbidden, ip)
+	}
+	return nil
+}
+
+func isPublicWebhookAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+func webhookMaxAttempts() int {
+	if n := configs.AppSettings.WebhookParams.MaxAttempts; n > 0 {
+		return n
+	}
+	return defaultWebhookMaxAttempts
+}
+
+func webhookDisableAfter() int {
+	if n := configs.AppSettings.WebhookParams.DisableAfterFailures; n > 0 {
+		return n
+	}
+	return defaultWebhookDisableAfter
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"marketplace/internal/configs"
+	"marketplace/internal/contracts"
+	"marketplace/internal/errs"
+	"marketplace/internal/models/domain"
+	"marketplace/utils"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// noopDriver выдает транзакции без базы: фейковый репозиторий хранит состояние в памяти,
+// а сервису нужен только *sqlx.Tx, который можно зафиксировать или откатить.
+type noopDriver struct{}
+
+type noopConn struct{}
+
+func (noopDriver) Open(string) (driver.Conn, error) { return noopConn{}, nil }
+
+func (noopConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (noopConn) Close() error                        { return nil }
+func (noopConn) Begin() (driver.Tx, error)           { return noopConn{}, nil }
+func (noopConn) Commit() error                       { return nil }
+func (noopConn) Rollback() error                     { return nil }
+
+func init() {
+	sql.Register("noop", noopDriver{})
+}
+
+// webhookRepository - репозиторий в памяти для одной доставки и одного вебхука.
+type webhookRepository struct {
+	contracts.RepositoryI
+	db       *sqlx.DB
+	mu       sync.Mutex
+	endpoint domain.WebhookEndpoint
+	delivery domain.WebhookDelivery
+	attempts []domain.WebhookDeliveryAttempt
+}
+
+func newWebhookRepository(t *testing.T, url string) *webhookRepository {
+	t.Helper()
+	db, err := sqlx.Open("noop", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &webhookRepository{
+		db:       db,
+		endpoint: domain.WebhookEndpoint{ID: 1, ShopID: 1, URL: url, Secret: "whsec_test", Active: true},
+		delivery: domain.WebhookDelivery{
+			ID: 1, EndpointID: 1, EventID: "evt-1", EventType: domain.EventOrderCreated,
+			Payload: []byte(`{"order_id":1}`), Status: domain.WebhookDeliveryPending,
+		},
+	}
+}
+
+func (r *webhookRepository) BeginTx() (*sqlx.Tx, error) { return r.db.Beginx() }
+
+func (r *webhookRepository) GetWebhookDeliveryByID(int64) (*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery := r.delivery
+	return &delivery, nil
+}
+
+func (r *webhookRepository) GetWebhookEndpointByID(int64) (*domain.WebhookEndpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	endpoint := r.endpoint
+	return &endpoint, nil
+}
+
+func (r *webhookRepository) RecordWebhookAttemptWithTx(_ *sqlx.Tx, attempt *domain.WebhookDeliveryAttempt, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts = append(r.attempts, *attempt)
+	r.delivery.Status = status
+	r.delivery.Attempts = attempt.Attempt
+	r.delivery.LastResponseStatus = attempt.ResponseStatus
+	return nil
+}
+
+func (r *webhookRepository) IncrementWebhookFailuresWithTx(*sqlx.Tx, int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.endpoint.ConsecutiveFailures++
+	return r.endpoint.ConsecutiveFailures, nil
+}
+
+func (r *webhookRepository) ResetWebhookFailuresWithTx(*sqlx.Tx, int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.endpoint.ConsecutiveFailures = 0
+	return nil
+}
+
+func (r *webhookRepository) DisableWebhookEndpointWithTx(_ *sqlx.Tx, _ int64, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.endpoint.Active = false
+	r.endpoint.DisabledReason = reason
+	return nil
+}
+
+// webhookReceiver - локальный получатель вебхуков: на неверную подпись отвечает 401, иначе
+// статусами из responses по очереди, последний статус повторяется.
+func webhookReceiver(t *testing.T, secret string, responses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(calls.Add(1))
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		if !utils.VerifyWebhookSignature(secret, r.Header.Get(utils.WebhookTimestampHeader), r.Header.Get(utils.WebhookSignatureHeader), body, time.Minute, time.Now()) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if got := r.Header.Get(utils.WebhookEventHeader); got != domain.EventOrderCreated {
+			t.Errorf("call %d: event header %q, want %q", call, got, domain.EventOrderCreated)
+		}
+		w.WriteHeader(responses[min(call, len(responses))-1])
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func withWebhookParams(t *testing.T, params configs.WebhookParams) {
+	t.Helper()
+	saved := configs.AppSettings.WebhookParams
+	configs.AppSettings.WebhookParams = params
+	t.Cleanup(func() { configs.AppSettings.WebhookParams = saved })
+}
+
+func TestDeliverWebhookSignature(t *testing.T) {
+	withWebhookParams(t, configs.WebhookParams{AllowPrivateTargets: true})
+	server, calls := webhookReceiver(t, "whsec_test", http.StatusNoContent)
+	repository := newWebhookRepository(t, server.URL)
+	s := NewService(repository)
+
+	if err := s.DeliverWebhook(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 1 || repository.delivery.Status != domain.WebhookDeliverySucceeded {
+		t.Fatalf("calls %d, status %s; want 1 succeeded call", calls.Load(), repository.delivery.Status)
+	}
+
+	// Подпись другим секретом получатель не принимает
+	repository.delivery.Status = domain.WebhookDeliveryPending
+	repository.endpoint.Secret = "whsec_rotated"
+	if err := s.DeliverWebhook(context.Background(), 1); err == nil {
+		t.Fatal("delivery signed with a wrong secret succeeded")
+	}
+	if status := repository.delivery.LastResponseStatus; status == nil || *status != http.StatusUnauthorized {
+		t.Fatalf("response status %v, want 401", status)
+	}
+}
+
+// Ошибка доставки возвращается очереди задач, которая повторяет ее с растущей задержкой;
+// успешный ответ сбрасывает счетчик неудач, исчерпанные попытки переводят доставку в failed.
+func TestDeliverWebhookRetries(t *testing.T) {
+	withWebhookParams(t, configs.WebhookParams{MaxAttempts: 4, DisableAfterFailures: 10, AllowPrivateTargets: true})
+	server, calls := webhookReceiver(t, "whsec_test", http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK)
+	repository := newWebhookRepository(t, server.URL)
+	s := NewService(repository)
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if err := s.DeliverWebhook(context.Background(), 1); err == nil {
+			t.Fatalf("attempt %d: expected an error to retry the job", attempt)
+		}
+		if repository.delivery.Status != domain.WebhookDeliveryPending || repository.endpoint.ConsecutiveFailures != attempt {
+			t.Fatalf("attempt %d: status %s, failures %d", attempt, repository.delivery.Status, repository.endpoint.ConsecutiveFailures)
+		}
+	}
+	if err := s.DeliverWebhook(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if repository.delivery.Status != domain.WebhookDeliverySucceeded || repository.endpoint.ConsecutiveFailures != 0 {
+		t.Fatalf("status %s, failures %d; want succeeded, 0", repository.delivery.Status, repository.endpoint.ConsecutiveFailures)
+	}
+	if calls.Load() != 3 || len(repository.attempts) != 3 || *repository.attempts[1].ResponseStatus != http.StatusBadGateway {
+		t.Fatalf("calls %d, attempts %+v", calls.Load(), repository.attempts)
+	}
+
+	// Получатель, который всегда отвечает 500: после max_attempts попыток доставка в failed
+	failing, _ := webhookReceiver(t, "whsec_test", http.StatusInternalServerError)
+	repository.endpoint.URL = failing.URL
+	repository.delivery.Status, repository.delivery.Attempts = domain.WebhookDeliveryPending, 0
+	for attempt := 1; attempt < 4; attempt++ {
+		if err := s.DeliverWebhook(context.Background(), 1); err == nil {
+			t.Fatalf("attempt %d: expected an error to retry the job", attempt)
+		}
+	}
+	if err := s.DeliverWebhook(context.Background(), 1); err != nil {
+		t.Fatalf("last attempt returned %v, want nil so the job is not retried", err)
+	}
+	if repository.delivery.Status != domain.WebhookDeliveryFailed {
+		t.Fatalf("status %s, want failed", repository.delivery.Status)
+	}
+}
+
+func TestWebhookRetryBackoff(t *testing.T) {
+	saved := configs.AppSettings.JobParams
+	configs.AppSettings.JobParams.BackoffBaseSeconds, configs.AppSettings.JobParams.BackoffMaxSeconds = 10, 60
+	t.Cleanup(func() { configs.AppSettings.JobParams = saved })
+
+	for attempt, want := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 3: 40 * time.Second, 4: time.Minute, 8: time.Minute} {
+		for range 20 {
+			if got := jobBackoff(attempt); got < want || got > want+want/5 {
+				t.Fatalf("jobBackoff(%d) = %s, want %s..%s", attempt, got, want, want+want/5)
+			}
+		}
+	}
+	job, err := newWebhookJob(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.MaxAttempts != webhookMaxAttempts() {
+		t.Errorf("job max attempts %d, want %d", job.MaxAttempts, webhookMaxAttempts())
+	}
+}
+
+func TestDeliverWebhookDisablesEndpoint(t *testing.T) {
+	withWebhookParams(t, configs.WebhookParams{MaxAttempts: 10, DisableAfterFailures: 3, AllowPrivateTargets: true})
+	server, calls := webhookReceiver(t, "whsec_test", http.StatusServiceUnavailable)
+	repository := newWebhookRepository(t, server.URL)
+	s := NewService(repository)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		if err := s.DeliverWebhook(context.Background(), 1); err == nil {
+			t.Fatalf("attempt %d: expected an error", attempt)
+		}
+		if active := repository.endpoint.Active; active != (attempt < 3) {
+			t.Fatalf("attempt %d: active = %v", attempt, active)
+		}
+	}
+	if repository.endpoint.DisabledReason == "" {
+		t.Error("disabled webhook has no reason")
+	}
+
+	// Отключенный вебхук не вызывается: доставка сразу завершается неудачей
+	if err := s.DeliverWebhook(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 3 || repository.delivery.Status != domain.WebhookDeliveryFailed {
+		t.Fatalf("calls %d, status %s; want 3 calls, failed", calls.Load(), repository.delivery.Status)
+	}
+	if last := repository.attempts[len(repository.attempts)-1]; last.ResponseStatus != nil || last.Error != "webhook is disabled" {
+		t.Errorf("last attempt %+v", last)
+	}
+}
+
+func TestWebhookPrivateTargets(t *testing.T) {
+	withWebhookParams(t, configs.WebhookParams{MaxAttempts: 2})
+	for url, allowed := range map[string]bool{
+		"https://hooks.example.com/orders": true,
+		"https://93.184.215.14/hook":       true,
+		"http://127.0.0.1:8080/hook":       false,
+		"http://localhost/hook":            false,
+		"http://[::1]/hook":                false,
+		"http://10.0.0.5/hook":             false,
+		"http://192.168.1.1/hook":          false,
+		"http://169.254.169.254/latest":    false,
+		"http://0.0.0.0/hook":              false,
+		"http://[::ffff:127.0.0.1]/hook":   false,
+	} {
+		err := applyWebhookInput(&domain.WebhookEndpoint{}, domain.WebhookEndpointInput{URL: url, EventTypes: []string{domain.EventOrderCreated}})
+		if got := !errors.Is(err, errs.ErrWebhookTargetForbidden); got != allowed || (allowed && err != nil) {
+			t.Errorf("%s: error = %v, allowed = %v", url, err, allowed)
+		}
+	}
+
+	// Доставка проверяет адрес при соединении: получатель на loopback не вызывается
+	server, calls := webhookReceiver(t, "whsec_test", http.StatusOK)
+	repository := newWebhookRepository(t, server.URL)
+	if err := NewService(repository).DeliverWebhook(context.Background(), 1); !errors.Is(err, errs.ErrWebhookTargetForbidden) {
+		t.Fatalf("delivery to loopback: error = %v", err)
+	}
+	if calls.Load() != 0 || len(repository.attempts) != 1 || repository.attempts[0].ResponseStatus != nil {
+		t.Errorf("calls %d, attempts %+v", calls.Load(), repository.attempts)
+	}
+}
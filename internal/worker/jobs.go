@@ -0,0 +1,246 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"marketplace/internal/contracts"
+	"marketplace/internal/models/domain"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultJobConcurrency      = 4
+	defaultJobPollInterval     = time.Second
+	defaultJobScheduleInterval = 30 * time.Second
+)
+
+// JobHandler выполняет задачу одного типа. Ошибка приводит к повтору с задержкой.
+type JobHandler func(ctx context.Context, job domain.Job) error
+
+// JobRunnerConfig задает параметры воркеров очереди задач.
+type JobRunnerConfig struct {
+	Concurrency      int
+	PollInterval     time.Duration
+	ScheduleInterval time.Duration
+}
+
+// JobRunner забирает задачи из очереди в Postgres (FOR UPDATE SKIP LOCKED) в Concurrency горутинах
+// и ставит в очередь задачи по cron-расписаниям.
+type JobRunner struct {
+	service  contracts.ServiceI
+	config   JobRunnerConfig
+	workerID string
+	handlers map[string]JobHandler
+	logger   zerolog.Logger
+
+	stopPolling context.CancelFunc
+	cancelJobs  context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+func NewJobRunner(service contracts.ServiceI, config JobRunnerConfig) *JobRunner {
+	if config.Concurrency <= 0 {
+		config.Concurrency = defaultJobConcurrency
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultJobPollInterval
+	}
+	if config.ScheduleInterval <= 0 {
+		config.ScheduleInterval = defaultJobScheduleInterval
+	}
+	hostname, _ := os.Hostname()
+	r := &JobRunner{
+		service:  service,
+		config:   config,
+		workerID: hostname + ":" + strconv.Itoa(os.Getpid()),
+		handlers: make(map[string]JobHandler),
+		logger:   zerolog.New(os.Stdout).With().Timestamp().Str("entity", "job_runner").Logger(),
+	}
+	r.registerBuiltinJobs()
+	return r
+}
+
+// Register назначает обработчик типу задач. Вызывается до Start.
+func (r *JobRunner) Register(jobType string, handler JobHandler) {
+	r.handlers[jobType] = handler
+}
+
+// RegisterTyped регистрирует обработчик, получающий payload задачи, разобранный в T.
+func RegisterTyped[T any](r *JobRunner, jobType string, handler func(ctx context.Context, payload T) error) {
+	r.Register(jobType, func(ctx context.Context, job domain.Job) error {
+		var payload T
+		if len(job.Payload) > 0 {
+			if err := json.Unmarshal(job.Payload, &payload); err != nil {
+				return fmt.Errorf("invalid payload for job type %s: %w", jobType, err)
+			}
+		}
+		return handler(ctx, payload)
+	})
+}
+
+func (r *JobRunner) registerBuiltinJobs() {
+	r.Register(domain.JobTypePurgeIdempotencyKeys, func(ctx context.Context, job domain.Job) error {
+		deleted, err := r.service.PurgeExpiredIdempotencyKeys()
+		if err == nil && deleted > 0 {
+			r.logger.Info().Int64("deleted", deleted).Msg("expired idempotency keys purged")
+		}
+		return err
+	})
+	r.Register(domain.JobTypeFinishFlashSales, func(ctx context.Context, job domain.Job) error {
+		_, err := r.service.FinishEndedFlashSales()
+		return err
+	})
+	RegisterTyped(r, domain.JobTypeDeliverWebhook, func(ctx context.Context, payload domain.WebhookDeliveryJob) error {
+		return r.service.DeliverWebhook(ctx, payload.DeliveryID)
+	})
+	RegisterTyped(r, domain.JobTypeSendNotification, func(ctx context.Context, payload domain.NotificationJob) error {
+		return r.service.SendNotification(ctx, payload.NotificationID)
+	})
+	r.Register(domain.JobTypeSendNotificationDigests, func(ctx context.Context, job domain.Job) error {
+		sent, err := r.service.SendNotificationDigests(ctx)
+		if sent > 0 {
+			r.logger.Info().Int("digests", sent).Msg("notification digests created")
+		}
+		return err
+	})
+	RegisterTyped(r, domain.JobTypeGenerateThumbnails, func(ctx context.Context, payload domain.ImageJob) error {
+		return r.service.GenerateImageThumbnails(ctx, payload.ImageID)
+	})
+	r.Register(domain.JobTypePurgeBlobs, func(ctx context.Context, job domain.Job) error {
+		deleted, err := r.service.PurgeOrphanBlobs(ctx)
+		if deleted > 0 {
+			r.logger.Info().Int("deleted", deleted).Msg("orphan blobs purged")
+		}
+		return err
+	})
+	RegisterTyped(r, domain.JobTypeImportProducts, func(ctx context.Context, payload domain.ProductImportJob) error {
+		return r.service.RunProductImport(ctx, payload.ImportID)
+	})
+	r.Register(domain.JobTypePurgeProductImports, func(ctx context.Context, job domain.Job) error {
+		deleted, err := r.service.PurgeProductImports()
+		if err == nil && deleted > 0 {
+			r.logger.Info().Int64("deleted", deleted).Msg("old product imports purged")
+		}
+		return err
+	})
+	r.Register(domain.JobTypePurgeOutboxEvents, func(ctx context.Context, job domain.Job) error {
+		deleted, err := r.service.PurgeOutboxEvents()
+		if err == nil && deleted > 0 {
+			r.logger.Info().Int64("deleted", deleted).Msg("published outbox events purged")
+		}
+		return err
+	})
+}
+
+// Start запускает воркеры и планировщик расписаний.
+func (r *JobRunner) Start() {
+	pollCtx, stopPolling := context.WithCancel(context.Background())
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	r.stopPolling = stopPolling
+	r.cancelJobs = cancelJobs
+	for i := 0; i < r.config.Concurrency; i++ {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.work(pollCtx, jobCtx)
+		}()
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.schedule(pollCtx)
+	}()
+	r.logger.Info().Int("concurrency", r.config.Concurrency).Str("worker_id", r.workerID).Msg("job runner started")
+}
+
+// Stop перестает забирать новые задачи и ждет завершения выполняющихся. Если ctx истекает раньше,
+// контекст задач отменяется; незавершенные задачи после lock_timeout заберет другой экземпляр.
+func (r *JobRunner) Stop(ctx context.Context) {
+	if r.stopPolling == nil {
+		return
+	}
+	r.stopPolling()
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		r.logger.Info().Msg("job runner drained")
+	case <-ctx.Done():
+		r.logger.Warn().Msg("job runner drain timeout, cancelling running jobs")
+		r.cancelJobs()
+		<-done
+	}
+	r.cancelJobs()
+}
+
+func (r *JobRunner) work(pollCtx, jobCtx context.Context) {
+	for {
+		if pollCtx.Err() != nil {
+			return
+		}
+		jobs, err := r.service.ClaimJobs(r.workerID, 1)
+		if err != nil || len(jobs) == 0 {
+			select {
+			case <-pollCtx.Done():
+				return
+			case <-time.After(r.config.PollInterval):
+			}
+			continue
+		}
+		for _, job := range jobs {
+			r.run(jobCtx, job)
+		}
+	}
+}
+
+func (r *JobRunner) run(ctx context.Context, job domain.Job) {
+	logger := r.logger.With().Int64("job_id", job.ID).Str("type", job.Type).Int("attempt", job.Attempts).Logger()
+	started := time.Now()
+	err := r.execute(ctx, job)
+	if err != nil {
+		logger.Warn().Err(err).Dur("duration", time.Since(started)).Msg("job failed")
+		_ = r.service.FailJob(&job, r.workerID, err)
+		return
+	}
+	logger.Info().Dur("duration", time.Since(started)).Msg("job completed")
+	_ = r.service.CompleteJob(&job, r.workerID)
+}
+
+func (r *JobRunner) execute(ctx context.Context, job domain.Job) (err error) {
+	handler, ok := r.handlers[job.Type]
+	if !ok {
+		return fmt.Errorf("no handler registered for job type %q", job.Type)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+func (r *JobRunner) schedule(ctx context.Context) {
+	ticker := time.NewTicker(r.config.ScheduleInterval)
+	defer ticker.Stop()
+	for {
+		if enqueued, err := r.service.EnqueueDueSchedules(); err != nil {
+			r.logger.Error().Err(err).Msg("failed to enqueue scheduled jobs")
+		} else if enqueued > 0 {
+			r.logger.Info().Int("enqueued", enqueued).Msg("scheduled jobs enqueued")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"context"
+	"marketplace/internal/contracts"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const defaultExpiryInterval = time.Minute
+
+// OrderExpiryWorker периодически отменяет неоплаченные заказы с истекшим сроком.
+type OrderExpiryWorker struct {
+	service  contracts.ServiceI
+	interval time.Duration
+	logger   zerolog.Logger
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewOrderExpiryWorker(service contracts.ServiceI, interval time.Duration) *OrderExpiryWorker {
+	if interval <= 0 {
+		interval = defaultExpiryInterval
+	}
+	return &OrderExpiryWorker{
+		service:  service,
+		interval: interval,
+		logger:   zerolog.New(os.Stdout).With().Timestamp().Str("entity", "order_expiry_worker").Logger(),
+	}
+}
+
+// Start запускает проверку в фоне; первая проверка выполняется сразу.
+func (w *OrderExpiryWorker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			w.run()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	w.logger.Info().Dur("interval", w.interval).Msg("order expiry worker started")
+}
+
+// Stop останавливает воркер и дожидается завершения текущей проверки.
+func (w *OrderExpiryWorker) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	w.wg.Wait()
+	w.logger.Info().Msg("order expiry worker stopped")
+}
+
+func (w *OrderExpiryWorker) run() {
+	expired, err := w.service.ExpireAbandonedOrders()
+	if err != nil {
+		w.logger.Error().Err(err).Msg("failed to expire abandoned orders")
+		return
+	}
+	if expired > 0 {
+		w.logger.Info().Int("expired", expired).Msg("abandoned orders cancelled")
+	}
+}
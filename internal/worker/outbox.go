@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"context"
+	"marketplace/internal/contracts"
+	"marketplace/internal/events"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultRelayInterval  = time.Second
+	defaultRelayBatchSize = 100
+)
+
+// OutboxRelay периодически публикует события из outbox в диспетчер. Полная пачка
+// означает, что событий может быть больше, и следующая публикуется без ожидания.
+type OutboxRelay struct {
+	service    contracts.ServiceI
+	dispatcher *events.Dispatcher
+	interval   time.Duration
+	batchSize  int
+	logger     zerolog.Logger
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+func NewOutboxRelay(service contracts.ServiceI, dispatcher *events.Dispatcher, interval time.Duration, batchSize int) *OutboxRelay {
+	if interval <= 0 {
+		interval = defaultRelayInterval
+	}
+	if batchSize <= 0 {
+		batchSize = defaultRelayBatchSize
+	}
+	return &OutboxRelay{
+		service:    service,
+		dispatcher: dispatcher,
+		interval:   interval,
+		batchSize:  batchSize,
+		logger:     zerolog.New(os.Stdout).With().Timestamp().Str("entity", "outbox_relay").Logger(),
+	}
+}
+
+func (r *OutboxRelay) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for {
+			published, err := r.service.RelayOutboxEvents(ctx, r.batchSize, r.dispatcher.Dispatch)
+			if err != nil {
+				r.logger.Error().Err(err).Msg("failed to relay outbox events")
+			}
+			if err == nil && published == r.batchSize {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(r.interval):
+			}
+		}
+	}()
+	r.logger.Info().Dur("interval", r.interval).Int("batch_size", r.batchSize).Msg("outbox relay started")
+}
+
+// Stop останавливает ретранслятор и дожидается публикации текущей пачки.
+func (r *OutboxRelay) Stop() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	r.wg.Wait()
+	r.logger.Info().Msg("outbox relay stopped")
+}
@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"context"
+	"marketplace/internal/contracts"
+	"marketplace/internal/models/domain"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+const (
+	streamListenerMinReconnect = 10 * time.Second
+	streamListenerMaxReconnect = time.Minute
+	streamListenerPingInterval = 90 * time.Second
+)
+
+// StreamListener слушает канал LISTEN/NOTIFY и раздает события потока обновлений соединениям
+// этого экземпляра. Уведомления, пришедшие во время переподключения к Postgres, теряются:
+// поток - оперативный канал, а не журнал, клиенты перечитывают состояние после переподключения.
+type StreamListener struct {
+	service  contracts.ServiceI
+	listener *pq.Listener
+	logger   zerolog.Logger
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewStreamListener(service contracts.ServiceI, dsn string) *StreamListener {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Str("entity", "stream_listener").Logger()
+	listener := pq.NewListener(dsn, streamListenerMinReconnect, streamListenerMaxReconnect, func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventDisconnected:
+			logger.Warn().Err(err).Msg("stream listener disconnected")
+		case pq.ListenerEventReconnected:
+			logger.Info().Msg("stream listener reconnected")
+		case pq.ListenerEventConnectionAttemptFailed:
+			logger.Error().Err(err).Msg("stream listener connection attempt failed")
+		}
+	})
+	return &StreamListener{service: service, listener: listener, logger: logger}
+}
+
+func (l *StreamListener) Start() error {
+	if err := l.listener.Listen(domain.StreamNotifyChannel); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		ticker := time.NewTicker(streamListenerPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case notification := <-l.listener.Notify:
+				// nil приходит после переподключения
+				if notification == nil {
+					continue
+				}
+				if err := l.service.BroadcastStreamEvent(notification.Extra); err != nil {
+					l.logger.Error().Err(err).Msg("failed to broadcast stream event")
+				}
+			case <-ticker.C:
+				if err := l.listener.Ping(); err != nil {
+					l.logger.Warn().Err(err).Msg("stream listener ping failed")
+				}
+			}
+		}
+	}()
+	l.logger.Info().Str("channel", domain.StreamNotifyChannel).Msg("stream listener started")
+	return nil
+}
+
+func (l *StreamListener) Stop() {
+	if l.cancel != nil {
+		l.cancel()
+		l.wg.Wait()
+	}
+	if err := l.listener.Close(); err != nil {
+		l.logger.Error().Err(err).Msg("failed to close stream listener")
+	}
+	l.logger.Info().Msg("stream listener stopped")
+}
@@ -0,0 +1,26 @@
+-- Адресная книга пользователей
+CREATE TABLE IF NOT EXISTS addresses (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    label VARCHAR(100),
+    recipient_name VARCHAR(255) NOT NULL,
+    phone VARCHAR(20),
+    country VARCHAR(2) NOT NULL,
+    region VARCHAR(255),
+    city VARCHAR(255) NOT NULL,
+    postal_code VARCHAR(20),
+    line1 VARCHAR(255) NOT NULL,
+    line2 VARCHAR(255),
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_addresses_user_id ON addresses(user_id) WHERE deleted_at IS NULL;
+-- У пользователя может быть только один адрес по умолчанию
+CREATE UNIQUE INDEX IF NOT EXISTS uq_addresses_user_default ON addresses(user_id) WHERE is_default AND deleted_at IS NULL;
+
+-- Неизменяемый снимок адреса доставки на момент оформления заказа
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_address JSONB;
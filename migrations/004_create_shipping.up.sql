@@ -0,0 +1,59 @@
+-- Вес и габариты товаров для расчета доставки
+ALTER TABLE products ADD COLUMN IF NOT EXISTS weight_grams INT NOT NULL DEFAULT 0 CHECK (weight_grams >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS length_mm INT NOT NULL DEFAULT 0 CHECK (length_mm >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS width_mm INT NOT NULL DEFAULT 0 CHECK (width_mm >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS height_mm INT NOT NULL DEFAULT 0 CHECK (height_mm >= 0);
+
+-- Зоны доставки магазина (пустой список стран = остальной мир)
+CREATE TABLE IF NOT EXISTS shipping_zones (
+    id SERIAL PRIMARY KEY,
+    shop_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    countries VARCHAR(2)[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (shop_id) REFERENCES shops(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipping_zones_shop_id ON shipping_zones(shop_id);
+
+-- Способы доставки внутри зоны
+CREATE TABLE IF NOT EXISTS shipping_methods (
+    id SERIAL PRIMARY KEY,
+    zone_id INT NOT NULL,
+    shop_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    rate NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (rate >= 0),
+    per_kg_rate NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (per_kg_rate >= 0),
+    free_threshold NUMERIC(10, 2) CHECK (free_threshold >= 0),
+    max_weight_grams INT CHECK (max_weight_grams > 0),
+    currency VARCHAR(10) NOT NULL DEFAULT 'USD',
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (zone_id) REFERENCES shipping_zones(id) ON DELETE CASCADE,
+    FOREIGN KEY (shop_id) REFERENCES shops(id) ON DELETE CASCADE,
+    CHECK (type IN ('flat_rate', 'weight_based', 'free_over_threshold', 'local_pickup'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipping_methods_zone_id ON shipping_methods(zone_id);
+
+-- Стоимость доставки отдельной строкой в заказе и счете
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS subtotal NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (subtotal >= 0);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_total NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (shipping_total >= 0);
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS shipping_amount NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (shipping_amount >= 0);
+
+CREATE TABLE IF NOT EXISTS order_shipping_lines (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL,
+    shop_id INT NOT NULL,
+    shipping_method_id INT,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL CHECK (amount >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    FOREIGN KEY (shop_id) REFERENCES shops(id) ON DELETE CASCADE,
+    FOREIGN KEY (shipping_method_id) REFERENCES shipping_methods(id) ON DELETE SET NULL
+);
@@ -0,0 +1,58 @@
+-- Купоны и промо-акции
+CREATE TABLE IF NOT EXISTS coupons (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    value NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (value >= 0),
+    currency VARCHAR(10),
+    scope VARCHAR(20) NOT NULL DEFAULT 'marketplace',
+    shop_id INT,
+    product_ids INT[] NOT NULL DEFAULT '{}',
+    min_order_value NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (min_order_value >= 0),
+    starts_at TIMESTAMP WITH TIME ZONE,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    usage_limit INT CHECK (usage_limit > 0),
+    usage_limit_per_user INT CHECK (usage_limit_per_user > 0),
+    times_used INT NOT NULL DEFAULT 0 CHECK (times_used >= 0),
+    stackable BOOLEAN NOT NULL DEFAULT false,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_by INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (shop_id) REFERENCES shops(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE,
+    CHECK (type IN ('percentage', 'fixed_amount', 'free_shipping')),
+    CHECK (scope IN ('marketplace', 'shop', 'products')),
+    CHECK (type <> 'percentage' OR value <= 100),
+    CHECK (usage_limit IS NULL OR times_used <= usage_limit)
+);
+
+-- Погашения купонов (для лимитов на пользователя)
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id SERIAL PRIMARY KEY,
+    coupon_id INT NOT NULL,
+    user_id INT NOT NULL,
+    order_id INT NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL CHECK (amount >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (coupon_id) REFERENCES coupons(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_coupon_user ON coupon_redemptions(coupon_id, user_id);
+
+-- Скидки, примененные к заказу
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS discount_total NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (discount_total >= 0);
+
+CREATE TABLE IF NOT EXISTS order_discounts (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL,
+    coupon_id INT,
+    code VARCHAR(50) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL CHECK (amount >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    FOREIGN KEY (coupon_id) REFERENCES coupons(id) ON DELETE SET NULL
+);
@@ -0,0 +1,13 @@
+-- Фиксированная сумма купона хранится отдельно от процента
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS amount NUMERIC(10, 2) CHECK (amount > 0);
+
+UPDATE coupons SET amount = value, value = 0 WHERE type = 'fixed_amount' AND amount IS NULL;
+
+-- Минимальная сумма заказа задается в валюте купона, NULL означает отсутствие ограничения
+ALTER TABLE coupons ALTER COLUMN min_order_value DROP NOT NULL;
+ALTER TABLE coupons ALTER COLUMN min_order_value DROP DEFAULT;
+
+UPDATE coupons SET min_order_value = NULL WHERE min_order_value = 0;
+
+ALTER TABLE coupons ADD CONSTRAINT coupons_fixed_amount_check CHECK (type <> 'fixed_amount' OR (amount IS NOT NULL AND currency IS NOT NULL));
+ALTER TABLE coupons ADD CONSTRAINT coupons_min_order_value_currency_check CHECK (min_order_value IS NULL OR currency IS NOT NULL);
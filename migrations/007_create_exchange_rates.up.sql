@@ -0,0 +1,31 @@
+-- Курсы валют: 1 единица base_currency = rate единиц quote_currency
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id SERIAL PRIMARY KEY,
+    base_currency VARCHAR(3) NOT NULL,
+    quote_currency VARCHAR(3) NOT NULL,
+    rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    source VARCHAR(50) NOT NULL DEFAULT 'manual',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (base_currency, quote_currency),
+    CHECK (base_currency <> quote_currency)
+);
+
+-- Валюта отображения цен для покупателя
+ALTER TABLE users ADD COLUMN IF NOT EXISTS display_currency VARCHAR(3);
+
+-- Исходная цена позиции, если товар продается в другой валюте
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS original_unit_price NUMERIC(10, 2);
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS original_currency VARCHAR(3);
+
+-- Курсы, по которым позиции заказа пересчитаны в валюту заказа
+CREATE TABLE IF NOT EXISTS order_exchange_rates (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL,
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    UNIQUE (order_id, from_currency)
+);
@@ -0,0 +1,44 @@
+-- Налоговый класс товара
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tax_class VARCHAR(50) NOT NULL DEFAULT 'standard';
+
+-- Ставки налога по региону и налоговому классу; пустой region - ставка для всей страны
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id SERIAL PRIMARY KEY,
+    country VARCHAR(2) NOT NULL,
+    region VARCHAR(100) NOT NULL DEFAULT '',
+    tax_class VARCHAR(50) NOT NULL DEFAULT 'standard',
+    name VARCHAR(100) NOT NULL,
+    rate NUMERIC(7, 4) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    inclusive BOOLEAN NOT NULL DEFAULT false,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (country, region, tax_class)
+);
+
+-- Налог по позициям заказа
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_amount NUMERIC(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(7, 4) NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_inclusive BOOLEAN NOT NULL DEFAULT false;
+
+-- Итог налогов заказа и счета
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax_total NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (tax_total >= 0);
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS tax_amount NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (tax_amount >= 0);
+
+-- Сводка налогов заказа по ставкам
+CREATE TABLE IF NOT EXISTS order_tax_lines (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL,
+    tax_rate_id INT,
+    name VARCHAR(100) NOT NULL,
+    country VARCHAR(2) NOT NULL,
+    region VARCHAR(100) NOT NULL DEFAULT '',
+    tax_class VARCHAR(50) NOT NULL,
+    rate NUMERIC(7, 4) NOT NULL,
+    inclusive BOOLEAN NOT NULL,
+    taxable_amount NUMERIC(10, 2) NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL CHECK (amount >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    FOREIGN KEY (tax_rate_id) REFERENCES tax_rates(id) ON DELETE SET NULL
+);
@@ -0,0 +1,45 @@
+-- Флеш-распродажи: остаток товара заранее выделяется из products.quantity и делится на слоты,
+-- чтобы покупатели не ждали друг друга на блокировке одной строки товара
+CREATE TABLE IF NOT EXISTS flash_sales (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL,
+    allocated_quantity INT NOT NULL CHECK (allocated_quantity > 0),
+    slot_count INT NOT NULL CHECK (slot_count > 0),
+    per_user_limit INT NOT NULL CHECK (per_user_limit > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    returned_quantity INT NOT NULL DEFAULT 0 CHECK (returned_quantity >= 0),
+    created_by INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE,
+    CHECK (status IN ('active', 'finished')),
+    CHECK (ends_at > starts_at)
+);
+
+-- У товара может быть только одна незавершенная распродажа
+CREATE UNIQUE INDEX IF NOT EXISTS idx_flash_sales_active_product ON flash_sales(product_id) WHERE status = 'active';
+
+-- Слоты остатка: покупка списывает из любого свободного слота (FOR UPDATE SKIP LOCKED)
+CREATE TABLE IF NOT EXISTS flash_sale_slots (
+    flash_sale_id INT NOT NULL,
+    slot INT NOT NULL,
+    remaining INT NOT NULL CHECK (remaining >= 0),
+    PRIMARY KEY (flash_sale_id, slot),
+    FOREIGN KEY (flash_sale_id) REFERENCES flash_sales(id) ON DELETE CASCADE
+);
+
+-- Купленное количество по пользователям (лимит на покупателя)
+CREATE TABLE IF NOT EXISTS flash_sale_user_totals (
+    flash_sale_id INT NOT NULL,
+    user_id INT NOT NULL,
+    quantity INT NOT NULL CHECK (quantity >= 0),
+    PRIMARY KEY (flash_sale_id, user_id),
+    FOREIGN KEY (flash_sale_id) REFERENCES flash_sales(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Позиции заказа, оплаченные из остатка распродажи
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS flash_sale_id INT REFERENCES flash_sales(id) ON DELETE SET NULL;
@@ -0,0 +1,21 @@
+-- Причина и время отмены заказа
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS cancel_reason VARCHAR(255);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMP WITH TIME ZONE;
+
+-- Поиск просроченных неоплаченных заказов
+CREATE INDEX IF NOT EXISTS idx_orders_pending_created_at ON orders(created_at) WHERE status = 'pending' AND deleted_at IS NULL;
+
+-- История смены статусов заказа; changed_by = NULL - изменение системой
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL,
+    from_status VARCHAR(50) NOT NULL,
+    to_status VARCHAR(50) NOT NULL,
+    reason VARCHAR(255),
+    changed_by INT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id ON order_status_history(order_id);
@@ -0,0 +1,44 @@
+-- Периодические задачи: cron-выражение из 5 полей (минута, час, день месяца, месяц, день недели)
+CREATE TABLE IF NOT EXISTS job_schedules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    job_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    cron VARCHAR(100) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT true,
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Очередь фоновых задач. pending - ждет выполнения (в том числе повтора после ошибки),
+-- running - взята воркером, dead - исчерпаны попытки, cancelled - отменена администратором
+CREATE TABLE IF NOT EXISTS jobs (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0 CHECK (attempts >= 0),
+    max_attempts INT NOT NULL DEFAULT 5 CHECK (max_attempts > 0),
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_at TIMESTAMP WITH TIME ZONE,
+    locked_by VARCHAR(100),
+    last_error TEXT,
+    schedule_id INT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    FOREIGN KEY (schedule_id) REFERENCES job_schedules(id) ON DELETE SET NULL,
+    CHECK (status IN ('pending', 'running', 'completed', 'dead', 'cancelled'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_pending_run_at ON jobs(run_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_jobs_running_locked_at ON jobs(locked_at) WHERE status = 'running';
+CREATE INDEX IF NOT EXISTS idx_jobs_status_type ON jobs(status, type);
+
+-- Встроенные расписания
+INSERT INTO job_schedules (name, job_type, cron) VALUES
+    ('purge-idempotency-keys', 'idempotency_keys.purge', '15 * * * *'),
+    ('finish-ended-flash-sales', 'flash_sales.finish_ended', '* * * * *')
+ON CONFLICT (name) DO NOTHING;
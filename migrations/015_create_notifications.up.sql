@@ -0,0 +1,94 @@
+-- Шаблоны уведомлений по типу события, каналу и языку (синтаксис Go text/template,
+-- данные - payload события)
+CREATE TABLE IF NOT EXISTS notification_templates (
+    id SERIAL PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    locale VARCHAR(10) NOT NULL,
+    subject TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (event_type, channel, locale),
+    CHECK (channel IN ('email', 'sms', 'in_app'))
+);
+
+-- Каналы и язык уведомлений пользователя; без записи действуют значения по умолчанию
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INT PRIMARY KEY,
+    locale VARCHAR(10) NOT NULL,
+    email BOOLEAN NOT NULL DEFAULT true,
+    sms BOOLEAN NOT NULL DEFAULT false,
+    in_app BOOLEAN NOT NULL DEFAULT true,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Уведомления: in_app - входящие пользователя, email и sms - исходящие сообщения.
+-- digest - ждет отправки в сводке, digested - отправлено в сводке digest_id
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    event_id UUID,
+    event_type VARCHAR(100) NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    subject TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    last_error TEXT,
+    digest_id BIGINT,
+    read_at TIMESTAMP WITH TIME ZONE,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (digest_id) REFERENCES notifications(id) ON DELETE SET NULL,
+    UNIQUE (user_id, event_id, channel),
+    CHECK (channel IN ('email', 'sms', 'in_app')),
+    CHECK (status IN ('pending', 'digest', 'digested', 'sent', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_inbox ON notifications(user_id, id DESC) WHERE channel = 'in_app';
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(user_id) WHERE channel = 'in_app' AND read_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_notifications_recent ON notifications(user_id, channel, created_at);
+CREATE INDEX IF NOT EXISTS idx_notifications_digest ON notifications(user_id, channel) WHERE status = 'digest';
+
+INSERT INTO notification_templates (event_type, channel, locale, subject, body) VALUES
+    ('order.created', 'in_app', 'ru', 'Новый заказ #{{.order_id}}', 'Поступил заказ #{{.order_id}} на {{.total.amount}} {{.total.currency}}, позиций: {{.item_count}}.'),
+    ('order.created', 'email', 'ru', 'Новый заказ #{{.order_id}}', 'Здравствуйте!
+
+В ваш магазин поступил заказ #{{.order_id}} на {{.total.amount}} {{.total.currency}}, позиций: {{.item_count}}.'),
+    ('order.created', 'in_app', 'en', 'New order #{{.order_id}}', 'Order #{{.order_id}} for {{.total.amount}} {{.total.currency}} was placed, items: {{.item_count}}.'),
+    ('order.created', 'email', 'en', 'New order #{{.order_id}}', 'Hello!
+
+Your shop received order #{{.order_id}} for {{.total.amount}} {{.total.currency}}, items: {{.item_count}}.'),
+    ('order.status_changed', 'in_app', 'ru', 'Заказ #{{.order_id}}: {{.to_status}}', 'Статус заказа #{{.order_id}} изменился: {{.from_status}} → {{.to_status}}.'),
+    ('order.status_changed', 'email', 'ru', 'Заказ #{{.order_id}}: {{.to_status}}', 'Здравствуйте!
+
+Статус вашего заказа #{{.order_id}} изменился: {{.from_status}} → {{.to_status}}.{{if .reason}}
+Причина: {{.reason}}{{end}}'),
+    ('order.status_changed', 'sms', 'ru', '', 'Заказ #{{.order_id}}: {{.to_status}}'),
+    ('order.status_changed', 'in_app', 'en', 'Order #{{.order_id}}: {{.to_status}}', 'Order #{{.order_id}} status changed: {{.from_status}} → {{.to_status}}.'),
+    ('order.status_changed', 'email', 'en', 'Order #{{.order_id}}: {{.to_status}}', 'Hello!
+
+The status of your order #{{.order_id}} changed: {{.from_status}} → {{.to_status}}.{{if .reason}}
+Reason: {{.reason}}{{end}}'),
+    ('order.status_changed', 'sms', 'en', '', 'Order #{{.order_id}}: {{.to_status}}'),
+    ('stock.low', 'in_app', 'ru', 'Заканчивается товар', 'Остаток товара «{{.name}}»: {{.quantity}} шт.'),
+    ('stock.low', 'email', 'ru', 'Заканчивается товар «{{.name}}»', 'Остаток товара «{{.name}}» (#{{.product_id}}) опустился до {{.quantity}} шт.'),
+    ('stock.low', 'in_app', 'en', 'Low stock', 'Only {{.quantity}} left of "{{.name}}".'),
+    ('stock.low', 'email', 'en', 'Low stock: {{.name}}', 'Stock of "{{.name}}" (#{{.product_id}}) dropped to {{.quantity}}.'),
+    ('user.registered', 'email', 'ru', 'Добро пожаловать, {{.username}}!', 'Вы зарегистрировались в маркетплейсе под именем {{.username}}.'),
+    ('user.registered', 'email', 'en', 'Welcome, {{.username}}!', 'You have signed up to the marketplace as {{.username}}.'),
+    ('notification.digest', 'email', 'ru', 'Сводка уведомлений: {{.count}}', 'За последнее время у вас {{.count}} уведомлений:
+{{range .items}}
+- {{.subject}}{{end}}'),
+    ('notification.digest', 'sms', 'ru', '', 'У вас {{.count}} новых уведомлений'),
+    ('notification.digest', 'email', 'en', 'Notification digest: {{.count}}', 'You have {{.count}} notifications:
+{{range .items}}
+- {{.subject}}{{end}}'),
+    ('notification.digest', 'sms', 'en', '', 'You have {{.count}} new notifications')
+ON CONFLICT (event_type, channel, locale) DO NOTHING;
+
+INSERT INTO job_schedules (name, job_type, cron) VALUES
+    ('send-notification-digests', 'notifications.send_digests', '*/15 * * * *')
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,13 @@
+-- Характеристики товара для фильтров и фасетов: плоский JSON-объект {"color": "red", "size": "M"}
+ALTER TABLE products ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE products ADD CONSTRAINT products_attributes_object_check CHECK (jsonb_typeof(attributes) = 'object');
+
+CREATE INDEX IF NOT EXISTS idx_products_attributes ON products USING GIN (attributes jsonb_path_ops) WHERE deleted_at IS NULL;
+
+-- Индексы сортировок каталога
+CREATE INDEX IF NOT EXISTS idx_products_created_at ON products(created_at DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_products_price ON products(currency, price, id) WHERE deleted_at IS NULL;
+
+-- Популярность товара считается по проданным позициям
+CREATE INDEX IF NOT EXISTS idx_order_items_product_id ON order_items(product_id);
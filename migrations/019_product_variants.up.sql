@@ -0,0 +1,47 @@
+-- Типы опций товара (размер, цвет) с допустимыми значениями в порядке показа
+CREATE TABLE IF NOT EXISTS product_options (
+    id BIGSERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    "values" TEXT[] NOT NULL DEFAULT '{}',
+    UNIQUE (product_id, name)
+);
+
+-- Варианты товара: у каждого свои SKU, цена (в валюте товара) и остаток. options - значение
+-- каждой опции товара, {"size": "M", "color": "red"}; у живых вариантов набор уникален.
+CREATE TABLE IF NOT EXISTS product_variants (
+    id BIGSERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    sku VARCHAR(100),
+    options JSONB NOT NULL DEFAULT '{}' CHECK (jsonb_typeof(options) = 'object'),
+    price NUMERIC(10, 2) NOT NULL CHECK (price >= 0),
+    quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    active BOOLEAN NOT NULL DEFAULT true,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_sku ON product_variants(sku) WHERE deleted_at IS NULL AND sku IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_options ON product_variants(product_id, options) WHERE deleted_at IS NULL;
+
+-- Позиция заказа ссылается на купленный вариант; sku и name хранятся снимком
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS variant_id BIGINT REFERENCES product_variants(id) ON DELETE RESTRICT;
+
+-- Схема характеристик категории: тип значения, допустимые значения для enum, обязательность
+-- и участие в фасетах. Схема наследуется подкатегориями.
+CREATE TABLE IF NOT EXISTS category_attributes (
+    id BIGSERIAL PRIMARY KEY,
+    category_id BIGINT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    key VARCHAR(100) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('string', 'number', 'boolean', 'enum')),
+    "values" TEXT[] NOT NULL DEFAULT '{}',
+    required BOOLEAN NOT NULL DEFAULT false,
+    filterable BOOLEAN NOT NULL DEFAULT true,
+    position INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (category_id, key),
+    CHECK (type <> 'enum' OR cardinality("values") > 0)
+);
@@ -0,0 +1,36 @@
+-- Изображения товаров. Оригинал и миниатюры лежат в хранилище файлов (BlobStore), в базе -
+-- ключи и размеры. variant_id привязывает изображение к варианту товара.
+CREATE TABLE IF NOT EXISTS product_images (
+    id BIGSERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id BIGINT REFERENCES product_variants(id) ON DELETE SET NULL,
+    blob_key VARCHAR(255) NOT NULL UNIQUE,
+    content_type VARCHAR(50) NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes > 0),
+    width INT NOT NULL CHECK (width > 0),
+    height INT NOT NULL CHECK (height > 0),
+    -- [{"size": 160, "key": "...", "width": 160, "height": 120}]
+    thumbnails JSONB NOT NULL DEFAULT '[]',
+    status VARCHAR(20) NOT NULL DEFAULT 'processing' CHECK (status IN ('processing', 'ready', 'failed')),
+    position INTEGER NOT NULL DEFAULT 0,
+    is_primary BOOLEAN NOT NULL DEFAULT false,
+    alt VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_images_product ON product_images(product_id, position, id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_images_primary ON product_images(product_id) WHERE is_primary;
+
+-- Очередь удаления файлов, на которые больше не ссылается база: изображения удаленных товаров
+-- и удаленные изображения. Файлы удаляет задача images.purge_blobs.
+CREATE TABLE IF NOT EXISTS blob_deletions (
+    id BIGSERIAL PRIMARY KEY,
+    blob_key VARCHAR(255) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO job_schedules (name, job_type, cron) VALUES
+    ('purge-orphan-blobs', 'images.purge_blobs', '*/10 * * * *')
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,32 @@
+-- SKU уникален в пределах магазина среди неудаленных товаров: импорт обновляет товар по SKU,
+-- а разные магазины могут использовать одинаковые артикулы.
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_sku_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_shop_sku ON products(shop_id, sku) WHERE deleted_at IS NULL AND sku IS NOT NULL;
+
+-- Импорт товаров из CSV/JSONL. Загруженный файл и отчет об ошибках строк лежат в хранилище
+-- файлов (BlobStore), строку обрабатывает задача products.import.
+CREATE TABLE IF NOT EXISTS product_imports (
+    id BIGSERIAL PRIMARY KEY,
+    shop_id INT NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    format VARCHAR(10) NOT NULL CHECK (format IN ('csv', 'jsonl')),
+    dry_run BOOLEAN NOT NULL DEFAULT false,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    input_key VARCHAR(255) NOT NULL,
+    errors_key VARCHAR(255),
+    total_rows INT NOT NULL DEFAULT 0,
+    created_count INT NOT NULL DEFAULT 0,
+    updated_count INT NOT NULL DEFAULT 0,
+    failed_count INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_imports_shop ON product_imports(shop_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_product_imports_finished ON product_imports(finished_at) WHERE finished_at IS NOT NULL;
+
+INSERT INTO job_schedules (name, job_type, cron) VALUES
+    ('purge-product-imports', 'products.purge_imports', '30 3 * * *')
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,5 @@
+-- Версия строки для оптимистичной блокировки: каждое изменение товара, магазина или заказа
+-- увеличивает version на 1. API отдает ее в ETag и проверяет в If-Match.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE shops ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,34 @@
+-- Денежные колонки были NUMERIC(10, 2): суммы в валютах с тремя знаками minor units
+-- (BHD, IQD, JOD, KWD, LYD, OMR, TND) округлялись до двух знаков при записи.
+-- NUMERIC(19, 4) хранит все поддерживаемые экспоненты (0, 2, 3) без потерь.
+-- coupons.value - процент скидки, а не сумма, и не меняется.
+ALTER TABLE products ALTER COLUMN price TYPE NUMERIC(19, 4);
+ALTER TABLE product_variants ALTER COLUMN price TYPE NUMERIC(19, 4);
+ALTER TABLE orders
+    ALTER COLUMN total TYPE NUMERIC(19, 4),
+    ALTER COLUMN subtotal TYPE NUMERIC(19, 4),
+    ALTER COLUMN shipping_total TYPE NUMERIC(19, 4),
+    ALTER COLUMN discount_total TYPE NUMERIC(19, 4),
+    ALTER COLUMN tax_total TYPE NUMERIC(19, 4);
+ALTER TABLE order_items
+    ALTER COLUMN unit_price TYPE NUMERIC(19, 4),
+    ALTER COLUMN total_price TYPE NUMERIC(19, 4),
+    ALTER COLUMN original_unit_price TYPE NUMERIC(19, 4),
+    ALTER COLUMN tax_amount TYPE NUMERIC(19, 4);
+ALTER TABLE invoices
+    ALTER COLUMN amount TYPE NUMERIC(19, 4),
+    ALTER COLUMN shipping_amount TYPE NUMERIC(19, 4),
+    ALTER COLUMN tax_amount TYPE NUMERIC(19, 4);
+ALTER TABLE shipping_methods
+    ALTER COLUMN rate TYPE NUMERIC(19, 4),
+    ALTER COLUMN per_kg_rate TYPE NUMERIC(19, 4),
+    ALTER COLUMN free_threshold TYPE NUMERIC(19, 4);
+ALTER TABLE order_shipping_lines ALTER COLUMN amount TYPE NUMERIC(19, 4);
+ALTER TABLE coupons
+    ALTER COLUMN min_order_value TYPE NUMERIC(19, 4),
+    ALTER COLUMN amount TYPE NUMERIC(19, 4);
+ALTER TABLE coupon_redemptions ALTER COLUMN amount TYPE NUMERIC(19, 4);
+ALTER TABLE order_discounts ALTER COLUMN amount TYPE NUMERIC(19, 4);
+ALTER TABLE order_tax_lines
+    ALTER COLUMN taxable_amount TYPE NUMERIC(19, 4),
+    ALTER COLUMN amount TYPE NUMERIC(19, 4);
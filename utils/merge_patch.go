@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidMergePatch = errors.New("merge patch must be a JSON object")
+
+// MergePatch применяет JSON Merge Patch (RFC 7386) к документу doc: ключи патча заменяют
+// значения документа, null удаляет ключ, вложенные объекты сливаются рекурсивно.
+// Массивы и прочие значения заменяются целиком.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var patchValue map[string]any
+	if err := decodeJSONNumbers(patch, &patchValue); err != nil || patchValue == nil {
+		return nil, ErrInvalidMergePatch
+	}
+	var docValue any
+	if len(doc) > 0 {
+		if err := decodeJSONNumbers(doc, &docValue); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(mergePatchValue(docValue, patchValue))
+}
+
+// MergePatchKeys возвращает ключи верхнего уровня патча.
+func MergePatchKeys(patch []byte) ([]string, error) {
+	var patchValue map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &patchValue); err != nil || patchValue == nil {
+		return nil, ErrInvalidMergePatch
+	}
+	keys := make([]string, 0, len(patchValue))
+	for key := range patchValue {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func mergePatchValue(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatchValue(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// decodeJSONNumbers разбирает JSON, сохраняя числа как json.Number без потери точности.
+func decodeJSONNumbers(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookIDHeader        = "X-Webhook-Id"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+
+	webhookSignatureVersion = "v1="
+)
+
+// NewWebhookSecret возвращает случайный секрет для подписи вебхуков.
+func NewWebhookSecret() string {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return "whsec_" + hex.EncodeToString(b[:])
+}
+
+// SignWebhook подписывает тело запроса: v1=hex(HMAC-SHA256(secret, "<timestamp>.<body>")).
+// Метка времени входит в подпись, чтобы перехваченный запрос нельзя было повторить позже.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return webhookSignatureVersion + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature проверяет подпись полученного вебхука и то, что метка времени
+// отличается от now не больше чем на tolerance. Предназначена для получателей вебхуков.
+func VerifyWebhookSignature(secret, timestampHeader, signatureHeader string, body []byte, tolerance time.Duration, now time.Time) bool {
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return false
+	}
+	if diff := now.Sub(time.Unix(timestamp, 0)); diff > tolerance || diff < -tolerance {
+		return false
+	}
+	if !strings.HasPrefix(signatureHeader, webhookSignatureVersion) {
+		return false
+	}
+	expected := SignWebhook(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(signatureHeader))
+}